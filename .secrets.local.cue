@@ -1 +1,6 @@
 PostgreDSN: "test"
+TemporalTLSCertPath:  ""
+TemporalTLSKeyPath:   ""
+TemporalAPIKey:       ""
+PayloadEncryptionKey: ""
+WebhookSigningKey:    ""
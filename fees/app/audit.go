@@ -0,0 +1,43 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// ErrAuditNotPublished means the change was made, only its audit event is missing. A use case returns it along
+// with its result, the caller decides whether to report it.
+var ErrAuditNotPublished = errors.New("bill audit event not published")
+
+// NewBillEvent snapshots the bill after a state change, the change time is the bill UpdatedAt.
+func NewBillEvent(t views.BillEventType, b domain.Bill, correlationID string) views.BillEvent {
+	return views.BillEvent{
+		Type:          t,
+		BillID:        string(b.ID),
+		CustomerID:    b.CustomerID,
+		BillingPeriod: string(b.BillingPeriod),
+		Status:        string(b.Status),
+		ItemCount:     len(b.Items),
+		Total:         libmoney.NewMoneyMinor(b.Total),
+		OccurredAt:    b.UpdatedAt,
+		CorrelationID: correlationID,
+	}
+}
+
+// PublishBillEvent publishes the event of a change that already happened, a failure is ErrAuditNotPublished.
+// Nil k means no audit.
+func PublishBillEvent(ctx context.Context, k Kafka, t views.BillEventType, b domain.Bill) error {
+	if k == nil {
+		return nil
+	}
+	if err := k.PublishBillEvent(ctx, NewBillEvent(t, b, CorrelationID(ctx))); err != nil {
+		return fmt.Errorf("%w: %s of %s, %w", ErrAuditNotPublished, t, b.ID, err)
+	}
+
+	return nil
+}
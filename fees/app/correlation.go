@@ -0,0 +1,33 @@
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// MemoKeyCorrelationID is the workflow memo key holding the correlation ID of the request that started the bill.
+const MemoKeyCorrelationID = "CorrelationID"
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns ctx carrying the correlation ID, it ties an API request to the Temporal calls it makes.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID of ctx, or an empty string.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+
+	return id
+}
+
+// EnsureCorrelationID keeps the correlation ID of ctx, or generates one, so all calls of a use case share it.
+func EnsureCorrelationID(ctx context.Context) context.Context {
+	if CorrelationID(ctx) != "" {
+		return ctx
+	}
+
+	return WithCorrelationID(ctx, uuid.NewString())
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"errors"
+	"time"
 
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
@@ -14,14 +15,114 @@ import (
 )
 
 var (
-	ErrBillWithPeriodAlreadyStarted = errors.New("a bill already exists for this customer and period")
-	ErrLineItemAlreadyAdded         = errors.New("the line item already added")
-	ErrBillNotFound                 = errors.New("bill not found")
-	ErrBillAlreadyClosed            = errors.New("bill already closed")
+	ErrBillWithPeriodAlreadyStarted = domain.NewError(domain.CodeConflict, "a bill already exists for this customer and period")
+	ErrLineItemAlreadyAdded         = domain.NewError(domain.CodeConflict, "the line item already added")
+	ErrBillNotFound                 = domain.NewError(domain.CodeNotFound, "bill not found")
+	ErrBillBusy                     = domain.NewError(domain.CodeUnavailable,
+		"bill is busy, its query wasn't served in time, retry later")
+	// ErrBillArchived means the bill existed, but its workflow is past the namespace retention, so it can't be queried.
+	// It's a not found, Encore has no 410 Gone code, so the message tells it from a bill that never existed.
+	ErrBillArchived = domain.NewError(domain.CodeNotFound,
+		"bill is archived, it's past retention and can no longer be queried")
+	// ErrBillRunStale means the run targeted with WithRunID isn't running anymore, e.g. the bill continued as new
+	// since the caller read it, so nothing was signaled.
+	ErrBillRunStale = domain.NewError(domain.CodeFailedPrecondition,
+		"bill run is no longer running, read the bill again and retry on its latest run")
+	ErrBillAlreadyClosed       = domain.NewError(domain.CodeFailedPrecondition, "bill already closed")
+	ErrBillNotInError          = domain.NewError(domain.CodeFailedPrecondition, "bill is not in error state")
+	ErrBillEmpty               = domain.NewError(domain.CodeFailedPrecondition, "bill has no line items, add one before closing")
+	ErrBillItemLimit           = domain.NewError(domain.CodeFailedPrecondition, "bill has reached its line item limit")
+	ErrTerminateReasonRequired = domain.NewError(domain.CodeInvalid, "a reason is required to terminate a bill")
+	ErrUnknownTaxJurisdiction  = domain.NewError(domain.CodeInvalid, "unknown tax jurisdiction")
+	ErrInvalidAmount           = domain.NewError(domain.CodeInvalid, "amount is invalid")
+	ErrInvalidAmountMinor      = domain.NewError(domain.CodeInvalid, "amountMinor is invalid")
+	ErrCreditNoteAlreadyExists = domain.NewError(domain.CodeConflict,
+		"a credit note with this idempotency key already exists")
+	ErrInvalidTotalRange     = domain.NewError(domain.CodeInvalid, "minTotal must be <= maxTotal")
+	ErrInvalidItemCountRange = domain.NewError(domain.CodeInvalid, "minItems must be <= maxItems")
+	ErrInvalidCreatedRange   = domain.NewError(domain.CodeInvalid, "createdFrom must be <= createdTo")
+	ErrInvalidActiveRange    = domain.NewError(domain.CodeInvalid, "activeFrom must be <= activeTo")
 )
 
+// The search and setup errors have no domain code, the API maps them itself.
+var (
+	// ErrTooManyBills means a search hit the page cap before the last page, the filters should be narrowed.
+	ErrTooManyBills = errors.New("search matched too many bills")
+	// ErrSearchAttributesNotRegistered is a setup error: the namespace lacks the bill search attributes,
+	// see `make init-temporal`.
+	ErrSearchAttributesNotRegistered = errors.New("bill search attributes are not registered in the namespace")
+	// ErrPurgeNotAllowed guards the namespaces with real bills, purging is for dev and test namespaces only.
+	ErrPurgeNotAllowed = errors.New("purging bills is not allowed in this namespace")
+)
+
+// DefaultMaxItems is the line item cap of a bill without MaxItems, the whole bill is in the workflow state
+// and in every query result.
+const DefaultMaxItems = 10000
+
+// MaxItemsOrDefault is the effective line item cap for a configured one, zero means DefaultMaxItems.
+func MaxItemsOrDefault(maxItems int) int {
+	if maxItems <= 0 {
+		return DefaultMaxItems
+	}
+
+	return maxItems
+}
+
+// MemoKeyCreateIdempotencyKey is the workflow memo key holding the Idempotency-Key of the create request.
+const MemoKeyCreateIdempotencyKey = "CreateIdempotencyKey"
+
+// MemoKeyInvoiceURI is the workflow memo key holding the archived invoice URI, upserted once the bill is charged.
+const MemoKeyInvoiceURI = "InvoiceURI"
+
+// MemoKeyErrorReason is the workflow memo key holding the last invoicing failure, upserted when the bill errors.
+// It stays after a successful retry, the status tells whether the bill is still errored.
+const MemoKeyErrorReason = "ErrorReason"
+
+// MemoKeyCreateParams is the workflow memo key holding the MonthlyFeeAccrualWorkflowParams the bill was started
+// with, to reproduce a bill while debugging. Bills started before it was added lack it.
+const MemoKeyCreateParams = "CreateParams"
+
+// MemoKeyOriginalBillID is the credit note workflow memo key holding the BillID the credit note offsets.
+const MemoKeyOriginalBillID = "OriginalBillID"
+
+// BillMemo is the memo a bill workflow is started with, empty fields were not given on start.
+type BillMemo struct {
+	CorrelationID        string
+	CreateIdempotencyKey string
+	// InvoiceURI is empty until the invoice is archived.
+	InvoiceURI string
+	// ErrorReason is empty until the invoicing fails.
+	ErrorReason string
+	// CreateParams is nil for the bills started before they were stored.
+	CreateParams *MonthlyFeeAccrualWorkflowParams
+}
+
+// Kafka publishes the audit trail of bills, every state change is one event.
 type Kafka interface {
-	PublishAudit()
+	PublishBillEvent(ctx context.Context, event views.BillEvent) error
+}
+
+// Alerter fans errored bills out to operators, e.g. an on-call channel.
+type Alerter interface {
+	NotifyBillError(ctx context.Context, billID, reason string) error
+}
+
+// InvoiceArchiver stores the immutable final invoice of a charged bill, e.g. in object storage.
+type InvoiceArchiver interface {
+	// ArchiveInvoice returns the storage URI, archiving the same bill again must be safe as activities are retried.
+	ArchiveInvoice(ctx context.Context, bill domain.Bill) (string, error)
+}
+
+// PaymentGateway charges the customer of a closed bill at the payment provider.
+type PaymentGateway interface {
+	// Charge must be idempotent on idempotencyKey, the same key is sent again when the charge activity is retried.
+	Charge(ctx context.Context, idempotencyKey string, bill domain.Bill) error
+}
+
+// WebhookPoster delivers a bill notification to a customer URL and returns the HTTP status of the response.
+// signature is sent along for the customer to verify the payload, empty means unsigned.
+type WebhookPoster interface {
+	PostWebhook(ctx context.Context, url string, payload []byte, signature string) (int, error)
 }
 
 type MonthlyFeeAccrualWorkflowParams struct {
@@ -30,21 +131,130 @@ type MonthlyFeeAccrualWorkflowParams struct {
 	Period       domain.BillingPeriod
 	PeriodYYYYMM int64
 	Currency     libmoney.Currency
+	// Jurisdiction is optional, empty means no tax line item is computed at close time.
+	Jurisdiction string
+	// InvoiceRetry is optional, zero fields fall back to the workflow defaults.
+	InvoiceRetry RetryConfig
+	// ActivityTaskQueue is optional, empty means activities run on the workflow's own task queue.
+	ActivityTaskQueue string
+	// MinChargeMinor is optional, in minor units of Currency (e.g. 50 is $0.50), a bill with a lower total
+	// is written off instead of invoiced. Zero means no minimum.
+	MinChargeMinor int64
+	// CreateIdempotencyKey is optional, it goes to the memo so a retried create can be told from a conflicting one.
+	CreateIdempotencyKey string
+	// StrictCurrency rejects line items in another currency than the bill's, by default they're relabeled.
+	StrictCurrency bool
+	// AutoClose closes the bill when its period ends, as if it got the close signal.
+	AutoClose bool
+	// AllowEmptyBills lets a bill without line items be closed (and invoiced or written off), otherwise
+	// the close (signal or auto-close) is refused and the bill stays open.
+	AllowEmptyBills bool
+	// DrainItemsOnClose adds the line items already delivered when the bill is closed (signal or auto-close),
+	// before it moves to Pending. Otherwise a line item buffered behind the close is dropped as a late one.
+	// Only the bills serving a buffered close first leave line items behind it, bills started since
+	// the close-first version 2 serve the buffered line items before the close anyway.
+	DrainItemsOnClose bool
+	// MaxItems caps the line items of the bill, further ones are rejected. Zero means DefaultMaxItems.
+	MaxItems int
+	// Template is optional, its items are added on start, before any signal, see BillTemplates.Resolve.
+	// AddedAt is ignored, the items get the workflow start time.
+	Template []domain.LineItem
+	// WebhookURL is optional, the customer URL notified once the bill is closed.
+	WebhookURL string
+	// SkipSearchAttributes is set when the namespace lacks the bill SAs, the bill works but isn't searchable.
+	SkipSearchAttributes bool
+}
+
+// CreditNoteWorkflowParams starts a credit note workflow, the note is already validated against its bill.
+type CreditNoteWorkflowParams struct {
+	CreditNote domain.CreditNote
+}
+
+// RetryConfig is a plain (serializable) copy of the Temporal retry policy knobs, as params go into workflow history.
+type RetryConfig struct {
+	InitialInterval        time.Duration
+	MaximumAttempts        int32
+	BackoffCoefficient     float64
+	MaximumInterval        time.Duration
+	NonRetryableErrorTypes []string
 }
 
 type SearchBillFilter struct {
 	CustomerID string
-	FromYYYYMM *int64
-	ToYYYYMM   *int64
-	Status     []string
+	// AllCustomers drops the CustomerID filter, for the admin searches across all customers. Without it an empty
+	// CustomerID matches no bill, not every bill.
+	AllCustomers bool
+	FromYYYYMM   *int64
+	ToYYYYMM     *int64
+	Status       []string
+	// FinalizedWithinDays is optional, >0 keeps bills with BillFinalizedAt within the last N days from now.
+	FinalizedWithinDays int
+	// MinTotalCents and MaxTotalCents are optional inclusive bounds on BillTotalCents.
+	MinTotalCents *int64
+	MaxTotalCents *int64
+	// MinItemCount and MaxItemCount are optional inclusive bounds on BillItemCount, MaxItemCount 0 finds empty bills.
+	MinItemCount *int64
+	MaxItemCount *int64
+	// CreatedFrom and CreatedTo are optional inclusive bounds on BillCreatedAt, the wall-clock start of the bill.
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// ActiveFrom and ActiveTo are optional, they keep bills whose billing period overlaps the range, i.e.
+	// BillPeriodStart <= ActiveTo and BillPeriodEnd >= ActiveFrom. Both set to X finds the bills active at X.
+	ActiveFrom *time.Time
+	ActiveTo   *time.Time
+}
+
+// SearchBillPage is one page of the bills matching a search, NextPageToken is empty on the last page.
+type SearchBillPage struct {
+	Bills         []views.BillSummary
+	NextPageToken []byte
+}
+
+// RefreshPage is the outcome of signaling one page of running bills, NextPageToken is empty on the last page.
+type RefreshPage struct {
+	Signaled      int
+	Skipped       int // completed between listing and signaling
+	NextPageToken []byte
 }
 
 type TemporalPort interface {
 	StartMonthlyBill(ctx context.Context, params MonthlyFeeAccrualWorkflowParams) error
+	// StartCreditNote starts the credit note workflow, a note with the same ID returns ErrCreditNoteAlreadyExists.
+	StartCreditNote(ctx context.Context, note domain.CreditNote) error
+	// ListCreditNotes lists the credit notes of the bill. It's read from visibility, a note started a moment ago
+	// may be missing.
+	ListCreditNotes(ctx context.Context, id domain.BillID) ([]domain.CreditNote, error)
+	// The signals and queries of a bill go to the run of ctx, see WithRunID, the latest one by default.
 	AddLineItem(ctx context.Context, id domain.BillID, li domain.LineItem) error
+	UpdateLineItemDescription(ctx context.Context, id domain.BillID, idempotencyKey, description string) error
+	CorrectLineItemAmount(ctx context.Context, id domain.BillID, idempotencyKey string, amount libmoney.Money) error
+	SetBillNote(ctx context.Context, id domain.BillID, note string) error
 	CloseBill(ctx context.Context, id domain.BillID) error
+	RetryInvoicing(ctx context.Context, id domain.BillID) error
+	ReconcileBill(ctx context.Context, id domain.BillID) error
+	// TerminateBill force-stops the bill workflow, unlike CloseBill nothing is invoiced.
+	TerminateBill(ctx context.Context, id domain.BillID, reason string) error
 	QueryBill(ctx context.Context, id domain.BillID) (domain.Bill, error)
+	// QueryBillSummary is QueryBill without the line items.
+	QueryBillSummary(ctx context.Context, id domain.BillID) (views.BillStateSummary, error)
+	// QueryBillChangeLog queries the bill changes kept by the workflow, a cheap audit trail without the history.
+	QueryBillChangeLog(ctx context.Context, id domain.BillID) (views.BillChangeLog, error)
+	GetBillMemo(ctx context.Context, id domain.BillID) (BillMemo, error)
+	// QueryBillAsOf reconstructs the bill as it was at a past time from its workflow history, read-only.
+	QueryBillAsOf(ctx context.Context, id domain.BillID, at time.Time) (domain.Bill, error)
+	// QueryBillByExecution queries a specific run, empty runID means the latest one.
+	QueryBillByExecution(ctx context.Context, workflowID, runID string) (domain.Bill, error)
 	SearchBills(ctx context.Context, params SearchBillFilter) ([]views.BillSummary, error)
+	// SearchBillsPage is one page of SearchBills, for the callers streaming the bills, nil token is the first page.
+	SearchBillsPage(ctx context.Context, params SearchBillFilter, pageToken []byte) (SearchBillPage, error)
+	CountBills(ctx context.Context, params SearchBillFilter) (int64, error)
+	// AggregateBillTotals sums BillTotalCents per billing period and currency, sorted by period then currency.
+	// from and to are optional YYYYMM bounds, periods without bills are left out.
+	AggregateBillTotals(ctx context.Context, customerID string, from, to *int64) ([]views.BillPeriodTotal, error)
+	// ListBillPeriods lists the distinct "YYYY-MM" periods the customer has bills for, latest first.
+	ListBillPeriods(ctx context.Context, customerID string) ([]string, error)
+	// RefreshSearchAttributes signals one page of running bills to re-upsert their SAs, nil token is the first page.
+	RefreshSearchAttributes(ctx context.Context, pageToken []byte) (RefreshPage, error)
 }
 
 type TemporalClient interface {
@@ -0,0 +1,18 @@
+package app
+
+import "context"
+
+type runIDKey struct{}
+
+// WithRunID returns ctx targeting the bill run runID, the signals and queries of the gateway go to that run of
+// a Continue-As-New chain instead of the latest one. Empty runID is the latest run.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunID returns the run ctx targets, or an empty string for the latest one.
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+
+	return id
+}
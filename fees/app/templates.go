@@ -0,0 +1,47 @@
+package app
+
+import (
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// ErrUnknownBillTemplate is a create request naming a template that isn't configured.
+var ErrUnknownBillTemplate = domain.NewError(domain.CodeInvalid, "unknown bill template")
+
+// BillTemplateItem is one base fee of a template, the amount is in minor units of the bill currency.
+type BillTemplateItem struct {
+	// Key is unique within the template, the item idempotency key is derived from it.
+	Key         string
+	Description string
+	AmountMinor int64
+}
+
+// BillTemplates are the fee sets a bill can be created with, by template ID, e.g. one per plan.
+type BillTemplates map[string][]BillTemplateItem
+
+// TemplateIdempotencyKey is the key of a template item on the bill, the same on every replay,
+// so the item is never added twice.
+func TemplateIdempotencyKey(templateID, itemKey string) string {
+	return "template:" + templateID + ":" + itemKey
+}
+
+// Resolve returns the items of the template in the currency c, empty templateID means no template.
+func (t BillTemplates) Resolve(templateID string, c libmoney.Currency) ([]domain.LineItem, error) {
+	if templateID == "" {
+		return nil, nil
+	}
+	tmpl, ok := t[templateID]
+	if !ok {
+		return nil, ErrUnknownBillTemplate.Detailf("%s", templateID)
+	}
+	items := make([]domain.LineItem, 0, len(tmpl))
+	for _, it := range tmpl {
+		items = append(items, domain.LineItem{
+			IdempotencyKey: TemplateIdempotencyKey(templateID, it.Key),
+			Description:    it.Description,
+			Amount:         libmoney.FromMinorUnits(it.AmountMinor, c),
+		})
+	}
+
+	return items, nil
+}
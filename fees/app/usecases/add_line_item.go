@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 )
 
@@ -11,11 +12,25 @@ type AddLineItemCmd struct {
 	CustomerID string
 	Period     domain.BillingPeriod
 	Item       domain.LineItem
+	// RawAmount is optional, when set it's parsed in the bill currency into the amount of Item.
+	RawAmount AmountInput
 }
 
-type AddLineItem struct{ T app.TemporalPort }
+type AddLineItem struct {
+	T app.TemporalPort
+	// Audit is optional, nil means no audit events.
+	Audit app.Kafka
+	// Poll bounds the wait for the item to show up on the bill, zero means the defaults.
+	Poll PollBackoff
+}
 
+// Handle returns the bill with the item along with app.ErrAuditNotPublished, if only its audit event failed.
 func (uc AddLineItem) Handle(ctx context.Context, c AddLineItemCmd) (domain.Bill, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	// the reserved prefixes are for the items the service adds itself, e.g. the tax
+	if err := domain.ValidateExternalIdempotencyKey(c.Item.IdempotencyKey); err != nil {
+		return domain.Bill{}, err
+	}
 	billID := domain.MakeBillID(c.CustomerID, c.Period)
 
 	bill, err := uc.T.QueryBill(ctx, billID)
@@ -25,16 +40,45 @@ func (uc AddLineItem) Handle(ctx context.Context, c AddLineItemCmd) (domain.Bill
 	if !bill.IsActive() {
 		return domain.Bill{}, app.ErrBillAlreadyClosed
 	}
+	if !c.RawAmount.isZero() {
+		if c.Item.Amount, err = c.RawAmount.parse(bill.Currency); err != nil {
+			return domain.Bill{}, err
+		}
+	}
+	// e.g. 1.5 for a JPY bill, a caller may not know the bill currency
+	if err := c.Item.Amount.CheckPrecision(bill.Currency); err != nil {
+		return domain.Bill{}, err
+	}
+	// checked here and not in the workflow, rejecting there would change the signal handling of running bills
+	if err := bill.CheckCredit(c.Item.Amount); err != nil {
+		return domain.Bill{}, err
+	}
 
 	for _, li := range bill.Items {
 		if li.IdempotencyKey == c.Item.IdempotencyKey {
 			return domain.Bill{}, app.ErrLineItemAlreadyAdded
 		}
 	}
+	// the cap the bill was started with, bills started before the limit have none
+	if bill.IsFull() {
+		return domain.Bill{}, app.ErrBillItemLimit
+	}
 
 	if err := uc.T.AddLineItem(ctx, billID, c.Item); err != nil {
 		return domain.Bill{}, err
 	}
 
-	return uc.T.QueryBill(ctx, billID)
+	// the signal is fire-and-forget, the item shows up once the workflow handled it, unless the bill was full
+	bill, err = pollBill(ctx, uc.T, billID, uc.Poll, func(b domain.Bill) bool {
+		return b.HasItem(c.Item.IdempotencyKey) || b.IsFull()
+	})
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	// concurrent adds may have filled the bill before this one was handled
+	if !bill.HasItem(c.Item.IdempotencyKey) && bill.IsFull() {
+		return domain.Bill{}, app.ErrBillItemLimit
+	}
+
+	return bill, app.PublishBillEvent(ctx, uc.Audit, views.BillEventItemAdded, bill)
 }
@@ -0,0 +1,70 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
+)
+
+type AggregateBillTotalsCmd struct {
+	CustomerID string
+	// PeriodFrom and PeriodTo are optional, with both set every period of the range is listed, even without bills.
+	PeriodFrom domain.BillingPeriod
+	PeriodTo   domain.BillingPeriod
+}
+
+type AggregateBillTotals struct{ T app.TemporalPort }
+
+func (uc AggregateBillTotals) Handle(ctx context.Context, c AggregateBillTotalsCmd) ([]views.BillPeriodTotal, error) {
+	fromInt, err := libtime.ToYYYYMMNullable(string(c.PeriodFrom))
+	if err != nil {
+		return nil, fmt.Errorf("fromInt conversion error, %w", err)
+	}
+	toInt, err := libtime.ToYYYYMMNullable(string(c.PeriodTo))
+	if err != nil {
+		return nil, fmt.Errorf("toInt conversion error, %w", err)
+	}
+	if fromInt != nil && toInt != nil && *fromInt > *toInt {
+		return nil, domain.ErrInvalidPeriodRange
+	}
+
+	totals, err := uc.T.AggregateBillTotals(ctx, c.CustomerID, fromInt, toInt)
+	if err != nil {
+		return nil, fmt.Errorf("AggregateBillTotals UC failer, %w", err)
+	}
+	if fromInt == nil || toInt == nil {
+		return totals, nil
+	}
+
+	return fillEmptyPeriods(totals, *fromInt, *toInt), nil
+}
+
+// fillEmptyPeriods adds a zero total for every period of from..to without bills, totals are sorted by period.
+func fillEmptyPeriods(totals []views.BillPeriodTotal, from, to int64) []views.BillPeriodTotal {
+	out := make([]views.BillPeriodTotal, 0, len(totals))
+	i := 0
+	for p := from; p <= to; p = nextPeriodYYYYMM(p) {
+		if i == len(totals) || totals[i].PeriodYYYYMM != p {
+			out = append(out, views.BillPeriodTotal{PeriodYYYYMM: p})
+
+			continue
+		}
+		for ; i < len(totals) && totals[i].PeriodYYYYMM == p; i++ {
+			out = append(out, totals[i])
+		}
+	}
+
+	return out
+}
+
+func nextPeriodYYYYMM(p int64) int64 {
+	if p%100 == 12 { //nolint:mnd
+		return (p/100+1)*100 + 1 //nolint:mnd
+	}
+
+	return p + 1
+}
@@ -0,0 +1,43 @@
+package usecases
+
+import (
+	"errors"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// AmountInput is an amount as the client sent it, in the bill currency the client may not know, so it's parsed
+// once the bill is queried. At most one of Amount (decimal, "10.50") and AmountMinor (integer minor units, "1050")
+// is set, none means the amount of the command is used as is.
+type AmountInput struct {
+	Amount      string
+	AmountMinor string
+}
+
+func (a AmountInput) isZero() bool {
+	return a.Amount == "" && a.AmountMinor == ""
+}
+
+// parse parses AmountMinor if set, Amount otherwise, in the currency c. A malformed amount is app.ErrInvalidAmount
+// or app.ErrInvalidAmountMinor, one finer than the minor unit of c is libmoney.ErrPrecisionExceeded.
+func (a AmountInput) parse(c libmoney.Currency) (libmoney.Money, error) {
+	if a.AmountMinor != "" {
+		// minor units of c, e.g. cents, or yen for JPY
+		m, err := libmoney.NewFromMinorUnitsString(a.AmountMinor, c)
+		if err != nil {
+			return libmoney.Money{}, app.ErrInvalidAmountMinor.Detailf("%s", err)
+		}
+
+		return m, nil
+	}
+	m, err := libmoney.NewFromStringStrict(a.Amount, c)
+	if errors.Is(err, libmoney.ErrPrecisionExceeded) {
+		return libmoney.Money{}, err
+	}
+	if err != nil {
+		return libmoney.Money{}, app.ErrInvalidAmount
+	}
+
+	return m, nil
+}
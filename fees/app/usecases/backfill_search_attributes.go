@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+)
+
+type BackfillSearchAttributesCmd struct {
+	// PageToken resumes an interrupted run, nil starts from the beginning.
+	PageToken []byte
+	// OnProgress is optional, called after each page.
+	OnProgress func(BackfillSearchAttributesResult)
+}
+
+type BackfillSearchAttributesResult struct {
+	Pages    int
+	Signaled int
+	Skipped  int
+	// NextPageToken is the page to resume from, empty when all running bills are signaled.
+	NextPageToken []byte
+}
+
+// BackfillSearchAttributes signals all running bills to refresh their SAs, e.g. after a new SA (BillUpdatedAt) is added.
+type BackfillSearchAttributes struct{ T app.TemporalPort }
+
+func (uc BackfillSearchAttributes) Handle(
+	ctx context.Context,
+	c BackfillSearchAttributesCmd,
+) (BackfillSearchAttributesResult, error) {
+	res := BackfillSearchAttributesResult{NextPageToken: c.PageToken}
+	for {
+		page, err := uc.T.RefreshSearchAttributes(ctx, res.NextPageToken)
+		if err != nil {
+			// res.NextPageToken still points to the failed page
+			return res, fmt.Errorf("backfill search attributes, page %d: %w", res.Pages+1, err)
+		}
+		res.Pages++
+		res.Signaled += page.Signaled
+		res.Skipped += page.Skipped
+		res.NextPageToken = page.NextPageToken
+		if c.OnProgress != nil {
+			c.OnProgress(res)
+		}
+		if len(res.NextPageToken) == 0 {
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+const defaultCloseAllConcurrency = 4
+
+type CloseAllBillsCmd struct {
+	CustomerID string
+}
+
+type CloseAllBillsOutcome string
+
+const (
+	CloseAllBillsClosed CloseAllBillsOutcome = "closed"
+	// CloseAllBillsSkipped is a bill closed meanwhile, search results lag behind the workflows.
+	CloseAllBillsSkipped CloseAllBillsOutcome = "skipped"
+	CloseAllBillsError   CloseAllBillsOutcome = "error"
+)
+
+type CloseAllBillsResult struct {
+	BillID  domain.BillID
+	Outcome CloseAllBillsOutcome
+	// Err is set for CloseAllBillsError.
+	Err error
+}
+
+// CloseAllBills closes every open bill of a customer, e.g. at the end of the month.
+// A failing bill doesn't stop the others, each one gets its result.
+type CloseAllBills struct {
+	T app.TemporalPort
+	// Concurrency bounds the bills closed at once, zero means defaultCloseAllConcurrency.
+	Concurrency int
+	// Poll is the CloseBill one, for every bill.
+	Poll PollBackoff
+}
+
+// Handle fails only if the open bills can't be searched, the results are in the search order.
+func (uc CloseAllBills) Handle(ctx context.Context, c CloseAllBillsCmd) ([]CloseAllBillsResult, error) {
+	// a run ID is of a single bill, each bill is closed in its latest run
+	ctx = app.WithRunID(app.EnsureCorrelationID(ctx), "")
+	bills, err := uc.T.SearchBills(ctx, app.SearchBillFilter{
+		CustomerID: c.CustomerID,
+		Status:     []string{string(domain.BillStatusOpen)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CloseAllBills UC search, %w", err)
+	}
+
+	closer := CloseBill{T: uc.T, Poll: uc.Poll}
+	results := make([]CloseAllBillsResult, len(bills))
+	sem := make(chan struct{}, uc.concurrency())
+	var wg sync.WaitGroup
+	for i, b := range bills {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			id := domain.BillID(b.WorkflowID)
+			_, err := closer.close(ctx, id)
+			switch {
+			case err == nil:
+				results[i] = CloseAllBillsResult{BillID: id, Outcome: CloseAllBillsClosed}
+			case errors.Is(err, app.ErrBillAlreadyClosed):
+				results[i] = CloseAllBillsResult{BillID: id, Outcome: CloseAllBillsSkipped}
+			default:
+				results[i] = CloseAllBillsResult{BillID: id, Outcome: CloseAllBillsError, Err: err}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func (uc CloseAllBills) concurrency() int {
+	if uc.Concurrency <= 0 {
+		return defaultCloseAllConcurrency
+	}
+
+	return uc.Concurrency
+}
@@ -12,11 +12,20 @@ type CloseBillCmd struct {
 	Period     domain.BillingPeriod
 }
 
-type CloseBill struct{ T app.TemporalPort }
+type CloseBill struct {
+	T app.TemporalPort
+	// Poll bounds the wait for the bill to leave OPEN, zero means the defaults.
+	Poll PollBackoff
+}
 
 // This is actually idempotant at Workflow level.
 func (uc CloseBill) Handle(ctx context.Context, c CloseBillCmd) (domain.Bill, error) {
-	id := domain.MakeBillID(c.CustomerID, c.Period)
+	ctx = app.EnsureCorrelationID(ctx)
+
+	return uc.close(ctx, domain.MakeBillID(c.CustomerID, c.Period))
+}
+
+func (uc CloseBill) close(ctx context.Context, id domain.BillID) (domain.Bill, error) {
 	bill, err := uc.T.QueryBill(ctx, id)
 	if err != nil {
 		return domain.Bill{}, err
@@ -24,9 +33,20 @@ func (uc CloseBill) Handle(ctx context.Context, c CloseBillCmd) (domain.Bill, er
 	if !bill.IsActive() {
 		return domain.Bill{}, app.ErrBillAlreadyClosed
 	}
+	// line items are never removed, the workflow would refuse the close as well
+	if bill.ItemsRequired && len(bill.Items) == 0 {
+		return domain.Bill{}, app.ErrBillEmpty
+	}
 	if err := uc.T.CloseBill(ctx, id); err != nil {
 		return domain.Bill{}, err
 	}
 
-	return uc.T.QueryBill(ctx, id)
+	bill, err = pollBill(ctx, uc.T, id, uc.Poll, func(b domain.Bill) bool {
+		return b.Status != domain.BillStatusOpen
+	})
+	if err != nil {
+		return domain.Bill{}, err
+	}
+
+	return bill, nil
 }
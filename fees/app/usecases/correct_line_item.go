@@ -0,0 +1,56 @@
+package usecases
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type CorrectLineItemAmountCmd struct {
+	CustomerID     string
+	Period         domain.BillingPeriod
+	IdempotencyKey string
+	Amount         libmoney.Money
+	// RawAmount is optional, when set it's parsed in the bill currency into Amount.
+	RawAmount AmountInput
+}
+
+type CorrectLineItemAmount struct{ T app.TemporalPort }
+
+func (uc CorrectLineItemAmount) Handle(ctx context.Context, c CorrectLineItemAmountCmd) (domain.Bill, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	billID := domain.MakeBillID(c.CustomerID, c.Period)
+
+	bill, err := uc.T.QueryBill(ctx, billID)
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	if !bill.IsActive() {
+		return domain.Bill{}, app.ErrBillAlreadyClosed
+	}
+	if !c.RawAmount.isZero() {
+		if c.Amount, err = c.RawAmount.parse(bill.Currency); err != nil {
+			return domain.Bill{}, err
+		}
+	}
+	if err := c.Amount.CheckPrecision(bill.Currency); err != nil {
+		return domain.Bill{}, err
+	}
+	// the signal is fire-and-forget, so the correction is tried on a copy to report an unknown key,
+	// a currency mismatch or a negative total to the caller
+	draft := bill
+	draft.Items = slices.Clone(bill.Items)
+	if err := draft.CorrectItemAmount(c.IdempotencyKey, c.Amount, time.Now()); err != nil {
+		return domain.Bill{}, err
+	}
+
+	if err := uc.T.CorrectLineItemAmount(ctx, billID, c.IdempotencyKey, c.Amount); err != nil {
+		return domain.Bill{}, err
+	}
+
+	return uc.T.QueryBill(ctx, billID)
+}
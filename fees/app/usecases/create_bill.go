@@ -2,27 +2,87 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
-	"github.com/outofboxer/temporal-workflow/libs/time"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 type CreateBillCmd struct {
 	CustomerID string
 	Period     domain.BillingPeriod
 	Currency   libmoney.Currency
+	// Jurisdiction is optional, it enables tax computation at close time.
+	Jurisdiction string
+	// IdempotencyKey is optional, a create retried with the same key gets the existing bill instead of a conflict.
+	IdempotencyKey string
+	// TemplateID is optional, the bill starts with the items of this template of CreateBill.Templates.
+	TemplateID string
+	// WebhookURL is optional, it's notified once the bill is closed.
+	WebhookURL string
+	// AutoClose is optional, nil takes CreateBill.AutoClose.
+	AutoClose *bool
 }
 
-type CreateBill struct{ T app.TemporalPort }
+type CreateBillResult struct {
+	Bill domain.Bill
+	// Replayed is set when the bill was created by an earlier request with the same IdempotencyKey.
+	Replayed bool
+}
+
+type CreateBill struct {
+	T app.TemporalPort
+	// PeriodWindow limits the periods a bill can be created for, nil means domain.DefaultBillingPeriodWindow.
+	// A zero window allows the current month only.
+	PeriodWindow *domain.BillingPeriodWindow
+	// Now is used for the period window check, defaults to time.Now.
+	Now func() time.Time
+	// Audit is optional, nil means no audit events.
+	Audit app.Kafka
+	// AllowEmptyBills is the close policy of the new bills, see app.MonthlyFeeAccrualWorkflowParams.
+	AllowEmptyBills bool
+	// MaxItems is the line item cap of the new bills, zero means app.DefaultMaxItems.
+	MaxItems int
+	// DrainItemsOnClose is the close policy of the new bills for the line items buffered behind the close,
+	// see app.MonthlyFeeAccrualWorkflowParams.
+	DrainItemsOnClose bool
+	// AutoClose is whether the new bills close themselves when their period ends, see
+	// app.MonthlyFeeAccrualWorkflowParams.
+	AutoClose bool
+	// MinChargeMinor is the minimum charge of the new bills by currency, in its minor units, the bills in a currency
+	// without one are invoiced whatever their total.
+	MinChargeMinor map[libmoney.Currency]int64
+	// IsTaxJurisdiction tells the jurisdictions with a tax rate, nil accepts any.
+	IsTaxJurisdiction func(jurisdiction string) bool
+	// Templates are the fee sets CreateBillCmd.TemplateID resolves to, nil means none is configured.
+	Templates app.BillTemplates
+}
 
-func (uc CreateBill) Handle(ctx context.Context, c CreateBillCmd) (domain.Bill, error) {
+// Handle returns the created bill along with app.ErrAuditNotPublished, if only its audit event failed.
+func (uc CreateBill) Handle(ctx context.Context, c CreateBillCmd) (CreateBillResult, error) {
+	// the same correlation ID goes with every Temporal call of this command
+	ctx = app.EnsureCorrelationID(ctx)
 	id := domain.MakeBillID(c.CustomerID, c.Period)
-	yyyymm, err := time.ToYYYYMM(string(c.Period))
+	yyyymm, err := libtime.ToYYYYMM(string(c.Period))
+	if err != nil {
+		return CreateBillResult{}, fmt.Errorf("period formatting error, %w", err)
+	}
+	if err := uc.periodWindow().Validate(c.Period, uc.now()); err != nil {
+		return CreateBillResult{}, err
+	}
+	// checked here, the tax is computed at close time, when the bill can no longer be fixed
+	if c.Jurisdiction != "" && uc.IsTaxJurisdiction != nil && !uc.IsTaxJurisdiction(c.Jurisdiction) {
+		return CreateBillResult{}, app.ErrUnknownTaxJurisdiction.Detailf("%s", c.Jurisdiction)
+	}
+	// resolved here, the workflow gets the items themselves, so a template change doesn't affect running bills
+	template, err := uc.Templates.Resolve(c.TemplateID, c.Currency)
 	if err != nil {
-		return domain.Bill{}, fmt.Errorf("period formatting error, %w", err)
+		return CreateBillResult{}, err
 	}
 	workflowParams := app.MonthlyFeeAccrualWorkflowParams{
 		BillID:       id,
@@ -30,10 +90,72 @@ func (uc CreateBill) Handle(ctx context.Context, c CreateBillCmd) (domain.Bill,
 		Period:       c.Period,
 		PeriodYYYYMM: yyyymm,
 		Currency:     c.Currency,
+		Jurisdiction: c.Jurisdiction,
+
+		MinChargeMinor:       uc.MinChargeMinor[c.Currency],
+		AutoClose:            uc.AutoClose,
+		AllowEmptyBills:      uc.AllowEmptyBills,
+		DrainItemsOnClose:    uc.DrainItemsOnClose,
+		MaxItems:             uc.MaxItems,
+		CreateIdempotencyKey: c.IdempotencyKey,
+		Template:             template,
+		WebhookURL:           c.WebhookURL,
+	}
+	if c.AutoClose != nil {
+		workflowParams.AutoClose = *c.AutoClose
+	}
+	err = uc.T.StartMonthlyBill(ctx, workflowParams)
+	if errors.Is(err, app.ErrBillWithPeriodAlreadyStarted) && c.IdempotencyKey != "" {
+		return uc.replay(ctx, id, c.IdempotencyKey, err)
+	}
+	if err != nil {
+		return CreateBillResult{}, err
+	}
+
+	b, err := uc.T.QueryBill(ctx, id)
+	if err != nil {
+		return CreateBillResult{}, err
 	}
-	if err := uc.T.StartMonthlyBill(ctx, workflowParams); err != nil {
-		return domain.Bill{}, err
+	// the bill is created even if its audit event isn't published
+	return CreateBillResult{Bill: b}, app.PublishBillEvent(ctx, uc.Audit, views.BillEventCreated, b)
+}
+
+// replay returns the existing bill if it was created with the same idempotency key, a client retry,
+// otherwise the conflict stays.
+func (uc CreateBill) replay(
+	ctx context.Context,
+	id domain.BillID,
+	idempotencyKey string,
+	conflict error,
+) (CreateBillResult, error) {
+	memo, err := uc.T.GetBillMemo(ctx, id)
+	if err != nil {
+		return CreateBillResult{}, errors.Join(conflict, err)
+	}
+	if memo.CreateIdempotencyKey != idempotencyKey {
+		return CreateBillResult{}, conflict
+	}
+
+	b, err := uc.T.QueryBill(ctx, id)
+	if err != nil {
+		return CreateBillResult{}, err
+	}
+
+	return CreateBillResult{Bill: b, Replayed: true}, nil
+}
+
+func (uc CreateBill) periodWindow() domain.BillingPeriodWindow {
+	if uc.PeriodWindow == nil {
+		return domain.DefaultBillingPeriodWindow
+	}
+
+	return *uc.PeriodWindow
+}
+
+func (uc CreateBill) now() time.Time {
+	if uc.Now == nil {
+		return time.Now()
 	}
 
-	return uc.T.QueryBill(ctx, id)
+	return uc.Now()
 }
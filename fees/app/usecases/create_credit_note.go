@@ -0,0 +1,58 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type CreateCreditNoteCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+	// IdempotencyKey tells credit notes of one bill apart, a retry with the same key gets ErrCreditNoteAlreadyExists.
+	IdempotencyKey string
+	// Amount is positive, the credit note total is its negation.
+	Amount libmoney.Money
+	Reason string
+}
+
+type CreateCreditNote struct {
+	T app.TemporalPort
+	// Now is the credit note creation time, defaults to time.Now.
+	Now func() time.Time
+}
+
+func (uc CreateCreditNote) Handle(ctx context.Context, c CreateCreditNoteCmd) (domain.CreditNote, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	billID := domain.MakeBillID(c.CustomerID, c.Period)
+
+	// a completed bill workflow still answers queries, ErrBillNotFound if there was never one
+	bill, err := uc.T.QueryBill(ctx, billID)
+	if err != nil {
+		return domain.CreditNote{}, err
+	}
+	credited, err := uc.T.ListCreditNotes(ctx, billID)
+	if err != nil {
+		return domain.CreditNote{}, err
+	}
+	note, err := domain.NewCreditNote(bill, credited, c.IdempotencyKey, c.Amount, c.Reason, uc.now())
+	if err != nil {
+		return domain.CreditNote{}, err
+	}
+	if err := uc.T.StartCreditNote(ctx, note); err != nil {
+		return domain.CreditNote{}, err
+	}
+
+	return note, nil
+}
+
+func (uc CreateCreditNote) now() time.Time {
+	if uc.Now == nil {
+		return time.Now()
+	}
+
+	return uc.Now()
+}
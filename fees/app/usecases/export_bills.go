@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+type ExportBillsCmd struct {
+	CustomerID string
+	// Emit is called with every page of bills as it's fetched, the first page even if it's empty. An error stops
+	// the export.
+	Emit func([]views.BillSummary) error
+}
+
+// ExportBills pages through all the bills of a customer, a page at a time, so a large export isn't held in memory.
+type ExportBills struct{ T app.TemporalPort }
+
+func (uc ExportBills) Handle(ctx context.Context, c ExportBillsCmd) error {
+	filter := app.SearchBillFilter{CustomerID: c.CustomerID}
+	var token []byte
+	for pages := 1; ; pages++ {
+		page, err := uc.T.SearchBillsPage(ctx, filter, token)
+		if err != nil {
+			return fmt.Errorf("ExportBills UC, page %d: %w", pages, err)
+		}
+		if err := c.Emit(page.Bills); err != nil {
+			return fmt.Errorf("ExportBills UC, page %d: %w", pages, err)
+		}
+		if len(page.NextPageToken) == 0 {
+			return nil
+		}
+		token = page.NextPageToken
+	}
+}
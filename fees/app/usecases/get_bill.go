@@ -2,8 +2,10 @@ package usecases
 
 import (
 	"context"
+	"time"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 )
 
@@ -19,3 +21,56 @@ func (uc GetBill) Handle(ctx context.Context, c GetBillCmd) (domain.Bill, error)
 
 	return uc.T.QueryBill(ctx, id)
 }
+
+// GetBillSummary gets the bill without its line items, see app.TemporalPort.QueryBillSummary.
+type GetBillSummary struct{ T app.TemporalPort }
+
+func (uc GetBillSummary) Handle(ctx context.Context, c GetBillCmd) (views.BillStateSummary, error) {
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+
+	return uc.T.QueryBillSummary(ctx, id)
+}
+
+// GetBillChangeLog gets the bill changes kept by its workflow, see app.TemporalPort.QueryBillChangeLog.
+type GetBillChangeLog struct{ T app.TemporalPort }
+
+func (uc GetBillChangeLog) Handle(ctx context.Context, c GetBillCmd) (views.BillChangeLog, error) {
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+
+	return uc.T.QueryBillChangeLog(ctx, id)
+}
+
+// GetBillMemo gets the memo the bill was started with, incl. its create params, see app.TemporalPort.GetBillMemo.
+type GetBillMemo struct{ T app.TemporalPort }
+
+func (uc GetBillMemo) Handle(ctx context.Context, c GetBillCmd) (app.BillMemo, error) {
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+
+	return uc.T.GetBillMemo(ctx, id)
+}
+
+type GetBillAsOfCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+	At         time.Time
+}
+
+// GetBillAsOf gets the bill as it was at a past time, see app.TemporalPort.QueryBillAsOf.
+type GetBillAsOf struct{ T app.TemporalPort }
+
+func (uc GetBillAsOf) Handle(ctx context.Context, c GetBillAsOfCmd) (domain.Bill, error) {
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+
+	return uc.T.QueryBillAsOf(ctx, id, c.At)
+}
+
+type GetBillByExecutionCmd struct {
+	WorkflowID string
+	RunID      string
+}
+
+type GetBillByExecution struct{ T app.TemporalPort }
+
+func (uc GetBillByExecution) Handle(ctx context.Context, c GetBillByExecutionCmd) (domain.Bill, error) {
+	return uc.T.QueryBillByExecution(ctx, c.WorkflowID, c.RunID)
+}
@@ -0,0 +1,24 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+)
+
+type ListBillPeriodsCmd struct {
+	CustomerID string
+}
+
+type ListBillPeriods struct{ T app.TemporalPort }
+
+// Handle lists the "YYYY-MM" periods the customer has bills for, latest first, e.g. for a period dropdown.
+func (uc ListBillPeriods) Handle(ctx context.Context, c ListBillPeriodsCmd) ([]string, error) {
+	periods, err := uc.T.ListBillPeriods(ctx, c.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("ListBillPeriods UC failed, %w", err)
+	}
+
+	return periods, nil
+}
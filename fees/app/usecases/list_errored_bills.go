@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type ListErroredBillsCmd struct {
+	// PeriodFrom and PeriodTo are optional "YYYY-MM" bounds.
+	PeriodFrom domain.BillingPeriod
+	PeriodTo   domain.BillingPeriod
+}
+
+// ListErroredBills finds the bills whose invoicing failed (CHARGE_FAILED, REJECTED or ERROR) across all customers,
+// for ops.
+type ListErroredBills struct{ T app.TemporalPort }
+
+func (uc ListErroredBills) Handle(ctx context.Context, c ListErroredBillsCmd) ([]views.BillSummary, error) {
+	filter, err := toSearchBillFilter(SearchBillCmd{
+		PeriodFrom: c.PeriodFrom,
+		PeriodTo:   c.PeriodTo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	filter.AllCustomers = true
+	filter.Status = []string{
+		string(domain.BillStatusChargeFailed), string(domain.BillStatusRejected), string(domain.BillStatusError),
+	}
+
+	bills, err := uc.T.SearchBills(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("ListErroredBills UC failed, %w", err)
+	}
+
+	return bills, nil
+}
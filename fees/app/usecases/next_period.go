@@ -0,0 +1,44 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type NextPeriodCmd struct {
+	CustomerID string
+}
+
+// NextPeriod finds the period of the next bill to create for a customer, e.g. for a "create next bill" button.
+// It isn't checked against the create window, a customer billed ahead gets a period the create refuses.
+type NextPeriod struct {
+	T app.TemporalPort
+	// Now gives the current month for a customer without bills, defaults to time.Now.
+	Now func() time.Time
+}
+
+// Handle returns the month after the latest period the customer has a bill for, the current month without bills.
+func (uc NextPeriod) Handle(ctx context.Context, c NextPeriodCmd) (domain.BillingPeriod, error) {
+	periods, err := uc.T.ListBillPeriods(ctx, c.CustomerID)
+	if err != nil {
+		return "", fmt.Errorf("NextPeriod UC failed, %w", err)
+	}
+	if len(periods) == 0 {
+		return domain.CurrentBillingPeriod(uc.now()), nil
+	}
+
+	// latest first
+	return domain.BillingPeriod(periods[0]).Next()
+}
+
+func (uc NextPeriod) now() time.Time {
+	if uc.Now == nil {
+		return time.Now()
+	}
+
+	return uc.Now()
+}
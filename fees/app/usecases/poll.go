@@ -0,0 +1,73 @@
+package usecases
+
+import (
+	"context"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// Defaults of PollBackoff, a signal is usually handled within the first couple of re-queries.
+const (
+	defaultPollInitialInterval = 50 * time.Millisecond
+	defaultPollMaxInterval     = 400 * time.Millisecond
+	defaultPollTimeout         = 2 * time.Second
+)
+
+// PollBackoff bounds the re-queries of a bill after a signal, see pollBill. Zero fields take the defaults.
+type PollBackoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// Timeout is the whole wait, once it passes the last state read is returned as is.
+	Timeout time.Duration
+}
+
+func (p PollBackoff) withDefaults() PollBackoff {
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = defaultPollInitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = defaultPollMaxInterval
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = defaultPollTimeout
+	}
+
+	return p
+}
+
+// pollBill queries the bill until done holds or the timeout passes, doubling the interval up to MaxInterval.
+// A signal is async, a query right after it may still see the bill without it. There's no jitter, a caller waits
+// the same on every call. A query error, ctx being done included, is returned right away.
+func pollBill(
+	ctx context.Context,
+	t app.TemporalPort,
+	id domain.BillID,
+	p PollBackoff,
+	done func(domain.Bill) bool,
+) (domain.Bill, error) {
+	p = p.withDefaults()
+	deadline := time.Now().Add(p.Timeout)
+	interval := p.InitialInterval
+
+	for {
+		bill, err := t.QueryBill(ctx, id)
+		if err != nil {
+			return domain.Bill{}, err
+		}
+		if done(bill) || time.Now().Add(interval).After(deadline) {
+			return bill, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return domain.Bill{}, ctx.Err()
+		case <-timer.C:
+		}
+		interval = min(interval*2, max(p.MaxInterval, p.InitialInterval))
+	}
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// testPoll keeps the waits of the use case tests short.
+var testPoll = PollBackoff{
+	InitialInterval: time.Millisecond,
+	MaxInterval:     2 * time.Millisecond,
+	Timeout:         20 * time.Millisecond,
+}
+
+func TestPollBill(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	open := createTestBill()
+	withItem := createTestBill()
+	withItem.Items = []domain.LineItem{createTestLineItem()}
+	hasItem := func(b domain.Bill) bool { return len(b.Items) > 0 && b.Items[0].IdempotencyKey == "item-123" }
+
+	tests := []struct {
+		name      string
+		mockSetup func(*MockTemporalPort)
+		poll      PollBackoff
+		wantItems int
+		wantErr   error
+		queries   int
+	}{
+		{
+			name: "visible at once",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+			},
+			poll:      testPoll,
+			wantItems: 1,
+			queries:   1,
+		},
+		{
+			name: "delayed visibility",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(open, nil).Twice()
+				m.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+			},
+			poll:      PollBackoff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Timeout: time.Second},
+			wantItems: 1,
+			queries:   3,
+		},
+		{
+			name: "never visible, the last state after the timeout",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(open, nil)
+			},
+			poll:      testPoll,
+			wantItems: 0,
+		},
+		{
+			name: "query error is returned right away",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(open, nil).Once()
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound).Once()
+			},
+			poll:    PollBackoff{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Timeout: time.Second},
+			wantErr: app.ErrBillNotFound,
+			queries: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MockTemporalPort{}
+			tt.mockSetup(m)
+
+			bill, err := pollBill(context.Background(), m, billID, tt.poll, hasItem)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, bill.Items, tt.wantItems)
+			}
+			if tt.queries > 0 {
+				m.AssertNumberOfCalls(t, "QueryBill", tt.queries)
+			}
+		})
+	}
+}
+
+func TestPollBill_Backoff(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	m := &MockTemporalPort{}
+	var at []time.Time
+	m.On("QueryBill", mock.Anything, billID).Run(func(mock.Arguments) {
+		at = append(at, time.Now())
+	}).Return(createTestBill(), nil)
+
+	p := PollBackoff{
+		InitialInterval: 10 * time.Millisecond,
+		MaxInterval:     20 * time.Millisecond,
+		Timeout:         100 * time.Millisecond,
+	}
+	_, err := pollBill(context.Background(), m, billID, p, func(domain.Bill) bool { return false })
+	require.NoError(t, err)
+
+	// 10ms, 20ms, then capped at 20ms, no query is started past the timeout
+	require.GreaterOrEqual(t, len(at), 3)
+	assert.GreaterOrEqual(t, at[1].Sub(at[0]), 10*time.Millisecond)
+	assert.GreaterOrEqual(t, at[2].Sub(at[1]), 20*time.Millisecond)
+	assert.LessOrEqual(t, at[len(at)-1].Sub(at[0]), p.Timeout)
+}
+
+func TestPollBill_ContextCanceled(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &MockTemporalPort{}
+	// the workflow never handles the signal, the caller gives up
+	m.On("QueryBill", mock.Anything, billID).Run(func(mock.Arguments) { cancel() }).Return(createTestBill(), nil).Once()
+
+	_, err := pollBill(ctx, m, billID, PollBackoff{InitialInterval: time.Second, Timeout: time.Minute},
+		func(domain.Bill) bool { return false })
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	m.AssertNumberOfCalls(t, "QueryBill", 1)
+}
+
+func TestAddLineItem_Handle_DelayedVisibility(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	withItem := createTestBill()
+	withItem.Items = []domain.LineItem{createTestLineItem()}
+	m := &MockTemporalPort{}
+	// the first re-query is served before the workflow handled the signal
+	m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Twice()
+	m.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil).Once()
+	m.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+
+	bill, err := AddLineItem{T: m, Poll: testPoll}.Handle(context.Background(), AddLineItemCmd{
+		CustomerID: "customer-123", Period: "2025-01", Item: createTestLineItem(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []domain.LineItem{createTestLineItem()}, bill.Items)
+	m.AssertExpectations(t)
+}
+
+func TestCloseBill_Handle_DelayedVisibility(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	open := createTestBill()
+	open.Items = []domain.LineItem{createTestLineItem()}
+	pending := open
+	pending.Status = domain.BillStatusPending
+	m := &MockTemporalPort{}
+	m.On("QueryBill", mock.Anything, billID).Return(open, nil).Twice()
+	m.On("CloseBill", mock.Anything, billID).Return(nil).Once()
+	m.On("QueryBill", mock.Anything, billID).Return(pending, nil).Once()
+
+	bill, err := CloseBill{T: m, Poll: testPoll}.Handle(context.Background(), CloseBillCmd{
+		CustomerID: "customer-123", Period: "2025-01",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, domain.BillStatusPending, bill.Status)
+	m.AssertExpectations(t)
+}
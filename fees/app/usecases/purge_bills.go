@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+const defaultPurgeConcurrency = 8
+
+type PurgeBillsCmd struct {
+	CustomerID string
+	// Status is optional, empty purges the bills in any status.
+	Status string
+	// CreatedBefore is optional, it keeps the bills started afterwards, e.g. the ones of a running test.
+	CreatedBefore *time.Time
+	// Reason is recorded on every terminated workflow.
+	Reason string
+}
+
+type PurgeBillsResult struct {
+	Matched    int
+	Terminated int
+	// Skipped are bills completed meanwhile, there's nothing to terminate.
+	Skipped int
+	Failed  int
+	// Failures holds the error of every failed bill, for the logs.
+	Failures map[domain.BillID]error
+}
+
+// PurgeBills terminates the bills matching a filter, to clean up the stale bills piling up in a dev or test
+// namespace. Nothing is invoiced, so it refuses to run unless Namespace is in AllowedNamespaces.
+type PurgeBills struct {
+	T app.TemporalPort
+	// Namespace is where the bills run, AllowedNamespaces the ones purging is allowed in, empty allows none.
+	Namespace         string
+	AllowedNamespaces []string
+	// Concurrency bounds the bills terminated at once, zero means defaultPurgeConcurrency.
+	Concurrency int
+}
+
+// Handle fails only if it's not allowed or the bills can't be searched, a failing bill doesn't stop the others.
+func (uc PurgeBills) Handle(ctx context.Context, c PurgeBillsCmd) (PurgeBillsResult, error) {
+	// a run ID is of a single bill, each bill is terminated in its latest run
+	ctx = app.WithRunID(app.EnsureCorrelationID(ctx), "")
+	if !slices.Contains(uc.AllowedNamespaces, uc.Namespace) {
+		return PurgeBillsResult{}, fmt.Errorf("%w: %q", app.ErrPurgeNotAllowed, uc.Namespace)
+	}
+	if strings.TrimSpace(c.Reason) == "" {
+		return PurgeBillsResult{}, app.ErrTerminateReasonRequired
+	}
+
+	filter := app.SearchBillFilter{CustomerID: c.CustomerID, CreatedTo: c.CreatedBefore}
+	if c.Status != "" {
+		filter.Status = []string{c.Status}
+	}
+	bills, err := uc.T.SearchBills(ctx, filter)
+	if err != nil {
+		return PurgeBillsResult{}, fmt.Errorf("PurgeBills UC search, %w", err)
+	}
+
+	res := PurgeBillsResult{Matched: len(bills), Failures: map[domain.BillID]error{}}
+	var mu sync.Mutex
+	sem := make(chan struct{}, uc.concurrency())
+	var wg sync.WaitGroup
+	for _, b := range bills {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			id := domain.BillID(b.WorkflowID)
+			err := uc.T.TerminateBill(ctx, id, c.Reason)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				res.Terminated++
+			case errors.Is(err, app.ErrBillNotFound):
+				res.Skipped++
+			default:
+				res.Failed++
+				res.Failures[id] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	return res, nil
+}
+
+func (uc PurgeBills) concurrency() int {
+	if uc.Concurrency <= 0 {
+		return defaultPurgeConcurrency
+	}
+
+	return uc.Concurrency
+}
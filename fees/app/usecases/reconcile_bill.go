@@ -0,0 +1,51 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type ReconcileBillCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+}
+
+type ReconcileBillResult struct {
+	Bill   domain.Bill
+	Before libmoney.Money
+	After  libmoney.Money
+	Drift  bool
+}
+
+// ReconcileBill repairs an open bill whose total drifted from the sum of its items.
+type ReconcileBill struct{ T app.TemporalPort }
+
+func (uc ReconcileBill) Handle(ctx context.Context, c ReconcileBillCmd) (ReconcileBillResult, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+	bill, err := uc.T.QueryBill(ctx, id)
+	if err != nil {
+		return ReconcileBillResult{}, err
+	}
+	if !bill.IsActive() {
+		return ReconcileBillResult{}, app.ErrBillAlreadyClosed
+	}
+	if err := uc.T.ReconcileBill(ctx, id); err != nil {
+		return ReconcileBillResult{}, err
+	}
+
+	after, err := uc.T.QueryBill(ctx, id)
+	if err != nil {
+		return ReconcileBillResult{}, err
+	}
+
+	return ReconcileBillResult{
+		Bill:   after,
+		Before: bill.Total,
+		After:  after.Total,
+		Drift:  !bill.Total.Equal(after.Total),
+	}, nil
+}
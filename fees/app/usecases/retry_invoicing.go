@@ -0,0 +1,42 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type RetryInvoicingCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+}
+
+// RetryInvoicing re-runs invoicing of a ChargeFailed bill, a Rejected one failed for good.
+type RetryInvoicing struct{ T app.TemporalPort }
+
+func (uc RetryInvoicing) Handle(ctx context.Context, c RetryInvoicingCmd) (domain.Bill, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	id := domain.MakeBillID(c.CustomerID, c.Period)
+	bill, err := uc.T.QueryBill(ctx, id)
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	switch bill.Status {
+	case domain.BillStatusChargeFailed:
+	case domain.BillStatusError:
+		// a bill that failed invoicing before ChargeFailed and Rejected, its flag tells which failure it was
+	case domain.BillStatusRejected:
+		return domain.Bill{}, domain.ErrInvoicingNotRetryable
+	default:
+		return domain.Bill{}, app.ErrBillNotInError
+	}
+	if !bill.InvoicingRetryable {
+		return domain.Bill{}, domain.ErrInvoicingNotRetryable
+	}
+	if err := uc.T.RetryInvoicing(ctx, id); err != nil {
+		return domain.Bill{}, err
+	}
+
+	return uc.T.QueryBill(ctx, id)
+}
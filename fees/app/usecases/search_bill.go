@@ -3,11 +3,12 @@ package usecases
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
 	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
-	"github.com/outofboxer/temporal-workflow/libs/time"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 type SearchBillCmd struct {
@@ -15,35 +16,101 @@ type SearchBillCmd struct {
 	PeriodFrom domain.BillingPeriod
 	PeriodTo   domain.BillingPeriod
 	Status     string
+	// FinalizedWithinDays is optional, see app.SearchBillFilter.
+	FinalizedWithinDays int
+	// MinTotalCents and MaxTotalCents are optional, see app.SearchBillFilter.
+	MinTotalCents *int64
+	MaxTotalCents *int64
+	// MinItemCount and MaxItemCount are optional, see app.SearchBillFilter.
+	MinItemCount *int64
+	MaxItemCount *int64
+	// CreatedFrom and CreatedTo are optional, see app.SearchBillFilter.
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// ActiveFrom and ActiveTo are optional, see app.SearchBillFilter.
+	ActiveFrom *time.Time
+	ActiveTo   *time.Time
 }
 
 type SearchBill struct{ T app.TemporalPort }
 
 func (uc SearchBill) Handle(ctx context.Context, c SearchBillCmd) ([]views.BillSummary, error) {
-	fromInt, err := time.ToYYYYMMNullable(string(c.PeriodFrom))
+	filter, err := toSearchBillFilter(c)
 	if err != nil {
-		return nil, fmt.Errorf("fromInt conversion error, %w", err)
+		return nil, err
 	}
-	toInt, err := time.ToYYYYMMNullable(string(c.PeriodTo))
+
+	bills, err := uc.T.SearchBills(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("SearchBills UC failer, %w", err)
+	}
+
+	return bills, nil
+}
+
+// CountBillsCmd filters the same way as SearchBillCmd.
+type CountBillsCmd SearchBillCmd
+
+type CountBills struct{ T app.TemporalPort }
+
+func (uc CountBills) Handle(ctx context.Context, c CountBillsCmd) (int64, error) {
+	filter, err := toSearchBillFilter(SearchBillCmd(c))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := uc.T.CountBills(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("CountBills UC failer, %w", err)
+	}
+
+	return n, nil
+}
+
+func toSearchBillFilter(c SearchBillCmd) (app.SearchBillFilter, error) {
+	fromInt, err := libtime.ToYYYYMMNullable(string(c.PeriodFrom))
 	if err != nil {
-		return nil, fmt.Errorf("toInt conversion error, %w", err)
+		return app.SearchBillFilter{}, fmt.Errorf("fromInt conversion error, %w", err)
+	}
+	toInt, err := libtime.ToYYYYMMNullable(string(c.PeriodTo))
+	if err != nil {
+		return app.SearchBillFilter{}, fmt.Errorf("toInt conversion error, %w", err)
+	}
+	if fromInt != nil && toInt != nil && *fromInt > *toInt {
+		return app.SearchBillFilter{}, domain.ErrInvalidPeriodRange
+	}
+	if c.MinTotalCents != nil && c.MaxTotalCents != nil && *c.MinTotalCents > *c.MaxTotalCents {
+		return app.SearchBillFilter{}, app.ErrInvalidTotalRange
+	}
+	if c.MinItemCount != nil && c.MaxItemCount != nil && *c.MinItemCount > *c.MaxItemCount {
+		return app.SearchBillFilter{}, app.ErrInvalidItemCountRange
+	}
+	if c.CreatedFrom != nil && c.CreatedTo != nil && c.CreatedFrom.After(*c.CreatedTo) {
+		return app.SearchBillFilter{}, app.ErrInvalidCreatedRange
+	}
+	if c.ActiveFrom != nil && c.ActiveTo != nil && c.ActiveFrom.After(*c.ActiveTo) {
+		return app.SearchBillFilter{}, app.ErrInvalidActiveRange
 	}
 	// the logic assumes OPEN and PENDING statuses should be fetched as the same logically opened for search only statuses.
 	statuses := []string{c.Status}
 	if c.Status == string(domain.BillStatusOpen) {
 		statuses = append(statuses, string(domain.BillStatusPending))
 	}
-	filter := app.SearchBillFilter{
+
+	return app.SearchBillFilter{
 		CustomerID: c.CustomerID,
 		FromYYYYMM: fromInt,
 		ToYYYYMM:   toInt,
 		Status:     statuses,
-	}
-
-	bills, err := uc.T.SearchBills(ctx, filter)
-	if err != nil {
-		return nil, fmt.Errorf("SearchBills UC failer, %w", err)
-	}
 
-	return bills, nil
+		FinalizedWithinDays: c.FinalizedWithinDays,
+		MinTotalCents:       c.MinTotalCents,
+		MaxTotalCents:       c.MaxTotalCents,
+		MinItemCount:        c.MinItemCount,
+		MaxItemCount:        c.MaxItemCount,
+		CreatedFrom:         c.CreatedFrom,
+		CreatedTo:           c.CreatedTo,
+		ActiveFrom:          c.ActiveFrom,
+		ActiveTo:            c.ActiveTo,
+	}, nil
 }
@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type SetBillNoteCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+	// Note replaces the current one, empty clears it.
+	Note string
+}
+
+type SetBillNote struct{ T app.TemporalPort }
+
+func (uc SetBillNote) Handle(ctx context.Context, c SetBillNoteCmd) (domain.Bill, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	billID := domain.MakeBillID(c.CustomerID, c.Period)
+
+	// the signal is fire-and-forget, so check what the workflow would discard to report it to the caller
+	if utf8.RuneCountInString(c.Note) > domain.MaxNoteLength {
+		return domain.Bill{}, domain.ErrNoteTooLong
+	}
+	bill, err := uc.T.QueryBill(ctx, billID)
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	if !bill.IsActive() {
+		return domain.Bill{}, app.ErrBillAlreadyClosed
+	}
+
+	if err := uc.T.SetBillNote(ctx, billID, c.Note); err != nil {
+		return domain.Bill{}, err
+	}
+
+	return uc.T.QueryBill(ctx, billID)
+}
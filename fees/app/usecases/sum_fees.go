@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type SumFeesCmd struct {
+	CustomerID string
+	Period     domain.BillingPeriod
+	// DescriptionPattern is a case-insensitive substring, or a glob (path.Match syntax) if it has * ? or [.
+	DescriptionPattern string
+}
+
+type SumFeesResult struct {
+	Currency libmoney.Currency
+	Total    libmoney.Money
+	Count    int
+}
+
+type SumFees struct{ T app.TemporalPort }
+
+func (uc SumFees) Handle(ctx context.Context, c SumFeesCmd) (SumFeesResult, error) {
+	bill, err := GetBill{T: uc.T}.Handle(ctx, GetBillCmd{CustomerID: c.CustomerID, Period: c.Period})
+	if err != nil {
+		return SumFeesResult{}, err
+	}
+
+	res := SumFeesResult{Currency: bill.Currency, Total: libmoney.NewFromInt(0, bill.Currency)}
+	for _, li := range bill.Items {
+		ok, err := matchDescription(c.DescriptionPattern, li.Description)
+		if err != nil {
+			return SumFeesResult{}, fmt.Errorf("description pattern error, %w", err)
+		}
+		if !ok {
+			continue
+		}
+		res.Total = res.Total.Add(li.Amount)
+		res.Count++
+	}
+
+	return res, nil
+}
+
+// CheckDescriptionPattern returns path.ErrBadPattern for a malformed glob, e.g. an unclosed [.
+func CheckDescriptionPattern(pattern string) error {
+	_, err := matchDescription(pattern, "")
+
+	return err
+}
+
+func matchDescription(pattern, description string) (bool, error) {
+	pattern = strings.ToLower(pattern)
+	description = strings.ToLower(description)
+	if strings.ContainsAny(pattern, "*?[") {
+		return path.Match(pattern, description)
+	}
+
+	return strings.Contains(description, pattern), nil
+}
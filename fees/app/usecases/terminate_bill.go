@@ -0,0 +1,27 @@
+package usecases
+
+import (
+	"context"
+	"strings"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type TerminateBillCmd struct {
+	BillID domain.BillID
+	Reason string
+}
+
+// TerminateBill force-stops a stuck bill workflow. Unlike CloseBill it bypasses invoicing entirely,
+// the bill is left as it was, so it's an ops tool only.
+type TerminateBill struct{ T app.TemporalPort }
+
+func (uc TerminateBill) Handle(ctx context.Context, c TerminateBillCmd) error {
+	ctx = app.EnsureCorrelationID(ctx)
+	if strings.TrimSpace(c.Reason) == "" {
+		return app.ErrTerminateReasonRequired
+	}
+
+	return uc.T.TerminateBill(ctx, c.BillID, c.Reason)
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+type UpdateLineItemDescriptionCmd struct {
+	CustomerID     string
+	Period         domain.BillingPeriod
+	IdempotencyKey string
+	Description    string
+}
+
+type UpdateLineItemDescription struct{ T app.TemporalPort }
+
+func (uc UpdateLineItemDescription) Handle(ctx context.Context, c UpdateLineItemDescriptionCmd) (domain.Bill, error) {
+	ctx = app.EnsureCorrelationID(ctx)
+	billID := domain.MakeBillID(c.CustomerID, c.Period)
+
+	bill, err := uc.T.QueryBill(ctx, billID)
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	if !bill.IsActive() {
+		return domain.Bill{}, app.ErrBillAlreadyClosed
+	}
+	// the signal is fire-and-forget, so check the item exists to report it to the caller
+	found := false
+	for _, li := range bill.Items {
+		if li.IdempotencyKey == c.IdempotencyKey {
+			found = true
+
+			break
+		}
+	}
+	if !found {
+		return domain.Bill{}, fmt.Errorf("%w: %s", domain.ErrLineItemNotFound, c.IdempotencyKey)
+	}
+
+	if err := uc.T.UpdateLineItemDescription(ctx, billID, c.IdempotencyKey, c.Description); err != nil {
+		return domain.Bill{}, err
+	}
+
+	return uc.T.QueryBill(ctx, billID)
+}
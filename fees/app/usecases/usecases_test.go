@@ -3,6 +3,9 @@ package usecases
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/outofboxer/temporal-workflow/fees/app"
 	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/kafka"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
 )
 
@@ -26,26 +30,120 @@ func (m *MockTemporalPort) StartMonthlyBill(ctx context.Context, params app.Mont
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) StartCreditNote(ctx context.Context, note domain.CreditNote) error {
+	args := m.Called(ctx, note)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) ListCreditNotes(ctx context.Context, id domain.BillID) ([]domain.CreditNote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]domain.CreditNote), args.Error(1)
+}
+
 func (m *MockTemporalPort) AddLineItem(ctx context.Context, id domain.BillID, li domain.LineItem) error {
 	args := m.Called(ctx, id, li)
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) UpdateLineItemDescription(ctx context.Context, id domain.BillID, key, description string) error {
+	args := m.Called(ctx, id, key, description)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) CorrectLineItemAmount(ctx context.Context, id domain.BillID, idempotencyKey string, amount libmoney.Money) error {
+	args := m.Called(ctx, id, idempotencyKey, amount)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) SetBillNote(ctx context.Context, id domain.BillID, note string) error {
+	args := m.Called(ctx, id, note)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) ReconcileBill(ctx context.Context, id domain.BillID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockTemporalPort) CloseBill(ctx context.Context, id domain.BillID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) RetryInvoicing(ctx context.Context, id domain.BillID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockTemporalPort) QueryBill(ctx context.Context, id domain.BillID) (domain.Bill, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(domain.Bill), args.Error(1)
 }
 
+func (m *MockTemporalPort) TerminateBill(ctx context.Context, id domain.BillID, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) QueryBillSummary(ctx context.Context, id domain.BillID) (views.BillStateSummary, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(views.BillStateSummary), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillChangeLog(ctx context.Context, id domain.BillID) (views.BillChangeLog, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(views.BillChangeLog), args.Error(1)
+}
+
+func (m *MockTemporalPort) GetBillMemo(ctx context.Context, id domain.BillID) (app.BillMemo, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(app.BillMemo), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillAsOf(ctx context.Context, id domain.BillID, at time.Time) (domain.Bill, error) {
+	args := m.Called(ctx, id, at)
+	return args.Get(0).(domain.Bill), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillByExecution(ctx context.Context, workflowID, runID string) (domain.Bill, error) {
+	args := m.Called(ctx, workflowID, runID)
+	return args.Get(0).(domain.Bill), args.Error(1)
+}
+
 func (m *MockTemporalPort) SearchBills(ctx context.Context, params app.SearchBillFilter) ([]views.BillSummary, error) {
 	args := m.Called(ctx, params)
 	return args.Get(0).([]views.BillSummary), args.Error(1)
 }
 
+func (m *MockTemporalPort) SearchBillsPage(
+	ctx context.Context,
+	params app.SearchBillFilter,
+	pageToken []byte,
+) (app.SearchBillPage, error) {
+	args := m.Called(ctx, params, pageToken)
+	return args.Get(0).(app.SearchBillPage), args.Error(1)
+}
+
+func (m *MockTemporalPort) CountBills(ctx context.Context, params app.SearchBillFilter) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTemporalPort) AggregateBillTotals(ctx context.Context, customerID string, from, to *int64) ([]views.BillPeriodTotal, error) {
+	args := m.Called(ctx, customerID, from, to)
+	return args.Get(0).([]views.BillPeriodTotal), args.Error(1)
+}
+
+func (m *MockTemporalPort) ListBillPeriods(ctx context.Context, customerID string) ([]string, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTemporalPort) RefreshSearchAttributes(ctx context.Context, pageToken []byte) (app.RefreshPage, error) {
+	args := m.Called(ctx, pageToken)
+	return args.Get(0).(app.RefreshPage), args.Error(1)
+}
+
 // Helper functions for creating test data
 var fixedTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
 
@@ -102,6 +200,29 @@ func TestCreateBill_Handle(t *testing.T) {
 			},
 			expectedResult: createTestBill(),
 		},
+		{
+			name: "jurisdiction is passed to the workflow",
+			cmd: CreateBillCmd{
+				CustomerID:   "customer-123",
+				Period:       "2025-01",
+				Currency:     libmoney.CurrencyUSD,
+				Jurisdiction: "US-CA",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				expectedParams := app.MonthlyFeeAccrualWorkflowParams{
+					BillID:       "bill/customer-123/2025-01",
+					CustomerID:   "customer-123",
+					Period:       "2025-01",
+					PeriodYYYYMM: 202501,
+					Currency:     libmoney.CurrencyUSD,
+					Jurisdiction: "US-CA",
+				}
+
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(nil)
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil)
+			},
+			expectedResult: createTestBill(),
+		},
 		{
 			name: "invalid period format",
 			cmd: CreateBillCmd{
@@ -114,6 +235,30 @@ func TestCreateBill_Handle(t *testing.T) {
 			},
 			expectedError: "period formatting error",
 		},
+		{
+			name: "period more than a month ahead",
+			cmd: CreateBillCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-03",
+				Currency:   libmoney.CurrencyUSD,
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as error occurs before Temporal calls
+			},
+			expectedError: domain.ErrBillingPeriodOutOfRange.Error(),
+		},
+		{
+			name: "period older than the window",
+			cmd: CreateBillCmd{
+				CustomerID: "customer-123",
+				Period:     "2022-12",
+				Currency:   libmoney.CurrencyUSD,
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as error occurs before Temporal calls
+			},
+			expectedError: domain.ErrBillingPeriodOutOfRange.Error(),
+		},
 		{
 			name: "temporal start workflow error",
 			cmd: CreateBillCmd{
@@ -162,7 +307,7 @@ func TestCreateBill_Handle(t *testing.T) {
 			mockTemporal := &MockTemporalPort{}
 			tt.mockSetup(mockTemporal)
 
-			uc := CreateBill{T: mockTemporal}
+			uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }}
 			result, err := uc.Handle(context.Background(), tt.cmd)
 
 			if tt.expectedError != "" {
@@ -170,9 +315,300 @@ func TestCreateBill_Handle(t *testing.T) {
 				assert.Contains(t, err.Error(), tt.expectedError)
 			} else {
 				require.NoError(t, err)
-				assert.Equal(t, tt.expectedResult, result)
+				assert.Equal(t, tt.expectedResult, result.Bill)
+				assert.False(t, result.Replayed)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateBill_IdempotencyKey(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	cmd := CreateBillCmd{
+		CustomerID:     "customer-123",
+		Period:         "2025-01",
+		Currency:       libmoney.CurrencyUSD,
+		IdempotencyKey: "create-1",
+	}
+	expectedParams := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:               billID,
+		CustomerID:           "customer-123",
+		Period:               "2025-01",
+		PeriodYYYYMM:         202501,
+		Currency:             libmoney.CurrencyUSD,
+		CreateIdempotencyKey: "create-1",
+	}
+
+	tests := []struct {
+		name          string
+		mockSetup     func(*MockTemporalPort)
+		expectedError error
+		replayed      bool
+	}{
+		{
+			name: "first request creates the bill",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			},
+		},
+		{
+			name: "retry with the matching key returns the existing bill",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, billID).
+					Return(app.BillMemo{CorrelationID: "req-1", CreateIdempotencyKey: "create-1"}, nil)
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			},
+			replayed: true,
+		},
+		{
+			name: "non-matching key is a conflict",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, billID).
+					Return(app.BillMemo{CreateIdempotencyKey: "create-other"}, nil)
+			},
+			expectedError: app.ErrBillWithPeriodAlreadyStarted,
+		},
+		{
+			name: "bill created without a key is a conflict",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{}, nil)
+			},
+			expectedError: app.ErrBillWithPeriodAlreadyStarted,
+		},
+		{
+			name: "memo lookup failure keeps the conflict",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{}, errors.New("describe failed"))
+			},
+			expectedError: app.ErrBillWithPeriodAlreadyStarted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }}
+			result, err := uc.Handle(context.Background(), cmd)
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, createTestBill(), result.Bill)
+				assert.Equal(t, tt.replayed, result.Replayed)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateBill_Template(t *testing.T) {
+	templates := app.BillTemplates{
+		"standard": {
+			{Key: "platform", Description: "Platform fee", AmountMinor: 1000},
+			{Key: "support", Description: "Support fee", AmountMinor: 250},
+		},
+	}
+
+	t.Run("template items go to the workflow params in the bill currency", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		expectedParams := app.MonthlyFeeAccrualWorkflowParams{
+			BillID:       "bill/customer-123/2025-01",
+			CustomerID:   "customer-123",
+			Period:       "2025-01",
+			PeriodYYYYMM: 202501,
+			Currency:     libmoney.CurrencyGEL,
+			Template: []domain.LineItem{
+				{
+					IdempotencyKey: "template:standard:platform",
+					Description:    "Platform fee",
+					Amount:         libmoney.FromMinorUnits(1000, libmoney.CurrencyGEL),
+				},
+				{
+					IdempotencyKey: "template:standard:support",
+					Description:    "Support fee",
+					Amount:         libmoney.FromMinorUnits(250, libmoney.CurrencyGEL),
+				},
+			},
+		}
+		mockTemporal.On("StartMonthlyBill", mock.Anything, expectedParams).Return(nil)
+		mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+			Return(createTestBill(), nil)
+
+		uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, Templates: templates}
+		_, err := uc.Handle(context.Background(), CreateBillCmd{
+			CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyGEL, TemplateID: "standard",
+		})
+
+		require.NoError(t, err)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("unknown template starts nothing", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+
+		uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, Templates: templates}
+		_, err := uc.Handle(context.Background(), CreateBillCmd{
+			CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD, TemplateID: "premium",
+		})
+
+		require.ErrorIs(t, err, app.ErrUnknownBillTemplate)
+		mockTemporal.AssertNotCalled(t, "StartMonthlyBill", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateBill_TaxJurisdiction(t *testing.T) {
+	isTaxJurisdiction := func(jurisdiction string) bool { return jurisdiction == "GE" }
+
+	t.Run("known", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("StartMonthlyBill", mock.Anything, mock.MatchedBy(func(p app.MonthlyFeeAccrualWorkflowParams) bool {
+			return p.Jurisdiction == "GE"
+		})).Return(nil)
+		mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+			Return(createTestBill(), nil)
+
+		uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, IsTaxJurisdiction: isTaxJurisdiction}
+		_, err := uc.Handle(context.Background(), CreateBillCmd{
+			CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD, Jurisdiction: "GE",
+		})
+
+		require.NoError(t, err)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+
+		uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, IsTaxJurisdiction: isTaxJurisdiction}
+		_, err := uc.Handle(context.Background(), CreateBillCmd{
+			CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD, Jurisdiction: "XX",
+		})
+
+		require.ErrorIs(t, err, app.ErrUnknownTaxJurisdiction)
+		mockTemporal.AssertNotCalled(t, "StartMonthlyBill", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateBill_PeriodWindow(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		window  *domain.BillingPeriodWindow
+		period  domain.BillingPeriod
+		wantErr bool
+	}{
+		{name: "unset is the default window", period: "2024-12"},
+		{name: "zero window allows the current month", window: &domain.BillingPeriodWindow{}, period: "2025-01"},
+		{name: "zero window refuses the last month", window: &domain.BillingPeriodWindow{}, period: "2024-12", wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			billID := domain.MakeBillID("customer-123", tt.period)
+			if !tt.wantErr {
+				mockTemporal.On("StartMonthlyBill", mock.Anything, mock.Anything).Return(nil)
+				mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			}
+
+			uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, PeriodWindow: tt.window}
+			_, err := uc.Handle(context.Background(), CreateBillCmd{
+				CustomerID: "customer-123", Period: tt.period, Currency: libmoney.CurrencyUSD,
+			})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, domain.ErrBillingPeriodOutOfRange)
+			} else {
+				require.NoError(t, err)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateBill_ClosePolicy(t *testing.T) {
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("StartMonthlyBill", mock.Anything, mock.MatchedBy(func(p app.MonthlyFeeAccrualWorkflowParams) bool {
+		return p.DrainItemsOnClose && p.AllowEmptyBills
+	})).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+		Return(createTestBill(), nil)
+
+	uc := CreateBill{
+		T: mockTemporal, Now: func() time.Time { return fixedTime }, AllowEmptyBills: true, DrainItemsOnClose: true,
+	}
+	_, err := uc.Handle(context.Background(), CreateBillCmd{
+		CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD,
+	})
+
+	require.NoError(t, err)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestCreateBill_MinCharge(t *testing.T) {
+	for _, tt := range []struct {
+		currency libmoney.Currency
+		want     int64
+	}{
+		{currency: libmoney.CurrencyUSD, want: 50},
+		{currency: libmoney.CurrencyGEL, want: 0},
+	} {
+		t.Run(string(tt.currency), func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			mockTemporal.On("StartMonthlyBill", mock.Anything, mock.MatchedBy(func(p app.MonthlyFeeAccrualWorkflowParams) bool {
+				return p.MinChargeMinor == tt.want
+			})).Return(nil)
+			mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+				Return(createTestBill(), nil)
+
+			uc := CreateBill{
+				T: mockTemporal, Now: func() time.Time { return fixedTime },
+				MinChargeMinor: map[libmoney.Currency]int64{libmoney.CurrencyUSD: 50},
 			}
+			_, err := uc.Handle(context.Background(), CreateBillCmd{
+				CustomerID: "customer-123", Period: "2025-01", Currency: tt.currency,
+			})
+
+			require.NoError(t, err)
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateBill_AutoClose(t *testing.T) {
+	on, off := true, false
+	for _, tt := range []struct {
+		name     string
+		fallback bool
+		cmd      *bool
+		want     bool
+	}{
+		{name: "config default", fallback: true, want: true},
+		{name: "request turns it off", fallback: true, cmd: &off, want: false},
+		{name: "request turns it on", cmd: &on, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			mockTemporal.On("StartMonthlyBill", mock.Anything, mock.MatchedBy(func(p app.MonthlyFeeAccrualWorkflowParams) bool {
+				return p.AutoClose == tt.want
+			})).Return(nil)
+			mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+				Return(createTestBill(), nil)
+
+			uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, AutoClose: tt.fallback}
+			_, err := uc.Handle(context.Background(), CreateBillCmd{
+				CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD, AutoClose: tt.cmd,
+			})
 
+			require.NoError(t, err)
 			mockTemporal.AssertExpectations(t)
 		})
 	}
@@ -240,6 +676,46 @@ func TestAddLineItem_Handle(t *testing.T) {
 			},
 			expectedError: app.ErrBillAlreadyClosed.Error(),
 		},
+		{
+			name: "amount finer than the bill currency",
+			cmd: AddLineItemCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+				Item: domain.LineItem{
+					IdempotencyKey: "item-jpy",
+					Description:    "API usage fee",
+					Amount:         libmoney.NewFromFloat(10.5, libmoney.CurrencyNone),
+				},
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				jpyBill := createTestBill()
+				jpyBill.Currency = libmoney.CurrencyJPY
+
+				m.On("QueryBill", mock.Anything, billID).Return(jpyBill, nil)
+			},
+			expectedError: libmoney.ErrPrecisionExceeded.Error(),
+		},
+		{
+			name: "credit larger than the bill total",
+			cmd: AddLineItemCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+				Item: domain.LineItem{
+					IdempotencyKey: "credit-1",
+					Description:    "Goodwill credit",
+					Amount:         libmoney.NewFromFloat(-10.01, libmoney.CurrencyNone),
+				},
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				bill := createTestBill()
+				bill.Total = libmoney.NewFromInt(10, libmoney.CurrencyUSD)
+
+				m.On("QueryBill", mock.Anything, billID).Return(bill, nil)
+			},
+			expectedError: domain.ErrNegativeTotal.Error(),
+		},
 		{
 			name: "line item already added (idempotency)",
 			cmd: AddLineItemCmd{
@@ -256,6 +732,20 @@ func TestAddLineItem_Handle(t *testing.T) {
 			},
 			expectedError: app.ErrLineItemAlreadyAdded.Error(),
 		},
+		{
+			name: "reserved idempotency key prefix is rejected before any query",
+			cmd: AddLineItemCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+				Item: func() domain.LineItem {
+					li := createTestLineItem()
+					li.IdempotencyKey = domain.TaxIdempotencyKey("US-CA")
+					return li
+				}(),
+			},
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: domain.ErrReservedIdempotencyKey.Error(),
+		},
 		{
 			name: "temporal add line item error",
 			cmd: AddLineItemCmd{
@@ -300,7 +790,7 @@ func TestAddLineItem_Handle(t *testing.T) {
 			mockTemporal := &MockTemporalPort{}
 			tt.mockSetup(mockTemporal)
 
-			uc := AddLineItem{T: mockTemporal}
+			uc := AddLineItem{T: mockTemporal, Poll: testPoll}
 			result, err := uc.Handle(context.Background(), tt.cmd)
 
 			if tt.expectedError != "" {
@@ -316,27 +806,778 @@ func TestAddLineItem_Handle(t *testing.T) {
 	}
 }
 
-func TestCloseBill_Handle(t *testing.T) {
+func TestAddLineItem_Handle_MaxItems(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	cmd := AddLineItemCmd{CustomerID: "customer-123", Period: "2025-01", Item: createTestLineItem()}
+	other := createTestLineItem()
+	other.IdempotencyKey = "item-other"
+
+	t.Run("full bill is rejected before the signal", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		full := createTestBill()
+		full.Items = []domain.LineItem{other}
+		full.MaxItems = 1
+		m.On("QueryBill", mock.Anything, billID).Return(full, nil)
+
+		_, err := AddLineItem{T: m}.Handle(context.Background(), cmd)
+
+		require.ErrorIs(t, err, app.ErrBillItemLimit)
+		m.AssertNotCalled(t, "AddLineItem", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("the cap of the bill, not the configured one", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		// started before the limit, the bill has no cap whatever the configuration is now
+		uncapped := createTestBill()
+		uncapped.Items = []domain.LineItem{other}
+		withItem := uncapped
+		withItem.Items = []domain.LineItem{other, createTestLineItem()}
+		m.On("QueryBill", mock.Anything, billID).Return(uncapped, nil).Once()
+		m.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil)
+		m.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+
+		bill, err := AddLineItem{T: m}.Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		assert.Len(t, bill.Items, 2)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("bill filled by a concurrent add", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		empty := createTestBill()
+		empty.MaxItems = 1
+		filled := empty
+		filled.Items = []domain.LineItem{other}
+		m.On("QueryBill", mock.Anything, billID).Return(empty, nil).Once()
+		m.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil)
+		m.On("QueryBill", mock.Anything, billID).Return(filled, nil).Once()
+
+		_, err := AddLineItem{T: m}.Handle(context.Background(), cmd)
+
+		require.ErrorIs(t, err, app.ErrBillItemLimit)
+		m.AssertExpectations(t)
+	})
+}
+
+func TestAddLineItem_Handle_RawAmount(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	item := createTestLineItem()
+	item.Amount = libmoney.Money{}
+	jpyBill := createTestBill()
+	jpyBill.Currency = libmoney.CurrencyJPY
+
 	tests := []struct {
-		name           string
-		cmd            CloseBillCmd
-		mockSetup      func(*MockTemporalPort)
-		expectedError  string
-		expectedResult domain.Bill
+		name          string
+		raw           AmountInput
+		wantAmount    string
+		expectedError error
 	}{
-		{
-			name: "successful bill closure",
-			cmd: CloseBillCmd{
-				CustomerID: "customer-123",
-				Period:     "2025-01",
-			},
-			mockSetup: func(m *MockTemporalPort) {
-				billID := domain.BillID("bill/customer-123/2025-01")
-				openBill := createTestBill()
-				closedBill := createTestBill()
-				closedBill.Status = domain.BillStatusClosed
+		{name: "minor units of the bill currency", raw: AmountInput{AmountMinor: "1050"}, wantAmount: "1050"},
+		{name: "decimal amount", raw: AmountInput{Amount: "1050"}, wantAmount: "1050"},
+		{name: "finer than the bill currency", raw: AmountInput{Amount: "10.50"}, expectedError: libmoney.ErrPrecisionExceeded},
+		{name: "malformed minor units", raw: AmountInput{AmountMinor: "10.50"}, expectedError: app.ErrInvalidAmountMinor},
+		{name: "malformed amount", raw: AmountInput{Amount: "ten"}, expectedError: app.ErrInvalidAmount},
+	}
 
-				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MockTemporalPort{}
+			m.On("QueryBill", mock.Anything, billID).Return(jpyBill, nil).Once()
+			if tt.expectedError == nil {
+				withItem := jpyBill
+				withItem.Items = []domain.LineItem{item}
+				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
+					return li.Amount.ToString() == tt.wantAmount && li.Amount.Currency() == libmoney.CurrencyJPY
+				})).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+			}
+
+			_, err := AddLineItem{T: m}.Handle(context.Background(), AddLineItemCmd{
+				CustomerID: "customer-123", Period: "2025-01", Item: item, RawAmount: tt.raw,
+			})
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+				m.AssertNotCalled(t, "AddLineItem", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+			}
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateLineItemDescription_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	cmd := UpdateLineItemDescriptionCmd{
+		CustomerID:     "customer-123",
+		Period:         "2025-01",
+		IdempotencyKey: "item-123",
+		Description:    "Corrected item",
+	}
+	billWithItem := func() domain.Bill {
+		bill := createTestBill()
+		bill.Items = []domain.LineItem{createTestLineItem()}
+		return bill
+	}
+
+	tests := []struct {
+		name          string
+		cmd           UpdateLineItemDescriptionCmd
+		mockSetup     func(*MockTemporalPort)
+		expectedError error
+	}{
+		{
+			name: "successful description update",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+				m.On("UpdateLineItemDescription", mock.Anything, billID, "item-123", "Corrected item").Return(nil)
+			},
+		},
+		{
+			name: "bill not found",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+		{
+			name: "bill already closed",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				bill := billWithItem()
+				bill.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(bill, nil)
+			},
+			expectedError: app.ErrBillAlreadyClosed,
+		},
+		{
+			name: "line item not found",
+			cmd: func() UpdateLineItemDescriptionCmd {
+				c := cmd
+				c.IdempotencyKey = "missing"
+				return c
+			}(),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+			},
+			expectedError: domain.ErrLineItemNotFound,
+		},
+		{
+			name: "signal error",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+				m.On("UpdateLineItemDescription", mock.Anything, billID, "item-123", "Corrected item").
+					Return(app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			uc := UpdateLineItemDescription{T: mockTemporal}
+			_, err := uc.Handle(context.Background(), tt.cmd)
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCorrectLineItemAmount_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	mustMoney := func(s string) libmoney.Money {
+		m, err := libmoney.NewFromString(s, libmoney.CurrencyNone)
+		require.NoError(t, err)
+		return m
+	}
+	cmd := CorrectLineItemAmountCmd{
+		CustomerID:     "customer-123",
+		Period:         "2025-01",
+		IdempotencyKey: "item-123",
+		Amount:         mustMoney("7.50"),
+	}
+	withAmount := func(c CorrectLineItemAmountCmd, amount string) CorrectLineItemAmountCmd {
+		c.Amount = mustMoney(amount)
+		return c
+	}
+	billWithItem := func() domain.Bill {
+		bill := createTestBill()
+		amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+		_, err := bill.AddItem("item-123", "Test item", amount, fixedTime)
+		require.NoError(t, err)
+		return bill
+	}
+
+	tests := []struct {
+		name          string
+		cmd           CorrectLineItemAmountCmd
+		mockSetup     func(*MockTemporalPort)
+		expectedError error
+	}{
+		{
+			name: "successful amount correction",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", cmd.Amount).Return(nil)
+			},
+		},
+		{
+			name: "bill not found",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+		{
+			name: "bill already closed",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				bill := billWithItem()
+				bill.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(bill, nil)
+			},
+			expectedError: app.ErrBillAlreadyClosed,
+		},
+		{
+			name: "line item not found",
+			cmd: func() CorrectLineItemAmountCmd {
+				c := cmd
+				c.IdempotencyKey = "missing"
+				return c
+			}(),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+			},
+			expectedError: domain.ErrLineItemNotFound,
+		},
+		{
+			name: "precision exceeded",
+			cmd:  withAmount(cmd, "7.505"),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+			},
+			expectedError: libmoney.ErrPrecisionExceeded,
+		},
+		{
+			name: "raw minor units in the bill currency",
+			cmd: CorrectLineItemAmountCmd{
+				CustomerID: "customer-123", Period: "2025-01", IdempotencyKey: "item-123", RawAmount: AmountInput{AmountMinor: "750"},
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", mock.MatchedBy(func(a libmoney.Money) bool {
+					return a.ToFixedString() == "7.50" && a.Currency() == libmoney.CurrencyUSD
+				})).Return(nil)
+			},
+		},
+		{
+			name: "raw amount invalid",
+			cmd: CorrectLineItemAmountCmd{
+				CustomerID: "customer-123", Period: "2025-01", IdempotencyKey: "item-123", RawAmount: AmountInput{Amount: "seven"},
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+			},
+			expectedError: app.ErrInvalidAmount,
+		},
+		{
+			name: "negative total",
+			cmd:  withAmount(cmd, "-0.01"),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+			},
+			expectedError: domain.ErrNegativeTotal,
+		},
+		{
+			name: "signal error",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(billWithItem(), nil)
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", cmd.Amount).
+					Return(app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			uc := CorrectLineItemAmount{T: mockTemporal}
+			_, err := uc.Handle(context.Background(), tt.cmd)
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSetBillNote_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	cmd := SetBillNoteCmd{CustomerID: "customer-123", Period: "2025-01", Note: "split the invoice"}
+
+	tests := []struct {
+		name          string
+		cmd           SetBillNoteCmd
+		mockSetup     func(*MockTemporalPort)
+		expectedError error
+	}{
+		{
+			name: "note is set",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				noted := createTestBill()
+				noted.Notes = "split the invoice"
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+				m.On("SetBillNote", mock.Anything, billID, "split the invoice").Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(noted, nil).Once()
+			},
+		},
+		{
+			name: "note too long",
+			cmd: func() SetBillNoteCmd {
+				c := cmd
+				c.Note = strings.Repeat("a", domain.MaxNoteLength+1)
+				return c
+			}(),
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: domain.ErrNoteTooLong,
+		},
+		{
+			name: "bill not found",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+		{
+			name: "bill already closed",
+			cmd:  cmd,
+			mockSetup: func(m *MockTemporalPort) {
+				bill := createTestBill()
+				bill.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(bill, nil)
+			},
+			expectedError: app.ErrBillAlreadyClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			uc := SetBillNote{T: mockTemporal}
+			bill, err := uc.Handle(context.Background(), tt.cmd)
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "split the invoice", bill.Notes)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReconcileBill_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	cmd := ReconcileBillCmd{CustomerID: "customer-123", Period: "2025-01"}
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	consistent := func() domain.Bill {
+		bill := createTestBill()
+		item := createTestLineItem()
+		item.Amount = amount
+		bill.Items = []domain.LineItem{item}
+		bill.Total = amount
+		return bill
+	}
+	drifted := consistent()
+	drifted.Total = amount.AddCents(1)
+
+	t.Run("drift is repaired and reported", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		m.On("QueryBill", mock.Anything, billID).Return(drifted, nil).Once()
+		m.On("ReconcileBill", mock.Anything, billID).Return(nil)
+		m.On("QueryBill", mock.Anything, billID).Return(consistent(), nil).Once()
+
+		res, err := ReconcileBill{T: m}.Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		assert.True(t, res.Drift)
+		assert.Equal(t, "10.01", res.Before.ToString())
+		assert.Equal(t, "10", res.After.ToString())
+		m.AssertExpectations(t)
+	})
+
+	t.Run("consistent bill", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		m.On("QueryBill", mock.Anything, billID).Return(consistent(), nil)
+		m.On("ReconcileBill", mock.Anything, billID).Return(nil)
+
+		res, err := ReconcileBill{T: m}.Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		assert.False(t, res.Drift)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("closed bill", func(t *testing.T) {
+		m := &MockTemporalPort{}
+		closed := consistent()
+		closed.Status = domain.BillStatusClosed
+		m.On("QueryBill", mock.Anything, billID).Return(closed, nil)
+
+		_, err := ReconcileBill{T: m}.Handle(context.Background(), cmd)
+
+		require.ErrorIs(t, err, app.ErrBillAlreadyClosed)
+		m.AssertExpectations(t)
+	})
+}
+
+func TestTerminateBill_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+
+	t.Run("terminates with the reason", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("TerminateBill", mock.Anything, billID, "stuck").Return(nil)
+
+		err := TerminateBill{T: mockTemporal}.Handle(context.Background(), TerminateBillCmd{BillID: billID, Reason: "stuck"})
+
+		require.NoError(t, err)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("blank reason is rejected", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+
+		err := TerminateBill{T: mockTemporal}.Handle(context.Background(), TerminateBillCmd{BillID: billID, Reason: "  "})
+
+		assert.ErrorIs(t, err, app.ErrTerminateReasonRequired)
+		mockTemporal.AssertNotCalled(t, "TerminateBill", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateCreditNote_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	now := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	cmd := CreateCreditNoteCmd{
+		CustomerID:     "customer-123",
+		Period:         "2025-01",
+		IdempotencyKey: "goodwill-1",
+		Amount:         libmoney.NewFromInt(5, libmoney.CurrencyNone),
+		Reason:         "outage",
+	}
+	closedBill := func() domain.Bill {
+		b := createTestBill()
+		b.Status = domain.BillStatusClosed
+		b.Total = libmoney.NewFromInt(20, libmoney.CurrencyUSD)
+		return b
+	}
+
+	t.Run("credit note against a closed bill", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+		mockTemporal.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
+		mockTemporal.On("StartCreditNote", mock.Anything, mock.MatchedBy(func(n domain.CreditNote) bool {
+			return n.BillID == billID && n.Total.ToFixedString() == "-5.00" && n.CreatedAt.Equal(now)
+		})).Return(nil)
+
+		note, err := CreateCreditNote{T: mockTemporal, Now: func() time.Time { return now }}.Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.CreditNoteID("credit/bill/customer-123/2025-01/goodwill-1"), note.ID)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("non-existent bill", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+
+		_, err := CreateCreditNote{T: mockTemporal}.Handle(context.Background(), cmd)
+
+		assert.ErrorIs(t, err, app.ErrBillNotFound)
+		mockTemporal.AssertNotCalled(t, "StartCreditNote", mock.Anything, mock.Anything)
+	})
+
+	t.Run("open bill", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+		mockTemporal.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
+
+		_, err := CreateCreditNote{T: mockTemporal}.Handle(context.Background(), cmd)
+
+		assert.ErrorIs(t, err, domain.ErrCreditNoteBillNotClosed)
+		mockTemporal.AssertNotCalled(t, "StartCreditNote", mock.Anything, mock.Anything)
+	})
+
+	t.Run("credit notes together over the bill total", func(t *testing.T) {
+		earlier, err := domain.NewCreditNote(closedBill(), nil, "goodwill-0",
+			libmoney.NewFromInt(18, libmoney.CurrencyNone), "outage", now)
+		require.NoError(t, err)
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+		mockTemporal.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote{earlier}, nil)
+
+		_, err = CreateCreditNote{T: mockTemporal}.Handle(context.Background(), cmd)
+
+		assert.ErrorIs(t, err, domain.ErrCreditNoteExceedsBill)
+		mockTemporal.AssertNotCalled(t, "StartCreditNote", mock.Anything, mock.Anything)
+	})
+
+	t.Run("retried with the same key", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+		mockTemporal.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
+		mockTemporal.On("StartCreditNote", mock.Anything, mock.Anything).Return(app.ErrCreditNoteAlreadyExists)
+
+		_, err := CreateCreditNote{T: mockTemporal}.Handle(context.Background(), cmd)
+
+		assert.ErrorIs(t, err, app.ErrCreditNoteAlreadyExists)
+	})
+}
+
+func TestCloseAllBills_Handle(t *testing.T) {
+	billAt := func(period string, status domain.BillStatus) domain.Bill {
+		b := createTestBill()
+		b.ID = domain.MakeBillID("customer-123", domain.BillingPeriod(period))
+		b.BillingPeriod = domain.BillingPeriod(period)
+		b.Status = status
+		return b
+	}
+	openFilter := app.SearchBillFilter{CustomerID: "customer-123", Status: []string{"OPEN"}}
+
+	mockTemporal := &MockTemporalPort{}
+	// the search lags, 2025-02 was closed meanwhile
+	mockTemporal.On("SearchBills", mock.Anything, openFilter).Return([]views.BillSummary{
+		{WorkflowID: "bill/customer-123/2025-01", CustomerID: "customer-123", Status: "OPEN"},
+		{WorkflowID: "bill/customer-123/2025-02", CustomerID: "customer-123", Status: "OPEN"},
+		{WorkflowID: "bill/customer-123/2025-03", CustomerID: "customer-123", Status: "OPEN"},
+	}, nil)
+	jan, feb, mar := billAt("2025-01", domain.BillStatusOpen), billAt("2025-02", domain.BillStatusClosed),
+		billAt("2025-03", domain.BillStatusOpen)
+	janPending := jan
+	janPending.Status = domain.BillStatusPending
+	mockTemporal.On("QueryBill", mock.Anything, jan.ID).Return(jan, nil).Once()
+	mockTemporal.On("CloseBill", mock.Anything, jan.ID).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, jan.ID).Return(janPending, nil).Once()
+	mockTemporal.On("QueryBill", mock.Anything, feb.ID).Return(feb, nil)
+	mockTemporal.On("QueryBill", mock.Anything, mar.ID).Return(mar, nil)
+	mockTemporal.On("CloseBill", mock.Anything, mar.ID).Return(errors.New("signal failed"))
+
+	results, err := CloseAllBills{T: mockTemporal}.Handle(context.Background(), CloseAllBillsCmd{CustomerID: "customer-123"})
+
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, CloseAllBillsResult{BillID: jan.ID, Outcome: CloseAllBillsClosed}, results[0])
+	assert.Equal(t, CloseAllBillsResult{BillID: feb.ID, Outcome: CloseAllBillsSkipped}, results[1])
+	assert.Equal(t, mar.ID, results[2].BillID)
+	assert.Equal(t, CloseAllBillsError, results[2].Outcome)
+	assert.EqualError(t, results[2].Err, "signal failed")
+	mockTemporal.AssertExpectations(t)
+	mockTemporal.AssertNotCalled(t, "CloseBill", mock.Anything, feb.ID)
+}
+
+func TestCloseAllBills_Concurrency(t *testing.T) {
+	var summaries []views.BillSummary
+	for m := 1; m <= 6; m++ {
+		summaries = append(summaries, views.BillSummary{WorkflowID: fmt.Sprintf("bill/customer-123/2025-%02d", m)})
+	}
+	var inFlight, maxInFlight atomic.Int32
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, mock.Anything).Return(summaries, nil)
+	bill := createTestBill()
+	bill.Items = []domain.LineItem{createTestLineItem()}
+	mockTemporal.On("QueryBill", mock.Anything, mock.Anything).Return(bill, nil)
+	mockTemporal.On("CloseBill", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		n := inFlight.Add(1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+	}).Return(nil)
+
+	// the mock never shows the bills closed, each one waits out the poll timeout
+	results, err := CloseAllBills{T: mockTemporal, Concurrency: 2, Poll: testPoll}.Handle(context.Background(),
+		CloseAllBillsCmd{CustomerID: "customer-123"})
+
+	require.NoError(t, err)
+	require.Len(t, results, 6)
+	for i, r := range results {
+		assert.Equal(t, domain.BillID(summaries[i].WorkflowID), r.BillID, "results keep the search order")
+		assert.Equal(t, CloseAllBillsClosed, r.Outcome)
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+}
+
+func TestCloseAllBills_IgnoresRunID(t *testing.T) {
+	latestRun := mock.MatchedBy(func(ctx context.Context) bool { return app.RunID(ctx) == "" })
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, mock.Anything).Return([]views.BillSummary{
+		{WorkflowID: "bill/customer-123/2025-01"},
+		{WorkflowID: "bill/customer-123/2025-02"},
+	}, nil)
+	closed := createTestBill()
+	closed.Status = domain.BillStatusClosed
+	mockTemporal.On("QueryBill", latestRun, mock.Anything).Return(closed, nil)
+
+	ctx := app.WithRunID(context.Background(), "0d6f4b7e-5c1a-4f0e-9b7a-3f2d1c0b9a88")
+	results, err := CloseAllBills{T: mockTemporal}.Handle(ctx, CloseAllBillsCmd{CustomerID: "customer-123"})
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	mockTemporal.AssertNumberOfCalls(t, "QueryBill", 2)
+}
+
+func TestCloseAllBills_SearchFails(t *testing.T) {
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, mock.Anything).
+		Return([]views.BillSummary(nil), app.ErrSearchAttributesNotRegistered)
+
+	_, err := CloseAllBills{T: mockTemporal}.Handle(context.Background(), CloseAllBillsCmd{CustomerID: "customer-123"})
+
+	assert.ErrorIs(t, err, app.ErrSearchAttributesNotRegistered)
+}
+
+func TestPurgeBills_Guard(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		allowed   []string
+	}{
+		{name: "no allowlist", namespace: "default"},
+		{name: "namespace not allowed", namespace: "fees-prod", allowed: []string{"default", "fees-dev"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+
+			_, err := PurgeBills{T: mockTemporal, Namespace: tt.namespace, AllowedNamespaces: tt.allowed}.
+				Handle(context.Background(), PurgeBillsCmd{CustomerID: "customer-123", Reason: "dev cleanup"})
+
+			require.ErrorIs(t, err, app.ErrPurgeNotAllowed)
+			mockTemporal.AssertNotCalled(t, "SearchBills", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("reason required", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+
+		_, err := PurgeBills{T: mockTemporal, Namespace: "default", AllowedNamespaces: []string{"default"}}.
+			Handle(context.Background(), PurgeBillsCmd{CustomerID: "customer-123", Reason: " "})
+
+		require.ErrorIs(t, err, app.ErrTerminateReasonRequired)
+		mockTemporal.AssertNotCalled(t, "SearchBills", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPurgeBills_Handle(t *testing.T) {
+	before := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	var summaries []views.BillSummary
+	for m := 1; m <= 6; m++ {
+		summaries = append(summaries, views.BillSummary{WorkflowID: fmt.Sprintf("bill/customer-123/2025-%02d", m)})
+	}
+	var inFlight, maxInFlight atomic.Int32
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, app.SearchBillFilter{
+		CustomerID: "customer-123", Status: []string{"OPEN"}, CreatedTo: &before,
+	}).Return(summaries, nil)
+	terminate := func(mock.Arguments) {
+		n := inFlight.Add(1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+	}
+	// 2025-02 completed meanwhile, 2025-03 fails
+	mockTemporal.On("TerminateBill", mock.Anything, domain.BillID("bill/customer-123/2025-02"), "dev cleanup").
+		Run(terminate).Return(app.ErrBillNotFound)
+	mockTemporal.On("TerminateBill", mock.Anything, domain.BillID("bill/customer-123/2025-03"), "dev cleanup").
+		Run(terminate).Return(errors.New("temporal unavailable"))
+	mockTemporal.On("TerminateBill", mock.Anything, mock.Anything, "dev cleanup").Run(terminate).Return(nil)
+
+	res, err := PurgeBills{T: mockTemporal, Namespace: "default", AllowedNamespaces: []string{"default"}, Concurrency: 2}.
+		Handle(context.Background(), PurgeBillsCmd{
+			CustomerID: "customer-123", Status: "OPEN", CreatedBefore: &before, Reason: "dev cleanup",
+		})
+
+	require.NoError(t, err)
+	assert.Equal(t, 6, res.Matched)
+	assert.Equal(t, 4, res.Terminated)
+	assert.Equal(t, 1, res.Skipped)
+	assert.Equal(t, 1, res.Failed)
+	assert.EqualError(t, res.Failures["bill/customer-123/2025-03"], "temporal unavailable")
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+	mockTemporal.AssertNumberOfCalls(t, "TerminateBill", 6)
+}
+
+func TestPurgeBills_SearchFails(t *testing.T) {
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, app.SearchBillFilter{CustomerID: "customer-123"}).
+		Return([]views.BillSummary(nil), app.ErrSearchAttributesNotRegistered)
+
+	_, err := PurgeBills{T: mockTemporal, Namespace: "default", AllowedNamespaces: []string{"default"}}.
+		Handle(context.Background(), PurgeBillsCmd{CustomerID: "customer-123", Reason: "dev cleanup"})
+
+	assert.ErrorIs(t, err, app.ErrSearchAttributesNotRegistered)
+}
+
+func TestCloseBill_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		cmd            CloseBillCmd
+		mockSetup      func(*MockTemporalPort)
+		expectedError  string
+		expectedResult domain.Bill
+	}{
+		{
+			name: "successful bill closure",
+			cmd: CloseBillCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				openBill := createTestBill()
+				closedBill := createTestBill()
+				closedBill.Status = domain.BillStatusClosed
+
+				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
 				m.On("CloseBill", mock.Anything, billID).Return(nil)
 				m.On("QueryBill", mock.Anything, billID).Return(closedBill, nil).Once()
 			},
@@ -373,6 +1614,40 @@ func TestCloseBill_Handle(t *testing.T) {
 			},
 			expectedError: app.ErrBillAlreadyClosed.Error(),
 		},
+		{
+			name: "empty bill refused by the policy",
+			cmd: CloseBillCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				emptyBill := createTestBill()
+				emptyBill.ItemsRequired = true
+				m.On("QueryBill", mock.Anything, billID).Return(emptyBill, nil)
+			},
+			expectedError: app.ErrBillEmpty.Error(),
+		},
+		{
+			name: "empty bill allowed by the policy",
+			cmd: CloseBillCmd{
+				CustomerID: "customer-123",
+				Period:     "2025-01",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				closedBill := createTestBill()
+				closedBill.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+				m.On("CloseBill", mock.Anything, billID).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(closedBill, nil).Once()
+			},
+			expectedResult: func() domain.Bill {
+				bill := createTestBill()
+				bill.Status = domain.BillStatusClosed
+				return bill
+			}(),
+		},
 		{
 			name: "bill in pending status",
 			cmd: CloseBillCmd{
@@ -426,7 +1701,7 @@ func TestCloseBill_Handle(t *testing.T) {
 			mockTemporal := &MockTemporalPort{}
 			tt.mockSetup(mockTemporal)
 
-			uc := CloseBill{T: mockTemporal}
+			uc := CloseBill{T: mockTemporal, Poll: testPoll}
 			result, err := uc.Handle(context.Background(), tt.cmd)
 
 			if tt.expectedError != "" {
@@ -442,6 +1717,91 @@ func TestCloseBill_Handle(t *testing.T) {
 	}
 }
 
+func TestRetryInvoicing_Handle(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	failedBill := func(status domain.BillStatus, retryable bool) domain.Bill {
+		b := createTestBill()
+		b.Status = status
+		b.InvoicingRetryable = retryable
+		return b
+	}
+	tests := []struct {
+		name          string
+		mockSetup     func(*MockTemporalPort)
+		expectedError error
+	}{
+		{
+			name: "retryable failure is retried",
+			mockSetup: func(m *MockTemporalPort) {
+				pending := createTestBill()
+				pending.Status = domain.BillStatusPending
+
+				m.On("QueryBill", mock.Anything, billID).Return(failedBill(domain.BillStatusChargeFailed, true), nil).Once()
+				m.On("RetryInvoicing", mock.Anything, billID).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(pending, nil).Once()
+			},
+		},
+		{
+			name: "retryable failure of a bill errored before the split is retried",
+			mockSetup: func(m *MockTemporalPort) {
+				pending := createTestBill()
+				pending.Status = domain.BillStatusPending
+
+				m.On("QueryBill", mock.Anything, billID).Return(failedBill(domain.BillStatusError, true), nil).Once()
+				m.On("RetryInvoicing", mock.Anything, billID).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(pending, nil).Once()
+			},
+		},
+		{
+			name: "rejected bill",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(failedBill(domain.BillStatusRejected, true), nil)
+			},
+			expectedError: domain.ErrInvoicingNotRetryable,
+		},
+		{
+			name: "bill not in error",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			},
+			expectedError: app.ErrBillNotInError,
+		},
+		{
+			name: "non-retryable failure",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(failedBill(domain.BillStatusChargeFailed, false), nil)
+			},
+			expectedError: domain.ErrInvoicingNotRetryable,
+		},
+		{
+			name: "bill not found",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			uc := RetryInvoicing{T: mockTemporal}
+			result, err := uc.Handle(context.Background(), RetryInvoicingCmd{CustomerID: "customer-123", Period: "2025-01"})
+
+			if tt.expectedError != nil {
+				require.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, domain.BillStatusPending, result.Status)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGetBill_Handle(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -498,17 +1858,77 @@ func TestGetBill_Handle(t *testing.T) {
 			uc := GetBill{T: mockTemporal}
 			result, err := uc.Handle(context.Background(), tt.cmd)
 
-			if tt.expectedError != "" {
-				require.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				require.NoError(t, err)
-				assert.Equal(t, tt.expectedResult, result)
-			}
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedResult, result)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetBillSummary_Handle(t *testing.T) {
+	summary := views.BillStateSummary{ID: "bill/customer-123/2025-01", Status: "OPEN", ItemCount: 2}
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("QueryBillSummary", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+		Return(summary, nil)
+
+	uc := GetBillSummary{T: mockTemporal}
+	result, err := uc.Handle(context.Background(), GetBillCmd{CustomerID: "customer-123", Period: "2025-01"})
+
+	require.NoError(t, err)
+	assert.Equal(t, summary, result)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestGetBillMemo_Handle(t *testing.T) {
+	memo := app.BillMemo{
+		CorrelationID: "req-42",
+		CreateParams:  &app.MonthlyFeeAccrualWorkflowParams{BillID: "bill/customer-123/2025-01", CustomerID: "customer-123"},
+	}
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("GetBillMemo", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(memo, nil)
+
+	uc := GetBillMemo{T: mockTemporal}
+	result, err := uc.Handle(context.Background(), GetBillCmd{CustomerID: "customer-123", Period: "2025-01"})
+
+	require.NoError(t, err)
+	assert.Equal(t, memo, result)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestGetBillAsOf_Handle(t *testing.T) {
+	at := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("QueryBillAsOf", mock.Anything, domain.BillID("bill/customer-123/2025-01"), at).
+		Return(createTestBill(), nil)
+
+	uc := GetBillAsOf{T: mockTemporal}
+	result, err := uc.Handle(context.Background(), GetBillAsOfCmd{CustomerID: "customer-123", Period: "2025-01", At: at})
+
+	require.NoError(t, err)
+	assert.Equal(t, createTestBill(), result)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestGetBillByExecution_Handle(t *testing.T) {
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("QueryBillByExecution", mock.Anything, "bill/customer-123/2025-01", "run-1").
+		Return(createTestBill(), nil)
+
+	uc := GetBillByExecution{T: mockTemporal}
+	result, err := uc.Handle(context.Background(), GetBillByExecutionCmd{
+		WorkflowID: "bill/customer-123/2025-01",
+		RunID:      "run-1",
+	})
 
-			mockTemporal.AssertExpectations(t)
-		})
-	}
+	require.NoError(t, err)
+	assert.Equal(t, createTestBill(), result)
+	mockTemporal.AssertExpectations(t)
 }
 
 func TestSearchBill_Handle(t *testing.T) {
@@ -519,6 +1939,23 @@ func TestSearchBill_Handle(t *testing.T) {
 		expectedError  string
 		expectedResult []views.BillSummary
 	}{
+		{
+			name: "finalized within days is passed to the filter",
+			cmd: SearchBillCmd{
+				CustomerID:          "customer-123",
+				Status:              string(domain.BillStatusClosed),
+				FinalizedWithinDays: 7,
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				expectedFilter := app.SearchBillFilter{
+					CustomerID:          "customer-123",
+					Status:              []string{string(domain.BillStatusClosed)},
+					FinalizedWithinDays: 7,
+				}
+				m.On("SearchBills", mock.Anything, expectedFilter).Return([]views.BillSummary{}, nil)
+			},
+			expectedResult: []views.BillSummary{},
+		},
 		{
 			name: "successful search with open status",
 			cmd: SearchBillCmd{
@@ -687,7 +2124,631 @@ func TestSearchBill_Handle(t *testing.T) {
 	}
 }
 
+func TestSumFees_Handle(t *testing.T) {
+	billWithItems := func() domain.Bill {
+		bill := createTestBill()
+		bill.Items = []domain.LineItem{
+			{IdempotencyKey: "k1", Description: "API usage fee", Amount: libmoney.NewFromFloat(10.50, libmoney.CurrencyUSD)},
+			{IdempotencyKey: "k2", Description: "Storage fee", Amount: libmoney.NewFromFloat(3.00, libmoney.CurrencyUSD)},
+			{IdempotencyKey: "k3", Description: "api usage fee (burst)", Amount: libmoney.NewFromFloat(2.25, libmoney.CurrencyUSD)},
+		}
+		return bill
+	}
+
+	tests := []struct {
+		name          string
+		pattern       string
+		bill          domain.Bill
+		queryErr      error
+		expectedTotal string
+		expectedCount int
+		expectedError string
+	}{
+		{
+			name:          "substring match is case-insensitive and excludes non-matches",
+			pattern:       "API usage",
+			bill:          billWithItems(),
+			expectedTotal: "12.75",
+			expectedCount: 2,
+		},
+		{
+			name:          "glob match",
+			pattern:       "storage*",
+			bill:          billWithItems(),
+			expectedTotal: "3",
+			expectedCount: 1,
+		},
+		{
+			name:          "no matches",
+			pattern:       "support",
+			bill:          billWithItems(),
+			expectedTotal: "0",
+			expectedCount: 0,
+		},
+		{
+			name:          "invalid glob",
+			pattern:       "[api",
+			bill:          billWithItems(),
+			expectedError: "description pattern error",
+		},
+		{
+			name:          "bill not found",
+			pattern:       "api",
+			queryErr:      app.ErrBillNotFound,
+			expectedError: app.ErrBillNotFound.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			mockTemporal.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(tt.bill, tt.queryErr)
+
+			uc := SumFees{T: mockTemporal}
+			result, err := uc.Handle(context.Background(), SumFeesCmd{
+				CustomerID:         "customer-123",
+				Period:             "2025-01",
+				DescriptionPattern: tt.pattern,
+			})
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedTotal, result.Total.ToString())
+				assert.Equal(t, tt.expectedCount, result.Count)
+				assert.Equal(t, libmoney.CurrencyUSD, result.Currency)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
 // Helper function to create int64 pointer
 func int64Ptr(i int64) *int64 {
 	return &i
 }
+
+func TestBackfillSearchAttributes_Handle(t *testing.T) {
+	tests := []struct {
+		name           string
+		cmd            BackfillSearchAttributesCmd
+		mockSetup      func(*MockTemporalPort)
+		expectedError  string
+		expectedResult BackfillSearchAttributesResult
+		expectedPages  int
+	}{
+		{
+			name: "two pages",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("RefreshSearchAttributes", mock.Anything, []byte(nil)).
+					Return(app.RefreshPage{Signaled: 100, NextPageToken: []byte("page-2")}, nil).Once()
+				m.On("RefreshSearchAttributes", mock.Anything, []byte("page-2")).
+					Return(app.RefreshPage{Signaled: 3, Skipped: 1}, nil).Once()
+			},
+			expectedResult: BackfillSearchAttributesResult{Pages: 2, Signaled: 103, Skipped: 1},
+			expectedPages:  2,
+		},
+		{
+			name: "resumes from the given page token",
+			cmd:  BackfillSearchAttributesCmd{PageToken: []byte("page-2")},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("RefreshSearchAttributes", mock.Anything, []byte("page-2")).
+					Return(app.RefreshPage{Signaled: 3}, nil).Once()
+			},
+			expectedResult: BackfillSearchAttributesResult{Pages: 1, Signaled: 3},
+			expectedPages:  1,
+		},
+		{
+			name: "error keeps the failed page token to resume from",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("RefreshSearchAttributes", mock.Anything, []byte(nil)).
+					Return(app.RefreshPage{Signaled: 100, NextPageToken: []byte("page-2")}, nil).Once()
+				m.On("RefreshSearchAttributes", mock.Anything, []byte("page-2")).
+					Return(app.RefreshPage{}, errors.New("list failed")).Once()
+			},
+			expectedError:  "page 2: list failed",
+			expectedResult: BackfillSearchAttributesResult{Pages: 1, Signaled: 100, NextPageToken: []byte("page-2")},
+			expectedPages:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			tt.mockSetup(mockTemporal)
+
+			progressCalls := 0
+			cmd := tt.cmd
+			cmd.OnProgress = func(BackfillSearchAttributesResult) { progressCalls++ }
+
+			uc := BackfillSearchAttributes{T: mockTemporal}
+			result, err := uc.Handle(context.Background(), cmd)
+
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResult, result)
+			assert.Equal(t, tt.expectedPages, progressCalls)
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCountBills_Handle(t *testing.T) {
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("CountBills", mock.Anything, app.SearchBillFilter{
+		CustomerID: "customer-123",
+		FromYYYYMM: int64Ptr(202501),
+		Status:     []string{string(domain.BillStatusOpen), string(domain.BillStatusPending)},
+	}).Return(int64(7), nil)
+
+	uc := CountBills{T: mockTemporal}
+	n, err := uc.Handle(context.Background(), CountBillsCmd{
+		CustomerID: "customer-123",
+		PeriodFrom: "2025-01",
+		Status:     string(domain.BillStatusOpen),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	mockTemporal.AssertExpectations(t)
+
+	_, err = uc.Handle(context.Background(), CountBillsCmd{CustomerID: "customer-123", PeriodFrom: "2025-13"})
+	assert.ErrorContains(t, err, "fromInt conversion error")
+}
+
+// failingKafka is an audit publisher that is down
+type failingKafka struct{}
+
+func (failingKafka) PublishBillEvent(context.Context, views.BillEvent) error {
+	return errors.New("broker unavailable")
+}
+
+func TestAudit_CreateBill(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("StartMonthlyBill", mock.Anything, mock.Anything).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+	audit := kafka.NewInMemory()
+
+	uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, Audit: audit}
+	ctx := app.WithCorrelationID(context.Background(), "req-1")
+	_, err := uc.Handle(ctx, CreateBillCmd{CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD})
+
+	require.NoError(t, err)
+	events := audit.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, views.BillEventCreated, events[0].Type)
+	assert.Equal(t, string(billID), events[0].BillID)
+	assert.Equal(t, "OPEN", events[0].Status)
+	assert.Equal(t, "req-1", events[0].CorrelationID)
+	assert.Equal(t, fixedTime, events[0].OccurredAt)
+}
+
+func TestAudit_CreateBillReplayPublishesNothing(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("StartMonthlyBill", mock.Anything, mock.Anything).Return(app.ErrBillWithPeriodAlreadyStarted)
+	mockTemporal.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{CreateIdempotencyKey: "create-1"}, nil)
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+	audit := kafka.NewInMemory()
+
+	uc := CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }, Audit: audit}
+	_, err := uc.Handle(context.Background(), CreateBillCmd{
+		CustomerID: "customer-123", Period: "2025-01", Currency: libmoney.CurrencyUSD, IdempotencyKey: "create-1",
+	})
+
+	require.NoError(t, err)
+	assert.Empty(t, audit.Events(), "the bill was created, and audited, by the first request")
+}
+
+func TestAudit_AddLineItem(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	amount, _ := libmoney.NewFromString("10.50", libmoney.CurrencyUSD)
+	withItem := createTestBill()
+	withItem.Items = []domain.LineItem{createTestLineItem()}
+	withItem.Total = amount
+
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+	mockTemporal.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+	audit := kafka.NewInMemory()
+
+	_, err := AddLineItem{T: mockTemporal, Audit: audit}.Handle(context.Background(), AddLineItemCmd{
+		CustomerID: "customer-123", Period: "2025-01", Item: createTestLineItem(),
+	})
+	require.NoError(t, err)
+
+	events := audit.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, views.BillEventItemAdded, events[0].Type)
+	assert.Equal(t, 1, events[0].ItemCount)
+	assert.Equal(t, libmoney.MoneyMinor{Minor: 1050, Currency: libmoney.CurrencyUSD}, events[0].Total)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestAudit_PublishFailureKeepsTheResult(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	withItem := createTestBill()
+	withItem.Items = []domain.LineItem{createTestLineItem()}
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+	mockTemporal.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(withItem, nil).Once()
+
+	bill, err := AddLineItem{T: mockTemporal, Audit: failingKafka{}}.Handle(context.Background(), AddLineItemCmd{
+		CustomerID: "customer-123", Period: "2025-01", Item: createTestLineItem(),
+	})
+
+	// the item was added, only its event is missing
+	assert.ErrorIs(t, err, app.ErrAuditNotPublished)
+	assert.ErrorContains(t, err, "broker unavailable")
+	assert.Equal(t, withItem, bill)
+}
+
+func TestSearchBill_PeriodRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     domain.BillingPeriod
+		to       domain.BillingPeriod
+		wantErr  bool
+		wantFrom *int64
+		wantTo   *int64
+	}{
+		{name: "reversed", from: "2025-03", to: "2025-01", wantErr: true},
+		{name: "equal", from: "2025-01", to: "2025-01", wantFrom: int64Ptr(202501), wantTo: int64Ptr(202501)},
+		{name: "only from", from: "2025-03", wantFrom: int64Ptr(202503)},
+		{name: "only to", to: "2025-01", wantTo: int64Ptr(202501)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			if !tt.wantErr {
+				mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return assert.ObjectsAreEqual(tt.wantFrom, f.FromYYYYMM) && assert.ObjectsAreEqual(tt.wantTo, f.ToYYYYMM)
+				})).Return([]views.BillSummary{}, nil)
+				mockTemporal.On("CountBills", mock.Anything, mock.Anything).Return(int64(0), nil)
+			}
+			cmd := SearchBillCmd{CustomerID: "customer-123", PeriodFrom: tt.from, PeriodTo: tt.to, Status: "OPEN"}
+
+			_, err := SearchBill{T: mockTemporal}.Handle(context.Background(), cmd)
+			_, errCount := CountBills{T: mockTemporal}.Handle(context.Background(), CountBillsCmd(cmd))
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, domain.ErrInvalidPeriodRange)
+				require.ErrorIs(t, errCount, domain.ErrInvalidPeriodRange)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, errCount)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchBill_TotalRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     *int64
+		max     *int64
+		wantErr bool
+	}{
+		{name: "reversed", min: int64Ptr(500), max: int64Ptr(100), wantErr: true},
+		{name: "equal", min: int64Ptr(100), max: int64Ptr(100)},
+		{name: "only min", min: int64Ptr(100)},
+		{name: "only max", max: int64Ptr(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			if !tt.wantErr {
+				mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return assert.ObjectsAreEqual(tt.min, f.MinTotalCents) && assert.ObjectsAreEqual(tt.max, f.MaxTotalCents)
+				})).Return([]views.BillSummary{}, nil)
+			}
+
+			_, err := SearchBill{T: mockTemporal}.Handle(context.Background(), SearchBillCmd{
+				CustomerID: "customer-123", Status: "OPEN", MinTotalCents: tt.min, MaxTotalCents: tt.max,
+			})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, app.ErrInvalidTotalRange)
+			} else {
+				require.NoError(t, err)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchBill_ItemCountRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     *int64
+		max     *int64
+		wantErr bool
+	}{
+		{name: "reversed", min: int64Ptr(10), max: int64Ptr(1), wantErr: true},
+		{name: "empty bills", max: int64Ptr(0)},
+		{name: "only min", min: int64Ptr(100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			if !tt.wantErr {
+				mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return assert.ObjectsAreEqual(tt.min, f.MinItemCount) && assert.ObjectsAreEqual(tt.max, f.MaxItemCount)
+				})).Return([]views.BillSummary{}, nil)
+			}
+
+			_, err := SearchBill{T: mockTemporal}.Handle(context.Background(), SearchBillCmd{
+				CustomerID: "customer-123", Status: "OPEN", MinItemCount: tt.min, MaxItemCount: tt.max,
+			})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, app.ErrInvalidItemCountRange)
+			} else {
+				require.NoError(t, err)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchBill_CreatedRange(t *testing.T) {
+	jan1 := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := jan1.AddDate(0, 0, 1)
+	tests := []struct {
+		name    string
+		from    *time.Time
+		to      *time.Time
+		wantErr bool
+	}{
+		{name: "reversed", from: &jan2, to: &jan1, wantErr: true},
+		{name: "equal", from: &jan1, to: &jan1},
+		{name: "only to", to: &jan2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			if !tt.wantErr {
+				mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return f.CreatedFrom == tt.from && f.CreatedTo == tt.to
+				})).Return([]views.BillSummary{}, nil)
+			}
+
+			_, err := SearchBill{T: mockTemporal}.Handle(context.Background(), SearchBillCmd{
+				CustomerID: "customer-123", Status: "OPEN", CreatedFrom: tt.from, CreatedTo: tt.to,
+			})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, app.ErrInvalidCreatedRange)
+			} else {
+				require.NoError(t, err)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSearchBill_ActiveRange(t *testing.T) {
+	jan15 := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb15 := jan15.AddDate(0, 1, 0)
+	tests := []struct {
+		name    string
+		from    *time.Time
+		to      *time.Time
+		wantErr bool
+	}{
+		{name: "reversed", from: &feb15, to: &jan15, wantErr: true},
+		{name: "active at an instant", from: &jan15, to: &jan15},
+		{name: "only from", from: &jan15},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			if !tt.wantErr {
+				mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return f.ActiveFrom == tt.from && f.ActiveTo == tt.to
+				})).Return([]views.BillSummary{}, nil)
+			}
+
+			_, err := SearchBill{T: mockTemporal}.Handle(context.Background(), SearchBillCmd{
+				CustomerID: "customer-123", Status: "OPEN", ActiveFrom: tt.from, ActiveTo: tt.to,
+			})
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, app.ErrInvalidActiveRange)
+			} else {
+				require.NoError(t, err)
+			}
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportBills_Handle(t *testing.T) {
+	filter := app.SearchBillFilter{CustomerID: "customer-123"}
+
+	t.Run("emits every page", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).Return(app.SearchBillPage{
+			Bills:         []views.BillSummary{{WorkflowID: "bill/customer-123/2025-01"}},
+			NextPageToken: []byte("page-2"),
+		}, nil).Once()
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte("page-2")).Return(app.SearchBillPage{
+			Bills: []views.BillSummary{{WorkflowID: "bill/customer-123/2025-02"}},
+		}, nil).Once()
+
+		var pages [][]views.BillSummary
+		err := ExportBills{T: mockTemporal}.Handle(context.Background(), ExportBillsCmd{
+			CustomerID: "customer-123",
+			Emit: func(bills []views.BillSummary) error {
+				pages = append(pages, bills)
+				return nil
+			},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.Equal(t, "bill/customer-123/2025-02", pages[1][0].WorkflowID)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("an emit error stops the export", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).Return(app.SearchBillPage{
+			NextPageToken: []byte("page-2"),
+		}, nil).Once()
+		errWrite := errors.New("client went away")
+
+		err := ExportBills{T: mockTemporal}.Handle(context.Background(), ExportBillsCmd{
+			CustomerID: "customer-123",
+			Emit:       func([]views.BillSummary) error { return errWrite },
+		})
+
+		require.ErrorIs(t, err, errWrite)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("search error", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).
+			Return(app.SearchBillPage{}, app.ErrSearchAttributesNotRegistered)
+
+		err := ExportBills{T: mockTemporal}.Handle(context.Background(), ExportBillsCmd{
+			CustomerID: "customer-123",
+			Emit:       func([]views.BillSummary) error { return nil },
+		})
+
+		require.ErrorIs(t, err, app.ErrSearchAttributesNotRegistered)
+	})
+}
+
+func TestListErroredBills_Handle(t *testing.T) {
+	from, to := int64(202501), int64(202503)
+	mockTemporal := &MockTemporalPort{}
+	mockTemporal.On("SearchBills", mock.Anything, app.SearchBillFilter{
+		AllCustomers: true, FromYYYYMM: &from, ToYYYYMM: &to, Status: []string{"CHARGE_FAILED", "REJECTED", "ERROR"},
+	}).Return([]views.BillSummary{
+		{WorkflowID: "bill/customer-1/2025-01", CustomerID: "customer-1", Status: "REJECTED", ErrorReason: "card declined"},
+		{WorkflowID: "bill/customer-2/2025-02", CustomerID: "customer-2", Status: "CHARGE_FAILED"},
+	}, nil)
+
+	bills, err := ListErroredBills{T: mockTemporal}.Handle(context.Background(), ListErroredBillsCmd{
+		PeriodFrom: "2025-01", PeriodTo: "2025-03",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, bills, 2)
+	assert.Equal(t, "card declined", bills[0].ErrorReason)
+	mockTemporal.AssertExpectations(t)
+
+	_, err = ListErroredBills{T: mockTemporal}.Handle(context.Background(), ListErroredBillsCmd{
+		PeriodFrom: "2025-03", PeriodTo: "2025-01",
+	})
+	assert.ErrorIs(t, err, domain.ErrInvalidPeriodRange)
+}
+
+func TestAggregateBillTotals_Handle(t *testing.T) {
+	t.Run("periods without bills are listed in a closed range", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("AggregateBillTotals", mock.Anything, "customer-123", int64Ptr(202411), int64Ptr(202503)).
+			Return([]views.BillPeriodTotal{
+				{PeriodYYYYMM: 202412, Currency: "USD", TotalCents: 1500, Count: 2},
+				{PeriodYYYYMM: 202502, Currency: "GEL", TotalCents: 300, Count: 1},
+				{PeriodYYYYMM: 202502, Currency: "USD", TotalCents: 700, Count: 1},
+			}, nil)
+
+		totals, err := AggregateBillTotals{T: mockTemporal}.Handle(context.Background(), AggregateBillTotalsCmd{
+			CustomerID: "customer-123", PeriodFrom: "2024-11", PeriodTo: "2025-03",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []views.BillPeriodTotal{
+			{PeriodYYYYMM: 202411},
+			{PeriodYYYYMM: 202412, Currency: "USD", TotalCents: 1500, Count: 2},
+			{PeriodYYYYMM: 202501},
+			{PeriodYYYYMM: 202502, Currency: "GEL", TotalCents: 300, Count: 1},
+			{PeriodYYYYMM: 202502, Currency: "USD", TotalCents: 700, Count: 1},
+			{PeriodYYYYMM: 202503},
+		}, totals)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("open range lists only periods with bills", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		want := []views.BillPeriodTotal{
+			{PeriodYYYYMM: 202501, Currency: "USD", TotalCents: 1000, Count: 1},
+			{PeriodYYYYMM: 202504, Currency: "USD", TotalCents: 2000, Count: 1},
+		}
+		mockTemporal.On("AggregateBillTotals", mock.Anything, "customer-123", int64Ptr(202501), (*int64)(nil)).
+			Return(want, nil)
+
+		totals, err := AggregateBillTotals{T: mockTemporal}.Handle(context.Background(), AggregateBillTotalsCmd{
+			CustomerID: "customer-123", PeriodFrom: "2025-01",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, want, totals)
+	})
+
+	t.Run("reversed range", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+
+		_, err := AggregateBillTotals{T: mockTemporal}.Handle(context.Background(), AggregateBillTotalsCmd{
+			CustomerID: "customer-123", PeriodFrom: "2025-03", PeriodTo: "2025-01",
+		})
+
+		require.ErrorIs(t, err, domain.ErrInvalidPeriodRange)
+		mockTemporal.AssertNotCalled(t, "AggregateBillTotals")
+	})
+}
+
+func TestNextPeriod_Handle(t *testing.T) {
+	now := func() time.Time { return time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name    string
+		periods []string
+		want    domain.BillingPeriod
+	}{
+		{name: "after the latest bill", periods: []string{"2025-05", "2025-03"}, want: "2025-06"},
+		{name: "December rolls over to January", periods: []string{"2024-12", "2024-11"}, want: "2025-01"},
+		{name: "no bills is the current month", periods: []string{}, want: "2025-06"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTemporal := &MockTemporalPort{}
+			mockTemporal.On("ListBillPeriods", mock.Anything, "customer-123").Return(tt.periods, nil)
+
+			got, err := NextPeriod{T: mockTemporal, Now: now}.Handle(context.Background(), NextPeriodCmd{CustomerID: "customer-123"})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("search fails", func(t *testing.T) {
+		mockTemporal := &MockTemporalPort{}
+		mockTemporal.On("ListBillPeriods", mock.Anything, "customer-123").Return([]string(nil), app.ErrTooManyBills)
+
+		_, err := NextPeriod{T: mockTemporal, Now: now}.Handle(context.Background(), NextPeriodCmd{CustomerID: "customer-123"})
+
+		require.ErrorIs(t, err, app.ErrTooManyBills)
+	})
+}
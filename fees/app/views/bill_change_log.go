@@ -0,0 +1,34 @@
+package views
+
+import (
+	"time"
+
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type BillChangeType string
+
+const (
+	BillChangeItemAdded              BillChangeType = "item_added"
+	BillChangeItemDescriptionUpdated BillChangeType = "item_description_updated"
+	BillChangeItemAmountCorrected    BillChangeType = "item_amount_corrected"
+	BillChangeStatusChanged          BillChangeType = "status_changed"
+)
+
+// BillChangeLog lists the bill changes kept by its workflow, oldest first. The workflow keeps a bounded log,
+// Dropped counts the oldest changes no longer in it.
+type BillChangeLog struct {
+	Changes []BillChange
+	Dropped int
+}
+
+// BillChange is one bill change, only the fields of its Type are set.
+type BillChange struct {
+	Type           BillChangeType
+	At             time.Time
+	IdempotencyKey string
+	Description    string
+	Amount         libmoney.Money
+	FromStatus     string
+	ToStatus       string
+}
@@ -0,0 +1,30 @@
+package views
+
+import (
+	"time"
+
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+type BillEventType string
+
+const (
+	BillEventCreated   BillEventType = "bill.created"
+	BillEventItemAdded BillEventType = "bill.item_added"
+	BillEventClosed    BillEventType = "bill.closed"
+	BillEventErrored   BillEventType = "bill.errored"
+)
+
+// BillEvent is the audit record of a bill state change, the bill fields are the state right after the change.
+type BillEvent struct {
+	Type          BillEventType       `json:"type"`
+	BillID        string              `json:"billId"`
+	CustomerID    string              `json:"customerId"`
+	BillingPeriod string              `json:"billingPeriod"`
+	Status        string              `json:"status"`
+	ItemCount     int                 `json:"itemCount"`
+	Total         libmoney.MoneyMinor `json:"total"`
+	OccurredAt    time.Time           `json:"occurredAt"`
+	// CorrelationID ties the event to the API request that caused it, empty if unknown.
+	CorrelationID string `json:"correlationId,omitempty"`
+}
@@ -0,0 +1,10 @@
+package views
+
+// BillPeriodTotal sums the bills of one billing period in one currency, from their Search Attributes.
+type BillPeriodTotal struct {
+	PeriodYYYYMM int64
+	// Currency is empty for a period without bills.
+	Currency   string
+	TotalCents int64
+	Count      int64
+}
@@ -0,0 +1,21 @@
+package views
+
+import (
+	"time"
+
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// BillStateSummary is the bill without its line items, for callers that only need the status and total.
+type BillStateSummary struct {
+	ID            string
+	CustomerID    string
+	Currency      libmoney.Currency
+	BillingPeriod string
+	Status        string
+	Total         libmoney.Money
+	ItemCount     int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      *time.Time
+}
@@ -1,13 +1,28 @@
 package views
 
+import "time"
+
 type BillSummary struct {
 	WorkflowID string
 	RunID      string
 	Status     string
 	Currency   string
+	// Where the workflow lives, for deployments spanning namespaces/environments.
+	Namespace string
+	TaskQueue string
 	// From Search Attributes:
 	CustomerID       string
 	BillingPeriodNum int64
 	TotalCents       int64
 	ItemCount        int64
+	// UpdatedAt is nil for bills started before the BillUpdatedAt SA and not backfilled yet.
+	UpdatedAt *time.Time
+	// CreatedAt is nil for bills started before the BillCreatedAt SA.
+	CreatedAt *time.Time
+	// PeriodStart and PeriodEnd bound the billing period, nil for bills started before their SAs and not refreshed yet.
+	PeriodStart *time.Time
+	PeriodEnd   *time.Time
+	// ErrorReason is the last invoicing failure from the memo, empty if the bill never errored (or errored
+	// before it was kept).
+	ErrorReason string
 }
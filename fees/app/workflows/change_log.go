@@ -0,0 +1,82 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// maxChangeLogEntries caps the log kept in workflow memory, the oldest entries are dropped first.
+const maxChangeLogEntries = 500
+
+// ChangeLogDTO is the answer of QueryChangeLog, Dropped counts the oldest entries over maxChangeLogEntries.
+type ChangeLogDTO struct {
+	Entries []ChangeLogEntryDTO
+	Dropped int
+}
+
+// ChangeLogEntryDTO is one bill change, only the fields of its Type are set.
+type ChangeLogEntryDTO struct {
+	Type views.BillChangeType
+	At   time.Time
+	// item changes
+	IdempotencyKey string
+	Description    string
+	Amount         libmoney.Money
+	// status changes, FromStatus is empty for the opening of the bill
+	FromStatus string
+	ToStatus   string
+}
+
+// changeLog accumulates the bill changes of the run. It's rebuilt on replay from the same signals,
+// so it needs no versioning, neither does the query handler exposing it.
+type changeLog struct {
+	entries []ChangeLogEntryDTO
+	dropped int
+	status  domain.BillStatus
+}
+
+func (l *changeLog) add(e ChangeLogEntryDTO) {
+	if len(l.entries) >= maxChangeLogEntries {
+		l.entries = l.entries[1:]
+		l.dropped++
+	}
+	l.entries = append(l.entries, e)
+}
+
+func (l *changeLog) itemAdded(li domain.LineItem) {
+	l.add(ChangeLogEntryDTO{
+		Type: views.BillChangeItemAdded, At: li.AddedAt,
+		IdempotencyKey: li.IdempotencyKey, Description: li.Description, Amount: li.Amount,
+	})
+}
+
+func (l *changeLog) itemDescriptionUpdated(idempotencyKey, description string, at time.Time) {
+	l.add(ChangeLogEntryDTO{
+		Type: views.BillChangeItemDescriptionUpdated, At: at, IdempotencyKey: idempotencyKey, Description: description,
+	})
+}
+
+func (l *changeLog) itemAmountCorrected(idempotencyKey string, amount libmoney.Money, at time.Time) {
+	l.add(ChangeLogEntryDTO{
+		Type: views.BillChangeItemAmountCorrected, At: at, IdempotencyKey: idempotencyKey, Amount: amount,
+	})
+}
+
+// statusChanged logs the bill status if it differs from the last logged one, so it can be called after
+// any transition attempt.
+func (l *changeLog) statusChanged(bill domain.Bill, at time.Time) {
+	if bill.Status == l.status {
+		return
+	}
+	l.add(ChangeLogEntryDTO{
+		Type: views.BillChangeStatusChanged, At: at, FromStatus: string(l.status), ToStatus: string(bill.Status),
+	})
+	l.status = bill.Status
+}
+
+func (l *changeLog) toDTO() ChangeLogDTO {
+	return ChangeLogDTO{Entries: append([]ChangeLogEntryDTO(nil), l.entries...), Dropped: l.dropped}
+}
@@ -0,0 +1,22 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// CreditNoteWorkflow records a credit note against a closed bill. The bill workflow is completed by then,
+// so the credit note runs on its own and links back to the bill with the OriginalBillID memo.
+// Its result is the credit note, kept in history for as long as the namespace retention.
+//
+// future, the refund over the payment gateway goes here as an activity, like invoicing in the bill workflow.
+func CreditNoteWorkflow(ctx workflow.Context, params app.CreditNoteWorkflowParams) (domain.CreditNote, error) {
+	logger := billLogger(ctx)
+	note := params.CreditNote
+	logger.Info("credit note created",
+		"creditNoteID", note.ID, "billID", note.BillID, "total", note.Total.ToString(), "reason", note.Reason)
+
+	return note, nil
+}
@@ -1,6 +1,7 @@
 package workflows
 
 import (
+	"slices"
 	"time"
 
 	"github.com/outofboxer/temporal-workflow/fees/domain"
@@ -9,19 +10,69 @@ import (
 
 const WorkflowTypeMonthlyBill = "MonthlyFeeAccrualWorkflow"
 
+const WorkflowTypeCreditNote = "CreditNoteWorkflow"
+
 const (
 	SignalAddLineItem = "SignalAddLineItem"
 	SignalCloseBill   = "SignalCloseBill"
-	QueryState        = "CurrentBillState"
+	// SignalRefreshSearchAttributes re-upserts all mutable SAs from the bill state, e.g. to backfill a new SA.
+	SignalRefreshSearchAttributes = "SignalRefreshSearchAttributes"
+	// SignalRetryInvoicing re-runs invoicing of a bill in CHARGE_FAILED state.
+	SignalRetryInvoicing = "SignalRetryInvoicing"
+	// SignalUpdateLineItemDescription corrects an item description of an open bill, the amount is never changed.
+	SignalUpdateLineItemDescription = "SignalUpdateLineItemDescription"
+	// SignalCorrectLineItemAmount corrects an item amount of an open bill in place, the key is kept.
+	SignalCorrectLineItemAmount = "SignalCorrectLineItemAmount"
+	// SignalSetBillNote replaces the internal note of an open bill.
+	SignalSetBillNote = "SignalSetBillNote"
+	// SignalReconcileBill recomputes the total from the items, an ops safety valve against a drifted total.
+	SignalReconcileBill = "SignalReconcileBill"
+	QueryState          = "CurrentBillState"
+	// QuerySummary is QueryState without the line items, cheap for bills with many items.
+	QuerySummary = "CurrentBillSummary"
+	// QueryChangeLog returns the bill changes of the run, oldest first, see ChangeLogDTO.
+	QueryChangeLog = "BillChangeLog"
 )
 
+// Signal payloads carry the CorrelationID of the API request, if any, for the workflow logs.
+
 // CloseBillSignal is sent when the service signals the end of the month [2].
-type CloseBillSignal struct{}
+type CloseBillSignal struct {
+	CorrelationID string
+}
+
+type RetryInvoicingSignal struct {
+	CorrelationID string
+}
+
+type ReconcileBillSignal struct {
+	CorrelationID string
+}
 
 type AddLineItemPayload struct {
 	Description    string
 	Amount         libmoney.Money
 	IdempotencyKey string
+	CorrelationID  string
+	// Tags are optional, the signals of older API versions have none.
+	Tags map[string]string
+}
+
+type UpdateLineItemDescriptionPayload struct {
+	IdempotencyKey string
+	NewDescription string
+	CorrelationID  string
+}
+
+type CorrectLineItemAmountPayload struct {
+	IdempotencyKey string
+	NewAmount      libmoney.Money
+	CorrelationID  string
+}
+
+type SetBillNotePayload struct {
+	Note          string
+	CorrelationID string
 }
 
 type BillDTO struct {
@@ -34,6 +85,28 @@ type BillDTO struct {
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	ClosedAt       *time.Time
+	Notes          string
+	InvoiceURI     string
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	// InvoicingRetryable tells a failed bill still waits for SignalRetryInvoicing.
+	InvoicingRetryable bool
+	// ItemsRequired tells the bill refuses a close without line items.
+	ItemsRequired bool
+	// MaxItems is the line item cap of the bill, zero is no cap.
+	MaxItems int
+}
+
+type BillSummaryDTO struct {
+	ID, CustomerID string
+	Currency       libmoney.Currency
+	BillingPeriod  string
+	Status         string
+	Total          libmoney.Money
+	ItemCount      int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	ClosedAt       *time.Time
 }
 
 type LineItemDTO struct {
@@ -41,16 +114,22 @@ type LineItemDTO struct {
 	Description    string
 	Amount         libmoney.Money
 	AddedAt        time.Time
+	Tags           map[string]string
 }
 
+// billToDTO guarantees BillDTO.Items are ordered by AddedAt, then by IdempotencyKey (see domain.SortLineItems),
+// so clients get a stable order whatever the internal storage order is.
 func billToDTO(bill domain.Bill) BillDTO {
-	lineItems := make([]LineItemDTO, 0, len(bill.Items))
-	for _, li := range bill.Items {
+	items := slices.Clone(bill.Items)
+	domain.SortLineItems(items)
+	lineItems := make([]LineItemDTO, 0, len(items))
+	for _, li := range items {
 		lineItems = append(lineItems, LineItemDTO{
 			IdempotencyKey: li.IdempotencyKey,
 			Description:    li.Description,
 			Amount:         li.Amount,
 			AddedAt:        li.AddedAt,
+			Tags:           li.Tags,
 		})
 	}
 
@@ -65,5 +144,28 @@ func billToDTO(bill domain.Bill) BillDTO {
 		CreatedAt:     bill.CreatedAt,
 		UpdatedAt:     bill.UpdatedAt,
 		ClosedAt:      bill.FinalizedAt,
+		Notes:         bill.Notes,
+		InvoiceURI:    bill.InvoiceURI,
+		PeriodStart:   bill.PeriodStart,
+		PeriodEnd:     bill.PeriodEnd,
+
+		InvoicingRetryable: bill.InvoicingRetryable,
+		ItemsRequired:      bill.ItemsRequired,
+		MaxItems:           bill.MaxItems,
+	}
+}
+
+func billToSummaryDTO(bill domain.Bill) BillSummaryDTO {
+	return BillSummaryDTO{
+		ID:            string(bill.ID),
+		CustomerID:    bill.CustomerID,
+		Currency:      bill.Currency,
+		BillingPeriod: string(bill.BillingPeriod),
+		Status:        string(bill.Status),
+		Total:         bill.Total,
+		ItemCount:     len(bill.Items),
+		CreatedAt:     bill.CreatedAt,
+		UpdatedAt:     bill.UpdatedAt,
+		ClosedAt:      bill.FinalizedAt,
 	}
 }
@@ -0,0 +1,46 @@
+package workflows
+
+import (
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Counters emitted by MonthlyFeeAccrualWorkflow via the worker metrics handler, all tagged with "currency":
+//   - line_items_accepted: a line item was added to the bill;
+//   - line_items_rejected_closed: a line item arrived after the bill was closed (or is being closed);
+//   - line_items_rejected_duplicate: a line item with an already added idempotency key, a retry or a collision;
+//   - line_items_rejected_currency: a line item in another currency than the bill's, with params.StrictCurrency;
+//   - line_items_rejected_limit: a new line item on a bill with params.MaxItems line items already;
+//   - bills_closed: the bill was invoiced and closed, or closed without a charge as its total is zero or negative;
+//   - bills_written_off: the bill was below the minimum charge and written off without invoicing.
+const (
+	MetricLineItemsAccepted          = "line_items_accepted"
+	MetricLineItemsRejectedClosed    = "line_items_rejected_closed"
+	MetricLineItemsRejectedDuplicate = "line_items_rejected_duplicate"
+	MetricLineItemsRejectedCurrency  = "line_items_rejected_currency"
+	MetricLineItemsRejectedLimit     = "line_items_rejected_limit"
+	MetricBillsClosed                = "bills_closed"
+	MetricBillsWrittenOff            = "bills_written_off"
+
+	metricTagCurrency = "currency"
+)
+
+type billMetrics struct {
+	ctx     workflow.Context
+	handler client.MetricsHandler
+}
+
+func newBillMetrics(ctx workflow.Context, currency string) billMetrics {
+	return billMetrics{
+		ctx:     ctx,
+		handler: workflow.GetMetricsHandler(ctx).WithTags(map[string]string{metricTagCurrency: currency}),
+	}
+}
+
+func (m billMetrics) inc(name string) {
+	// the SDK handler already drops replayed metrics, the explicit check keeps it so for any custom handler
+	if workflow.IsReplaying(m.ctx) {
+		return
+	}
+	m.handler.Counter(name).Inc(1)
+}
@@ -3,17 +3,22 @@
 package workflows
 
 import (
+	"errors"
+	"slices"
 	"time"
 
-	"github.com/shopspring/decimal"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/log"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 // This execution is on a single thread–while this means we don’t have to worry about parallelism,
@@ -24,23 +29,40 @@ import (
 //
 //nolint:funlen
 func MonthlyFeeAccrualWorkflow(ctx workflow.Context, params app.MonthlyFeeAccrualWorkflowParams) (domain.Bill, error) {
-	logger := workflow.GetLogger(ctx) // workflow replay safe logger
+	logger := billLogger(ctx) // workflow replay safe logger
+	if params.SkipSearchAttributes {
+		// upserting SAs missing in the namespace fails the workflow task, so the bill would get stuck.
+		logger.Warn("bill search attributes are not registered, skipping SA upserts")
+		ctx = workflow.WithValue(ctx, skipSearchAttributesKey{}, true)
+	}
 
 	// future optimization, At the start of the workflow, if params.Snapshot != nil,
 	// restore bw.bill from it instead of building a fresh one, then re‐upsert the SAs to keep visibility correct.
 	// Also, Continue-As-New, re-upsert any “static” SAs (customer, period, currency) on the new run for consistency.
-	bill, err := newBillBuilderFromWorkflow(ctx).
-		WithID(params.BillID).
-		ForCustomer(params.CustomerID).
-		ForPeriod(params.Period).
-		WithCurrency(params.Currency).
-		WithCreatedAt(workflow.Now(ctx)).
-		Open().
-		Build()
+	// The snapshot is the whole domain.Bill, so the state that isn't in the SAs (items, Notes) carries over with it.
+	bill, err := NewBill(params, workflow.Now(ctx))
 	if err != nil {
+		logger.Error("Couldn't build the bill", "err", err)
+
 		return domain.Bill{}, err
 	}
 
+	metrics := newBillMetrics(ctx, string(params.Currency))
+	changes := &changeLog{}
+	changes.statusChanged(bill, bill.CreatedAt)
+
+	// Seeded from params, not an activity, so a replay adds the same items. Bills started before
+	// templates have none, so no SA upsert is recorded for them and they replay as they ran.
+	if len(params.Template) > 0 {
+		for _, li := range bill.Items {
+			changes.itemAdded(li)
+		}
+		logger.Info("seeded template items", "count", len(params.Template), "total", bill.Total.ToString())
+		if err := UpdateInsertItemSearchAttributes(ctx, bill); err != nil {
+			logger.Error("UpdateInsertItemSearchAttributes upsert failed", "error", err)
+		}
+	}
+
 	// Define Signal and Query Handlers (Progressive Accrual Phase)
 
 	// Register Query Handler
@@ -57,30 +79,77 @@ func MonthlyFeeAccrualWorkflow(ctx workflow.Context, params app.MonthlyFeeAccrua
 
 		return domain.Bill{}, errQuery
 	}
+	if errQuery := workflow.SetQueryHandler(ctx, QuerySummary, func() (BillSummaryDTO, error) {
+		return billToSummaryDTO(bill), nil
+	}); errQuery != nil {
+		logger.Error("SetQueryHandler failed", "errQuery", errQuery)
+
+		return domain.Bill{}, errQuery
+	}
+	if errQuery := workflow.SetQueryHandler(ctx, QueryChangeLog, func() (ChangeLogDTO, error) {
+		return changes.toDTO(), nil
+	}); errQuery != nil {
+		logger.Error("SetQueryHandler failed", "errQuery", errQuery)
+
+		return domain.Bill{}, errQuery
+	}
 
 	// Define channel to receive the Close Signal
 	addItemCh := workflow.GetSignalChannel(ctx, SignalAddLineItem)
 	closeCh := workflow.GetSignalChannel(ctx, SignalCloseBill)
+	refreshCh := workflow.GetSignalChannel(ctx, SignalRefreshSearchAttributes)
+	updateDescriptionCh := workflow.GetSignalChannel(ctx, SignalUpdateLineItemDescription)
+	correctAmountCh := workflow.GetSignalChannel(ctx, SignalCorrectLineItemAmount)
+	reconcileCh := workflow.GetSignalChannel(ctx, SignalReconcileBill)
+	noteCh := workflow.GetSignalChannel(ctx, SignalSetBillNote)
 	sel := workflow.NewSelector(ctx)
 
-	sel.AddReceive(addItemCh, func(c workflow.ReceiveChannel, _ bool) {
+	SetUpBill(&bill, params, func(changeID string, maxSupported workflow.Version) workflow.Version {
+		return workflow.GetVersion(ctx, changeID, workflow.DefaultVersion, maxSupported)
+	})
+
+	addItem := func(pl AddLineItemPayload) {
 		logger.Info("Starting addItem processing")
 		defer logger.Info("Finished addItem processing")
 
-		var pl AddLineItemPayload
-		c.Receive(ctx, &pl)
-		if !bill.IsActive() {
+		res, err := ApplyLineItem(&bill, params, pl, workflow.Now(ctx))
+		switch {
+		case errors.Is(err, domain.ErrBillNotOpen):
 			logger.Info("discarding a Line Item after bill is finalized", "lineItem", pl)
+			metrics.inc(MetricLineItemsRejectedClosed)
 			// ignore gracefully; API layer prevents this; idempotent sink
 			return
-		}
-		err := bill.AddItem(pl.IdempotencyKey, pl.Description, pl.Amount, workflow.Now(ctx))
-		if err != nil {
+		case errors.Is(err, domain.ErrCurrencyMismatch):
+			logger.Warn("discarding a Line Item in another currency", "lineItem", pl, "err", err)
+			metrics.inc(MetricLineItemsRejectedCurrency)
+
+			return
+		case errors.Is(err, app.ErrBillItemLimit):
+			logger.Warn("discarding a Line Item past the bill limit", "lineItem", pl, "maxItems", bill.MaxItems)
+			metrics.inc(MetricLineItemsRejectedLimit)
+
+			return
+		case errors.Is(err, domain.ErrLineItemAlreadyAdded):
+			// not a retry: two different items share the key, the second one is dropped
+			logger.Warn("discarding a Line Item colliding with an added one", "lineItem", pl, "err", err)
+			metrics.inc(MetricLineItemsRejectedDuplicate)
+
+			return
+		case err != nil:
 			logger.Error("Couldn't add Line Item", "err", err)
 
 			return
 		}
+		if res == domain.ItemDuplicateIgnored {
+			// a retry: nothing changed, so no SA upsert
+			logger.Info("skipping an already added Line Item", "lineItem", pl)
+			metrics.inc(MetricLineItemsRejectedDuplicate)
+
+			return
+		}
 		logger.Info("added item", "lineItem", pl)
+		metrics.inc(MetricLineItemsAccepted)
+		changes.itemAdded(bill.Items[len(bill.Items)-1])
 		// Temporal will retry it in case of failure of SA upsert
 		err = UpdateInsertItemSearchAttributes(ctx, bill)
 		if err != nil {
@@ -100,27 +169,40 @@ func MonthlyFeeAccrualWorkflow(ctx workflow.Context, params app.MonthlyFeeAccrua
 				"BillWorkflow.MonthlyFeeAccrualWorkflow", next)
 			// If you register a function workflow instead, pass the func identifier directly.
 		}*/
-	})
-
-	sel.AddReceive(closeCh, func(c workflow.ReceiveChannel, _ bool) {
-		logger.Info("Starting closing processing")
-		defer logger.Info("Finished closing processing")
+	}
 
-		var nothing struct{}
-		c.Receive(ctx, &nothing)
+	// closeBill moves the bill to Pending, on the close signal or the auto-close timer.
+	closeBill := func() {
 		if !bill.IsActive() {
-			logger.Info("discarding Close signal as bill is not active", "status", bill.Status)
+			logger.Info("discarding close as bill is not active", "status", bill.Status)
 			// this is idempotent processing
 			return
 		}
+		if params.DrainItemsOnClose {
+			// the items already delivered are added before the close, not dropped as late ones
+			for {
+				var pl AddLineItemPayload
+				if !addItemCh.ReceiveAsync(&pl) {
+					break
+				}
+				logger.Info("draining a Line Item before close", "lineItem", pl)
+				addItem(pl)
+			}
+		}
 
-		err := bill.Pending(workflow.Now(ctx))
+		err := PendBill(&bill, workflow.Now(ctx))
+		if errors.Is(err, domain.ErrBillEmpty) {
+			logger.Warn("refusing to close an empty bill, it stays open", "err", err)
+
+			return
+		}
 		if err != nil {
 			logger.Error("bill.Pending failed", "err", err.Error())
 
 			return
 		}
 		logger.Info("moved into Pending")
+		changes.statusChanged(bill, bill.UpdatedAt)
 
 		// Temporal does retry on failure by temporal automatically
 		err = UpdateBillStatusSearchAttributes(ctx, bill.Status)
@@ -130,91 +212,637 @@ func MonthlyFeeAccrualWorkflow(ctx workflow.Context, params app.MonthlyFeeAccrua
 			// return domain.Bill{}, fmt.Errorf("failed to update search attributes: %w", err)
 		}
 		logger.Info("UpdateBillStatusSearchAttributes ok")
+	}
+
+	sel.AddReceive(addItemCh, func(c workflow.ReceiveChannel, _ bool) {
+		var pl AddLineItemPayload
+		c.Receive(ctx, &pl)
+		addItem(pl)
+	})
+
+	onClose := func(sig CloseBillSignal) {
+		logger.Info("Starting closing processing")
+		defer logger.Info("Finished closing processing")
+
+		logger.Info("received Close signal", "signalCorrelationID", sig.CorrelationID)
+		closeBill()
+	}
+	sel.AddReceive(closeCh, func(c workflow.ReceiveChannel, _ bool) {
+		var sig CloseBillSignal
+		c.Receive(ctx, &sig)
+		onClose(sig)
 	})
 
+	sel.AddReceive(updateDescriptionCh, func(c workflow.ReceiveChannel, _ bool) {
+		var pl UpdateLineItemDescriptionPayload
+		c.Receive(ctx, &pl)
+
+		// no SA upsert: neither total nor item count changes
+		if err := bill.UpdateItemDescription(pl.IdempotencyKey, pl.NewDescription, workflow.Now(ctx)); err != nil {
+			logger.Warn("discarding a Line Item description update", "payload", pl, "err", err)
+
+			return
+		}
+		logger.Info("updated Line Item description", "payload", pl)
+		changes.itemDescriptionUpdated(pl.IdempotencyKey, pl.NewDescription, bill.UpdatedAt)
+	})
+
+	sel.AddReceive(correctAmountCh, func(c workflow.ReceiveChannel, _ bool) {
+		var pl CorrectLineItemAmountPayload
+		c.Receive(ctx, &pl)
+
+		// the total moves with any actual change, a repeated correction leaves it as is
+		total := bill.Total
+		if err := bill.CorrectItemAmount(pl.IdempotencyKey, pl.NewAmount, workflow.Now(ctx)); err != nil {
+			logger.Warn("discarding a Line Item amount correction", "payload", pl, "err", err)
+
+			return
+		}
+		if bill.Total.Equal(total) {
+			logger.Info("skipping a Line Item amount correction to the same amount", "payload", pl)
+
+			return
+		}
+		logger.Info("corrected Line Item amount", "payload", pl, "total", bill.Total.ToString())
+		changes.itemAmountCorrected(pl.IdempotencyKey, pl.NewAmount, bill.UpdatedAt)
+		if err := UpdateInsertItemSearchAttributes(ctx, bill); err != nil {
+			logger.Error("UpdateInsertItemSearchAttributes upsert failed", "error", err)
+		}
+	})
+
+	sel.AddReceive(noteCh, func(c workflow.ReceiveChannel, _ bool) {
+		var pl SetBillNotePayload
+		c.Receive(ctx, &pl)
+
+		// no SA upsert: the note isn't searchable
+		if err := bill.SetNote(pl.Note, workflow.Now(ctx)); err != nil {
+			logger.Warn("discarding a bill note", "noteLength", len(pl.Note), "err", err,
+				"signalCorrelationID", pl.CorrelationID)
+
+			return
+		}
+		logger.Info("bill note set", "noteLength", len(pl.Note), "signalCorrelationID", pl.CorrelationID)
+	})
+
+	sel.AddReceive(reconcileCh, func(c workflow.ReceiveChannel, _ bool) {
+		var sig ReconcileBillSignal
+		c.Receive(ctx, &sig)
+
+		before := bill.Total
+		if !bill.Reconcile() {
+			logger.Info("bill total is consistent with items", "signalCorrelationID", sig.CorrelationID)
+
+			return
+		}
+		logger.Warn("bill total drift repaired", "before", before.ToString(), "after", bill.Total.ToString(),
+			"signalCorrelationID", sig.CorrelationID)
+		if err := UpdateInsertItemSearchAttributes(ctx, bill); err != nil {
+			logger.Error("UpdateInsertItemSearchAttributes upsert failed", "error", err)
+		}
+	})
+
+	sel.AddReceive(refreshCh, func(c workflow.ReceiveChannel, _ bool) {
+		var nothing struct{}
+		c.Receive(ctx, &nothing)
+
+		if err := RefreshSearchAttributes(ctx, bill); err != nil {
+			logger.Error("RefreshSearchAttributes upsert failed", "error", err)
+
+			return
+		}
+		logger.Info("RefreshSearchAttributes ok")
+	})
+
+	// Auto-close came after bills were running, so it's gated, see versions.go.
+	autoCloseCtx, cancelAutoClose := workflow.WithCancel(ctx)
+	if workflow.GetVersion(ctx, changeIDAutoClose, workflow.DefaultVersion, versionAutoClose) >= versionAutoClose &&
+		params.AutoClose {
+		_, end, err := libtime.PeriodBounds(string(params.Period))
+		if err != nil {
+			cancelAutoClose()
+
+			return domain.Bill{}, err
+		}
+		sel.AddFuture(workflow.NewTimer(autoCloseCtx, max(end.Sub(workflow.Now(ctx)), 0)), func(f workflow.Future) {
+			if f.Get(ctx, nil) != nil {
+				// canceled, the bill was closed by the signal
+
+				return
+			}
+			logger.Info("billing period is over, auto-closing the bill", "periodEnd", end)
+			closeBill()
+		})
+	}
+
+	// A blocked Select takes the first signal delivered, but the signals buffered behind it are served
+	// in the AddReceive order, line items before the close. Serving a buffered close first (versionCloseFirst)
+	// also dropped the line items signaled before it, so the bills since versionCloseInOrder keep the Select
+	// order: no line item is lost to a close signaled after it, the ones handled after the close are rejected
+	// as the bill is no longer active.
+	closeFirst := workflow.GetVersion(ctx, changeIDCloseFirst, workflow.DefaultVersion, versionCloseInOrder) ==
+		versionCloseFirst
+
 	// Event loop until closing or error
 	for bill.IsActive() {
+		var sig CloseBillSignal
+		if closeFirst && closeCh.ReceiveAsync(&sig) {
+			onClose(sig)
+
+			continue
+		}
 		sel.Select(ctx)
 	}
+	cancelAutoClose()
+	// Line items signaled after close are never added, drain them to make it visible.
+	drainLateItems := func() {
+		var pl AddLineItemPayload
+		for addItemCh.ReceiveAsync(&pl) {
+			logger.Info("discarding a Line Item after bill is finalized", "lineItem", pl)
+			metrics.inc(MetricLineItemsRejectedClosed)
+		}
+	}
+	drainLateItems()
+	// notifyWebhook tells the customer the bill is closed, a failed notification leaves the bill as is.
+	notifyWebhook := func() {
+		if params.WebhookURL == "" || bill.Status != domain.BillStatusClosed {
+			return
+		}
+		if err := DoWebhookActivity(ctx, bill, params.WebhookURL, params.ActivityTaskQueue); err != nil {
+			logger.Error("NotifyWebhook failed", "error", err)
+		}
+	}
 
 	if !bill.IsReadyForInvoicing() {
 		logger.Info("exiting since bill is not ready to invoicing", "status", bill.Status)
 
 		return bill, err
 	}
-	logger.Info("Starting Invoicing activity ")
+	// The outcome audit events came after bills were running, so they're gated, see versions.go.
+	publishAudit := func(t views.BillEventType) {
+		if workflow.GetVersion(ctx, changeIDAuditEvents, workflow.DefaultVersion, versionAuditEvents) <
+			versionAuditEvents {
+			return
+		}
+		// the outcome stands whether its event is published or not
+		event := app.NewBillEvent(t, bill, correlationIDFromMemo(ctx))
+		if errAudit := DoAuditActivity(ctx, event, params.ActivityTaskQueue); errAudit != nil {
+			logger.Error("PublishBillEvent audit failed", "error", errAudit)
+		}
+	}
+	// Manual invoicing retries came after bills were running, so they're gated, see versions.go.
+	manualInvoiceRetry := func() bool {
+		return workflow.GetVersion(ctx, changeIDManualInvoiceRetry, workflow.DefaultVersion,
+			versionManualInvoiceRetry) >= versionManualInvoiceRetry
+	}
+	failFinalization := func(err error, retryable bool) {
+		logger.Error("Finalization failed.", "error", err, "retryable", retryable)
+
+		var errStatus error
+		if workflow.GetVersion(ctx, changeIDInvoicingFailureStatus, workflow.DefaultVersion,
+			versionInvoicingFailureStatus) >= versionInvoicingFailureStatus {
+			// ChargeFailed if a manual retry may succeed, Rejected if the failure is permanent
+			errStatus = bill.FailInvoicing(workflow.Now(ctx), retryable)
+		} else {
+			errStatus = bill.FailInvoicingAsError(workflow.Now(ctx), retryable)
+		}
+		if errStatus != nil {
+			logger.Error("bill.FailInvoicing transition failed.", "error", errStatus)
+		}
+		changes.statusChanged(bill, bill.UpdatedAt)
+		if manualInvoiceRetry() {
+			if errSA := UpdateBillStatusSearchAttributes(ctx, bill.Status); errSA != nil {
+				logger.Error("UpdateBillStatusSearchAttributes upsert failed", "error", errSA)
+			}
+		}
+		// the reason goes along with the summaries of the errored bills listing, see MemoKeyErrorReason
+		if workflow.GetVersion(ctx, changeIDErrorReasonMemo, workflow.DefaultVersion, versionErrorReasonMemo) >=
+			versionErrorReasonMemo {
+			if errMemo := workflow.UpsertMemo(ctx, map[string]any{app.MemoKeyErrorReason: err.Error()}); errMemo != nil {
+				logger.Error("ErrorReason memo upsert failed", "error", errMemo)
+			}
+		}
+		// An alert failure must not change the bill outcome, so it's only logged.
+		if workflow.GetVersion(ctx, changeIDErrorAlert, workflow.DefaultVersion, versionErrorAlert) >= versionErrorAlert {
+			if errAlert := DoAlertActivity(ctx, bill.ID, err.Error(), params.ActivityTaskQueue); errAlert != nil {
+				logger.Error("NotifyBillError alert failed", "error", errAlert)
+			}
+		}
+		publishAudit(views.BillEventErrored)
+	}
 
-	if err := DoInvoicesActivities(ctx, bill); err != nil {
-		logger.Error("Finalization failed.", "error", err)
+	if params.Jurisdiction != "" {
+		logger.Info("Starting Tax activity", "jurisdiction", params.Jurisdiction)
 
-		errStatus := bill.Error(workflow.Now(ctx))
-		if errStatus != nil {
-			logger.Error("bill.Error transition failed.", "error", err)
+		if err := DoTaxActivity(ctx, &bill, params.Jurisdiction, params.ActivityTaskQueue, params.InvoiceRetry); err != nil {
+			failFinalization(err, false)
+
+			return bill, err
 		}
+	}
 
-		return bill, err
+	// Checked after tax, on the amount that would be charged: providers reject charges below their threshold.
+	if bill.BelowMinimumCharge(params.MinChargeMinor) {
+		logger.Info("bill total is below the minimum charge, writing it off",
+			"total", bill.Total.ToString(), "minChargeMinor", params.MinChargeMinor)
+		if err := bill.WriteOff(workflow.Now(ctx)); err != nil {
+			logger.Error("bill.WriteOff transition failed.", "error", err)
+
+			return bill, err
+		}
+		changes.statusChanged(bill, bill.UpdatedAt)
+		metrics.inc(MetricBillsWrittenOff)
+		if err := UpdateBillClosedSearchAttributes(ctx, bill); err != nil {
+			logger.Error("UpdateBillClosedSearchAttributes upsert failed", "error", err)
+		}
+		publishAudit(views.BillEventClosed)
+		drainLateItems()
+
+		return bill, nil
+	}
+
+	// Nothing to charge, e.g. credits offset the fees: the bill is settled as is, without a payment attempt.
+	if workflow.GetVersion(ctx, changeIDSettleNonPositive, workflow.DefaultVersion, versionSettleNonPositive) >=
+		versionSettleNonPositive && bill.Total.IsZeroOrNegative() {
+		logger.Info("bill total is zero or negative, closing it without a charge", "total", bill.Total.ToString())
+		if err := bill.Close(workflow.Now(ctx)); err != nil {
+			logger.Error("bill.Close transition failed.", "error", err)
+
+			return bill, err
+		}
+		changes.statusChanged(bill, bill.UpdatedAt)
+		metrics.inc(MetricBillsClosed)
+		if err := UpdateBillClosedSearchAttributes(ctx, bill); err != nil {
+			logger.Error("UpdateBillClosedSearchAttributes upsert failed", "error", err)
+		}
+		publishAudit(views.BillEventClosed)
+		notifyWebhook()
+		drainLateItems()
+
+		return bill, nil
+	}
+
+	retryCh := workflow.GetSignalChannel(ctx, SignalRetryInvoicing)
+	for manualRetries := 0; ; manualRetries++ {
+		logger.Info("Starting Invoicing activity ", "manualRetries", manualRetries)
+
+		err := DoInvoicesActivities(ctx, bill, params.ActivityTaskQueue, params.InvoiceRetry)
+		if err == nil {
+			break
+		}
+		retryable := isRetryableInvoiceFailure(err, params.InvoiceRetry)
+		failFinalization(err, retryable)
+		if !retryable || !manualInvoiceRetry() || manualRetries >= maxManualInvoiceRetries ||
+			!waitRetryInvoicing(ctx, retryCh) {
+			// terminal: no more manual retries are accepted
+			bill.InvoicingRetryable = false
+
+			return bill, err
+		}
+		if err := bill.RetryInvoicing(workflow.Now(ctx)); err != nil {
+			logger.Error("bill.RetryInvoicing transition failed.", "error", err)
+
+			return bill, err
+		}
+		changes.statusChanged(bill, bill.UpdatedAt)
+		if errSA := UpdateBillStatusSearchAttributes(ctx, bill.Status); errSA != nil {
+			logger.Error("UpdateBillStatusSearchAttributes upsert failed", "error", errSA)
+		}
+	}
+	if workflow.GetVersion(ctx, changeIDInvoiceArchive, workflow.DefaultVersion, versionInvoiceArchive) >=
+		versionInvoiceArchive {
+		// The charge went through, so an archive failure only leaves the bill without its URI.
+		if err := archiveInvoice(ctx, &bill, params.ActivityTaskQueue, params.InvoiceRetry); err != nil {
+			logger.Error("ArchiveInvoice failed", "error", err)
+		}
 	}
 	err = bill.Close(workflow.Now(ctx))
 	if err != nil {
 		logger.Error("bill.Error() failed", "err", err.Error())
+	} else {
+		metrics.inc(MetricBillsClosed)
 	}
+	changes.statusChanged(bill, bill.UpdatedAt)
 	// Retried automatically on failure by Temporal
-	err = UpdateBillStatusSearchAttributes(ctx, bill.Status)
+	err = UpdateBillClosedSearchAttributes(ctx, bill)
 	if err != nil {
-		logger.Error("UpdateBillStatusSearchAttributes upsert failed", "error", err)
+		logger.Error("UpdateBillClosedSearchAttributes upsert failed", "error", err)
 		// I prefer not to fail-fast, rely on Temporal retries. But it depends on Org policies.
 		// return domain.Bill{}, fmt.Errorf("failed to update search attributes: %w", err)
 	}
+	publishAudit(views.BillEventClosed)
+	notifyWebhook()
+	drainLateItems()
 	// Workflow completes—final bill is queryable from history.
 	// For future: keep it running until periodEnd using timers, but these are tricky requirements to be clarified.
 
 	return bill, nil
 }
 
-func DoInvoicesActivities(ctx workflow.Context, bill domain.Bill) error {
-	ao := workflow.ActivityOptions{
-		StartToCloseTimeout: time.Minute,
-		//nolint:mnd
+const (
+	// maxManualInvoiceRetries caps SignalRetryInvoicing, after that the workflow completes with the failure.
+	maxManualInvoiceRetries = 3
+	// manualInvoiceRetryWindow is how long an errored bill waits for SignalRetryInvoicing.
+	manualInvoiceRetryWindow = 7 * 24 * time.Hour
+)
+
+// waitRetryInvoicing blocks until SignalRetryInvoicing or the retry window elapses, false means the window elapsed.
+func waitRetryInvoicing(ctx workflow.Context, retryCh workflow.ReceiveChannel) bool {
+	timerCtx, cancelTimer := workflow.WithCancel(ctx)
+	defer cancelTimer()
+
+	retry := false
+	workflow.NewSelector(ctx).
+		AddReceive(retryCh, func(c workflow.ReceiveChannel, _ bool) {
+			var sig RetryInvoicingSignal
+			c.Receive(ctx, &sig)
+			billLogger(ctx).Info("received invoicing retry signal", "signalCorrelationID", sig.CorrelationID)
+			retry = true
+		}).
+		AddFuture(workflow.NewTimer(timerCtx, manualInvoiceRetryWindow), func(workflow.Future) {}).
+		Select(ctx)
+
+	return retry
+}
+
+// isRetryableInvoiceFailure tells transient failures (retries exhausted, timeouts) from business ones,
+// i.e. non-retryable application errors, which a manual retry won't fix.
+func isRetryableInvoiceFailure(err error, cfg app.RetryConfig) bool {
+	var appErr *temporal.ApplicationError
+	if !errors.As(err, &appErr) {
+		return true
+	}
+	if appErr.NonRetryable() {
+		return false
+	}
+
+	return !slices.Contains(invoiceRetryPolicy(cfg).NonRetryableErrorTypes, appErr.Type())
+}
+
+// Default retry policy values for the invoicing activity, used for any RetryConfig field left unset.
+const (
+	defaultInvoiceInitialInterval    = time.Second
+	defaultInvoiceMaximumAttempts    = 5
+	defaultInvoiceBackoffCoefficient = 2.0
+	defaultInvoiceMaximumInterval    = 30 * time.Second
+)
+
+// USE NonRetryableErrorTypes for validation/domain errors.
+// Sample error, we don't have it in the demo.
+var defaultInvoiceNonRetryableErrorTypes = []string{"ValidationError", "BusinessRuleError"}
+
+// DoInvoicesActivities runs invoicing on the given activity task queue, empty one means the workflow's task queue.
+func DoInvoicesActivities(ctx workflow.Context, bill domain.Bill, taskQueue string, retry app.RetryConfig) error {
+	finalizationCtx := workflow.WithActivityOptions(ctx, finalizationActivityOptions(taskQueue, retry))
+
+	var charge *activities.ChargeActivities
+
+	return workflow.ExecuteActivity(finalizationCtx, charge.ProcessInvoiceAndChargeActivity, bill).
+		Get(finalizationCtx, nil)
+}
+
+// archiveInvoice archives the invoice of the charged bill, keeping its URI on the bill and in the memo.
+func archiveInvoice(ctx workflow.Context, bill *domain.Bill, taskQueue string, retry app.RetryConfig) error {
+	archiveCtx := workflow.WithActivityOptions(ctx, finalizationActivityOptions(taskQueue, retry))
+
+	var archive *activities.ArchiveActivities
+	var uri string
+	if err := workflow.ExecuteActivity(archiveCtx, archive.ArchiveInvoiceActivity, *bill).Get(archiveCtx, &uri); err != nil {
+		return err
+	}
+	if uri == "" {
+		// nothing was stored, e.g. the no-op archiver
+		return nil
+	}
+	bill.InvoiceURI = uri
+
+	return workflow.UpsertMemo(ctx, map[string]any{app.MemoKeyInvoiceURI: uri})
+}
+
+// Retry policy of the alerting and audit activities, short as they are side notifications of the bill.
+const (
+	alertStartToCloseTimeout = 10 * time.Second
+	alertMaximumAttempts     = 3
+)
+
+// DoAlertActivity notifies operators that the invoicing of the bill failed.
+func DoAlertActivity(ctx workflow.Context, billID domain.BillID, reason string, taskQueue string) error {
+	alertCtx := workflow.WithActivityOptions(ctx, notificationActivityOptions(taskQueue))
+
+	var alerts *activities.AlertActivities
+
+	return workflow.ExecuteActivity(alertCtx, alerts.NotifyBillErrorActivity, string(billID), reason).Get(alertCtx, nil)
+}
+
+// DoAuditActivity publishes the audit event of a change made by the workflow itself.
+func DoAuditActivity(ctx workflow.Context, event views.BillEvent, taskQueue string) error {
+	auditCtx := workflow.WithActivityOptions(ctx, notificationActivityOptions(taskQueue))
+
+	var audit *activities.AuditActivities
+
+	return workflow.ExecuteActivity(auditCtx, audit.PublishBillEventActivity, event).Get(auditCtx, nil)
+}
+
+// Retry policy of the webhook activity, longer than the alerts as a customer endpoint may be down for a while.
+const (
+	webhookStartToCloseTimeout = 10 * time.Second
+	webhookMaximumAttempts     = 10
+	webhookMaximumInterval     = 10 * time.Minute
+)
+
+// DoWebhookActivity notifies the customer webhookURL that the bill is closed.
+func DoWebhookActivity(ctx workflow.Context, bill domain.Bill, webhookURL string, taskQueue string) error {
+	webhookCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		TaskQueue:           taskQueue,
+		StartToCloseTimeout: webhookStartToCloseTimeout,
 		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			MaximumAttempts:    5,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    30 * time.Second,
-			// USE NonRetryableErrorTypes for validation/domain errors.
-			// Sample error, we don't have it in the demo.
-			NonRetryableErrorTypes: []string{"ValidationError", "BusinessRuleError"},
+			InitialInterval:        time.Second,
+			BackoffCoefficient:     2, //nolint:mnd
+			MaximumInterval:        webhookMaximumInterval,
+			MaximumAttempts:        webhookMaximumAttempts,
+			NonRetryableErrorTypes: []string{activities.ErrTypeWebhookRejected},
+		},
+	})
+
+	var webhooks *activities.WebhookActivities
+
+	return workflow.ExecuteActivity(webhookCtx, webhooks.NotifyWebhookActivity, bill, webhookURL).Get(webhookCtx, nil)
+}
+
+func notificationActivityOptions(taskQueue string) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		TaskQueue:           taskQueue,
+		StartToCloseTimeout: alertStartToCloseTimeout,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval: time.Second,
+			MaximumAttempts: alertMaximumAttempts,
 		},
 	}
-	finalizationCtx := workflow.WithActivityOptions(ctx, ao)
+}
 
-	return workflow.ExecuteActivity(finalizationCtx, activities.ProcessInvoiceAndChargeActivity, bill).
-		Get(finalizationCtx, nil)
+// DoTaxActivity computes the tax for the Pending bill and appends it as a line item.
+// The item key is deterministic per jurisdiction, so a replay never double-applies the tax.
+func DoTaxActivity(
+	ctx workflow.Context,
+	bill *domain.Bill,
+	jurisdiction string,
+	taskQueue string,
+	retry app.RetryConfig,
+) error {
+	logger := workflow.GetLogger(ctx)
+	taxCtx := workflow.WithActivityOptions(ctx, finalizationActivityOptions(taskQueue, retry))
+
+	var tax libmoney.Money
+	err := workflow.ExecuteActivity(taxCtx, activities.CalculateTaxActivity, *bill, jurisdiction).Get(taxCtx, &tax)
+	if err != nil {
+		return err
+	}
+	if err := bill.AddTaxItem(jurisdiction, tax, workflow.Now(ctx)); err != nil {
+		return err
+	}
+	logger.Info("added tax item", "jurisdiction", jurisdiction, "tax", tax.ToString())
+
+	// Temporal will retry it in case of failure of SA upsert
+	if err := UpdateInsertItemSearchAttributes(ctx, *bill); err != nil {
+		logger.Error("UpdateInsertItemSearchAttributes upsert failed", "error", err)
+	}
+
+	return nil
+}
+
+func finalizationActivityOptions(taskQueue string, retry app.RetryConfig) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		TaskQueue:           taskQueue,
+		StartToCloseTimeout: time.Minute,
+		RetryPolicy:         invoiceRetryPolicy(retry),
+	}
+}
+
+// invoiceRetryPolicy maps the given config to a Temporal retry policy, filling unset fields with defaults.
+func invoiceRetryPolicy(cfg app.RetryConfig) *temporal.RetryPolicy {
+	p := &temporal.RetryPolicy{
+		InitialInterval:        cfg.InitialInterval,
+		MaximumAttempts:        cfg.MaximumAttempts,
+		BackoffCoefficient:     cfg.BackoffCoefficient,
+		MaximumInterval:        cfg.MaximumInterval,
+		NonRetryableErrorTypes: cfg.NonRetryableErrorTypes,
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = defaultInvoiceInitialInterval
+	}
+	if p.MaximumAttempts <= 0 {
+		p.MaximumAttempts = defaultInvoiceMaximumAttempts
+	}
+	if p.BackoffCoefficient < 1 {
+		p.BackoffCoefficient = defaultInvoiceBackoffCoefficient
+	}
+	if p.MaximumInterval <= 0 {
+		p.MaximumInterval = defaultInvoiceMaximumInterval
+	}
+	if p.NonRetryableErrorTypes == nil {
+		p.NonRetryableErrorTypes = defaultInvoiceNonRetryableErrorTypes
+	}
+
+	return p
+}
+
+// billLogger adds the correlation ID of the create request, given in the memo, to the workflow logger.
+func billLogger(ctx workflow.Context) log.Logger {
+	logger := workflow.GetLogger(ctx)
+	if cid := correlationIDFromMemo(ctx); cid != "" {
+		return log.With(logger, "correlationID", cid)
+	}
+
+	return logger
+}
+
+// memoConverter decodes the memo, the workflow context doesn't expose the worker's DataConverter.
+var memoConverter = converter.GetDefaultDataConverter()
+
+// UseMemoDataConverter sets the converter the worker client was dialed with, call it before the worker starts.
+// The memo is written by the client, so an encrypting converter encrypts it too.
+func UseMemoDataConverter(dc converter.DataConverter) {
+	if dc != nil {
+		memoConverter = dc
+	}
+}
+
+func correlationIDFromMemo(ctx workflow.Context) string {
+	memo := workflow.GetInfo(ctx).Memo
+	p, ok := memo.GetFields()[app.MemoKeyCorrelationID]
+	if !ok {
+		return ""
+	}
+	var cid string
+	if err := memoConverter.FromPayload(p, &cid); err != nil {
+		return ""
+	}
+
+	return cid
+}
+
+type skipSearchAttributesKey struct{}
+
+func searchAttributesSkipped(ctx workflow.Context) bool {
+	skip, _ := ctx.Value(skipSearchAttributesKey{}).(bool)
+
+	return skip
 }
 
 // the side effect is possibly updated bill.status, set to error!
 func UpdateInsertItemSearchAttributes(ctx workflow.Context, bill domain.Bill) error {
+	if searchAttributesSkipped(ctx) {
+		return nil
+	}
 	// in case of error Temporal will retry this automatically, and replay the addReceive function
 	return workflow.UpsertTypedSearchAttributes(ctx,
-		sa.KeyBillTotalCents.ValueSet(moneyToCents(bill.Total)),
+		sa.KeyBillTotalCents.ValueSet(bill.Total.ToMinorUnits()),
 		sa.KeyBillItemCount.ValueSet(int64(len(bill.Items))),
+		sa.KeyBillUpdatedAt.ValueSet(bill.UpdatedAt),
 	)
 }
 
-// the side effect is possibly updated bill.status, set to error!
-func UpdateBillStatusSearchAttributes(ctx workflow.Context, status domain.BillStatus) error {
-	// in case of error Temporal will retry this automatically
-	return workflow.UpsertTypedSearchAttributes(ctx, sa.KeyBillStatus.ValueSet(string(status)))
+// RefreshSearchAttributes re-upserts every mutable SA from the bill, it's idempotent. The period bounds go along,
+// they're set on start, but bills started before BillPeriodStart/BillPeriodEnd get them this way.
+func RefreshSearchAttributes(ctx workflow.Context, bill domain.Bill) error {
+	if searchAttributesSkipped(ctx) {
+		return nil
+	}
+
+	return workflow.UpsertTypedSearchAttributes(ctx,
+		sa.KeyBillStatus.ValueSet(string(bill.Status)),
+		sa.KeyBillTotalCents.ValueSet(bill.Total.ToMinorUnits()),
+		sa.KeyBillItemCount.ValueSet(int64(len(bill.Items))),
+		sa.KeyBillUpdatedAt.ValueSet(bill.UpdatedAt),
+		sa.KeyBillPeriodStart.ValueSet(bill.PeriodStart),
+		sa.KeyBillPeriodEnd.ValueSet(bill.PeriodEnd),
+	)
 }
 
-func moneyToCents(m libmoney.Money) int64 {
-	scale := 2
-	factor := decimal.New(1, int32(scale)) // 10^scale
+// the side effect is possibly updated bill.status, set to error!
+// UpdateBillClosedSearchAttributes sets the final status along with BillFinalizedAt for "finalized within" searches.
+func UpdateBillClosedSearchAttributes(ctx workflow.Context, bill domain.Bill) error {
+	if searchAttributesSkipped(ctx) {
+		return nil
+	}
+	updates := []temporal.SearchAttributeUpdate{
+		sa.KeyBillStatus.ValueSet(string(bill.Status)),
+		sa.KeyBillUpdatedAt.ValueSet(bill.UpdatedAt),
+	}
+	if bill.FinalizedAt != nil {
+		updates = append(updates, sa.KeyBillFinalizedAt.ValueSet(*bill.FinalizedAt))
+	}
 
-	return m.MulOnDecimal(factor).Round(0).IntPart() // half-away-from-zero
+	// in case of error Temporal will retry this automatically
+	return workflow.UpsertTypedSearchAttributes(ctx, updates...)
 }
 
-func newBillBuilderFromWorkflow(ctx workflow.Context) *domain.BillBuilder {
-	runID := workflow.GetInfo(ctx).WorkflowExecution.RunID
-
-	return domain.NewBillBuilder().WithID(domain.BillID(runID))
+func UpdateBillStatusSearchAttributes(ctx workflow.Context, status domain.BillStatus) error {
+	if searchAttributesSkipped(ctx) {
+		return nil
+	}
+	// in case of error Temporal will retry this automatically
+	return workflow.UpsertTypedSearchAttributes(ctx,
+		sa.KeyBillStatus.ValueSet(string(status)),
+		sa.KeyBillUpdatedAt.ValueSet(workflow.Now(ctx)),
+	)
 }
@@ -0,0 +1,230 @@
+package workflows
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/kafka"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
+)
+
+// Record the scenario histories against a dev server, with the temporal CLI on the PATH (or in TEMPORAL_CLI):
+//
+//	go test ./fees/app/workflows -run TestReplay_RecordedHistories -record
+//
+// Record before changing the workflow, the point of a fixture is to be a history of the code as it was.
+var recordHistories = flag.Bool("record", false, "record the replay scenario histories to testdata")
+
+const replayTaskQueue = "FEES_TASK_QUEUE"
+
+// replayScenario drives a bill through a dev server, its history is recorded to testdata/file.
+type replayScenario struct {
+	file   string
+	params app.MonthlyFeeAccrualWorkflowParams
+	// signal sends the signals of the scenario, the last one is expected to end the workflow.
+	signal func(ctx context.Context, c client.Client, id string) error
+}
+
+func replayScenarios() []replayScenario {
+	usd := func(v string) libmoney.Money {
+		m, _ := libmoney.NewFromString(v, libmoney.CurrencyUSD)
+		return m
+	}
+	params := func(customerID string) app.MonthlyFeeAccrualWorkflowParams {
+		period := time.Now().UTC().Format("2006-01")
+		periodNum, _ := libtime.ToYYYYMM(period)
+
+		return app.MonthlyFeeAccrualWorkflowParams{
+			BillID:       domain.MakeBillID(customerID, domain.BillingPeriod(period)),
+			CustomerID:   customerID,
+			Period:       domain.BillingPeriod(period),
+			PeriodYYYYMM: periodNum,
+			Currency:     libmoney.CurrencyUSD,
+		}
+	}
+
+	happyPath := params("cust-replay-happy")
+	happyPath.AutoClose = true
+	itemSignals := params("cust-replay-items")
+	itemSignals.Jurisdiction = "GE"
+
+	return []replayScenario{
+		{
+			// one item, close, invoiced; the auto-close timer is canceled by the close
+			file:   "monthly_bill_happy_path.json",
+			params: happyPath,
+			signal: func(ctx context.Context, c client.Client, id string) error {
+				return sendSignals(ctx, c, id,
+					signal{SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API fee", Amount: usd("10.00")}},
+					signal{SignalCloseBill, struct{}{}},
+				)
+			},
+		},
+		{
+			// every item signal, a duplicate and a rejected one included, then closed with tax
+			file:   "monthly_bill_item_signals.json",
+			params: itemSignals,
+			signal: func(ctx context.Context, c client.Client, id string) error {
+				return sendSignals(ctx, c, id,
+					signal{SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API fee", Amount: usd("10.00")}},
+					signal{SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API fee", Amount: usd("10.00")}},
+					signal{SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-2", Description: "Storage", Amount: usd("5.25")}},
+					signal{SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "credit-1", Description: "Credit", Amount: usd("-100")}},
+					signal{SignalUpdateLineItemDescription, UpdateLineItemDescriptionPayload{IdempotencyKey: "item-2", NewDescription: "Object storage"}},
+					signal{SignalCorrectLineItemAmount, CorrectLineItemAmountPayload{IdempotencyKey: "item-1", NewAmount: usd("12.00")}},
+					signal{SignalSetBillNote, SetBillNotePayload{Note: "replay fixture"}},
+					signal{SignalReconcileBill, struct{}{}},
+					signal{SignalCloseBill, struct{}{}},
+				)
+			},
+		},
+	}
+}
+
+type signal struct {
+	name string
+	arg  any
+}
+
+// sendSignals sends the signals one workflow task apart, a query only returns once the earlier ones are handled.
+func sendSignals(ctx context.Context, c client.Client, id string, signals ...signal) error {
+	for _, s := range signals {
+		if err := c.SignalWorkflow(ctx, id, "", s.name, s.arg); err != nil {
+			return err
+		}
+		if s.name == SignalCloseBill {
+			continue
+		}
+		if _, err := c.QueryWorkflow(ctx, id, "", QueryState); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TestReplay_RecordedHistories replays every history in testdata against the current workflow code,
+// a non-determinism error means the change needs a workflow.GetVersion gate, see versions.go.
+func TestReplay_RecordedHistories(t *testing.T) {
+	if *recordHistories {
+		recordScenarioHistories(t)
+	}
+
+	files, err := filepath.Glob("testdata/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			replayer := worker.NewWorkflowReplayer()
+			replayer.RegisterWorkflowWithOptions(MonthlyFeeAccrualWorkflow,
+				workflow.RegisterOptions{Name: WorkflowTypeMonthlyBill})
+
+			require.NoError(t, replayer.ReplayWorkflowHistoryFromJSONFile(nil, file))
+		})
+	}
+}
+
+// recordScenarioHistories runs the replay scenarios on a dev server with the real activities and writes
+// their histories in the `temporal workflow show -o json` format.
+func recordScenarioHistories(t *testing.T) {
+	t.Helper()
+	cli := os.Getenv("TEMPORAL_CLI")
+	if cli == "" {
+		var err error
+		cli, err = exec.LookPath("temporal")
+		require.NoError(t, err, "recording needs the temporal CLI, set TEMPORAL_CLI or put it on the PATH")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	server, err := testsuite.StartDevServer(ctx, testsuite.DevServerOptions{
+		ExistingPath: cli,
+		ExtraArgs: []string{
+			"--search-attribute", "CustomerID=Keyword",
+			"--search-attribute", "BillingPeriodNum=Int",
+			"--search-attribute", "BillStatus=Keyword",
+			"--search-attribute", "BillCurrency=Keyword",
+			"--search-attribute", "BillItemCount=Int",
+			"--search-attribute", "BillTotalCents=Int",
+			"--search-attribute", "BillFinalizedAt=Datetime",
+			"--search-attribute", "BillUpdatedAt=Datetime",
+			"--search-attribute", "BillCreatedAt=Datetime",
+		},
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, server.Stop()) }()
+	c := server.Client()
+	waitSearchAttributes(ctx, t, c)
+
+	w := worker.New(c, replayTaskQueue, worker.Options{})
+	w.RegisterWorkflowWithOptions(MonthlyFeeAccrualWorkflow, workflow.RegisterOptions{Name: WorkflowTypeMonthlyBill})
+	w.RegisterActivity(&activities.ChargeActivities{Payments: activities.NoopPaymentGateway{}})
+	w.RegisterActivity(activities.CalculateTaxActivity)
+	w.RegisterActivity(&activities.AlertActivities{Alerter: activities.NoopAlerter{}})
+	w.RegisterActivity(&activities.AuditActivities{Kafka: kafka.LogPublisher{}})
+	w.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	require.NoError(t, w.Start())
+	defer w.Stop()
+
+	for _, sc := range replayScenarios() {
+		id := string(sc.params.BillID)
+		run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+			ID:        id,
+			TaskQueue: replayTaskQueue,
+			Memo:      map[string]any{app.MemoKeyCorrelationID: "replay-fixture"},
+		}, WorkflowTypeMonthlyBill, sc.params)
+		require.NoError(t, err)
+		require.NoError(t, sc.signal(ctx, c, id), sc.file)
+		require.NoError(t, run.Get(ctx, nil), sc.file)
+
+		writeHistory(ctx, t, c, run, filepath.Join("testdata", sc.file))
+	}
+}
+
+// waitSearchAttributes waits for the dev server to map the bill SAs, a workflow task upserting an unmapped one fails
+// and the failure would be recorded in the history.
+func waitSearchAttributes(ctx context.Context, t *testing.T, c client.Client) {
+	t.Helper()
+	q := "CustomerID = 'x' AND BillingPeriodNum = 1 AND BillStatus = 'x' AND BillCurrency = 'x' AND " +
+		"BillItemCount = 1 AND BillTotalCents = 1 AND BillFinalizedAt IS NULL AND BillUpdatedAt IS NULL AND " +
+		"BillCreatedAt IS NULL"
+	require.Eventually(t, func() bool {
+		_, err := c.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{Query: q})
+		return err == nil
+	}, 30*time.Second, 200*time.Millisecond, "the dev server didn't map the search attributes")
+}
+
+func writeHistory(ctx context.Context, t *testing.T, c client.Client, run client.WorkflowRun, path string) {
+	t.Helper()
+	history := &historypb.History{}
+	iter := c.GetWorkflowHistory(ctx, run.GetID(), run.GetRunID(), false, enumspb.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		require.NoError(t, err)
+		history.Events = append(history.Events, event)
+	}
+
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(history)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(data, '\n'), 0o600))
+	t.Logf("recorded %d events to %s", len(history.Events), path)
+}
@@ -0,0 +1,86 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// The bill rules of MonthlyFeeAccrualWorkflow. The bill history is replayed through them too (see
+// QueryBillAsOf of the Temporal gateway), so a bill as of a past time is the one the workflow had.
+
+// VersionOf tells the version of a change a bill runs, workflow.GetVersion in the workflow, the recorded version
+// markers in a replay of its history.
+type VersionOf func(changeID string, maxSupported workflow.Version) workflow.Version
+
+// NewBill builds the open bill of params with its template items.
+func NewBill(params app.MonthlyFeeAccrualWorkflowParams, createdAt time.Time) (domain.Bill, error) {
+	bill, err := domain.NewBillBuilder().
+		WithID(params.BillID).
+		ForCustomer(params.CustomerID).
+		ForPeriod(params.Period).
+		WithCurrency(params.Currency).
+		WithCreatedAt(createdAt).
+		Open().
+		Build()
+	if err != nil {
+		return domain.Bill{}, err
+	}
+	for _, li := range params.Template {
+		if _, err := bill.AddTaggedItemStrict(li.IdempotencyKey, li.Description, li.Amount, li.Tags, createdAt); err != nil {
+			return domain.Bill{}, fmt.Errorf("template item %q: %w", li.IdempotencyKey, err)
+		}
+	}
+
+	return bill, nil
+}
+
+// SetUpBill sets the close and line item rules of params on the bill, a bill started before a rule doesn't get it.
+func SetUpBill(bill *domain.Bill, params app.MonthlyFeeAccrualWorkflowParams, version VersionOf) {
+	if version(changeIDEmptyBillGuard, versionEmptyBillGuard) >= versionEmptyBillGuard && !params.AllowEmptyBills {
+		bill.ItemsRequired = true
+	}
+	// zero is no cap, as for the bills started before the limit
+	if version(changeIDItemLimit, versionItemLimit) >= versionItemLimit {
+		bill.MaxItems = app.MaxItemsOrDefault(params.MaxItems)
+	}
+}
+
+// ApplyLineItem adds the item of SignalAddLineItem. A discarded item leaves the bill as is, the error tells why:
+// domain.ErrBillNotOpen once the bill is closing, domain.ErrCurrencyMismatch with params.StrictCurrency,
+// app.ErrBillItemLimit at the cap of the bill and domain.ErrLineItemAlreadyAdded for a key collision.
+func ApplyLineItem(
+	bill *domain.Bill,
+	params app.MonthlyFeeAccrualWorkflowParams,
+	pl AddLineItemPayload,
+	now time.Time,
+) (domain.AddItemResult, error) {
+	if !bill.IsActive() {
+		return 0, domain.ErrBillNotOpen
+	}
+	if params.StrictCurrency {
+		if err := bill.CheckCurrency(pl.Amount); err != nil {
+			return 0, err
+		}
+	}
+	// a retry of an added item is still a no-op at the cap
+	if bill.IsFull() && !bill.HasItem(pl.IdempotencyKey) {
+		return 0, app.ErrBillItemLimit
+	}
+
+	return bill.AddTaggedItemStrict(pl.IdempotencyKey, pl.Description, pl.Amount, pl.Tags, now)
+}
+
+// PendBill moves the bill to Pending on close, a bill with ItemsRequired isn't closed empty (domain.ErrBillEmpty).
+func PendBill(bill *domain.Bill, now time.Time) error {
+	var guards []func(*domain.Bill) error
+	if bill.ItemsRequired {
+		guards = append(guards, domain.RequireItems)
+	}
+
+	return bill.Pending(now, guards...)
+}
@@ -10,6 +10,11 @@ const (
 	BillCurrencyName     = "BillCurrency"
 	BillItemCountName    = "BillItemCount"
 	BillTotalCentsName   = "BillTotalCents"
+	BillFinalizedAtName  = "BillFinalizedAt"
+	BillUpdatedAtName    = "BillUpdatedAt"
+	BillCreatedAtName    = "BillCreatedAt"
+	BillPeriodStartName  = "BillPeriodStart"
+	BillPeriodEndName    = "BillPeriodEnd"
 )
 
 var (
@@ -19,4 +24,9 @@ var (
 	KeyBillCurrency     = temporal.NewSearchAttributeKeyKeyword(BillCurrencyName)
 	KeyBillItemCount    = temporal.NewSearchAttributeKeyInt64(BillItemCountName)
 	KeyBillTotalCents   = temporal.NewSearchAttributeKeyInt64(BillTotalCentsName)
+	KeyBillFinalizedAt  = temporal.NewSearchAttributeKeyTime(BillFinalizedAtName) // set on close only
+	KeyBillUpdatedAt    = temporal.NewSearchAttributeKeyTime(BillUpdatedAtName)
+	KeyBillCreatedAt    = temporal.NewSearchAttributeKeyTime(BillCreatedAtName) // set on start only
+	KeyBillPeriodStart  = temporal.NewSearchAttributeKeyTime(BillPeriodStartName)
+	KeyBillPeriodEnd    = temporal.NewSearchAttributeKeyTime(BillPeriodEndName)
 )
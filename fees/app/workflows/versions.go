@@ -0,0 +1,54 @@
+package workflows
+
+// Change IDs for workflow.GetVersion, they keep running bills replaying deterministically when
+// MonthlyFeeAccrualWorkflow changes. The convention:
+//   - one change ID per feature, a kebab-case name of it, e.g. "auto-close";
+//   - a new branch is gated by GetVersion(ctx, changeID, workflow.DefaultVersion, version), old histories
+//     have no marker, so they get workflow.DefaultVersion and take the old path;
+//   - a later change of the same feature bumps its version, a change ID is never renamed or reused;
+//   - the old path is removed only once no bill started before the change is running (or retained).
+//
+// Replaying testdata/*.json histories recorded before a change proves the gate, see TestReplay_*.
+const (
+	// changeIDErrorAlert gates alerting operators of an invoicing failure, bills started before it fail silently.
+	changeIDErrorAlert = "error-alert"
+	versionErrorAlert  = 1
+	// changeIDManualInvoiceRetry gates keeping a bill whose invoicing failed open for SignalRetryInvoicing,
+	// bills started before it complete with the failure at once, as they did.
+	changeIDManualInvoiceRetry = "manual-invoice-retry"
+	versionManualInvoiceRetry  = 1
+	// changeIDAuditEvents gates publishing the audit events of the bill outcome, i.e. bill.errored and bill.closed,
+	// bills started before it publish none.
+	changeIDAuditEvents = "audit-events"
+	versionAuditEvents  = 1
+	// changeIDAutoClose gates the timer closing the bill when its period ends, see params.AutoClose.
+	changeIDAutoClose = "auto-close"
+	versionAutoClose  = 1
+	// changeIDEmptyBillGuard gates refusing to close empty bills, see params.AllowEmptyBills.
+	// Bills started before it always allow empty bills, as they did.
+	changeIDEmptyBillGuard = "empty-bill-guard"
+	versionEmptyBillGuard  = 1
+	// changeIDInvoiceArchive gates archiving the invoice after the charge.
+	changeIDInvoiceArchive = "invoice-archive"
+	versionInvoiceArchive  = 1
+	// changeIDCloseFirst gates serving a buffered close signal before the line items of the same task.
+	// Version 2 serves them in the Select order again, version 1 dropped the line items signaled before the close
+	// but served after it.
+	changeIDCloseFirst  = "close-first"
+	versionCloseFirst   = 1
+	versionCloseInOrder = 2
+	// changeIDItemLimit gates rejecting line items past params.MaxItems, bills started before it have no cap.
+	changeIDItemLimit = "item-limit"
+	versionItemLimit  = 1
+	// changeIDSettleNonPositive gates closing a bill with a zero or negative total without charging it,
+	// bills started before it are invoiced whatever their total.
+	changeIDSettleNonPositive = "settle-non-positive"
+	versionSettleNonPositive  = 1
+	// changeIDErrorReasonMemo gates keeping the invoicing failure of an errored bill in its memo.
+	changeIDErrorReasonMemo = "error-reason-memo"
+	versionErrorReasonMemo  = 1
+	// changeIDInvoicingFailureStatus gates failing invoicing into ChargeFailed or Rejected, by the failure,
+	// bills started before it go into Error whatever the failure.
+	changeIDInvoicingFailureStatus = "invoicing-failure-status"
+	versionInvoicingFailureStatus  = 1
+)
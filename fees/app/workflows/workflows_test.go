@@ -2,18 +2,30 @@ package workflows
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 // MockActivityEnvironment for testing activities
@@ -26,16 +38,60 @@ func (m *MockActivityEnvironment) ProcessInvoiceAndChargeActivity(ctx context.Co
 	return args.Error(0)
 }
 
+// charge references the charge activity in OnActivity, the struct is registered by the environment.
+var charge *activities.ChargeActivities
+
+// capturingMetricsHandler counts Inc calls per "name{currency}"
+type capturingMetricsHandler struct {
+	tags     map[string]string
+	counters map[string]int64
+}
+
+func newCapturingMetricsHandler() *capturingMetricsHandler {
+	return &capturingMetricsHandler{counters: map[string]int64{}}
+}
+
+func (h *capturingMetricsHandler) WithTags(tags map[string]string) client.MetricsHandler {
+	merged := map[string]string{}
+	for k, v := range h.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	return &capturingMetricsHandler{tags: merged, counters: h.counters}
+}
+
+func (h *capturingMetricsHandler) Counter(name string) client.MetricsCounter {
+	key := name + "{" + h.tags[metricTagCurrency] + "}"
+
+	return counterFunc(func(v int64) { h.counters[key] += v })
+}
+
+func (h *capturingMetricsHandler) Gauge(name string) client.MetricsGauge {
+	return client.MetricsNopHandler.Gauge(name)
+}
+
+func (h *capturingMetricsHandler) Timer(name string) client.MetricsTimer {
+	return client.MetricsNopHandler.Timer(name)
+}
+
+type counterFunc func(int64)
+
+func (f counterFunc) Inc(v int64) { f(v) }
+
 // TestMonthlyFeeAccrualWorkflow_CompleteFlow tests the complete workflow lifecycle
 func TestMonthlyFeeAccrualWorkflow_CompleteFlow(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
 	// Set workflow timeout to prevent hanging
 	env.SetTestTimeout(time.Minute)
 
 	// Mock the activity
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	// Test parameters
@@ -45,6 +101,8 @@ func TestMonthlyFeeAccrualWorkflow_CompleteFlow(t *testing.T) {
 		Period:       domain.BillingPeriod("2025-01"),
 		PeriodYYYYMM: 202501,
 		Currency:     libmoney.CurrencyUSD,
+
+		AllowEmptyBills: true,
 	}
 
 	// Register callback to send close signal after workflow starts
@@ -73,16 +131,158 @@ func TestMonthlyFeeAccrualWorkflow_CompleteFlow(t *testing.T) {
 	assert.NotNil(t, result.FinalizedAt)
 }
 
+func TestMonthlyFeeAccrualWorkflow_MinimumChargeWriteOff(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	// registered, not mocked: the test fails if the workflow charges the bill
+	env.RegisterActivity(&activities.ChargeActivities{Payments: activities.NoopPaymentGateway{}})
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:         domain.BillID("test-bill-min-charge"),
+		CustomerID:     "customer-min-charge",
+		Period:         domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM:   202501,
+		Currency:       libmoney.CurrencyUSD,
+		MinChargeMinor: 50,
+	}
+
+	amount, _ := libmoney.NewFromString("0.10", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1",
+			Description:    "API usage fee",
+			Amount:         amount,
+		})
+	}, time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, domain.BillStatusWrittenOff, result.Status)
+	assert.Equal(t, "0.1", result.Total.ToString())
+	assert.NotNil(t, result.FinalizedAt)
+	env.AssertActivityNumberOfCalls(t, "ProcessInvoiceAndChargeActivity", 0)
+}
+
+func TestMonthlyFeeAccrualWorkflow_SettleNonPositiveTotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		amounts []string
+		charged int
+	}{
+		{name: "exactly zero", amounts: []string{"10.00", "-10.00"}, charged: 0},
+		{name: "slightly negative", amounts: []string{"10.00", "-10.01"}, charged: 0},
+		{name: "positive", amounts: []string{"10.00", "-9.99"}, charged: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+			env.SetTestTimeout(time.Minute)
+
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+				Return(nil)
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       domain.BillID("test-bill-settle"),
+				CustomerID:   "customer-settle",
+				Period:       domain.BillingPeriod("2025-01"),
+				PeriodYYYYMM: 202501,
+				Currency:     libmoney.CurrencyUSD,
+			}
+
+			for i, v := range tt.amounts {
+				amount, err := libmoney.NewFromString(v, libmoney.CurrencyUSD)
+				require.NoError(t, err)
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+						IdempotencyKey: fmt.Sprintf("item-%d", i),
+						Description:    "fee or credit",
+						Amount:         amount,
+					})
+				}, time.Duration(i+1)*time.Millisecond)
+			}
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, time.Duration(len(tt.amounts)+1)*time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+
+			var result domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, domain.BillStatusClosed, result.Status)
+			assert.NotNil(t, result.FinalizedAt)
+			env.AssertActivityNumberOfCalls(t, "ProcessInvoiceAndChargeActivity", tt.charged)
+		})
+	}
+}
+
+func TestMonthlyFeeAccrualWorkflow_WebhookOnClose(t *testing.T) {
+	const webhookURL = "https://customer.example.com/hooks/bills"
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.RegisterActivity(&activities.WebhookActivities{})
+	defer env.AssertExpectations(t)
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil).Once()
+	var webhooks *activities.WebhookActivities
+	var notified domain.Bill
+	env.OnActivity(webhooks.NotifyWebhookActivity, mock.Anything, mock.Anything, webhookURL).
+		Run(func(args mock.Arguments) { notified = args.Get(1).(domain.Bill) }).
+		Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-webhook"),
+		CustomerID:   "customer-webhook",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+		Template:     feeTemplate(libmoney.CurrencyUSD),
+		WebhookURL:   webhookURL,
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertActivityNumberOfCalls(t, "NotifyWebhookActivity", 1)
+	assert.Equal(t, domain.BillStatusClosed, notified.Status)
+	assert.NotNil(t, notified.FinalizedAt)
+}
+
 // TestMonthlyFeeAccrualWorkflow_AddLineItems tests adding line items via signals
 func TestMonthlyFeeAccrualWorkflow_AddLineItems(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
 	// Set workflow timeout to prevent hanging
 	env.SetTestTimeout(time.Minute)
 
 	// Mock the activity
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	params := app.MonthlyFeeAccrualWorkflowParams{
@@ -143,12 +343,13 @@ func TestMonthlyFeeAccrualWorkflow_AddLineItems(t *testing.T) {
 func TestMonthlyFeeAccrualWorkflow_QueryHandler(t *testing.T) {
 	var suite testsuite.WorkflowTestSuite
 	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 	defer env.AssertExpectations(t)
 
 	env.SetTestTimeout(10 * time.Second)
 
 	// Mock activities used by the workflow
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	params := app.MonthlyFeeAccrualWorkflowParams{
@@ -157,6 +358,8 @@ func TestMonthlyFeeAccrualWorkflow_QueryHandler(t *testing.T) {
 		Period:       domain.BillingPeriod("2025-03"),
 		PeriodYYYYMM: 202503,
 		Currency:     libmoney.CurrencyGEL,
+
+		Template: feeTemplate(libmoney.CurrencyGEL),
 	}
 
 	var queryResult BillDTO
@@ -185,19 +388,137 @@ func TestMonthlyFeeAccrualWorkflow_QueryHandler(t *testing.T) {
 	assert.Equal(t, string(params.Period), queryResult.BillingPeriod)
 	assert.Equal(t, string(params.Currency), string(queryResult.Currency))
 	assert.Equal(t, string(domain.BillStatusOpen), queryResult.Status)
-	assert.Len(t, queryResult.Items, 0)
+	assert.Len(t, queryResult.Items, 1)
+}
+
+func TestMonthlyFeeAccrualWorkflow_ItemTags(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.SetTestTimeout(10 * time.Second)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-tags"),
+		CustomerID:   "customer-789",
+		Period:       domain.BillingPeriod("2025-03"),
+		PeriodYYYYMM: 202503,
+		Currency:     libmoney.CurrencyUSD,
+	}
+	tags := map[string]string{"costCenter": "R&D", "sku": "API-100"}
+	var queryResult BillDTO
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "tagged",
+			Description:    "API usage",
+			Amount:         libmoney.NewFromInt(10, libmoney.CurrencyUSD),
+			Tags:           tags,
+		})
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "plain",
+			Description:    "Support",
+			Amount:         libmoney.NewFromInt(5, libmoney.CurrencyUSD),
+		})
+	}, 1*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		v, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		require.NoError(t, v.Get(&queryResult))
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	require.Len(t, queryResult.Items, 2)
+	byKey := map[string]LineItemDTO{}
+	for _, li := range queryResult.Items {
+		byKey[li.IdempotencyKey] = li
+	}
+	assert.Equal(t, tags, byKey["tagged"].Tags)
+	assert.Nil(t, byKey["plain"].Tags)
+
+	var bill domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&bill))
+	assert.Equal(t, tags, bill.Items[0].Tags)
+}
+
+// TestMonthlyFeeAccrualWorkflow_SummaryQuery checks the summary query agrees with the full one
+func TestMonthlyFeeAccrualWorkflow_SummaryQuery(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	defer env.AssertExpectations(t)
+
+	env.SetTestTimeout(10 * time.Second)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-summary"),
+		CustomerID:   "customer-789",
+		Period:       domain.BillingPeriod("2025-03"),
+		PeriodYYYYMM: 202503,
+		Currency:     libmoney.CurrencyUSD,
+	}
+	amount1, _ := libmoney.NewFromString("10.25", libmoney.CurrencyUSD)
+	amount2, _ := libmoney.NewFromString("4.75", libmoney.CurrencyUSD)
+
+	var full BillDTO
+	var summary BillSummaryDTO
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount1,
+		})
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-2", Description: "Storage fee", Amount: amount2,
+		})
+	}, time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		v, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		require.NoError(t, v.Get(&full))
+		v, err = env.QueryWorkflow(QuerySummary)
+		require.NoError(t, err)
+		require.NoError(t, v.Get(&summary))
+	}, 2*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 3*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	require.Len(t, full.Items, 2)
+	assert.Equal(t, full.ID, summary.ID)
+	assert.Equal(t, full.CustomerID, summary.CustomerID)
+	assert.Equal(t, full.Currency, summary.Currency)
+	assert.Equal(t, full.BillingPeriod, summary.BillingPeriod)
+	assert.Equal(t, full.Status, summary.Status)
+	assert.Equal(t, "15", summary.Total.ToString())
+	assert.Equal(t, full.Total.ToString(), summary.Total.ToString())
+	assert.Equal(t, len(full.Items), summary.ItemCount)
+	assert.Equal(t, full.CreatedAt, summary.CreatedAt)
+	assert.Equal(t, full.UpdatedAt, summary.UpdatedAt)
+	assert.Nil(t, summary.ClosedAt)
 }
 
 // TestMonthlyFeeAccrualWorkflow_Idempotency tests idempotent line item addition
 func TestMonthlyFeeAccrualWorkflow_Idempotency(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
 	// Set workflow timeout to prevent hanging
 	env.SetTestTimeout(time.Minute)
 
 	// Mock the activity
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	params := app.MonthlyFeeAccrualWorkflowParams{
@@ -252,12 +573,13 @@ func TestMonthlyFeeAccrualWorkflow_Idempotency(t *testing.T) {
 func TestMonthlyFeeAccrualWorkflow_ClosedBillRejection(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
 	// Set workflow timeout to prevent hanging
 	env.SetTestTimeout(time.Minute)
 
 	// Mock the activity
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	params := app.MonthlyFeeAccrualWorkflowParams{
@@ -266,6 +588,8 @@ func TestMonthlyFeeAccrualWorkflow_ClosedBillRejection(t *testing.T) {
 		Period:       domain.BillingPeriod("2025-05"),
 		PeriodYYYYMM: 202505,
 		Currency:     libmoney.CurrencyUSD,
+
+		AllowEmptyBills: true,
 	}
 
 	// Register callbacks to send signals after workflow starts
@@ -303,12 +627,13 @@ func TestMonthlyFeeAccrualWorkflow_ClosedBillRejection(t *testing.T) {
 func TestMonthlyFeeAccrualWorkflow_CurrencyHandling(t *testing.T) {
 	testSuite := &testsuite.WorkflowTestSuite{}
 	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
 	// Set workflow timeout to prevent hanging
 	env.SetTestTimeout(time.Minute)
 
 	// Mock the activity
-	env.OnActivity(activities.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
 		Return(nil)
 
 	params := app.MonthlyFeeAccrualWorkflowParams{
@@ -360,134 +685,1808 @@ func TestMonthlyFeeAccrualWorkflow_CurrencyHandling(t *testing.T) {
 	assert.Equal(t, 2, len(result.Items))
 }
 
-// TestBillToDTO tests the DTO conversion function
-func TestBillToDTO(t *testing.T) {
-	now := time.Now()
-	bill := domain.Bill{
-		ID:            domain.BillID("test-bill-dto"),
-		CustomerID:    "customer-dto",
-		Currency:      libmoney.CurrencyUSD,
-		BillingPeriod: domain.BillingPeriod("2025-08"),
-		Status:        domain.BillStatusOpen,
-		Items: []domain.LineItem{
-			{
-				IdempotencyKey: "dto-item-1",
-				Description:    "DTO test item 1",
-				Amount:         libmoney.NewFromFloat(10.50, libmoney.CurrencyUSD),
-				AddedAt:        now,
-			},
-			{
-				IdempotencyKey: "dto-item-2",
-				Description:    "DTO test item 2",
-				Amount:         libmoney.NewFromFloat(25.75, libmoney.CurrencyUSD),
-				AddedAt:        now.Add(time.Hour),
-			},
-		},
-		Total:       libmoney.NewFromFloat(36.25, libmoney.CurrencyUSD),
-		CreatedAt:   now,
-		UpdatedAt:   now.Add(2 * time.Hour),
-		FinalizedAt: &now,
-	}
+// TestMonthlyFeeAccrualWorkflow_InvoiceRetryConfig tests that a single-attempt retry config errors the bill at once
+func TestMonthlyFeeAccrualWorkflow_InvoiceRetryConfig(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
 
-	dto := billToDTO(bill)
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
 
-	// Verify DTO fields
-	assert.Equal(t, string(bill.ID), dto.ID)
-	assert.Equal(t, bill.CustomerID, dto.CustomerID)
-	assert.Equal(t, bill.Currency, dto.Currency)
-	assert.Equal(t, string(bill.BillingPeriod), dto.BillingPeriod)
-	assert.Equal(t, string(bill.Status), dto.Status)
-	assert.Equal(t, bill.Total.ToString(), dto.Total.ToString())
-	assert.Equal(t, bill.CreatedAt, dto.CreatedAt)
-	assert.Equal(t, bill.UpdatedAt, dto.UpdatedAt)
-	assert.Equal(t, bill.FinalizedAt, dto.ClosedAt)
+	attempts := 0
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ domain.Bill) error {
+			attempts++
+			return errors.New("payment provider unavailable")
+		})
 
-	// Verify line items
-	assert.Equal(t, len(bill.Items), len(dto.Items))
-	for i, item := range bill.Items {
-		assert.Equal(t, item.IdempotencyKey, dto.Items[i].IdempotencyKey)
-		assert.Equal(t, item.Description, dto.Items[i].Description)
-		assert.Equal(t, item.Amount.ToString(), dto.Items[i].Amount.ToString())
-		assert.Equal(t, item.AddedAt, dto.Items[i].AddedAt)
-	}
-}
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-retry"),
+		CustomerID:   "customer-retry",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		InvoiceRetry: app.RetryConfig{MaximumAttempts: 1},
 
-// TestMoneyToCents tests the money conversion function
-func TestMoneyToCents(t *testing.T) {
-	tests := []struct {
-		name     string
-		amount   string
-		currency libmoney.Currency
-		expected int64
-	}{
-		{
-			name:     "USD 10.50",
-			amount:   "10.50",
-			currency: libmoney.CurrencyUSD,
-			expected: 1050,
-		},
-		{
-			name:     "USD 0.01",
-			amount:   "0.01",
-			currency: libmoney.CurrencyUSD,
-			expected: 1,
-		},
-		{
-			name:     "USD 100.00",
-			amount:   "100.00",
-			currency: libmoney.CurrencyUSD,
-			expected: 10000,
-		},
-		{
-			name:     "GEL 25.75",
-			amount:   "25.75",
-			currency: libmoney.CurrencyGEL,
-			expected: 2575,
-		},
-		{
-			name:     "USD 0.00",
-			amount:   "0.00",
-			currency: libmoney.CurrencyUSD,
-			expected: 0,
-		},
+		Template: feeTemplate(libmoney.CurrencyUSD),
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			money, err := libmoney.NewFromString(tt.amount, tt.currency)
-			require.NoError(t, err)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
 
-			result := moneyToCents(money)
-			assert.Equal(t, tt.expected, result)
-		})
-	}
-}
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
 
-// TestWorkflowConstants tests that constants are properly defined
-func TestWorkflowConstants(t *testing.T) {
-	assert.Equal(t, "MonthlyFeeAccrualWorkflow", WorkflowTypeMonthlyBill)
-	assert.Equal(t, "SignalAddLineItem", SignalAddLineItem)
-	assert.Equal(t, "SignalCloseBill", SignalCloseBill)
-	assert.Equal(t, "CurrentBillState", QueryState)
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Equal(t, 1, attempts)
 }
 
-// TestAddLineItemPayload tests the payload structure
-func TestAddLineItemPayload(t *testing.T) {
-	amount, _ := libmoney.NewFromString("99.99", libmoney.CurrencyUSD)
-	payload := AddLineItemPayload{
-		IdempotencyKey: "test-key",
-		Description:    "Test description",
-		Amount:         amount,
-	}
+// TestMonthlyFeeAccrualWorkflow_ActivityTaskQueue tests that invoicing is scheduled on the configured activity queue
+func TestMonthlyFeeAccrualWorkflow_ActivityTaskQueue(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
 
-	assert.Equal(t, "test-key", payload.IdempotencyKey)
-	assert.Equal(t, "Test description", payload.Description)
-	assert.Equal(t, amount.ToString(), payload.Amount.ToString())
-}
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
 
-// TestCloseBillSignal tests the close signal structure
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	var activityTaskQueue string
+	env.SetOnActivityStartedListener(func(info *activity.Info, _ context.Context, _ converter.EncodedValues) {
+		activityTaskQueue = info.TaskQueue
+	})
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:            domain.BillID("test-bill-queue"),
+		CustomerID:        "customer-queue",
+		Period:            domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM:      202506,
+		Currency:          libmoney.CurrencyUSD,
+		ActivityTaskQueue: "FEES_ACTIVITY_TASK_QUEUE",
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.Equal(t, "FEES_ACTIVITY_TASK_QUEUE", activityTaskQueue)
+}
+
+// TestMonthlyFeeAccrualWorkflow_Tax tests that tax is computed at close and added as a line item
+func TestMonthlyFeeAccrualWorkflow_Tax(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	// the real tax activity runs, the charging one is mocked
+	env.RegisterActivity(activities.CalculateTaxActivity)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-tax"),
+		CustomerID:   "customer-tax",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyGEL,
+		Jurisdiction: "GE",
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyGEL)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1",
+			Description:    "API usage fee",
+			Amount:         amount,
+		})
+	}, time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "tax:GE", result.Items[1].IdempotencyKey)
+	assert.Equal(t, "1.8", result.Items[1].Amount.ToString())
+	assert.Equal(t, "11.8", result.Total.ToString())
+	assert.Equal(t, domain.BillStatusClosed, result.Status)
+}
+
+// TestMonthlyFeeAccrualWorkflow_TaxUnknownJurisdiction tests that an unknown jurisdiction errors the bill
+func TestMonthlyFeeAccrualWorkflow_TaxUnknownJurisdiction(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.RegisterActivity(activities.CalculateTaxActivity)
+	env.RegisterActivity(&activities.ChargeActivities{Payments: activities.NoopPaymentGateway{}})
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-tax-unknown"),
+		CustomerID:   "customer-tax",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		Jurisdiction: "XX",
+
+		AllowEmptyBills: true,
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "unknown tax jurisdiction")
+}
+
+// TestInvoiceRetryPolicy tests defaults are applied only for unset fields
+func TestMonthlyFeeAccrualWorkflow_SkipSearchAttributes(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+	// any upsert would fail as in a namespace without the bill SAs
+	env.OnUpsertTypedSearchAttributes(mock.Anything).
+		Return(errors.New("search attribute BillStatus is not defined")).Maybe()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:               domain.BillID("test-bill-no-sa"),
+		CustomerID:           "customer-no-sa",
+		Period:               domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM:         202506,
+		Currency:             libmoney.CurrencyUSD,
+		SkipSearchAttributes: true,
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1",
+			Description:    "API usage fee",
+			Amount:         amount,
+		})
+	}, time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+
+	assert.Len(t, result.Items, 1)
+	assert.Equal(t, domain.BillStatusClosed, result.Status)
+	env.AssertNotCalled(t, "UpsertTypedSearchAttributes", mock.Anything)
+}
+
+func TestMonthlyFeeAccrualWorkflow_Metrics(t *testing.T) {
+	metrics := newCapturingMetricsHandler()
+	testSuite := &testsuite.WorkflowTestSuite{}
+	testSuite.SetMetricsHandler(metrics)
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-metrics"),
+		CustomerID:   "customer-metrics",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyGEL,
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyGEL)
+	other, _ := libmoney.NewFromString("20.00", libmoney.CurrencyGEL)
+	signals := []AddLineItemPayload{
+		{IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount},
+		{IdempotencyKey: "item-2", Description: "Storage fee", Amount: amount},
+		{IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount}, // retry
+		{IdempotencyKey: "item-2", Description: "Storage fee", Amount: other},    // collision
+	}
+	for i, pl := range signals {
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(SignalAddLineItem, pl)
+		}, time.Duration(i+1)*time.Millisecond)
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+		// arrives after close, in the same workflow task
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "late", Description: "Late", Amount: amount})
+	}, 10*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	assert.Equal(t, map[string]int64{
+		"line_items_accepted{GEL}":           2,
+		"line_items_rejected_duplicate{GEL}": 2,
+		"line_items_rejected_closed{GEL}":    1,
+		"bills_closed{GEL}":                  1,
+	}, filterCounters(metrics.counters, MetricLineItemsAccepted, MetricLineItemsRejectedDuplicate,
+		MetricLineItemsRejectedClosed, MetricBillsClosed))
+}
+
+// TestMonthlyFeeAccrualWorkflow_DrainItemsOnClose checks a line item delivered along with the close is added
+// before the close only when the bill drains them.
+func TestMonthlyFeeAccrualWorkflow_DrainItemsOnClose(t *testing.T) {
+	tests := []struct {
+		name          string
+		drain         bool
+		expectedItems []string
+		expectedTotal string
+	}{
+		{name: "draining adds the item", drain: true, expectedItems: []string{"early", "racing"}, expectedTotal: "20"},
+		{name: "default drops the item", drain: false, expectedItems: []string{"early"}, expectedTotal: "10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+			env.SetTestTimeout(10 * time.Second)
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil)
+			// only the bills serving a buffered close first leave line items behind it
+			env.OnGetVersion(changeIDCloseFirst, workflow.DefaultVersion, versionCloseInOrder).Return(workflow.Version(versionCloseFirst))
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:            domain.BillID("test-bill-drain"),
+				CustomerID:        "customer-drain",
+				Period:            domain.BillingPeriod("2025-06"),
+				PeriodYYYYMM:      202506,
+				Currency:          libmoney.CurrencyUSD,
+				DrainItemsOnClose: tt.drain,
+			}
+			amount := libmoney.NewFromInt(10, libmoney.CurrencyUSD)
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "early", Description: "Early", Amount: amount})
+			}, time.Millisecond)
+			env.RegisterDelayedCallback(func() {
+				// in quick succession, both are delivered in the same workflow task
+				env.SignalWorkflowSkippingWorkflowTask(SignalCloseBill, CloseBillSignal{})
+				env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "racing", Description: "Racing", Amount: amount})
+			}, 2*time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var bill domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&bill))
+			assert.Equal(t, domain.BillStatusClosed, bill.Status)
+			keys := make([]string, 0, len(bill.Items))
+			for _, li := range bill.Items {
+				keys = append(keys, li.IdempotencyKey)
+			}
+			assert.Equal(t, tt.expectedItems, keys)
+			assert.Equal(t, tt.expectedTotal, bill.Total.ToString())
+		})
+	}
+}
+
+// TestMonthlyFeeAccrualWorkflow_StrictCurrency checks a USD item is dropped from a GEL bill only in strict mode
+func TestMonthlyFeeAccrualWorkflow_StrictCurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		strict         bool
+		expectedItems  []string
+		expectedTotal  string
+		expectedReject int64
+	}{
+		{name: "strict mode rejects the USD item", strict: true, expectedItems: []string{"gel", "none"}, expectedTotal: "15", expectedReject: 1},
+		{name: "default relabels the USD item", strict: false, expectedItems: []string{"gel", "usd", "none"}, expectedTotal: "22"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := newCapturingMetricsHandler()
+			testSuite := &testsuite.WorkflowTestSuite{}
+			testSuite.SetMetricsHandler(metrics)
+			env := testSuite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+			env.SetTestTimeout(time.Minute)
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+				Return(nil)
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:         domain.BillID("test-bill-currency"),
+				CustomerID:     "customer-currency",
+				Period:         domain.BillingPeriod("2025-06"),
+				PeriodYYYYMM:   202506,
+				Currency:       libmoney.CurrencyGEL,
+				StrictCurrency: tt.strict,
+			}
+			gel, _ := libmoney.NewFromString("10", libmoney.CurrencyGEL)
+			usd, _ := libmoney.NewFromString("7", libmoney.CurrencyUSD)
+			// as parsed by the API, before the bill currency is known
+			none, _ := libmoney.NewFromString("5", libmoney.CurrencyNone)
+			signals := []AddLineItemPayload{
+				{IdempotencyKey: "gel", Description: "API usage fee", Amount: gel},
+				{IdempotencyKey: "usd", Description: "Storage fee", Amount: usd},
+				{IdempotencyKey: "none", Description: "Support fee", Amount: none},
+			}
+			for i, pl := range signals {
+				env.RegisterDelayedCallback(func() {
+					env.SignalWorkflow(SignalAddLineItem, pl)
+				}, time.Duration(i+1)*time.Millisecond)
+			}
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, 10*time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&result))
+			var keys []string
+			for _, li := range result.Items {
+				keys = append(keys, li.IdempotencyKey)
+				assert.Equal(t, libmoney.CurrencyGEL, li.Amount.Currency())
+			}
+			assert.Equal(t, tt.expectedItems, keys)
+			assert.Equal(t, tt.expectedTotal, result.Total.ToString())
+			assert.Equal(t, tt.expectedReject, metrics.counters["line_items_rejected_currency{GEL}"])
+		})
+	}
+}
+
+func TestMonthlyFeeAccrualWorkflow_MaxItems(t *testing.T) {
+	metrics := newCapturingMetricsHandler()
+	testSuite := &testsuite.WorkflowTestSuite{}
+	testSuite.SetMetricsHandler(metrics)
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.SetTestTimeout(time.Minute)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-max-items"),
+		CustomerID:   "customer-max-items",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		MaxItems:     2,
+	}
+	amount, _ := libmoney.NewFromString("10", libmoney.CurrencyUSD)
+	signals := []AddLineItemPayload{
+		{IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount},
+		{IdempotencyKey: "item-2", Description: "Storage fee", Amount: amount},
+		// at the cap: a retry is still a no-op, a new item is rejected
+		{IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount},
+		{IdempotencyKey: "item-3", Description: "Support fee", Amount: amount},
+	}
+	for i, pl := range signals {
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(SignalAddLineItem, pl)
+		}, time.Duration(i+1)*time.Millisecond)
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 10*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "20", result.Total.ToString())
+	assert.Equal(t, 2, result.MaxItems, "the bill tells its cap")
+	assert.Equal(t, map[string]int64{
+		"line_items_accepted{USD}":           2,
+		"line_items_rejected_duplicate{USD}": 1,
+		"line_items_rejected_limit{USD}":     1,
+	}, filterCounters(metrics.counters, MetricLineItemsAccepted, MetricLineItemsRejectedDuplicate,
+		MetricLineItemsRejectedLimit))
+}
+
+func TestMonthlyFeeAccrualWorkflow_Template(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(time.Minute)
+
+	template, err := app.BillTemplates{
+		"standard": {
+			{Key: "platform", Description: "Platform fee", AmountMinor: 1000},
+			{Key: "support", Description: "Support fee", AmountMinor: 550},
+		},
+	}.Resolve("standard", libmoney.CurrencyUSD)
+	require.NoError(t, err)
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-template"),
+		CustomerID:   "customer-template",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		Template:     template,
+	}
+	// the items are there before any signal is handled
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, res.Get(&dto))
+		require.Len(t, dto.Items, 2)
+		assert.Equal(t, "template:standard:platform", dto.Items[0].IdempotencyKey)
+		assert.Equal(t, "template:standard:support", dto.Items[1].IdempotencyKey)
+		assert.Equal(t, "15.5", dto.Total.ToString())
+		env.CancelWorkflow()
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+}
+
+// feeTemplate seeds a bill with a positive total, so closing it goes through the charge.
+func feeTemplate(c libmoney.Currency) []domain.LineItem {
+	return []domain.LineItem{{
+		IdempotencyKey: app.TemplateIdempotencyKey("test", "base"),
+		Description:    "Base fee",
+		Amount:         libmoney.FromMinorUnits(1000, c),
+	}}
+}
+
+// filterCounters drops the SDK own metrics
+func filterCounters(counters map[string]int64, names ...string) map[string]int64 {
+	out := map[string]int64{}
+	for k, v := range counters {
+		for _, n := range names {
+			if strings.HasPrefix(k, n+"{") {
+				out[k] = v
+			}
+		}
+	}
+
+	return out
+}
+
+func TestMonthlyFeeAccrualWorkflow_RefreshSearchAttributes(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-refresh"),
+		CustomerID:   "customer-refresh",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		AllowEmptyBills: true,
+	}
+
+	// the refresh re-upserts all mutable SAs at once, along with the period bounds
+	refreshed := false
+	env.OnUpsertTypedSearchAttributes(mock.MatchedBy(func(sas temporal.SearchAttributes) bool {
+		periodEnd, _ := sas.GetTime(sa.KeyBillPeriodEnd)
+		return sas.Size() == 6 && sas.ContainsKey(sa.KeyBillUpdatedAt) && sas.ContainsKey(sa.KeyBillPeriodStart) &&
+			periodEnd.Equal(time.Date(2025, 6, 30, 23, 59, 59, 999999999, time.UTC))
+	})).Run(func(mock.Arguments) { refreshed = true }).Return(nil).Once()
+	env.OnUpsertTypedSearchAttributes(mock.Anything).Return(nil)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalRefreshSearchAttributes, nil)
+	}, time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	assert.True(t, refreshed)
+}
+
+func TestMonthlyFeeAccrualWorkflow_UpdateLineItemDescription(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+	upserts := 0
+	env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(mock.Arguments) { upserts++ }).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-description"),
+		CustomerID:   "customer-description",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1",
+			Description:    "API usgae fee",
+			Amount:         amount,
+		})
+	}, time.Millisecond)
+
+	upsertsBefore := 0
+	env.RegisterDelayedCallback(func() {
+		upsertsBefore = upserts
+		env.SignalWorkflow(SignalUpdateLineItemDescription, UpdateLineItemDescriptionPayload{
+			IdempotencyKey: "item-1",
+			NewDescription: "API usage fee",
+		})
+		// an unknown key is discarded without failing the workflow
+		env.SignalWorkflow(SignalUpdateLineItemDescription, UpdateLineItemDescriptionPayload{
+			IdempotencyKey: "missing",
+			NewDescription: "whatever",
+		})
+	}, 2*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		// total and item count are unchanged, so no SA upsert is needed
+		assert.Positive(t, upsertsBefore)
+		assert.Equal(t, upsertsBefore, upserts)
+
+		res, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, res.Get(&dto))
+		require.Len(t, dto.Items, 1)
+		assert.Equal(t, "API usage fee", dto.Items[0].Description)
+		assert.Equal(t, "10", dto.Items[0].Amount.ToString())
+		assert.Equal(t, "10", dto.Total.ToString())
+		assert.True(t, dto.UpdatedAt.After(dto.Items[0].AddedAt))
+	}, 3*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 4*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
+
+func TestMonthlyFeeAccrualWorkflow_CorrectLineItemAmount(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+	upserts := 0
+	env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(mock.Arguments) { upserts++ }).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-correction"),
+		CustomerID:   "customer-correction",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+	}
+
+	ten, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	five, _ := libmoney.NewFromString("5.00", libmoney.CurrencyUSD)
+	corrected, _ := libmoney.NewFromString("7.50", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API fee", Amount: ten})
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-2", Description: "Storage", Amount: five})
+	}, time.Millisecond)
+
+	upsertsBefore := 0
+	env.RegisterDelayedCallback(func() {
+		upsertsBefore = upserts
+		env.SignalWorkflow(SignalCorrectLineItemAmount, CorrectLineItemAmountPayload{
+			IdempotencyKey: "item-1",
+			NewAmount:      corrected,
+		})
+		// a repeated correction and an unknown key are discarded without failing the workflow
+		env.SignalWorkflow(SignalCorrectLineItemAmount, CorrectLineItemAmountPayload{
+			IdempotencyKey: "item-1",
+			NewAmount:      corrected,
+		})
+		env.SignalWorkflow(SignalCorrectLineItemAmount, CorrectLineItemAmountPayload{
+			IdempotencyKey: "missing",
+			NewAmount:      corrected,
+		})
+	}, 2*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		// only the actual correction re-upserts the total
+		assert.Equal(t, upsertsBefore+1, upserts)
+
+		res, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, res.Get(&dto))
+		require.Len(t, dto.Items, 2)
+		assert.Equal(t, "item-1", dto.Items[0].IdempotencyKey, "the corrected item keeps its position")
+		assert.Equal(t, "7.5", dto.Items[0].Amount.ToString())
+		assert.Equal(t, "item-2", dto.Items[1].IdempotencyKey)
+		assert.Equal(t, "12.5", dto.Total.ToString())
+
+		res, err = env.QueryWorkflow(QueryChangeLog)
+		require.NoError(t, err)
+		var changes ChangeLogDTO
+		require.NoError(t, res.Get(&changes))
+		last := changes.Entries[len(changes.Entries)-1]
+		assert.Equal(t, views.BillChangeItemAmountCorrected, last.Type)
+		assert.Equal(t, "item-1", last.IdempotencyKey)
+		assert.Equal(t, "7.5", last.Amount.ToString())
+	}, 3*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 4*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
+
+func TestMonthlyFeeAccrualWorkflow_SetBillNote(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+	env.OnUpsertTypedSearchAttributes(mock.Anything).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-note"),
+		CustomerID:   "customer-note",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1",
+			Description:    "API usage fee",
+			Amount:         amount,
+		})
+	}, time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalSetBillNote, SetBillNotePayload{Note: "split the invoice"})
+		// too long, discarded without failing the workflow
+		env.SignalWorkflow(SignalSetBillNote, SetBillNotePayload{Note: strings.Repeat("a", domain.MaxNoteLength+1)})
+	}, 2*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		res, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, res.Get(&dto))
+		assert.Equal(t, "split the invoice", dto.Notes)
+		assert.Equal(t, "10", dto.Total.ToString())
+		assert.Equal(t, string(domain.BillStatusOpen), dto.Status)
+	}, 3*time.Millisecond)
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 4*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var bill domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&bill))
+	assert.Equal(t, "split the invoice", bill.Notes)
+	assert.Equal(t, domain.BillStatusClosed, bill.Status)
+}
+
+func TestMonthlyFeeAccrualWorkflow_ReconcileConsistentBill(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil)
+	upserts := 0
+	env.OnUpsertTypedSearchAttributes(mock.Anything).Run(func(mock.Arguments) { upserts++ }).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-reconcile"),
+		CustomerID:   "customer-reconcile",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+	}
+
+	amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "fee", Amount: amount})
+	}, time.Millisecond)
+	upsertsBefore := 0
+	env.RegisterDelayedCallback(func() {
+		upsertsBefore = upserts
+		env.SignalWorkflow(SignalReconcileBill, ReconcileBillSignal{})
+	}, 2*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		// no drift, nothing to re-upsert
+		assert.Equal(t, upsertsBefore, upserts)
+
+		res, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, res.Get(&dto))
+		assert.Equal(t, "10", dto.Total.ToString())
+	}, 3*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 4*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+}
+
+func TestMonthlyFeeAccrualWorkflow_RetryInvoicing(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(errors.New("payment provider unavailable")).Once()
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-manual-retry"),
+		CustomerID:   "customer-manual-retry",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		InvoiceRetry: app.RetryConfig{MaximumAttempts: 1},
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+	// the workflow stays alive in CHARGE_FAILED, waiting for a manual retry
+	env.RegisterDelayedCallback(func() {
+		v, err := env.QueryWorkflow(QueryState)
+		require.NoError(t, err)
+		var dto BillDTO
+		require.NoError(t, v.Get(&dto))
+		assert.Equal(t, string(domain.BillStatusChargeFailed), dto.Status)
+
+		env.SignalWorkflow(SignalRetryInvoicing, nil)
+	}, time.Hour)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, domain.BillStatusClosed, result.Status)
+	env.AssertExpectations(t)
+}
+
+func TestMonthlyFeeAccrualWorkflow_RetryInvoicingCap(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	attempts := 0
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, _ domain.Bill) error {
+			attempts++
+			return errors.New("payment provider unavailable")
+		})
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-retry-cap"),
+		CustomerID:   "customer-retry-cap",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		InvoiceRetry: app.RetryConfig{MaximumAttempts: 1},
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+	for i := 1; i <= maxManualInvoiceRetries+1; i++ {
+		env.RegisterDelayedCallback(func() {
+			env.SignalWorkflow(SignalRetryInvoicing, nil)
+		}, time.Duration(i)*time.Hour)
+	}
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	// the first run, then capped manual retries
+	assert.Equal(t, 1+maxManualInvoiceRetries, attempts)
+}
+
+func TestMonthlyFeeAccrualWorkflow_RetryInvoicingNonRetryable(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil)).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-non-retryable"),
+		CustomerID:   "customer-non-retryable",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	startedAt := env.Now()
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	// completes at once, no waiting for a manual retry
+	assert.Less(t, env.Now().Sub(startedAt), time.Hour)
+	env.AssertExpectations(t)
+}
+
+func TestMonthlyFeeAccrualWorkflow_InvoicingFailureStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		chargeErr  error
+		wantStatus domain.BillStatus
+	}{
+		{
+			name:       "retries exhausted",
+			chargeErr:  errors.New("payment provider unavailable"),
+			wantStatus: domain.BillStatusChargeFailed,
+		},
+		{
+			name:       "non-retryable business rule",
+			chargeErr:  temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil),
+			wantStatus: domain.BillStatusRejected,
+		},
+		{
+			name:       "non-retryable error type of the policy",
+			chargeErr:  temporal.NewApplicationError("bad invoice", "ValidationError"),
+			wantStatus: domain.BillStatusRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.AlertActivities{Alerter: activities.NoopAlerter{}})
+			env.SetTestTimeout(time.Minute)
+
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+				Return(tt.chargeErr)
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       domain.BillID("test-bill-failure-status"),
+				CustomerID:   "customer-failure-status",
+				Period:       domain.BillingPeriod("2025-06"),
+				PeriodYYYYMM: 202506,
+				Currency:     libmoney.CurrencyUSD,
+				InvoiceRetry: app.RetryConfig{MaximumAttempts: 1},
+
+				Template: feeTemplate(libmoney.CurrencyUSD),
+			}
+
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.Error(t, env.GetWorkflowError())
+			v, err := env.QueryWorkflow(QueryState)
+			require.NoError(t, err)
+			var dto BillDTO
+			require.NoError(t, v.Get(&dto))
+			assert.Equal(t, string(tt.wantStatus), dto.Status)
+		})
+	}
+}
+
+func TestMonthlyFeeAccrualWorkflow_AlertOnError(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil)).Once()
+	var alerts *activities.AlertActivities
+	env.OnActivity(alerts.NotifyBillErrorActivity, mock.Anything, "test-bill-alert",
+		mock.MatchedBy(func(reason string) bool { return strings.Contains(reason, "card declined") })).
+		Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-alert"),
+		CustomerID:   "customer-alert",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+	env.AssertActivityNumberOfCalls(t, "NotifyBillErrorActivity", 1)
+}
+
+func TestMonthlyFeeAccrualWorkflow_ErrorReasonMemo(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.AlertActivities{Alerter: activities.NoopAlerter{}})
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil)).Once()
+	env.OnUpsertMemo(mock.MatchedBy(func(memo map[string]any) bool {
+		reason, _ := memo[app.MemoKeyErrorReason].(string)
+		return strings.Contains(reason, "card declined")
+	})).Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-error-memo"),
+		CustomerID:   "customer-error-memo",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
+
+func TestMonthlyFeeAccrualWorkflow_AuditOnError(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil)).Once()
+	env.RegisterActivity(&activities.AlertActivities{Alerter: activities.NoopAlerter{}})
+	var audit *activities.AuditActivities
+	env.OnActivity(audit.PublishBillEventActivity, mock.Anything, mock.MatchedBy(func(e views.BillEvent) bool {
+		return e.Type == views.BillEventErrored && e.BillID == "test-bill-audit" && e.Status == "REJECTED"
+	})).Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-audit"),
+		CustomerID:   "customer-audit",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
+
+func TestMonthlyFeeAccrualWorkflow_AuditOnClose(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil).Once()
+	var audit *activities.AuditActivities
+	env.OnActivity(audit.PublishBillEventActivity, mock.Anything, mock.MatchedBy(func(e views.BillEvent) bool {
+		return e.Type == views.BillEventClosed && e.BillID == "test-bill-audit" && e.Status == "CLOSED" &&
+			e.ItemCount == 1
+	})).Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-audit"),
+		CustomerID:   "customer-audit",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	env.AssertExpectations(t)
+}
+
+func TestMonthlyFeeAccrualWorkflow_AlertFailureKeepsOutcome(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+
+	// Set workflow timeout to prevent hanging
+	env.SetTestTimeout(time.Minute)
+
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(temporal.NewNonRetryableApplicationError("card declined", "BusinessRuleError", nil)).Once()
+	var alerts *activities.AlertActivities
+	env.OnActivity(alerts.NotifyBillErrorActivity, mock.Anything, mock.Anything, mock.Anything).
+		Return(errors.New("on-call channel down"))
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-alert-down"),
+		CustomerID:   "customer-alert",
+		Period:       domain.BillingPeriod("2025-06"),
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.Error(t, env.GetWorkflowError())
+	assert.Contains(t, env.GetWorkflowError().Error(), "card declined")
+}
+
+func TestCorrelationIDFromMemo(t *testing.T) {
+	memoWorkflow := func(ctx workflow.Context) (string, error) {
+		return correlationIDFromMemo(ctx), nil
+	}
+
+	tests := []struct {
+		name     string
+		memo     map[string]interface{}
+		expected string
+	}{
+		{name: "memo set by the gateway", memo: map[string]interface{}{app.MemoKeyCorrelationID: "req-42"}, expected: "req-42"},
+		{name: "no memo", memo: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestWorkflowEnvironment()
+			env.RegisterWorkflow(memoWorkflow)
+			if tt.memo != nil {
+				require.NoError(t, env.SetMemoOnStart(tt.memo))
+			}
+
+			env.ExecuteWorkflow(memoWorkflow)
+
+			require.NoError(t, env.GetWorkflowError())
+			var got string
+			require.NoError(t, env.GetWorkflowResult(&got))
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestIsRetryableInvoiceFailure(t *testing.T) {
+	assert.True(t, isRetryableInvoiceFailure(errors.New("timeout"), app.RetryConfig{}))
+	assert.True(t, isRetryableInvoiceFailure(temporal.NewApplicationError("unavailable", "GatewayError"), app.RetryConfig{}))
+	assert.False(t, isRetryableInvoiceFailure(temporal.NewApplicationError("bad bill", "ValidationError"), app.RetryConfig{}))
+	assert.False(t, isRetryableInvoiceFailure(temporal.NewNonRetryableApplicationError("declined", "GatewayError", nil), app.RetryConfig{}))
+	assert.False(t, isRetryableInvoiceFailure(temporal.NewApplicationError("declined", "CardDeclined"),
+		app.RetryConfig{NonRetryableErrorTypes: []string{"CardDeclined"}}))
+}
+
+func TestInvoiceRetryPolicy(t *testing.T) {
+	p := invoiceRetryPolicy(app.RetryConfig{})
+	assert.Equal(t, time.Second, p.InitialInterval)
+	assert.Equal(t, int32(5), p.MaximumAttempts)
+	assert.Equal(t, 2.0, p.BackoffCoefficient)
+	assert.Equal(t, 30*time.Second, p.MaximumInterval)
+	assert.Equal(t, []string{"ValidationError", "BusinessRuleError"}, p.NonRetryableErrorTypes)
+
+	p = invoiceRetryPolicy(app.RetryConfig{
+		InitialInterval:        5 * time.Second,
+		MaximumAttempts:        10,
+		BackoffCoefficient:     1.5,
+		MaximumInterval:        time.Minute,
+		NonRetryableErrorTypes: []string{"CardDeclined"},
+	})
+	assert.Equal(t, 5*time.Second, p.InitialInterval)
+	assert.Equal(t, int32(10), p.MaximumAttempts)
+	assert.Equal(t, 1.5, p.BackoffCoefficient)
+	assert.Equal(t, time.Minute, p.MaximumInterval)
+	assert.Equal(t, []string{"CardDeclined"}, p.NonRetryableErrorTypes)
+}
+
+// TestBillToDTO tests the DTO conversion function
+func TestBillToDTO(t *testing.T) {
+	now := time.Now()
+	bill := domain.Bill{
+		ID:            domain.BillID("test-bill-dto"),
+		CustomerID:    "customer-dto",
+		Currency:      libmoney.CurrencyUSD,
+		BillingPeriod: domain.BillingPeriod("2025-08"),
+		Status:        domain.BillStatusOpen,
+		Items: []domain.LineItem{
+			{
+				IdempotencyKey: "dto-item-1",
+				Description:    "DTO test item 1",
+				Amount:         libmoney.NewFromFloat(10.50, libmoney.CurrencyUSD),
+				AddedAt:        now,
+			},
+			{
+				IdempotencyKey: "dto-item-2",
+				Description:    "DTO test item 2",
+				Amount:         libmoney.NewFromFloat(25.75, libmoney.CurrencyUSD),
+				AddedAt:        now.Add(time.Hour),
+			},
+		},
+		Total:       libmoney.NewFromFloat(36.25, libmoney.CurrencyUSD),
+		CreatedAt:   now,
+		UpdatedAt:   now.Add(2 * time.Hour),
+		FinalizedAt: &now,
+	}
+
+	dto := billToDTO(bill)
+
+	// Verify DTO fields
+	assert.Equal(t, string(bill.ID), dto.ID)
+	assert.Equal(t, bill.CustomerID, dto.CustomerID)
+	assert.Equal(t, bill.Currency, dto.Currency)
+	assert.Equal(t, string(bill.BillingPeriod), dto.BillingPeriod)
+	assert.Equal(t, string(bill.Status), dto.Status)
+	assert.Equal(t, bill.Total.ToString(), dto.Total.ToString())
+	assert.Equal(t, bill.CreatedAt, dto.CreatedAt)
+	assert.Equal(t, bill.UpdatedAt, dto.UpdatedAt)
+	assert.Equal(t, bill.FinalizedAt, dto.ClosedAt)
+
+	// Verify line items
+	assert.Equal(t, len(bill.Items), len(dto.Items))
+	for i, item := range bill.Items {
+		assert.Equal(t, item.IdempotencyKey, dto.Items[i].IdempotencyKey)
+		assert.Equal(t, item.Description, dto.Items[i].Description)
+		assert.Equal(t, item.Amount.ToString(), dto.Items[i].Amount.ToString())
+		assert.Equal(t, item.AddedAt, dto.Items[i].AddedAt)
+	}
+}
+
+// TestMoneyToCents tests the money conversion function
+func TestBillToDTO_ItemsOrder(t *testing.T) {
+	now := time.Now()
+	amount := libmoney.NewFromInt(1, libmoney.CurrencyUSD)
+	bill := domain.Bill{
+		ID:       domain.BillID("test-bill-order"),
+		Currency: libmoney.CurrencyUSD,
+		Items: []domain.LineItem{
+			{IdempotencyKey: "c", Amount: amount, AddedAt: now.Add(2 * time.Minute)},
+			{IdempotencyKey: "b", Amount: amount, AddedAt: now},
+			{IdempotencyKey: "d", Amount: amount, AddedAt: now.Add(time.Minute)},
+			{IdempotencyKey: "a", Amount: amount, AddedAt: now},
+		},
+	}
+
+	dto := billToDTO(bill)
+
+	keys := make([]string, 0, len(dto.Items))
+	for _, li := range dto.Items {
+		keys = append(keys, li.IdempotencyKey)
+	}
+	// by AddedAt, then by idempotency key on ties
+	assert.Equal(t, []string{"a", "b", "d", "c"}, keys)
+	// the bill itself is untouched
+	assert.Equal(t, "c", bill.Items[0].IdempotencyKey)
+}
+
+// TestWorkflowConstants tests that constants are properly defined
+// TestMonthlyFeeAccrualWorkflow_ItemsOrder checks the items signaled at once, sharing their AddedAt, are queried
+// in key order whatever their arrival order.
+func TestMonthlyFeeAccrualWorkflow_ItemsOrder(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.SetTestTimeout(10 * time.Second)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-order"),
+		CustomerID:   "customer-789",
+		Period:       domain.BillingPeriod("2025-03"),
+		PeriodYYYYMM: 202503,
+		Currency:     libmoney.CurrencyUSD,
+	}
+	var first, second BillDTO
+
+	env.RegisterDelayedCallback(func() {
+		for _, key := range []string{"item-c", "item-a", "item-b"} {
+			env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+				IdempotencyKey: key,
+				Description:    "fee",
+				Amount:         libmoney.NewFromInt(1, libmoney.CurrencyUSD),
+			})
+		}
+	}, 1*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		for _, dto := range []*BillDTO{&first, &second} {
+			v, err := env.QueryWorkflow(QueryState)
+			require.NoError(t, err)
+			require.NoError(t, v.Get(dto))
+		}
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, 2*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	keys := make([]string, 0, len(first.Items))
+	for _, li := range first.Items {
+		keys = append(keys, li.IdempotencyKey)
+	}
+	assert.Equal(t, []string{"item-a", "item-b", "item-c"}, keys)
+	assert.True(t, first.Items[0].AddedAt.Equal(first.Items[2].AddedAt))
+	assert.Equal(t, first.Items, second.Items)
+}
+
+func TestWorkflowConstants(t *testing.T) {
+	assert.Equal(t, "MonthlyFeeAccrualWorkflow", WorkflowTypeMonthlyBill)
+	assert.Equal(t, "SignalAddLineItem", SignalAddLineItem)
+	assert.Equal(t, "SignalCloseBill", SignalCloseBill)
+	assert.Equal(t, "CurrentBillState", QueryState)
+}
+
+func TestCreditNoteWorkflow(t *testing.T) {
+	bill := domain.Bill{
+		ID:            domain.MakeBillID("customer-123", "2025-01"),
+		CustomerID:    "customer-123",
+		BillingPeriod: "2025-01",
+		Currency:      libmoney.CurrencyUSD,
+		Status:        domain.BillStatusClosed,
+		Total:         libmoney.NewFromInt(40, libmoney.CurrencyUSD),
+	}
+	note, err := domain.NewCreditNote(bill, nil, "goodwill-1", libmoney.NewFromInt(15, libmoney.CurrencyNone), "outage",
+		time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.RegisterWorkflowWithOptions(CreditNoteWorkflow, workflow.RegisterOptions{Name: WorkflowTypeCreditNote})
+	require.NoError(t, env.SetMemoOnStart(map[string]interface{}{app.MemoKeyOriginalBillID: string(bill.ID)}))
+
+	env.ExecuteWorkflow(WorkflowTypeCreditNote, app.CreditNoteWorkflowParams{CreditNote: note})
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var got domain.CreditNote
+	require.NoError(t, env.GetWorkflowResult(&got))
+	assert.Equal(t, note.ID, got.ID)
+	assert.Equal(t, bill.ID, got.BillID)
+	assert.Equal(t, "-15.00", got.Total.ToFixedString())
+	assert.Equal(t, "outage", got.Reason)
+}
+
+// TestAddLineItemPayload tests the payload structure
+func TestAddLineItemPayload(t *testing.T) {
+	amount, _ := libmoney.NewFromString("99.99", libmoney.CurrencyUSD)
+	payload := AddLineItemPayload{
+		IdempotencyKey: "test-key",
+		Description:    "Test description",
+		Amount:         amount,
+	}
+
+	assert.Equal(t, "test-key", payload.IdempotencyKey)
+	assert.Equal(t, "Test description", payload.Description)
+	assert.Equal(t, amount.ToString(), payload.Amount.ToString())
+}
+
+// TestCloseBillSignal tests the close signal structure
 func TestCloseBillSignal(t *testing.T) {
 	signal := CloseBillSignal{}
 	// This is just a marker struct, so we just verify it can be instantiated
 	assert.NotNil(t, signal)
 }
+
+// TestReplay_PreAutoCloseHistory replays a bill recorded before auto-close (add item, close, invoiced),
+// the auto-close gate must keep it deterministic.
+func TestReplay_PreAutoCloseHistory(t *testing.T) {
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflowWithOptions(MonthlyFeeAccrualWorkflow, workflow.RegisterOptions{Name: WorkflowTypeMonthlyBill})
+
+	err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, "testdata/monthly_bill_pre_auto_close.json")
+
+	require.NoError(t, err)
+}
+
+// TestMonthlyFeeAccrualWorkflow_AutoClose checks the bill is closed and invoiced when its period ends
+func TestMonthlyFeeAccrualWorkflow_AutoClose(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	defer env.AssertExpectations(t)
+
+	env.SetStartTime(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-auto-close"),
+		CustomerID:   "customer-789",
+		Period:       domain.BillingPeriod("2025-03"),
+		PeriodYYYYMM: 202503,
+		Currency:     libmoney.CurrencyUSD,
+		AutoClose:    true,
+	}
+	amount, _ := libmoney.NewFromString("10", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+			IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount,
+		})
+	}, time.Hour)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, domain.BillStatusClosed, result.Status)
+	assert.Len(t, result.Items, 1)
+	require.NotNil(t, result.FinalizedAt)
+	assert.False(t, result.FinalizedAt.Before(libtime.PeriodEnd("2025-03")))
+}
+
+// TestMonthlyFeeAccrualWorkflow_AutoCloseCanceledByClose checks the timer doesn't outlive a closed bill
+func TestMonthlyFeeAccrualWorkflow_AutoCloseCanceledByClose(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	defer env.AssertExpectations(t)
+
+	start := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	env.SetStartTime(start)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-auto-close"),
+		CustomerID:   "customer-789",
+		Period:       domain.BillingPeriod("2025-03"),
+		PeriodYYYYMM: 202503,
+		Currency:     libmoney.CurrencyUSD,
+		AutoClose:    true,
+
+		Template: feeTemplate(libmoney.CurrencyUSD),
+	}
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, struct{}{})
+	}, time.Minute)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+	var result domain.Bill
+	require.NoError(t, env.GetWorkflowResult(&result))
+	assert.Equal(t, domain.BillStatusClosed, result.Status)
+	require.NotNil(t, result.FinalizedAt)
+	assert.True(t, result.FinalizedAt.Before(start.Add(time.Hour)))
+}
+
+// TestMonthlyFeeAccrualWorkflow_CloseEmptyBill checks the AllowEmptyBills policy on the close signal
+func TestMonthlyFeeAccrualWorkflow_CloseEmptyBill(t *testing.T) {
+	for _, allowEmpty := range []bool{true, false} {
+		t.Run(fmt.Sprintf("AllowEmptyBills=%t", allowEmpty), func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+			defer env.AssertExpectations(t)
+			env.SetTestTimeout(time.Minute)
+
+			var invoiced domain.Bill
+			if !allowEmpty {
+				env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { invoiced = args.Get(1).(domain.Bill) }).
+					Return(nil).Once()
+			}
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:          domain.BillID("test-bill-empty"),
+				CustomerID:      "customer-123",
+				Period:          domain.BillingPeriod("2025-01"),
+				PeriodYYYYMM:    202501,
+				Currency:        libmoney.CurrencyUSD,
+				AllowEmptyBills: allowEmpty,
+			}
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, time.Millisecond)
+			env.RegisterDelayedCallback(func() {
+				res, err := env.QueryWorkflow(QueryState)
+				require.NoError(t, err)
+				var dto BillDTO
+				require.NoError(t, res.Get(&dto))
+				if allowEmpty {
+					assert.Equal(t, string(domain.BillStatusClosed), dto.Status)
+
+					return
+				}
+				// refused, the bill stays open and takes items
+				assert.Equal(t, string(domain.BillStatusOpen), dto.Status)
+				assert.True(t, dto.ItemsRequired, "the query tells the policy")
+				amount, _ := libmoney.NewFromString("10", libmoney.CurrencyUSD)
+				env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+					IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount,
+				})
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, 2*time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, domain.BillStatusClosed, result.Status)
+			if allowEmpty {
+				// nothing to charge, closed without a payment attempt
+				assert.Empty(t, invoiced.ID)
+			} else {
+				assert.Len(t, invoiced.Items, 1)
+			}
+		})
+	}
+}
+
+// TestMonthlyFeeAccrualWorkflow_ArchiveInvoice checks the invoice is archived after the charge and its URI is kept
+func TestMonthlyFeeAccrualWorkflow_ArchiveInvoice(t *testing.T) {
+	const uri = "s3://invoices/customer-123/2025-01.json"
+	tests := []struct {
+		name       string
+		archiveErr error
+		wantURI    string
+	}{
+		{name: "archived", wantURI: uri},
+		{
+			name:       "archive failure keeps the bill closed",
+			archiveErr: temporal.NewNonRetryableApplicationError("bucket gone", "StorageError", nil),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			defer env.AssertExpectations(t)
+
+			charged := false
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+				Run(func(mock.Arguments) { charged = true }).
+				Return(nil).Once()
+			var archive *activities.ArchiveActivities
+			env.OnActivity(archive.ArchiveInvoiceActivity, mock.Anything, mock.Anything).
+				Run(func(mock.Arguments) { assert.True(t, charged, "archived after the charge") }).
+				Return(uri, tt.archiveErr).Once()
+			if tt.wantURI != "" {
+				env.OnUpsertMemo(map[string]any{app.MemoKeyInvoiceURI: uri}).Return(nil).Once()
+			}
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       domain.BillID("test-bill-archive"),
+				CustomerID:   "customer-123",
+				Period:       domain.BillingPeriod("2025-01"),
+				PeriodYYYYMM: 202501,
+				Currency:     libmoney.CurrencyUSD,
+			}
+			amount, _ := libmoney.NewFromString("10", libmoney.CurrencyUSD)
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{
+					IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount,
+				})
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, domain.BillStatusClosed, result.Status)
+			assert.Equal(t, tt.wantURI, result.InvoiceURI)
+
+			res, err := env.QueryWorkflow(QueryState)
+			require.NoError(t, err)
+			var dto BillDTO
+			require.NoError(t, res.Get(&dto))
+			assert.Equal(t, tt.wantURI, dto.InvoiceURI)
+		})
+	}
+}
+
+// TestMonthlyFeeAccrualWorkflow_CloseWithItemsInSameTask delivers two items and then a close in one workflow task,
+// the bills serving the signals in order keep both items, the close-first ones dropped the second.
+func TestMonthlyFeeAccrualWorkflow_CloseWithItemsInSameTask(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       workflow.Version
+		expectedItems []string
+	}{
+		{name: "in order", version: versionCloseInOrder, expectedItems: []string{"item-1", "item-2"}},
+		{name: "close first", version: versionCloseFirst, expectedItems: []string{"item-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var suite testsuite.WorkflowTestSuite
+			env := suite.NewTestWorkflowEnvironment()
+			env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+			env.OnGetVersion(changeIDCloseFirst, workflow.DefaultVersion, versionCloseInOrder).Return(tt.version)
+			var invoiced domain.Bill
+			env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) { invoiced = args.Get(1).(domain.Bill) }).
+				Return(nil).Once()
+
+			params := app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       domain.BillID("test-bill-same-task"),
+				CustomerID:   "customer-123",
+				Period:       domain.BillingPeriod("2025-01"),
+				PeriodYYYYMM: 202501,
+				Currency:     libmoney.CurrencyUSD,
+			}
+			amount, _ := libmoney.NewFromString("10", libmoney.CurrencyUSD)
+			// the signals are only buffered but the last one, which runs the workflow task with all of them
+			env.RegisterDelayedCallback(func() {
+				env.SignalWorkflowSkippingWorkflowTask(SignalAddLineItem, AddLineItemPayload{
+					IdempotencyKey: "item-1", Description: "API usage fee", Amount: amount,
+				})
+				env.SignalWorkflowSkippingWorkflowTask(SignalAddLineItem, AddLineItemPayload{
+					IdempotencyKey: "item-2", Description: "API usage fee", Amount: amount,
+				})
+				env.SignalWorkflow(SignalCloseBill, struct{}{})
+			}, time.Millisecond)
+
+			env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+			require.True(t, env.IsWorkflowCompleted())
+			require.NoError(t, env.GetWorkflowError())
+			var result domain.Bill
+			require.NoError(t, env.GetWorkflowResult(&result))
+			assert.Equal(t, domain.BillStatusClosed, result.Status)
+			keys := make([]string, 0, len(result.Items))
+			for _, li := range result.Items {
+				keys = append(keys, li.IdempotencyKey)
+			}
+			assert.Equal(t, tt.expectedItems, keys)
+			assert.Len(t, invoiced.Items, len(tt.expectedItems), "the invoiced bill has the same items")
+		})
+	}
+}
+
+func TestMonthlyFeeAccrualWorkflow_ChangeLog(t *testing.T) {
+	var suite testsuite.WorkflowTestSuite
+	env := suite.NewTestWorkflowEnvironment()
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.SetTestTimeout(time.Minute)
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil)
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-changelog"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	}
+	fee, _ := libmoney.NewFromString("10.50", libmoney.CurrencyUSD)
+	storage, _ := libmoney.NewFromString("2", libmoney.CurrencyUSD)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API usgae fee", Amount: fee})
+	}, time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-2", Description: "Storage", Amount: storage})
+		// a retried item isn't a change
+		env.SignalWorkflow(SignalAddLineItem, AddLineItemPayload{IdempotencyKey: "item-1", Description: "API usgae fee", Amount: fee})
+	}, 2*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalUpdateLineItemDescription, UpdateLineItemDescriptionPayload{
+			IdempotencyKey: "item-1", NewDescription: "API usage fee",
+		})
+	}, 3*time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(SignalCloseBill, CloseBillSignal{})
+	}, 4*time.Millisecond)
+
+	env.ExecuteWorkflow(MonthlyFeeAccrualWorkflow, params)
+
+	require.True(t, env.IsWorkflowCompleted())
+	require.NoError(t, env.GetWorkflowError())
+
+	res, err := env.QueryWorkflow(QueryChangeLog)
+	require.NoError(t, err)
+	var changes ChangeLogDTO
+	require.NoError(t, res.Get(&changes))
+	assert.Zero(t, changes.Dropped)
+
+	type change struct {
+		Type                 views.BillChangeType
+		Key, Description     string
+		FromStatus, ToStatus string
+	}
+	got := make([]change, 0, len(changes.Entries))
+	for i, e := range changes.Entries {
+		got = append(got, change{e.Type, e.IdempotencyKey, e.Description, e.FromStatus, e.ToStatus})
+		if i > 0 {
+			assert.False(t, e.At.Before(changes.Entries[i-1].At), "entry %d is older than the previous one", i)
+		}
+	}
+	assert.Equal(t, []change{
+		{Type: views.BillChangeStatusChanged, ToStatus: "OPEN"},
+		{Type: views.BillChangeItemAdded, Key: "item-1", Description: "API usgae fee"},
+		{Type: views.BillChangeItemAdded, Key: "item-2", Description: "Storage"},
+		{Type: views.BillChangeItemDescriptionUpdated, Key: "item-1", Description: "API usage fee"},
+		{Type: views.BillChangeStatusChanged, FromStatus: "OPEN", ToStatus: "PENDING"},
+		{Type: views.BillChangeStatusChanged, FromStatus: "PENDING", ToStatus: "CLOSED"},
+	}, got)
+	assert.Equal(t, "10.5", changes.Entries[1].Amount.ToString())
+}
+
+func TestChangeLog_DropsOldest(t *testing.T) {
+	var l changeLog
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range maxChangeLogEntries + 2 {
+		l.itemAdded(domain.LineItem{IdempotencyKey: fmt.Sprintf("item-%d", i), AddedAt: at})
+	}
+
+	dto := l.toDTO()
+	assert.Len(t, dto.Entries, maxChangeLogEntries)
+	assert.Equal(t, 2, dto.Dropped)
+	assert.Equal(t, "item-2", dto.Entries[0].IdempotencyKey)
+	assert.Equal(t, fmt.Sprintf("item-%d", maxChangeLogEntries+1), dto.Entries[len(dto.Entries)-1].IdempotencyKey)
+
+	// a status logged again isn't a change
+	l.statusChanged(domain.Bill{Status: domain.BillStatusOpen}, at)
+	l.statusChanged(domain.Bill{Status: domain.BillStatusOpen}, at)
+	assert.Equal(t, 3, l.toDTO().Dropped)
+}
+
+// TestAddLineItemPayload_TagsInHistory checks the tags survive the data converter, as the signal is in history,
+// and the signals recorded before the tags decode without them.
+func TestAddLineItemPayload_TagsInHistory(t *testing.T) {
+	dc := converter.GetDefaultDataConverter()
+	payload := AddLineItemPayload{
+		IdempotencyKey: "test-key",
+		Description:    "Test description",
+		Amount:         libmoney.NewFromInt(10, libmoney.CurrencyUSD),
+		Tags:           map[string]string{"costCenter": "R&D", "sku": "API-100"},
+	}
+
+	p, err := dc.ToPayload(payload)
+	require.NoError(t, err)
+	var decoded AddLineItemPayload
+	require.NoError(t, dc.FromPayload(p, &decoded))
+	assert.Equal(t, payload.Tags, decoded.Tags)
+
+	old, err := dc.ToPayload(map[string]any{"IdempotencyKey": "test-key", "Description": "Test description"})
+	require.NoError(t, err)
+	var decodedOld AddLineItemPayload
+	require.NoError(t, dc.FromPayload(old, &decodedOld))
+	assert.Nil(t, decodedOld.Tags)
+}
@@ -3,10 +3,15 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 type BillStatus string
@@ -17,28 +22,85 @@ const (
 	BillStatusPending BillStatus = "PENDING"
 	BillStatusClosed  BillStatus = "CLOSED"
 	BillStatusError   BillStatus = "ERROR"
+	// BillStatusWrittenOff is a final zero-charge state, the total was below the minimum charge and wasn't invoiced.
+	BillStatusWrittenOff BillStatus = "WRITTEN_OFF"
+	// BillStatusChargeFailed is an invoicing failure after all its retries, a manual invoicing retry may succeed.
+	BillStatusChargeFailed BillStatus = "CHARGE_FAILED"
+	// BillStatusRejected is a final invoicing failure, a business rule refused it and a retry won't change that.
+	BillStatusRejected BillStatus = "REJECTED"
 )
 
 var allowed = map[BillStatus]map[BillStatus]bool{
-	BillStatusOpen:    {BillStatusPending: true, BillStatusError: true},
-	BillStatusPending: {BillStatusClosed: true, BillStatusError: true},
-	BillStatusClosed:  {}, // manual copy on restart
-	BillStatusUnknown: {BillStatusError: true},
-	BillStatusError:   {BillStatusError: true},
+	BillStatusOpen: {BillStatusPending: true, BillStatusError: true},
+	BillStatusPending: {
+		BillStatusClosed: true, BillStatusError: true, BillStatusWrittenOff: true,
+		BillStatusChargeFailed: true, BillStatusRejected: true,
+	},
+	BillStatusClosed:     {}, // manual copy on restart
+	BillStatusWrittenOff: {},
+	BillStatusUnknown:    {BillStatusError: true},
+	// Pending for a manual invoicing retry, of the bills that failed invoicing before ChargeFailed and Rejected
+	BillStatusError:        {BillStatusError: true, BillStatusPending: true},
+	BillStatusChargeFailed: {BillStatusPending: true}, // a manual invoicing retry
+	BillStatusRejected:     {},
+}
+
+// requireInvoicingRetryable guards a manual invoicing retry, the workflow stops accepting them at some point.
+func requireInvoicingRetryable(b *Bill) error {
+	if !b.InvoicingRetryable {
+		return ErrInvoicingNotRetryable
+	}
+
+	return nil
+}
+
+// transitionGuards always apply to the transition, on top of the caller's guards.
+var transitionGuards = map[BillStatus]map[BillStatus]func(*Bill) error{
+	BillStatusError:        {BillStatusPending: requireInvoicingRetryable},
+	BillStatusChargeFailed: {BillStatusPending: requireInvoicingRetryable},
 }
 
 var (
-	ErrInvalidTransition   = errors.New("invalid status transition")
-	ErrGuardFailed         = errors.New("status guard failed")
-	ErrEmptyIdempotencyKey = errors.New("empty idempotency key")
-	ErrBillNotOpen         = errors.New("bill not open")
+	ErrInvalidTransition   = NewError(CodeFailedPrecondition, "invalid status transition")
+	ErrGuardFailed         = NewError(CodeFailedPrecondition, "status guard failed")
+	ErrEmptyIdempotencyKey = NewError(CodeInvalid, "empty idempotency key")
+	ErrBillNotOpen         = NewError(CodeFailedPrecondition, "bill not open")
+	// ErrInvoicingNotRetryable is returned on Error -> Pending when the invoicing failure was not retryable.
+	ErrInvoicingNotRetryable = NewError(CodeFailedPrecondition, "invoicing failure is not retryable")
+	// ErrLineItemAlreadyAdded is a key collision: the key exists with a different description or amount.
+	ErrLineItemAlreadyAdded = NewError(CodeConflict,
+		"line item with this idempotency key already added with a different payload")
+	ErrLineItemNotFound = NewError(CodeNotFound, "line item not found")
+	ErrCurrencyMismatch = NewError(CodeInvalid, "line item currency differs from the bill currency")
+	ErrNegativeTotal    = NewError(CodeFailedPrecondition, "line item would make the bill total negative")
+	ErrNoteTooLong      = NewError(CodeInvalid, fmt.Sprintf("bill note is longer than %d characters", MaxNoteLength))
+	ErrBillEmpty        = NewError(CodeFailedPrecondition, "bill has no line items")
 )
 
+// RequireItems is a Pending guard for the policy that forbids closing a bill without line items.
+func RequireItems(b *Bill) error {
+	if len(b.Items) == 0 {
+		return ErrBillEmpty
+	}
+
+	return nil
+}
+
+// IsFull tells the bill reached its MaxItems, a new line item would be rejected.
+func (b *Bill) IsFull() bool {
+	return b.MaxItems > 0 && len(b.Items) >= b.MaxItems
+}
+
+// MaxNoteLength caps Bill.Notes, in characters.
+const MaxNoteLength = 4096
+
 type LineItem struct {
 	IdempotencyKey string
 	Description    string
 	Amount         libmoney.Money
 	AddedAt        time.Time
+	// Tags are optional key-values for downstream reporting, e.g. a cost center or a SKU.
+	Tags map[string]string
 }
 
 type BillingPeriod string
@@ -54,6 +116,20 @@ type Bill struct {
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	FinalizedAt   *time.Time
+	// InvoicingRetryable is set along with the Error status when a manual invoicing retry may succeed.
+	InvoicingRetryable bool
+	// MaxItems is the line item cap of the bill, set by its workflow, zero is no cap.
+	MaxItems int
+	// ItemsRequired is the close policy of the bill, set by its workflow: a close without line items is refused,
+	// see RequireItems.
+	ItemsRequired bool
+	// Notes is a free-text internal note of the account managers, it never affects the total or the status.
+	Notes string
+	// InvoiceURI is where the final invoice is archived, set once the bill is charged.
+	InvoiceURI string
+	// PeriodStart and PeriodEnd are the first and the last instant of BillingPeriod (UTC), set by the builder.
+	PeriodStart time.Time
+	PeriodEnd   time.Time
 }
 
 func (b *Bill) Transition(to BillStatus, guards ...func(*Bill) error) error {
@@ -64,6 +140,9 @@ func (b *Bill) Transition(to BillStatus, guards ...func(*Bill) error) error {
 	if !selfTransition && !allowed[b.Status][to] {
 		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, b.Status, to)
 	}
+	if g := transitionGuards[b.Status][to]; g != nil && !selfTransition {
+		guards = append([]func(*Bill) error{g}, guards...)
+	}
 	for _, g := range guards {
 		if err := g(b); err != nil {
 			return fmt.Errorf("%w: %w", ErrGuardFailed, err)
@@ -74,17 +153,235 @@ func (b *Bill) Transition(to BillStatus, guards ...func(*Bill) error) error {
 	return nil
 }
 
-func (b *Bill) AddItem(idempotencyKey string, description string, amount libmoney.Money, updatedAt time.Time) error {
+// AddItemResult tells what AddItem did with an item it didn't reject, zero along with an error.
+type AddItemResult int
+
+const (
+	// ItemAdded is a new item, appended to the bill.
+	ItemAdded AddItemResult = iota + 1
+	// ItemDuplicateIgnored is a retry of an added item (same idempotency key), the bill is unchanged.
+	ItemDuplicateIgnored
+)
+
+func (r AddItemResult) String() string {
+	switch r {
+	case ItemAdded:
+		return "added"
+	case ItemDuplicateIgnored:
+		return "duplicate_ignored"
+	default:
+		return "unknown"
+	}
+}
+
+// AddItem appends the item to an open bill, an item with an added key is ignored whatever its payload.
+// The key must pass ValidateIdempotencyKey.
+func (b *Bill) AddItem(
+	idempotencyKey string,
+	description string,
+	amount libmoney.Money,
+	updatedAt time.Time,
+) (AddItemResult, error) {
+	if err := ValidateIdempotencyKey(idempotencyKey); err != nil {
+		return 0, err
+	}
+	if b.Status != BillStatusOpen {
+		return 0, ErrBillNotOpen
+	}
+
+	return b.appendItem(idempotencyKey, description, amount, nil, updatedAt), nil
+}
+
+// AddItemStrict is AddItem telling a genuine retry (same key, same payload: ItemDuplicateIgnored) from a key
+// collision (same key, different description or amount: ErrLineItemAlreadyAdded).
+func (b *Bill) AddItemStrict(
+	idempotencyKey string,
+	description string,
+	amount libmoney.Money,
+	updatedAt time.Time,
+) (AddItemResult, error) {
+	return b.AddTaggedItemStrict(idempotencyKey, description, amount, nil, updatedAt)
+}
+
+// AddTaggedItemStrict is AddItemStrict for an item with tags, a retry with other tags is a key collision too.
+// The tags are copied, nil and empty tags are the same. Only an empty key is rejected, the workflow replays the
+// signals of running bills through it, keys added before ValidateIdempotencyKey included.
+func (b *Bill) AddTaggedItemStrict(
+	idempotencyKey string,
+	description string,
+	amount libmoney.Money,
+	tags map[string]string,
+	updatedAt time.Time,
+) (AddItemResult, error) {
 	if idempotencyKey == "" {
+		return 0, ErrEmptyIdempotencyKey
+	}
+	if b.Status != BillStatusOpen {
+		return 0, ErrBillNotOpen
+	}
+	for _, li := range b.Items {
+		if li.IdempotencyKey != idempotencyKey {
+			continue
+		}
+		if li.Description != description || !li.Amount.Equal(amount) || !maps.Equal(li.Tags, tags) {
+			return 0, ErrLineItemAlreadyAdded.Detailf("%s", idempotencyKey)
+		}
+
+		return ItemDuplicateIgnored, nil
+	}
+
+	return b.appendItem(idempotencyKey, description, amount, tags, updatedAt), nil
+}
+
+// SortLineItems sorts the items the way they're shown: by AddedAt, then by IdempotencyKey, as the items added
+// in one workflow task share their AddedAt. Bill.Items stay in arrival order, but that order isn't kept by
+// snapshots and reconciliation, so the clients get this one.
+func SortLineItems(items []LineItem) {
+	slices.SortStableFunc(items, func(a, b LineItem) int {
+		if c := a.AddedAt.Compare(b.AddedAt); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.IdempotencyKey, b.IdempotencyKey)
+	})
+}
+
+// HasItem reports whether a line item with the idempotency key was added.
+func (b *Bill) HasItem(idempotencyKey string) bool {
+	for _, li := range b.Items {
+		if li.IdempotencyKey == idempotencyKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckCurrency returns ErrCurrencyMismatch for an amount in another currency than the bill's.
+// An amount without currency (libmoney.CurrencyNone) is fine, it takes the bill's one when added.
+func (b *Bill) CheckCurrency(amount libmoney.Money) error {
+	c := amount.Currency()
+	if c == "" || c == libmoney.CurrencyNone || c == b.Currency {
+		return nil
+	}
+
+	return ErrCurrencyMismatch.Detailf("%s item, %s bill", c, b.Currency)
+}
+
+// CheckCredit returns ErrNegativeTotal for a credit (negative amount) larger than the bill total,
+// a credit down to exactly zero is fine.
+func (b *Bill) CheckCredit(amount libmoney.Money) error {
+	if !amount.IsNegative() {
+		return nil
+	}
+	if _, err := b.Total.SubNonNegative(amount.Abs()); err != nil {
+		return fmt.Errorf("%w: %w", ErrNegativeTotal, err)
+	}
+
+	return nil
+}
+
+// TaxIdempotencyKey is deterministic per jurisdiction, so a replayed tax computation is never applied twice.
+func TaxIdempotencyKey(jurisdiction string) string {
+	return "tax:" + jurisdiction
+}
+
+// AddTaxItem appends the tax line item at close time, it's the only item allowed while the bill is Pending.
+func (b *Bill) AddTaxItem(jurisdiction string, amount libmoney.Money, updatedAt time.Time) error {
+	if jurisdiction == "" {
 		return ErrEmptyIdempotencyKey
 	}
+	if b.Status != BillStatusOpen && b.Status != BillStatusPending {
+		return ErrBillNotOpen
+	}
+	// a replayed tax computation is a duplicate, the tax is applied once
+	b.appendItem(TaxIdempotencyKey(jurisdiction), "Tax ("+jurisdiction+")", amount, nil, updatedAt)
+
+	return nil
+}
+
+// SetNote replaces the note of an open bill, an empty note clears it.
+func (b *Bill) SetNote(note string, now time.Time) error {
 	if b.Status != BillStatusOpen {
 		return ErrBillNotOpen
 	}
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return ErrNoteTooLong
+	}
+	b.Notes = note
+	b.UpdatedAt = now
+
+	return nil
+}
+
+// UpdateItemDescription corrects the description of an open bill's item, the amount and total stay untouched.
+func (b *Bill) UpdateItemDescription(idempotencyKey string, description string, now time.Time) error {
+	if idempotencyKey == "" {
+		return ErrEmptyIdempotencyKey
+	}
+	if b.Status != BillStatusOpen {
+		return ErrBillNotOpen
+	}
+	for i := range b.Items {
+		if b.Items[i].IdempotencyKey != idempotencyKey {
+			continue
+		}
+		b.Items[i].Description = description
+		b.UpdatedAt = now
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrLineItemNotFound, idempotencyKey)
+}
+
+// CorrectItemAmount replaces the amount of an open bill's item in place, keeping its key and position, and
+// recomputes Total. Correcting to the current amount is a no-op, so a repeated correction changes nothing.
+// Like a credit, a correction can bring the total down to zero but not below.
+func (b *Bill) CorrectItemAmount(idempotencyKey string, amount libmoney.Money, now time.Time) error {
+	if idempotencyKey == "" {
+		return ErrEmptyIdempotencyKey
+	}
+	if b.Status != BillStatusOpen {
+		return ErrBillNotOpen
+	}
+	if err := b.CheckCurrency(amount); err != nil {
+		return err
+	}
+	for i := range b.Items {
+		if b.Items[i].IdempotencyKey != idempotencyKey {
+			continue
+		}
+		amount = libmoney.NewResetCurrency(amount, b.Currency)
+		if b.Items[i].Amount.Equal(amount) {
+			return nil
+		}
+		total := b.Total.Sub(b.Items[i].Amount)
+		total = total.Add(amount)
+		if total.IsNegative() {
+			return ErrNegativeTotal.Detailf("%s", idempotencyKey)
+		}
+		b.Items[i].Amount = amount
+		b.Total = total
+		b.UpdatedAt = now
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrLineItemNotFound, idempotencyKey)
+}
+
+func (b *Bill) appendItem(
+	idempotencyKey string,
+	description string,
+	amount libmoney.Money,
+	tags map[string]string,
+	updatedAt time.Time,
+) AddItemResult {
 	for _, li := range b.Items {
 		if li.IdempotencyKey == idempotencyKey {
 			// just skip it, idempotency on the house.
-			return nil
+			return ItemDuplicateIgnored
 		}
 	}
 	amountMoney := libmoney.NewResetCurrency(amount, b.Currency)
@@ -94,22 +391,20 @@ func (b *Bill) AddItem(idempotencyKey string, description string, amount libmone
 		Amount:         amountMoney,
 		AddedAt:        updatedAt,
 	}
+	if len(tags) > 0 {
+		li.Tags = maps.Clone(tags)
+	}
 
 	b.Items = append(b.Items, li)
 	b.Total = b.Total.Add(li.Amount)
 	b.UpdatedAt = updatedAt
 
-	return nil
+	return ItemAdded
 }
 
-func (b *Bill) Pending(now time.Time) error {
-	err := b.Transition(BillStatusPending, func(_ *Bill) error {
-		// example of guard:
-		// if len(b.Items) == 0 {
-		//	return fmt.Errorf("cannot close empty bill")
-		// }
-		return nil
-	})
+// Pending moves the bill to invoicing, the guards are policies on top of the allowed transitions, e.g. RequireItems.
+func (b *Bill) Pending(now time.Time, guards ...func(*Bill) error) error {
+	err := b.Transition(BillStatusPending, guards...)
 	if err != nil {
 		return err
 	}
@@ -129,6 +424,28 @@ func (b *Bill) Close(closedAt time.Time) error {
 	return nil
 }
 
+// BelowMinimumCharge tells if the total is below minChargeMinor, in minor units of the bill currency.
+// Zero or negative minChargeMinor disables the minimum.
+func (b *Bill) BelowMinimumCharge(minChargeMinor int64) bool {
+	if minChargeMinor <= 0 {
+		return false
+	}
+
+	return b.Total.LessThan(libmoney.FromMinorUnits(minChargeMinor, b.Currency))
+}
+
+// WriteOff finalizes a Pending bill without charging it, e.g. when it's below the minimum charge.
+func (b *Bill) WriteOff(writtenOffAt time.Time) error {
+	err := b.Transition(BillStatusWrittenOff)
+	if err != nil {
+		return err
+	}
+	b.UpdatedAt = writtenOffAt
+	b.FinalizedAt = &writtenOffAt
+
+	return nil
+}
+
 func (b *Bill) Error(closedAt time.Time) error {
 	if !b.IsActive() { // includes in ERROR state
 		return nil
@@ -143,6 +460,48 @@ func (b *Bill) Error(closedAt time.Time) error {
 	return nil
 }
 
+// FailInvoicing moves a Pending bill into ChargeFailed when a manual invoicing retry may succeed (retryable), e.g.
+// the charge retries were exhausted, otherwise into Rejected.
+func (b *Bill) FailInvoicing(failedAt time.Time, retryable bool) error {
+	status := BillStatusRejected
+	if retryable {
+		status = BillStatusChargeFailed
+	}
+
+	return b.failInvoicing(failedAt, status, retryable)
+}
+
+// FailInvoicingAsError is FailInvoicing of the bills started before ChargeFailed and Rejected, any invoicing
+// failure moves them into Error.
+func (b *Bill) FailInvoicingAsError(failedAt time.Time, retryable bool) error {
+	return b.failInvoicing(failedAt, BillStatusError, retryable)
+}
+
+func (b *Bill) failInvoicing(failedAt time.Time, status BillStatus, retryable bool) error {
+	err := b.Transition(status)
+	if err != nil {
+		return err
+	}
+	b.UpdatedAt = failedAt
+	b.FinalizedAt = &failedAt
+	b.InvoicingRetryable = retryable
+
+	return nil
+}
+
+// RetryInvoicing moves a ChargeFailed (or Error) bill back to Pending, only if the invoicing failure was retryable.
+func (b *Bill) RetryInvoicing(now time.Time) error {
+	err := b.Transition(BillStatusPending)
+	if err != nil {
+		return err
+	}
+	b.UpdatedAt = now
+	b.FinalizedAt = nil
+	b.InvoicingRetryable = false
+
+	return nil
+}
+
 // IsActive Only Open means active and allows to add LineItems.
 func (b *Bill) IsActive() bool {
 	return b.Status == BillStatusOpen
@@ -152,6 +511,40 @@ func (b *Bill) IsReadyForInvoicing() bool {
 	return b.Status == BillStatusPending
 }
 
+// IsPeriodOver tells whether now is past the last instant of the billing period (UTC).
+// A malformed period counts as over.
+func (b *Bill) IsPeriodOver(now time.Time) bool {
+	return now.After(libtime.PeriodEnd(string(b.BillingPeriod)))
+}
+
+// DaysUntilPeriodEnd counts the UTC calendar days left in the billing period, today included,
+// e.g. 31 on January 1st and 1 on January 31st. It's 0 once the period is over, and the period length before it starts.
+func (b *Bill) DaysUntilPeriodEnd(now time.Time) int {
+	if b.IsPeriodOver(now) {
+		return 0
+	}
+	start, end, _ := libtime.PeriodBounds(string(b.BillingPeriod))
+	now = now.UTC()
+	if now.Before(start) {
+		now = start
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	lastDay := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(lastDay.Sub(today).Hours()/24) + 1 //nolint:mnd
+}
+
+// Reconcile repairs Total from the items if it drifted, it reports whether there was a drift.
+func (b *Bill) Reconcile() bool {
+	total := b.RecalcTotal()
+	if b.Total.Equal(total) {
+		return false
+	}
+	b.Total = total
+
+	return true
+}
+
 func (b *Bill) RecalcTotal() libmoney.Money {
 	sum := libmoney.NewFromInt(0, b.Currency)
 	for _, li := range b.Items {
@@ -260,6 +653,10 @@ func (b *BillBuilder) Build() (Bill, error) {
 	if b.createdAt == nil {
 		return Bill{}, errors.New("createdAt is required")
 	}
+	periodStart, periodEnd, err := libtime.PeriodBounds(string(b.period))
+	if err != nil {
+		return Bill{}, err
+	}
 
 	total, err := libmoney.NewFromString("0", b.currency)
 	if err != nil {
@@ -274,6 +671,8 @@ func (b *BillBuilder) Build() (Bill, error) {
 		CustomerID:    b.customerID,
 		Currency:      b.currency,
 		BillingPeriod: b.period,
+		PeriodStart:   periodStart,
+		PeriodEnd:     periodEnd,
 		Status:        b.status,
 		Items:         append([]LineItem(nil), b.items...), // copy for safety
 		Total:         total,                               // libmoney.Money{Amount: b.totalSum, Currency: b.currency},
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+var (
+	ErrCreditNoteBillNotClosed     = NewError(CodeFailedPrecondition, "a credit note can only offset a closed bill")
+	ErrCreditNoteAmountNotPositive = NewError(CodeInvalid, "credit note amount must be positive")
+	ErrCreditNoteExceedsBill       = NewError(CodeInvalid, "credit note amount exceeds the bill total")
+	ErrCreditNoteReasonRequired    = NewError(CodeInvalid, "a reason is required for a credit note")
+)
+
+type CreditNoteID string
+
+// MakeCreditNoteID is deterministic per bill and idempotency key, so a retried request can't credit twice.
+func MakeCreditNoteID(billID BillID, idempotencyKey string) CreditNoteID {
+	return CreditNoteID(fmt.Sprintf("credit/%s/%s", billID, idempotencyKey))
+}
+
+// CreditNote gives money back on a closed bill. The bill is terminal, so the credit lives apart and links back to it.
+type CreditNote struct {
+	ID            CreditNoteID
+	BillID        BillID
+	CustomerID    string
+	BillingPeriod BillingPeriod
+	Currency      libmoney.Currency
+	// Total is negative, it's the amount credited back to the customer.
+	Total     libmoney.Money
+	Reason    string
+	CreatedAt time.Time
+}
+
+// NewCreditNote credits amount (positive) back on a closed bill, the notes already credited on it included
+// at most the bill total.
+func NewCreditNote(
+	bill Bill,
+	credited []CreditNote,
+	idempotencyKey string,
+	amount libmoney.Money,
+	reason string,
+	now time.Time,
+) (CreditNote, error) {
+	if idempotencyKey == "" {
+		return CreditNote{}, ErrEmptyIdempotencyKey
+	}
+	if reason == "" {
+		return CreditNote{}, ErrCreditNoteReasonRequired
+	}
+	if bill.Status != BillStatusClosed {
+		return CreditNote{}, ErrCreditNoteBillNotClosed.Detailf("bill is %s", bill.Status)
+	}
+	if !amount.IsPositive() {
+		return CreditNote{}, ErrCreditNoteAmountNotPositive
+	}
+	if err := amount.CheckPrecision(bill.Currency); err != nil {
+		return CreditNote{}, err
+	}
+	credit := libmoney.NewResetCurrency(amount, bill.Currency)
+	// the totals of the notes are negative
+	already := libmoney.NewFromInt(0, bill.Currency)
+	for _, n := range credited {
+		already = already.Sub(n.Total)
+	}
+	if _, err := bill.Total.SubNonNegative(already, credit); err != nil {
+		exceeds := ErrCreditNoteExceedsBill
+		if len(credited) > 0 {
+			exceeds = exceeds.Detailf("%s already credited", already.ToFixedString())
+		}
+
+		return CreditNote{}, fmt.Errorf("%w: %w", exceeds, err)
+	}
+
+	return CreditNote{
+		ID:            MakeCreditNoteID(bill.ID, idempotencyKey),
+		BillID:        bill.ID,
+		CustomerID:    bill.CustomerID,
+		BillingPeriod: bill.BillingPeriod,
+		Currency:      bill.Currency,
+		Total:         credit.Neg(),
+		Reason:        reason,
+		CreatedAt:     now,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+func TestMakeCreditNoteID(t *testing.T) {
+	got := MakeCreditNoteID(MakeBillID("cust-123", "2025-01"), "goodwill-1")
+	if want := CreditNoteID("credit/bill/cust-123/2025-01/goodwill-1"); got != want {
+		t.Errorf("MakeCreditNoteID() = %q, want %q", got, want)
+	}
+}
+
+func TestNewCreditNote(t *testing.T) {
+	now := time.Date(2025, 2, 3, 10, 0, 0, 0, time.UTC)
+	closedBill := func() Bill {
+		return Bill{
+			ID:            MakeBillID("cust-123", "2025-01"),
+			CustomerID:    "cust-123",
+			BillingPeriod: "2025-01",
+			Currency:      libmoney.CurrencyUSD,
+			Status:        BillStatusClosed,
+			Total:         libmoney.NewFromInt(100, libmoney.CurrencyUSD),
+		}
+	}
+
+	t.Run("credit on a closed bill", func(t *testing.T) {
+		note, err := NewCreditNote(closedBill(), nil, "goodwill-1", libmoney.NewFromFloat(25.5, libmoney.CurrencyNone), "late delivery", now)
+		if err != nil {
+			t.Fatalf("NewCreditNote failed: %v", err)
+		}
+		if note.ID != "credit/bill/cust-123/2025-01/goodwill-1" || note.BillID != "bill/cust-123/2025-01" {
+			t.Errorf("Unexpected IDs %q, %q", note.ID, note.BillID)
+		}
+		if note.Total.ToFixedString() != "-25.50" || note.Total.Currency() != libmoney.CurrencyUSD {
+			t.Errorf("Expected total -25.50 USD, got %s %s", note.Total.ToFixedString(), note.Total.Currency())
+		}
+		if note.CustomerID != "cust-123" || note.BillingPeriod != "2025-01" || note.Reason != "late delivery" ||
+			!note.CreatedAt.Equal(now) {
+			t.Errorf("Unexpected credit note %+v", note)
+		}
+	})
+
+	t.Run("credit of the whole bill", func(t *testing.T) {
+		note, err := NewCreditNote(closedBill(), nil, "full", libmoney.NewFromInt(100, libmoney.CurrencyNone), "refund", now)
+		if err != nil {
+			t.Fatalf("NewCreditNote failed: %v", err)
+		}
+		if note.Total.ToFixedString() != "-100.00" {
+			t.Errorf("Expected total -100.00, got %s", note.Total.ToFixedString())
+		}
+	})
+
+	t.Run("credit notes together over the bill total", func(t *testing.T) {
+		first, err := NewCreditNote(closedBill(), nil, "goodwill-1", libmoney.NewFromInt(60, libmoney.CurrencyNone),
+			"outage", now)
+		if err != nil {
+			t.Fatalf("NewCreditNote failed: %v", err)
+		}
+
+		_, err = NewCreditNote(closedBill(), []CreditNote{first}, "goodwill-2",
+			libmoney.NewFromInt(50, libmoney.CurrencyNone), "outage", now)
+		if !errors.Is(err, ErrCreditNoteExceedsBill) {
+			t.Fatalf("NewCreditNote() error = %v, want %v", err, ErrCreditNoteExceedsBill)
+		}
+		if !strings.Contains(err.Error(), "60.00 already credited") {
+			t.Errorf("Expected the credited total in %q", err)
+		}
+
+		rest, err := NewCreditNote(closedBill(), []CreditNote{first}, "goodwill-2",
+			libmoney.NewFromInt(40, libmoney.CurrencyNone), "outage", now)
+		if err != nil {
+			t.Fatalf("NewCreditNote of the rest failed: %v", err)
+		}
+		if rest.Total.ToFixedString() != "-40.00" {
+			t.Errorf("Expected total -40.00, got %s", rest.Total.ToFixedString())
+		}
+	})
+
+	tests := []struct {
+		name   string
+		bill   func() Bill
+		key    string
+		amount libmoney.Money
+		reason string
+		want   error
+	}{
+		{
+			name: "open bill",
+			bill: func() Bill {
+				b := closedBill()
+				b.Status = BillStatusOpen
+				return b
+			},
+			key: "k", amount: libmoney.NewFromInt(1, libmoney.CurrencyNone), reason: "r", want: ErrCreditNoteBillNotClosed,
+		},
+		{
+			name: "written off bill",
+			bill: func() Bill {
+				b := closedBill()
+				b.Status = BillStatusWrittenOff
+				return b
+			},
+			key: "k", amount: libmoney.NewFromInt(1, libmoney.CurrencyNone), reason: "r", want: ErrCreditNoteBillNotClosed,
+		},
+		{
+			name: "more than the bill total", bill: closedBill,
+			key: "k", amount: libmoney.NewFromFloat(100.01, libmoney.CurrencyNone), reason: "r", want: ErrCreditNoteExceedsBill,
+		},
+		{
+			name: "zero amount", bill: closedBill,
+			key: "k", amount: libmoney.NewFromInt(0, libmoney.CurrencyNone), reason: "r", want: ErrCreditNoteAmountNotPositive,
+		},
+		{
+			name: "negative amount", bill: closedBill,
+			key: "k", amount: libmoney.NewFromInt(-5, libmoney.CurrencyNone), reason: "r", want: ErrCreditNoteAmountNotPositive,
+		},
+		{
+			name: "finer than the currency", bill: closedBill,
+			key: "k", amount: libmoney.NewFromFloat(1.005, libmoney.CurrencyNone), reason: "r", want: libmoney.ErrPrecisionExceeded,
+		},
+		{
+			name: "no idempotency key", bill: closedBill,
+			amount: libmoney.NewFromInt(1, libmoney.CurrencyNone), reason: "r", want: ErrEmptyIdempotencyKey,
+		},
+		{
+			name: "no reason", bill: closedBill,
+			key: "k", amount: libmoney.NewFromInt(1, libmoney.CurrencyNone), want: ErrCreditNoteReasonRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCreditNote(tt.bill(), nil, tt.key, tt.amount, tt.reason, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("NewCreditNote() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
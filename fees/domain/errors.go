@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies a DomainError, the API maps it to the HTTP status once instead of per error.
+type ErrorCode string
+
+const (
+	CodeNotFound           ErrorCode = "not_found"
+	CodeConflict           ErrorCode = "conflict"
+	CodeFailedPrecondition ErrorCode = "failed_precondition"
+	CodeInvalid            ErrorCode = "invalid"
+	// CodeUnavailable is a transient failure, the same call may succeed later.
+	CodeUnavailable ErrorCode = "unavailable"
+)
+
+// DomainError is an error with a code. The sentinels are *DomainError values, so errors.Is keeps matching them by
+// identity, a detailed error made with Detailf matches its sentinel too.
+type DomainError struct {
+	Code ErrorCode
+	Msg  string
+	// sentinel is the error Detailf was called on.
+	sentinel *DomainError
+}
+
+// NewError returns a sentinel DomainError.
+func NewError(code ErrorCode, msg string) *DomainError {
+	return &DomainError{Code: code, Msg: msg}
+}
+
+func (e *DomainError) Error() string {
+	return e.Msg
+}
+
+func (e *DomainError) Unwrap() error {
+	if e.sentinel == nil {
+		return nil
+	}
+
+	return e.sentinel
+}
+
+// Detailf returns e with the details appended to its message, it has the code of e and errors.Is(err, e) holds.
+func (e *DomainError) Detailf(format string, args ...any) *DomainError {
+	return &DomainError{Code: e.Code, Msg: e.Msg + ": " + fmt.Sprintf(format, args...), sentinel: e}
+}
+
+// AsDomainError returns the first DomainError in the chain of err.
+func AsDomainError(err error) (*DomainError, bool) {
+	var de *DomainError
+	if errors.As(err, &de) {
+		return de, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDomainError_Is(t *testing.T) {
+	detailed := ErrNegativeTotal.Detailf("%s", "k1")
+	if !errors.Is(detailed, ErrNegativeTotal) {
+		t.Errorf("errors.Is(%v, ErrNegativeTotal) = false", detailed)
+	}
+	if errors.Is(detailed, ErrBillEmpty) {
+		t.Errorf("errors.Is(%v, ErrBillEmpty) = true, same code is not the same error", detailed)
+	}
+	if got, want := detailed.Error(), "line item would make the bill total negative: k1"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if errors.Is(ErrNegativeTotal, detailed) {
+		t.Error("the sentinel must not match a detailed error")
+	}
+
+	wrapped := fmt.Errorf("add item: %w", detailed)
+	if !errors.Is(wrapped, ErrNegativeTotal) {
+		t.Errorf("errors.Is(%v, ErrNegativeTotal) = false", wrapped)
+	}
+}
+
+func TestAsDomainError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode ErrorCode
+		wantMsg  string
+		wantOK   bool
+	}{
+		{name: "sentinel", err: ErrLineItemNotFound, wantCode: CodeNotFound, wantMsg: "line item not found", wantOK: true},
+		{
+			name:     "wrapped",
+			err:      fmt.Errorf("close: %w", ErrBillEmpty),
+			wantCode: CodeFailedPrecondition,
+			wantMsg:  "bill has no line items",
+			wantOK:   true,
+		},
+		{
+			name:     "detailed keeps the details",
+			err:      fmt.Errorf("create: %w", ErrBillingPeriodOutOfRange.Detailf("2099-01")),
+			wantCode: CodeInvalid,
+			wantMsg:  "billing period is out of the allowed range: 2099-01",
+			wantOK:   true,
+		},
+		{
+			name:     "the outermost wins",
+			err:      fmt.Errorf("%w: %w", ErrCreditNoteExceedsBill, ErrNegativeTotal),
+			wantCode: CodeInvalid,
+			wantMsg:  "credit note amount exceeds the bill total",
+			wantOK:   true,
+		},
+		{name: "plain error", err: errors.New("boom")},
+		{name: "nil", err: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			de, ok := AsDomainError(tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("AsDomainError(%v) ok = %v, want %v", tt.err, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if de.Code != tt.wantCode || de.Error() != tt.wantMsg {
+				t.Errorf("AsDomainError(%v) = %s %q, want %s %q", tt.err, de.Code, de.Error(), tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxIdempotencyKeyLength caps a line item idempotency key, in bytes, like the payment providers do.
+const MaxIdempotencyKeyLength = 255
+
+// ReservedIdempotencyKeyPrefixes are the prefixes of the keys the service derives itself, e.g. TaxIdempotencyKey,
+// a client key with one of them could collide with an item the service adds.
+var ReservedIdempotencyKeyPrefixes = []string{"system:", "tax:", "template:"}
+
+var (
+	ErrInvalidIdempotencyKey = NewError(CodeInvalid, fmt.Sprintf(
+		"idempotency key must be letters, digits and - _ . : / only, up to %d characters", MaxIdempotencyKeyLength))
+	ErrReservedIdempotencyKey = NewError(CodeInvalid, "idempotency key prefix is reserved for internal use")
+)
+
+// ValidateIdempotencyKey checks the format of a line item key: not empty, at most MaxIdempotencyKeyLength and
+// only ASCII letters, digits and - _ . : / (UUIDs and prefixed keys like "tax:US-CA" fit).
+func ValidateIdempotencyKey(key string) error {
+	if key == "" {
+		return ErrEmptyIdempotencyKey
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return ErrInvalidIdempotencyKey.Detailf("%d characters", len(key))
+	}
+	for i, r := range key {
+		if !isIdempotencyKeyRune(r) {
+			return ErrInvalidIdempotencyKey.Detailf("%q at %d", r, i)
+		}
+	}
+
+	return nil
+}
+
+// ValidateExternalIdempotencyKey is ValidateIdempotencyKey for the keys of the API callers, which can't use
+// the reserved prefixes.
+func ValidateExternalIdempotencyKey(key string) error {
+	if err := ValidateIdempotencyKey(key); err != nil {
+		return err
+	}
+	for _, p := range ReservedIdempotencyKeyPrefixes {
+		if strings.HasPrefix(strings.ToLower(key), p) {
+			return ErrReservedIdempotencyKey.Detailf("%s", p)
+		}
+	}
+
+	return nil
+}
+
+func isIdempotencyKeyRune(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return true
+	case r == '-', r == '_', r == '.', r == ':', r == '/':
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "simple", key: "li-1"},
+		{name: "uuid", key: "0b7e4a9c-3f1d-4c2a-9e8b-5d6f7a8b9c0d"},
+		{name: "all the allowed punctuation", key: "Order_42.fee:2025/01-a"},
+		{name: "max length", key: strings.Repeat("k", MaxIdempotencyKeyLength)},
+		{name: "tax key", key: TaxIdempotencyKey("US-CA")},
+		{name: "empty", key: "", wantErr: ErrEmptyIdempotencyKey},
+		{name: "too long", key: strings.Repeat("k", MaxIdempotencyKeyLength+1), wantErr: ErrInvalidIdempotencyKey},
+		{name: "space", key: "li 1", wantErr: ErrInvalidIdempotencyKey},
+		{name: "newline", key: "li-1\n", wantErr: ErrInvalidIdempotencyKey},
+		{name: "non-ascii", key: "счёт-1", wantErr: ErrInvalidIdempotencyKey},
+		{name: "quote", key: `li"1`, wantErr: ErrInvalidIdempotencyKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIdempotencyKey(tt.key)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateIdempotencyKey(%q) = %v, want nil", tt.key, err)
+				}
+
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateIdempotencyKey(%q) = %v, want %v", tt.key, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateIdempotencyKey_Detail(t *testing.T) {
+	err := ValidateIdempotencyKey("ab cd")
+	if got, want := err.Error(), ErrInvalidIdempotencyKey.Error()+`: ' ' at 2`; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateExternalIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{name: "client key", key: "li-1"},
+		{name: "a client prefix", key: "order:42"},
+		{name: "reserved word not as a prefix", key: "li-tax:1"},
+		{name: "system prefix", key: "system:reconcile", wantErr: ErrReservedIdempotencyKey},
+		{name: "tax prefix", key: TaxIdempotencyKey("US-CA"), wantErr: ErrReservedIdempotencyKey},
+		{name: "template prefix", key: "template:basic:platform", wantErr: ErrReservedIdempotencyKey},
+		{name: "prefix in another case", key: "System:1", wantErr: ErrReservedIdempotencyKey},
+		{name: "format comes first", key: "system: 1", wantErr: ErrInvalidIdempotencyKey},
+		{name: "empty", key: "", wantErr: ErrEmptyIdempotencyKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExternalIdempotencyKey(tt.key)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("ValidateExternalIdempotencyKey(%q) = %v, want nil", tt.key, err)
+				}
+
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateExternalIdempotencyKey(%q) = %v, want %v", tt.key, err, tt.wantErr)
+			}
+			if de, ok := AsDomainError(err); !ok || de.Code != CodeInvalid {
+				t.Errorf("ValidateExternalIdempotencyKey(%q) = %v, want a %s DomainError", tt.key, err, CodeInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrBillingPeriodOutOfRange = NewError(CodeInvalid, "billing period is out of the allowed range")
+	// ErrInvalidPeriodRange is a reversed search range, it would silently match no bills.
+	ErrInvalidPeriodRange = NewError(CodeInvalid, "from must be <= to")
+)
+
+// BillingPeriodWindow limits which periods a bill can be created for, relative to the current month.
+type BillingPeriodWindow struct {
+	MonthsAhead int
+	MonthsBack  int
+}
+
+// DefaultBillingPeriodWindow allows the next month and the last two years.
+var DefaultBillingPeriodWindow = BillingPeriodWindow{MonthsAhead: 1, MonthsBack: 24}
+
+// CurrentBillingPeriod is the period of the month of now (UTC).
+func CurrentBillingPeriod(now time.Time) BillingPeriod {
+	return BillingPeriod(now.UTC().Format("2006-01"))
+}
+
+// Next is the period of the following month, December rolls over to January of the next year.
+func (p BillingPeriod) Next() (BillingPeriod, error) {
+	t, err := time.Parse("2006-01", string(p))
+	if err != nil {
+		return "", fmt.Errorf("billing period must be YYYY-MM, got %s: %w", p, err)
+	}
+
+	return BillingPeriod(t.AddDate(0, 1, 0).Format("2006-01")), nil
+}
+
+// ValidateBillingPeriod checks p against DefaultBillingPeriodWindow.
+func ValidateBillingPeriod(p BillingPeriod, now time.Time) error {
+	return DefaultBillingPeriodWindow.Validate(p, now)
+}
+
+// Validate rejects malformed periods and periods outside the window around the month of now (UTC).
+func (w BillingPeriodWindow) Validate(p BillingPeriod, now time.Time) error {
+	if !reYYYYMM.MatchString(string(p)) {
+		return fmt.Errorf("billing period must be YYYY-MM, got %s", p)
+	}
+	t, err := time.Parse("2006-01", string(p))
+	if err != nil {
+		return fmt.Errorf("billing period must be YYYY-MM, got %s: %w", p, err)
+	}
+
+	current := time.Date(now.UTC().Year(), now.UTC().Month(), 1, 0, 0, 0, 0, time.UTC)
+	earliest := current.AddDate(0, -w.MonthsBack, 0)
+	latest := current.AddDate(0, w.MonthsAhead, 0)
+	if t.Before(earliest) || t.After(latest) {
+		return ErrBillingPeriodOutOfRange.Detailf("%s is not within %s..%s",
+			p, earliest.Format("2006-01"), latest.Format("2006-01"))
+	}
+
+	return nil
+}
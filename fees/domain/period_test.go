@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateBillingPeriod(t *testing.T) {
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		period  BillingPeriod
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "current month", period: "2025-03"},
+		{name: "next month is allowed", period: "2025-04"},
+		{name: "two months ahead", period: "2025-05", wantErr: ErrBillingPeriodOutOfRange},
+		{name: "far future", period: "2099-01", wantErr: ErrBillingPeriodOutOfRange},
+		{name: "24 months back is allowed", period: "2023-03"},
+		{name: "25 months back", period: "2023-02", wantErr: ErrBillingPeriodOutOfRange},
+		{name: "malformed", period: "2025-3", anyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBillingPeriod(tt.period, now)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("ValidateBillingPeriod(%s) = %v, want %v", tt.period, err, tt.wantErr)
+				}
+			case tt.anyErr:
+				if err == nil || errors.Is(err, ErrBillingPeriodOutOfRange) {
+					t.Errorf("ValidateBillingPeriod(%s) = %v, want a format error", tt.period, err)
+				}
+			default:
+				if err != nil {
+					t.Errorf("ValidateBillingPeriod(%s) unexpected error: %v", tt.period, err)
+				}
+			}
+		})
+	}
+}
+
+func TestBillingPeriodWindow_Validate(t *testing.T) {
+	// month boundaries are computed in UTC, 23:30 on Dec 31 in UTC-5 is already January.
+	now := time.Date(2024, 12, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	w := BillingPeriodWindow{MonthsAhead: 0, MonthsBack: 1}
+
+	if err := w.Validate("2025-01", now); err != nil {
+		t.Errorf("Validate(2025-01) unexpected error: %v", err)
+	}
+	if err := w.Validate("2024-12", now); err != nil {
+		t.Errorf("Validate(2024-12) unexpected error: %v", err)
+	}
+	if err := w.Validate("2025-02", now); !errors.Is(err, ErrBillingPeriodOutOfRange) {
+		t.Errorf("Validate(2025-02) = %v, want %v", err, ErrBillingPeriodOutOfRange)
+	}
+	if err := w.Validate("2024-11", now); !errors.Is(err, ErrBillingPeriodOutOfRange) {
+		t.Errorf("Validate(2024-11) = %v, want %v", err, ErrBillingPeriodOutOfRange)
+	}
+}
+
+func TestBillingPeriod_Next(t *testing.T) {
+	tests := []struct {
+		period  BillingPeriod
+		want    BillingPeriod
+		wantErr bool
+	}{
+		{period: "2025-05", want: "2025-06"},
+		{period: "2024-12", want: "2025-01"},
+		{period: "2024-01", want: "2024-02"},
+		{period: "2025-13", wantErr: true},
+		{period: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.period), func(t *testing.T) {
+			got, err := tt.period.Next()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Next(%q) = %s, want an error", tt.period, got)
+				}
+
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Next(%q) = %s, %v, want %s", tt.period, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentBillingPeriod(t *testing.T) {
+	// in UTC, 23:30 on Dec 31 in UTC-5 is already January
+	now := time.Date(2024, 12, 31, 23, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	if got := CurrentBillingPeriod(now); got != "2025-01" {
+		t.Errorf("CurrentBillingPeriod() = %s, want 2025-01", got)
+	}
+}
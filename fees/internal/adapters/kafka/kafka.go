@@ -0,0 +1,53 @@
+// Package kafka holds the app.Kafka audit publishers. A real Kafka producer goes here too, behind the same port.
+package kafka
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+// InMemory keeps published events, for tests and local runs.
+type InMemory struct {
+	mu     sync.Mutex
+	events []views.BillEvent
+}
+
+func NewInMemory() *InMemory {
+	return &InMemory{}
+}
+
+func (k *InMemory) PublishBillEvent(_ context.Context, event views.BillEvent) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.events = append(k.events, event)
+
+	return nil
+}
+
+// Events returns a copy of the published events, in publish order.
+func (k *InMemory) Events() []views.BillEvent {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return append([]views.BillEvent(nil), k.events...)
+}
+
+// LogPublisher writes events to the structured log, a stand-in until the Kafka producer is configured.
+type LogPublisher struct{}
+
+func (LogPublisher) PublishBillEvent(ctx context.Context, event views.BillEvent) error {
+	slog.InfoContext(ctx, "bill audit event",
+		"type", event.Type,
+		"bill_id", event.BillID,
+		"status", event.Status,
+		"item_count", event.ItemCount,
+		"total_minor", event.Total.Minor,
+		"currency", event.Total.Currency,
+		"correlation_id", event.CorrelationID,
+	)
+
+	return nil
+}
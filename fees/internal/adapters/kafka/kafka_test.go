@@ -0,0 +1,26 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+func TestInMemory(t *testing.T) {
+	k := NewInMemory()
+	require.NoError(t, k.PublishBillEvent(context.Background(), views.BillEvent{Type: views.BillEventCreated}))
+	require.NoError(t, k.PublishBillEvent(context.Background(), views.BillEvent{Type: views.BillEventClosed}))
+
+	events := k.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, views.BillEventCreated, events[0].Type)
+	assert.Equal(t, views.BillEventClosed, events[1].Type)
+
+	// a copy, the caller can't change the published events
+	events[0].Type = views.BillEventErrored
+	assert.Equal(t, views.BillEventCreated, k.Events()[0].Type)
+}
@@ -2,34 +2,50 @@ package activities
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/shopspring/decimal"
 	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
 
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
 )
 
-// ProcessInvoiceAndChargeActivity handles the finalization and external charging steps.
-// Should send to payment gateway: total amount.
-func ProcessInvoiceAndChargeActivity(ctx context.Context, bill domain.Bill) error {
+// taxRates is a demo tax table, jurisdiction -> rate. In real app it comes from a tax provider (External API call).
+var taxRates = map[string]decimal.Decimal{
+	"GE":    decimal.RequireFromString("0.18"),
+	"US-CA": decimal.RequireFromString("0.0725"),
+	"US-NY": decimal.RequireFromString("0.04"),
+	"US-DE": decimal.Zero,
+}
+
+// IsTaxJurisdiction tells whether CalculateTaxActivity knows the rate of the jurisdiction, so a bill with
+// an unknown one is refused at creation instead of failing its close.
+func IsTaxJurisdiction(jurisdiction string) bool {
+	_, ok := taxRates[jurisdiction]
+
+	return ok
+}
+
+// CalculateTaxActivity computes the tax for the bill total in the given jurisdiction, rounded to cents.
+// Unknown jurisdiction is a ValidationError, which is non-retryable in the invoicing retry policy.
+func CalculateTaxActivity(ctx context.Context, bill domain.Bill, jurisdiction string) (libmoney.Money, error) {
 	log := activity.GetLogger(ctx)
 
-	log.Info("processing invoice",
+	rate, ok := taxRates[jurisdiction]
+	if !ok {
+		return libmoney.Money{}, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown tax jurisdiction %q", jurisdiction), "ValidationError", nil)
+	}
+	tax := bill.Total.MulOnDecimal(rate).Round(2) //nolint:mnd
+
+	log.Info("calculated tax",
 		"bill_id", bill.ID,
-		"customer_id", bill.CustomerID,
-		"period", bill.BillingPeriod,
-		"status", bill.Status,
+		"jurisdiction", jurisdiction,
 		"total", bill.Total.ToString(),
-		"items", len(bill.Items),
+		"tax", tax.ToString(),
 	)
 
-	// 1. Generate Invoice (External API call). Use idempotency keys to payment gateways because activities are retried.
-	// 2. Submit charge to payment gateway (External API call)
-	// 3. Final persistence/state change (Database update)
-	// 4. Error typing to leverage NonRetryableErrorTypes.
-	// 5. Apply tracing spans for external calls in the activity.
-
-	// The Activity input (state) indicates the total amount] and all line items being charged.
-	// Any failure here will result in the Activity being retried by Temporal.
-
-	return nil
+	return *tax, nil
 }
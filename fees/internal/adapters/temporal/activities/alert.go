@@ -0,0 +1,28 @@
+package activities
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+)
+
+// AlertActivities notifies operators through the Alerter, register it as a struct so the Alerter is injected.
+type AlertActivities struct {
+	Alerter app.Alerter
+}
+
+// NotifyBillErrorActivity reports a bill whose invoicing failed (CHARGE_FAILED, REJECTED or ERROR).
+func (a *AlertActivities) NotifyBillErrorActivity(ctx context.Context, billID, reason string) error {
+	activity.GetLogger(ctx).Warn("notifying bill error", "bill_id", billID, "reason", reason)
+
+	return a.Alerter.NotifyBillError(ctx, billID, reason)
+}
+
+// NoopAlerter is the default Alerter until an on-call channel is wired in.
+type NoopAlerter struct{}
+
+func (NoopAlerter) NotifyBillError(_ context.Context, _, _ string) error {
+	return nil
+}
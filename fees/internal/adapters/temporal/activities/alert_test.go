@@ -0,0 +1,59 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+)
+
+// MockAlerter implements app.Alerter for testing
+type MockAlerter struct {
+	mock.Mock
+}
+
+func (m *MockAlerter) NotifyBillError(ctx context.Context, billID, reason string) error {
+	args := m.Called(ctx, billID, reason)
+	return args.Error(0)
+}
+
+func TestNotifyBillErrorActivity(t *testing.T) {
+	tests := []struct {
+		name      string
+		alertErr  error
+		expectErr bool
+	}{
+		{name: "delivered", alertErr: nil},
+		{name: "alerter failure is returned for retry", alertErr: errors.New("channel down"), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerter := &MockAlerter{}
+			alerter.On("NotifyBillError", mock.Anything, "bill/customer-123/2025-01", "card declined").
+				Return(tt.alertErr).Once()
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(&AlertActivities{Alerter: alerter})
+
+			var alerts *AlertActivities
+			_, err := env.ExecuteActivity(alerts.NotifyBillErrorActivity, "bill/customer-123/2025-01", "card declined")
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "channel down")
+			} else {
+				require.NoError(t, err)
+			}
+			alerter.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNoopAlerter(t *testing.T) {
+	assert.NoError(t, NoopAlerter{}.NotifyBillError(context.Background(), "bill/customer-123/2025-01", "reason"))
+}
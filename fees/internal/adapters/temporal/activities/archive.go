@@ -0,0 +1,31 @@
+package activities
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// ArchiveActivities stores the final invoices through the InvoiceArchiver, register it as a struct so the
+// InvoiceArchiver is injected.
+type ArchiveActivities struct {
+	Archiver app.InvoiceArchiver
+}
+
+// ArchiveInvoiceActivity archives the invoice of a charged bill and returns its storage URI.
+func (a *ArchiveActivities) ArchiveInvoiceActivity(ctx context.Context, bill domain.Bill) (string, error) {
+	activity.GetLogger(ctx).Info("archiving invoice", "bill_id", bill.ID)
+
+	return a.Archiver.ArchiveInvoice(ctx, bill)
+}
+
+// NoopArchiver is the default InvoiceArchiver until an object storage is wired in, nothing is stored
+// and the URI is empty.
+type NoopArchiver struct{}
+
+func (NoopArchiver) ArchiveInvoice(_ context.Context, _ domain.Bill) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,68 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// MockArchiver implements app.InvoiceArchiver for testing
+type MockArchiver struct {
+	mock.Mock
+}
+
+func (m *MockArchiver) ArchiveInvoice(ctx context.Context, bill domain.Bill) (string, error) {
+	args := m.Called(ctx, bill)
+	return args.String(0), args.Error(1)
+}
+
+func TestArchiveInvoiceActivity(t *testing.T) {
+	bill := domain.Bill{ID: "bill/customer-123/2025-01", CustomerID: "customer-123", Status: domain.BillStatusPending}
+	tests := []struct {
+		name       string
+		uri        string
+		archiveErr error
+	}{
+		{name: "archived", uri: "s3://invoices/customer-123/2025-01.json"},
+		{name: "archiver failure is returned for retry", archiveErr: errors.New("bucket unavailable")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archiver := &MockArchiver{}
+			archiver.On("ArchiveInvoice", mock.Anything, mock.MatchedBy(func(b domain.Bill) bool {
+				return b.ID == bill.ID
+			})).Return(tt.uri, tt.archiveErr).Once()
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(&ArchiveActivities{Archiver: archiver})
+
+			var archive *ArchiveActivities
+			val, err := env.ExecuteActivity(archive.ArchiveInvoiceActivity, bill)
+			if tt.archiveErr != nil {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "bucket unavailable")
+			} else {
+				require.NoError(t, err)
+				var uri string
+				require.NoError(t, val.Get(&uri))
+				assert.Equal(t, tt.uri, uri)
+			}
+			archiver.AssertExpectations(t)
+		})
+	}
+}
+
+func TestNoopArchiver(t *testing.T) {
+	uri, err := NoopArchiver{}.ArchiveInvoice(context.Background(), domain.Bill{})
+	assert.NoError(t, err)
+	assert.Empty(t, uri)
+}
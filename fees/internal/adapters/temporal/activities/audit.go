@@ -0,0 +1,17 @@
+package activities
+
+import (
+	"context"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+// AuditActivities publishes the audit events of workflow-originated changes, e.g. an invoicing failure.
+type AuditActivities struct {
+	Kafka app.Kafka
+}
+
+func (a *AuditActivities) PublishBillEventActivity(ctx context.Context, event views.BillEvent) error {
+	return a.Kafka.PublishBillEvent(ctx, event)
+}
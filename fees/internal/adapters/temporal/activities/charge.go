@@ -0,0 +1,120 @@
+package activities
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// ChargeActivities charges the closed bills through the PaymentGateway, register it as a struct so the
+// PaymentGateway is injected.
+type ChargeActivities struct {
+	Payments app.PaymentGateway
+	// charged holds the idempotency keys of the charges that succeeded on this worker, so a retried attempt
+	// (e.g. its completion was lost) doesn't reach the provider again. It is a local guard only, the provider
+	// dedups on the same key across workers and restarts.
+	charged chargedKeys
+}
+
+// A retry of a lost completion comes within the invoicing retry policy, the keys are kept well past it and
+// a worker holds the recent ones only.
+const (
+	chargedKeyTTL  = 24 * time.Hour
+	chargedKeysMax = 10_000
+)
+
+// chargedKeys is the set of the recent charge keys, the zero value is empty. A key expires chargedKeyTTL after
+// its charge, and at chargedKeysMax keys the oldest one is dropped, so the set doesn't grow with the bills.
+type chargedKeys struct {
+	mu sync.Mutex
+	// expires is the expiry of each key.
+	expires map[string]time.Time
+}
+
+// has tells whether the key was charged and hasn't expired at now.
+func (c *chargedKeys) has(key string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	exp, ok := c.expires[key]
+
+	return ok && now.Before(exp)
+}
+
+// add keeps the key until chargedKeyTTL after now, and drops the expired keys, or the oldest one at the cap.
+func (c *chargedKeys) add(key string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.expires == nil {
+		c.expires = map[string]time.Time{}
+	}
+	for k, exp := range c.expires {
+		if !now.Before(exp) {
+			delete(c.expires, k)
+		}
+	}
+	if _, ok := c.expires[key]; !ok && len(c.expires) >= chargedKeysMax {
+		oldest := ""
+		for k, exp := range c.expires {
+			if oldest == "" || exp.Before(c.expires[oldest]) {
+				oldest = k
+			}
+		}
+		delete(c.expires, oldest)
+	}
+	c.expires[key] = now.Add(chargedKeyTTL)
+}
+
+// ProcessInvoiceAndChargeActivity charges the bill total. Temporal runs activities at least once, every attempt
+// sends the same idempotency key, so a retry can't charge the customer twice.
+func (a *ChargeActivities) ProcessInvoiceAndChargeActivity(ctx context.Context, bill domain.Bill) error {
+	log := activity.GetLogger(ctx)
+	key := ChargeIdempotencyKey(bill)
+
+	if a.charged.has(key, time.Now()) {
+		log.Info("bill already charged, skipping", "bill_id", bill.ID, "idempotency_key", key)
+
+		return nil
+	}
+
+	log.Info("processing invoice",
+		"bill_id", bill.ID,
+		"customer_id", bill.CustomerID,
+		"period", bill.BillingPeriod,
+		"status", bill.Status,
+		"total", bill.Total.ToString(),
+		"items", len(bill.Items),
+		"idempotency_key", key,
+	)
+
+	// Any failure here results in the activity being retried by Temporal, with the same key.
+	if err := a.Payments.Charge(ctx, key, bill); err != nil {
+		return err
+	}
+	a.charged.add(key, time.Now())
+
+	return nil
+}
+
+// ChargeIdempotencyKey is the key of the charge of the bill, derived from the bill ID and the charged total only,
+// so it's the same for every attempt of the activity.
+func ChargeIdempotencyKey(bill domain.Bill) string {
+	sum := sha256.Sum256([]byte(string(bill.ID) + "|" + bill.Total.String()))
+
+	return "charge-" + hex.EncodeToString(sum[:])
+}
+
+// NoopPaymentGateway is the default PaymentGateway until a payment provider is wired in, nothing is charged.
+type NoopPaymentGateway struct{}
+
+func (NoopPaymentGateway) Charge(_ context.Context, _ string, _ domain.Bill) error {
+	return nil
+}
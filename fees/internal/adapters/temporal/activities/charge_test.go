@@ -0,0 +1,127 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// MockPaymentGateway implements app.PaymentGateway for testing
+type MockPaymentGateway struct {
+	mock.Mock
+}
+
+func (m *MockPaymentGateway) Charge(ctx context.Context, idempotencyKey string, bill domain.Bill) error {
+	args := m.Called(ctx, idempotencyKey, bill)
+	return args.Error(0)
+}
+
+func chargeTestBill(t *testing.T, total string) domain.Bill {
+	t.Helper()
+	m, err := libmoney.NewFromString(total, libmoney.CurrencyUSD)
+	require.NoError(t, err)
+
+	return domain.Bill{ID: "bill/customer-123/2025-01", CustomerID: "customer-123", Status: domain.BillStatusPending,
+		Currency: libmoney.CurrencyUSD, Total: m}
+}
+
+func TestProcessInvoiceAndChargeActivity_RetryChargesOnce(t *testing.T) {
+	bill := chargeTestBill(t, "10.50")
+	key := ChargeIdempotencyKey(bill)
+	payments := &MockPaymentGateway{}
+	payments.On("Charge", mock.Anything, key, mock.Anything).Return(nil).Once()
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(&ChargeActivities{Payments: payments})
+
+	// the second attempt is Temporal retrying an activity whose completion was lost
+	var charge *ChargeActivities
+	for attempt := 1; attempt <= 2; attempt++ {
+		_, err := env.ExecuteActivity(charge.ProcessInvoiceAndChargeActivity, bill)
+		require.NoError(t, err, "attempt %d", attempt)
+	}
+	payments.AssertNumberOfCalls(t, "Charge", 1)
+}
+
+func TestProcessInvoiceAndChargeActivity_FailedChargeIsRetriedWithSameKey(t *testing.T) {
+	bill := chargeTestBill(t, "10.50")
+	key := ChargeIdempotencyKey(bill)
+	payments := &MockPaymentGateway{}
+	payments.On("Charge", mock.Anything, key, mock.Anything).Return(errors.New("provider timeout")).Once()
+	payments.On("Charge", mock.Anything, key, mock.Anything).Return(nil).Once()
+
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(&ChargeActivities{Payments: payments})
+
+	var charge *ChargeActivities
+	_, err := env.ExecuteActivity(charge.ProcessInvoiceAndChargeActivity, bill)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "provider timeout")
+
+	_, err = env.ExecuteActivity(charge.ProcessInvoiceAndChargeActivity, bill)
+	require.NoError(t, err)
+	payments.AssertExpectations(t)
+}
+
+func TestChargedKeys(t *testing.T) {
+	now := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("a key expires after the TTL", func(t *testing.T) {
+		var c chargedKeys
+		c.add("charge-1", now)
+
+		assert.True(t, c.has("charge-1", now.Add(chargedKeyTTL-time.Second)))
+		assert.False(t, c.has("charge-1", now.Add(chargedKeyTTL)))
+		assert.False(t, c.has("charge-2", now))
+	})
+
+	t.Run("the expired keys are dropped", func(t *testing.T) {
+		var c chargedKeys
+		c.add("charge-1", now)
+		c.add("charge-2", now.Add(chargedKeyTTL))
+
+		assert.Len(t, c.expires, 1)
+	})
+
+	t.Run("the oldest key is dropped at the cap", func(t *testing.T) {
+		var c chargedKeys
+		for i := range chargedKeysMax {
+			c.add(fmt.Sprintf("charge-%d", i), now.Add(time.Duration(i)*time.Millisecond))
+		}
+		c.add("charge-new", now.Add(time.Minute))
+
+		assert.Len(t, c.expires, chargedKeysMax)
+		assert.False(t, c.has("charge-0", now.Add(time.Minute)))
+		assert.True(t, c.has("charge-1", now.Add(time.Minute)))
+		assert.True(t, c.has("charge-new", now.Add(time.Minute)))
+	})
+}
+
+func TestChargeIdempotencyKey(t *testing.T) {
+	bill := chargeTestBill(t, "10.50")
+	key := ChargeIdempotencyKey(bill)
+
+	assert.Equal(t, key, ChargeIdempotencyKey(bill), "the key must be deterministic")
+	assert.Equal(t, key, ChargeIdempotencyKey(chargeTestBill(t, "10.5")), "the same amount must give the same key")
+
+	other := bill
+	other.ID = "bill/customer-123/2025-02"
+	assert.NotEqual(t, key, ChargeIdempotencyKey(other), "another bill")
+	assert.NotEqual(t, key, ChargeIdempotencyKey(chargeTestBill(t, "11.00")), "another total")
+}
+
+func TestNoopPaymentGateway(t *testing.T) {
+	assert.NoError(t, NoopPaymentGateway{}.Charge(context.Background(), "charge-key", domain.Bill{}))
+}
@@ -0,0 +1,194 @@
+package activities
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/temporal"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// WebhookSignatureHeader carries the signature of the payload, "sha256=" and the hex HMAC-SHA256 of the body
+// with the signing key.
+const WebhookSignatureHeader = "X-Fees-Signature"
+
+// ErrTypeWebhookRejected is the non-retryable error type of a notification the customer endpoint refused with a 4xx.
+const ErrTypeWebhookRejected = "WebhookRejected"
+
+// ErrWebhookAddressNotAllowed is a webhook URL that isn't https or resolves to an address of our network
+// (loopback, private, link-local...), it's never posted to so a customer can't reach internal services.
+var ErrWebhookAddressNotAllowed = errors.New("webhook address not allowed")
+
+// WebhookEventBillClosed is the event of the notification sent once the bill is closed.
+const WebhookEventBillClosed = "bill.closed"
+
+// WebhookPayload is the JSON body of a bill notification.
+type WebhookPayload struct {
+	Event         string     `json:"event"`
+	BillID        string     `json:"billId"`
+	CustomerID    string     `json:"customerId"`
+	BillingPeriod string     `json:"billingPeriod"`
+	Status        string     `json:"status"`
+	Currency      string     `json:"currency"`
+	Total         string     `json:"total"`
+	FinalizedAt   *time.Time `json:"finalizedAt,omitempty"`
+}
+
+// WebhookActivities notifies customers through the WebhookPoster, register it as a struct so the poster
+// and the signing key are injected.
+type WebhookActivities struct {
+	Poster app.WebhookPoster
+	// SigningKey signs the payloads, empty sends them unsigned.
+	SigningKey []byte
+}
+
+// NotifyWebhookActivity posts the closed bill to webhookURL. A 2xx is delivered, a 4xx other than 429 or an
+// address not allowed (ErrWebhookAddressNotAllowed) is a non-retryable ErrTypeWebhookRejected, anything else
+// (429, 5xx, a transport error) is retried.
+func (a *WebhookActivities) NotifyWebhookActivity(ctx context.Context, bill domain.Bill, webhookURL string) error {
+	activity.GetLogger(ctx).Info("notifying webhook", "bill_id", bill.ID)
+	if u, err := url.Parse(webhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+		return temporal.NewNonRetryableApplicationError("webhook URL must be https", ErrTypeWebhookRejected,
+			ErrWebhookAddressNotAllowed)
+	}
+
+	payload, err := json.Marshal(WebhookPayload{
+		Event:         WebhookEventBillClosed,
+		BillID:        string(bill.ID),
+		CustomerID:    bill.CustomerID,
+		BillingPeriod: string(bill.BillingPeriod),
+		Status:        string(bill.Status),
+		Currency:      string(bill.Currency),
+		Total:         bill.Total.ToFixedString(),
+		FinalizedAt:   bill.FinalizedAt,
+	})
+	if err != nil {
+		return temporal.NewNonRetryableApplicationError("webhook payload", "ValidationError", err)
+	}
+
+	status, err := a.Poster.PostWebhook(ctx, webhookURL, payload, SignWebhookPayload(a.SigningKey, payload))
+	if errors.Is(err, ErrWebhookAddressNotAllowed) {
+		return temporal.NewNonRetryableApplicationError(err.Error(), ErrTypeWebhookRejected, err)
+	}
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	switch {
+	case status >= http.StatusOK && status < http.StatusMultipleChoices:
+		return nil
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError &&
+		status != http.StatusTooManyRequests:
+		return temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("webhook rejected with status %d", status), ErrTypeWebhookRejected, nil)
+	default:
+		return fmt.Errorf("webhook responded with status %d", status)
+	}
+}
+
+// SignWebhookPayload is the WebhookSignatureHeader value of the payload, empty for an empty key.
+func SignWebhookPayload(key, payload []byte) string {
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HTTPWebhookPoster posts the notifications as JSON, nil Client means a NewWebhookHTTPClient without timeout.
+type HTTPWebhookPoster struct {
+	Client *http.Client
+}
+
+var defaultWebhookClient = NewWebhookHTTPClient(0)
+
+// NewWebhookHTTPClient is the client for the customer webhooks: it only connects to public addresses, checked
+// on the resolved IP at dial time so a DNS name can't point it at our network, and follows https redirects only.
+// No proxy is used, the proxy would dial on our behalf.
+func NewWebhookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second, Control: dialPublicOnly}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "https" {
+				return fmt.Errorf("%w: redirect to %s", ErrWebhookAddressNotAllowed, req.URL.Scheme)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+
+			return nil
+		},
+	}
+}
+
+// dialPublicOnly is the net.Dialer Control refusing the addresses of our network, address is the resolved IP.
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, host)
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		cgnatRange.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrWebhookAddressNotAllowed, ip)
+	}
+
+	return nil
+}
+
+// cgnatRange is the shared address space (RFC 6598) of carrier-grade NAT, not global though IsPrivate misses it.
+var cgnatRange = netip.MustParsePrefix("100.64.0.0/10")
+
+// webhookResponseLimit caps the response body read to reuse the connection, the body itself is ignored.
+const webhookResponseLimit = 64 << 10
+
+func (p HTTPWebhookPoster) PostWebhook(ctx context.Context, url string, payload []byte, signature string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if signature != "" {
+		req.Header.Set(WebhookSignatureHeader, signature)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseLimit))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,196 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// MockWebhookPoster implements app.WebhookPoster for testing
+type MockWebhookPoster struct {
+	mock.Mock
+}
+
+func (m *MockWebhookPoster) PostWebhook(ctx context.Context, url string, payload []byte, signature string) (int, error) {
+	args := m.Called(ctx, url, payload, signature)
+	return args.Int(0), args.Error(1)
+}
+
+func TestNotifyWebhookActivity(t *testing.T) {
+	const url = "https://customer.example.com/hooks/bills"
+	key := []byte("webhook-secret")
+	bill := domain.Bill{
+		ID:            "bill/customer-123/2025-01",
+		CustomerID:    "customer-123",
+		BillingPeriod: "2025-01",
+		Currency:      libmoney.CurrencyUSD,
+		Status:        domain.BillStatusClosed,
+		Total:         libmoney.FromMinorUnits(1050, libmoney.CurrencyUSD),
+	}
+	tests := []struct {
+		name         string
+		status       int
+		postErr      error
+		wantErr      bool
+		nonRetryable bool
+	}{
+		{name: "delivered", status: http.StatusOK},
+		{name: "accepted", status: http.StatusAccepted},
+		{name: "server error is retried", status: http.StatusBadGateway, wantErr: true},
+		{name: "rate limit is retried", status: http.StatusTooManyRequests, wantErr: true},
+		{name: "transport error is retried", postErr: errors.New("connection refused"), wantErr: true},
+		{name: "client error is not retried", status: http.StatusNotFound, wantErr: true, nonRetryable: true},
+		{name: "unauthorized is not retried", status: http.StatusUnauthorized, wantErr: true, nonRetryable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poster := &MockWebhookPoster{}
+			var payload []byte
+			var signature string
+			poster.On("PostWebhook", mock.Anything, url, mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					payload, signature = args.Get(2).([]byte), args.String(3)
+				}).
+				Return(tt.status, tt.postErr).Once()
+
+			testSuite := &testsuite.WorkflowTestSuite{}
+			env := testSuite.NewTestActivityEnvironment()
+			env.RegisterActivity(&WebhookActivities{Poster: poster, SigningKey: key})
+
+			var webhooks *WebhookActivities
+			_, err := env.ExecuteActivity(webhooks.NotifyWebhookActivity, bill, url)
+			poster.AssertExpectations(t)
+
+			var got WebhookPayload
+			require.NoError(t, json.Unmarshal(payload, &got))
+			assert.Equal(t, WebhookEventBillClosed, got.Event)
+			assert.Equal(t, string(bill.ID), got.BillID)
+			assert.Equal(t, "10.50", got.Total)
+			assert.Equal(t, SignWebhookPayload(key, payload), signature)
+
+			if !tt.wantErr {
+				require.NoError(t, err)
+
+				return
+			}
+			require.Error(t, err)
+			var appErr *temporal.ApplicationError
+			if tt.nonRetryable {
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, ErrTypeWebhookRejected, appErr.Type())
+				assert.True(t, appErr.NonRetryable())
+			} else if errors.As(err, &appErr) {
+				assert.False(t, appErr.NonRetryable())
+			}
+		})
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"event":"bill.closed"}`)
+
+	sig := SignWebhookPayload([]byte("key"), payload)
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.Equal(t, sig, SignWebhookPayload([]byte("key"), payload))
+	assert.NotEqual(t, sig, SignWebhookPayload([]byte("other-key"), payload))
+	assert.Empty(t, SignWebhookPayload(nil, payload))
+}
+
+func TestHTTPWebhookPoster(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	payload := []byte(`{"event":"bill.closed"}`)
+	status, err := HTTPWebhookPoster{Client: srv.Client()}.PostWebhook(context.Background(), srv.URL, payload, "sha256=abc")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+	assert.Equal(t, payload, gotBody)
+	assert.Equal(t, "application/json", gotHeader.Get("Content-Type"))
+	assert.Equal(t, "sha256=abc", gotHeader.Get(WebhookSignatureHeader))
+}
+
+func TestNotifyWebhookActivity_NotHTTPS(t *testing.T) {
+	poster := &MockWebhookPoster{}
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestActivityEnvironment()
+	env.RegisterActivity(&WebhookActivities{Poster: poster})
+
+	var webhooks *WebhookActivities
+	_, err := env.ExecuteActivity(webhooks.NotifyWebhookActivity, domain.Bill{ID: "bill/customer-123/2025-01"},
+		"http://customer.example.com/hooks/bills")
+
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, ErrTypeWebhookRejected, appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+	poster.AssertNotCalled(t, "PostWebhook", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNewWebhookHTTPClient_RefusesInternalAddresses(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	// the test server listens on loopback, as an internal service would
+	_, err := HTTPWebhookPoster{Client: NewWebhookHTTPClient(time.Second)}.PostWebhook(context.Background(),
+		srv.URL, []byte(`{}`), "")
+
+	require.ErrorIs(t, err, ErrWebhookAddressNotAllowed)
+	assert.Zero(t, hits.Load())
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{address: "93.184.215.14:443", allowed: true},
+		{address: "[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:443", allowed: true},
+		{address: "127.0.0.1:443"},
+		{address: "[::1]:443"},
+		{address: "10.1.2.3:443"},
+		{address: "172.16.0.1:443"},
+		{address: "192.168.1.1:443"},
+		{address: "169.254.169.254:80"},
+		{address: "100.64.0.1:443"},
+		{address: "0.0.0.0:443"},
+		{address: "[fe80::1]:443"},
+		{address: "[fd00::1]:443"},
+		{address: "[::ffff:10.0.0.1]:443"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := dialPublicOnly("tcp", tt.address, nil)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrWebhookAddressNotAllowed)
+			}
+		})
+	}
+}
@@ -1,24 +1,146 @@
 package temporal
 
-import "go.temporal.io/sdk/client"
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
 
 // This is custom struct wrapping the official client.
 type Client struct {
 	client client.Client
 }
 
-// NewClient initializes the connection to the Temporal frontend.
-func NewClient(hostPort, namespace string) (client.Client, error) {
-	// In a real app, you'd get the host from Encore's config/secrets system.
-	c, err := client.Dial(client.Options{
-		HostPort:  hostPort,
-		Namespace: namespace,
-	})
+// ConnectOptions secure the connection to the frontend and the payloads, the zero value is plaintext (local dev server).
+type ConnectOptions struct {
+	UseTLS bool
+	// TLSCertPath and TLSKeyPath are the PEM files of the mTLS client certificate, both empty means server-only TLS.
+	TLSCertPath string
+	TLSKeyPath  string
+	// UseAPIKey authenticates with a Temporal Cloud API key, it implies TLS.
+	UseAPIKey bool
+	APIKey    string
+	// DataConverter encodes the payloads, e.g. NewDataConverter with encryption, nil is the SDK default.
+	DataConverter converter.DataConverter
+}
+
+// DialRetry bounds the dial retries of NewClient, the frontend may still be starting when the services boot
+// (e.g. docker compose brings everything up at once).
+type DialRetry struct {
+	// MaxAttempts includes the first dial, below 1 means a single attempt.
+	MaxAttempts int
+	// MaxElapsed caps the whole dial including the waits, zero means no cap.
+	MaxElapsed time.Duration
+	// InitialInterval is the first wait, doubled after every failure up to MaxInterval.
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// DefaultDialRetry gives the frontend about a minute to come up.
+var DefaultDialRetry = DialRetry{
+	MaxAttempts:     10,
+	MaxElapsed:      time.Minute,
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+}
+
+type dialFunc func(ctx context.Context, options client.Options) (client.Client, error)
+
+// NewClient initializes the connection to the Temporal frontend, retrying with exponential backoff and jitter.
+func NewClient(hostPort, namespace string, conn ConnectOptions, retry DialRetry) (client.Client, error) {
+	opts, err := clientOptions(hostPort, namespace, conn)
 	if err != nil {
 		return nil, err
 	}
 
-	return c, nil
+	return dialWithRetry(context.Background(), client.DialContext, opts, retry)
+}
+
+// clientOptions builds the dial options, without connecting.
+func clientOptions(hostPort, namespace string, conn ConnectOptions) (client.Options, error) {
+	opts := client.Options{HostPort: hostPort, Namespace: namespace, DataConverter: conn.DataConverter}
+	if !conn.UseTLS && !conn.UseAPIKey {
+		return opts, nil
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch {
+	case conn.TLSCertPath != "" && conn.TLSKeyPath != "":
+		cert, err := tls.LoadX509KeyPair(conn.TLSCertPath, conn.TLSKeyPath)
+		if err != nil {
+			return client.Options{}, fmt.Errorf("temporal tls client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	case conn.TLSCertPath != "" || conn.TLSKeyPath != "":
+		return client.Options{}, errors.New("temporal tls: both the client certificate and the key path are required")
+	}
+	opts.ConnectionOptions.TLS = tlsCfg
+
+	if conn.UseAPIKey {
+		if conn.APIKey == "" {
+			return client.Options{}, errors.New("temporal api key: UseAPIKey is set but the key is empty")
+		}
+		opts.Credentials = client.NewAPIKeyStaticCredentials(conn.APIKey)
+		// Temporal Cloud routes API-key requests by the namespace header.
+		opts.HeadersProvider = namespaceHeader(namespace)
+	}
+
+	return opts, nil
+}
+
+// namespaceHeader sends the temporal-namespace header on every call.
+type namespaceHeader string
+
+func (h namespaceHeader) GetHeaders(context.Context) (map[string]string, error) {
+	return map[string]string{"temporal-namespace": string(h)}, nil
+}
+
+func dialWithRetry(ctx context.Context, dial dialFunc, opts client.Options, retry DialRetry) (client.Client, error) {
+	if retry.MaxElapsed > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, retry.MaxElapsed)
+		defer cancel()
+	}
+	attempts := max(retry.MaxAttempts, 1)
+	interval := retry.InitialInterval
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var c client.Client
+		if c, err = dial(ctx, opts); err == nil {
+			return c, nil
+		}
+		if attempt >= attempts {
+			break
+		}
+
+		t := time.NewTimer(jitter(interval))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+
+			return nil, fmt.Errorf("temporal dial %s: gave up after %d attempts: %w", opts.HostPort, attempt, err)
+		case <-t.C:
+		}
+		interval = min(interval*2, max(retry.MaxInterval, retry.InitialInterval))
+	}
+
+	return nil, fmt.Errorf("temporal dial %s: gave up after %d attempts: %w", opts.HostPort, attempts, err)
+}
+
+// jitter spreads the wait over [d/2, d), so services booting together don't dial in lockstep.
+func jitter(d time.Duration) time.Duration {
+	if half := d / 2; half > 0 {
+		return half + rand.N(half)
+	}
+
+	return d
 }
 
 func (tc *Client) Close() {
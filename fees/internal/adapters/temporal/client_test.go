@@ -0,0 +1,182 @@
+package temporal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// failingDial fails the first failures dials, then returns c.
+func failingDial(failures int, c client.Client) (dialFunc, *int) {
+	calls := 0
+
+	return func(_ context.Context, _ client.Options) (client.Client, error) {
+		calls++
+		if calls <= failures {
+			return nil, errUnavailable
+		}
+
+		return c, nil
+	}, &calls
+}
+
+func TestDialWithRetry_EventuallySucceeds(t *testing.T) {
+	want := new(MockTemporalClient)
+	dial, calls := failingDial(3, want)
+	retry := DialRetry{MaxAttempts: 5, InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+	c, err := dialWithRetry(context.Background(), dial, client.Options{HostPort: "temporal:7233"}, retry)
+
+	assert.NoError(t, err)
+	assert.Same(t, want, c)
+	assert.Equal(t, 4, *calls)
+}
+
+func TestDialWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	dial, calls := failingDial(100, new(MockTemporalClient))
+	retry := DialRetry{MaxAttempts: 3, InitialInterval: time.Millisecond}
+
+	c, err := dialWithRetry(context.Background(), dial, client.Options{HostPort: "temporal:7233"}, retry)
+
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.ErrorContains(t, err, "gave up after 3 attempts")
+	assert.Equal(t, 3, *calls)
+}
+
+func TestDialWithRetry_GivesUpAfterMaxElapsed(t *testing.T) {
+	dial, calls := failingDial(100, new(MockTemporalClient))
+	retry := DialRetry{MaxAttempts: 100, MaxElapsed: 50 * time.Millisecond, InitialInterval: 20 * time.Millisecond}
+
+	start := time.Now()
+	_, err := dialWithRetry(context.Background(), dial, client.Options{}, retry)
+
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Less(t, *calls, 100)
+}
+
+func TestDialWithRetry_SingleAttempt(t *testing.T) {
+	dial, calls := failingDial(1, new(MockTemporalClient))
+
+	_, err := dialWithRetry(context.Background(), dial, client.Options{}, DialRetry{})
+
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.Equal(t, 1, *calls)
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		d := jitter(100 * time.Millisecond)
+		assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+		assert.Less(t, d, 100*time.Millisecond)
+	}
+	assert.Equal(t, time.Duration(0), jitter(0))
+}
+
+// writeClientCert writes a self-signed client certificate and its key as PEM files.
+func writeClientCert(t *testing.T) (certPath, keyPath string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "fees-worker"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certPath, keyPath = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certPath, keyPath
+}
+
+func TestClientOptions(t *testing.T) {
+	certPath, keyPath := writeClientCert(t)
+
+	t.Run("plaintext", func(t *testing.T) {
+		opts, err := clientOptions("localhost:7233", "default", ConnectOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:7233", opts.HostPort)
+		assert.Equal(t, "default", opts.Namespace)
+		assert.Nil(t, opts.ConnectionOptions.TLS)
+		assert.Nil(t, opts.Credentials)
+		assert.Nil(t, opts.HeadersProvider)
+	})
+
+	t.Run("server-only TLS", func(t *testing.T) {
+		opts, err := clientOptions("temporal:7233", "fees", ConnectOptions{UseTLS: true})
+		require.NoError(t, err)
+		require.NotNil(t, opts.ConnectionOptions.TLS)
+		assert.Empty(t, opts.ConnectionOptions.TLS.Certificates)
+		assert.Nil(t, opts.Credentials)
+	})
+
+	t.Run("mTLS", func(t *testing.T) {
+		opts, err := clientOptions("temporal:7233", "fees",
+			ConnectOptions{UseTLS: true, TLSCertPath: certPath, TLSKeyPath: keyPath})
+		require.NoError(t, err)
+		require.NotNil(t, opts.ConnectionOptions.TLS)
+		assert.Len(t, opts.ConnectionOptions.TLS.Certificates, 1)
+		assert.Nil(t, opts.Credentials)
+	})
+
+	t.Run("API key implies TLS", func(t *testing.T) {
+		opts, err := clientOptions("fees.abc12.tmprl.cloud:7233", "fees.abc12",
+			ConnectOptions{UseAPIKey: true, APIKey: "secret-key"})
+		require.NoError(t, err)
+		require.NotNil(t, opts.ConnectionOptions.TLS)
+		assert.NotNil(t, opts.Credentials)
+		require.NotNil(t, opts.HeadersProvider)
+		headers, err := opts.HeadersProvider.GetHeaders(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"temporal-namespace": "fees.abc12"}, headers)
+	})
+
+	t.Run("API key with mTLS", func(t *testing.T) {
+		opts, err := clientOptions("temporal:7233", "fees",
+			ConnectOptions{UseTLS: true, TLSCertPath: certPath, TLSKeyPath: keyPath, UseAPIKey: true, APIKey: "secret-key"})
+		require.NoError(t, err)
+		assert.Len(t, opts.ConnectionOptions.TLS.Certificates, 1)
+		assert.NotNil(t, opts.Credentials)
+	})
+
+	errorCases := []struct {
+		name string
+		conn ConnectOptions
+	}{
+		{name: "API key missing", conn: ConnectOptions{UseAPIKey: true}},
+		{name: "key path missing", conn: ConnectOptions{UseTLS: true, TLSCertPath: certPath}},
+		{name: "cert file missing", conn: ConnectOptions{UseTLS: true, TLSCertPath: "/nonexistent.pem", TLSKeyPath: keyPath}},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := clientOptions("temporal:7233", "fees", tt.conn)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package temporal
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/converter"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+var testEncryptionKey = base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+func TestNewDataConverter_RoundTrip(t *testing.T) {
+	dc, err := NewDataConverter(testEncryptionKey)
+	require.NoError(t, err)
+
+	amount, _ := libmoney.NewFromString("10.50", libmoney.CurrencyUSD)
+	in := workflows.AddLineItemPayload{IdempotencyKey: "item-1", Description: "Consulting for Jane Doe", Amount: amount}
+	p, err := dc.ToPayload(in)
+	require.NoError(t, err)
+
+	assert.Equal(t, "binary/encrypted", string(p.GetMetadata()[converter.MetadataEncoding]))
+	assert.NotContains(t, string(p.GetData()), "Jane Doe", "the description isn't in plaintext")
+
+	var out workflows.AddLineItemPayload
+	require.NoError(t, dc.FromPayload(p, &out))
+	assert.Equal(t, in.Description, out.Description)
+	assert.Equal(t, "10.5", out.Amount.ToString())
+
+	// a fresh nonce per payload, equal values don't give equal ciphertexts
+	p2, err := dc.ToPayload(in)
+	require.NoError(t, err)
+	assert.NotEqual(t, p.GetData(), p2.GetData())
+}
+
+func TestNewDataConverter_DecodesPlaintext(t *testing.T) {
+	dc, err := NewDataConverter(testEncryptionKey)
+	require.NoError(t, err)
+	plain, err := converter.GetDefaultDataConverter().ToPayload("req-42")
+	require.NoError(t, err)
+
+	// history written before the key was set stays readable
+	var out string
+	require.NoError(t, dc.FromPayload(plain, &out))
+	assert.Equal(t, "req-42", out)
+}
+
+func TestNewDataConverter_WrongKey(t *testing.T) {
+	dc, err := NewDataConverter(testEncryptionKey)
+	require.NoError(t, err)
+	other, err := NewDataConverter(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	require.NoError(t, err)
+
+	p, err := dc.ToPayload("secret")
+	require.NoError(t, err)
+	var out string
+	assert.ErrorIs(t, other.FromPayload(p, &out), ErrEncryptionKeyMismatch)
+
+	// a tampered ciphertext fails authentication
+	p.Data[len(p.Data)-1] ^= 0xff
+	assert.ErrorContains(t, dc.FromPayload(p, &out), "decrypt payload")
+}
+
+func TestNewDataConverter_Key(t *testing.T) {
+	dc, err := NewDataConverter("")
+	require.NoError(t, err)
+	assert.Same(t, converter.GetDefaultDataConverter(), dc, "no key, no encryption")
+
+	_, err = NewDataConverter("not base64!")
+	assert.Error(t, err)
+
+	_, err = NewDataConverter(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.ErrorContains(t, err, "want 32 bytes")
+}
+
+func TestGateway_GetBillMemo_Encrypted(t *testing.T) {
+	dc, err := NewDataConverter(testEncryptionKey)
+	require.NoError(t, err)
+	cid, err := dc.ToPayload("req-42")
+	require.NoError(t, err)
+
+	mockClient := &MockTemporalClient{}
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "test-bill-123", "").
+		Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{
+				Memo: &commonpb.Memo{Fields: map[string]*commonpb.Payload{app.MemoKeyCorrelationID: cid}},
+			},
+		}, nil)
+
+	memo, err := NewGateway(mockClient, "test-namespace").WithDataConverter(dc).
+		GetBillMemo(context.Background(), "test-bill-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, "req-42", memo.CorrelationID)
+}
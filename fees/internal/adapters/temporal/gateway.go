@@ -1,12 +1,16 @@
 package temporal
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	commonpb "go.temporal.io/api/common/v1"
 	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/serviceerror"
@@ -21,90 +25,548 @@ import (
 	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
 	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
+// DefaultTaskQueue is the workflow task queue of the bills and credit notes, unless TaskQueueFor routes a bill
+// elsewhere. The worker listens on it by default.
+const DefaultTaskQueue = "FEES_TASK_QUEUE"
+
 const (
-	taskQueue           = "FEES_TASK_QUEUE"
 	pageSize            = 100
 	queryTimeoutSeconds = 8
+	// SearchBills limits, so one customer with thousands of bills can't tie up the service.
+	defaultSearchMaxPages    = 50
+	defaultSearchMaxDuration = 20 * time.Second
 )
 
+// defaultQueryRetry tries a query 3 times, all of them within queryTimeoutSeconds.
+var defaultQueryRetry = DialRetry{
+	MaxAttempts:     3,
+	InitialInterval: 200 * time.Millisecond,
+	MaxInterval:     time.Second,
+}
+
 type Gateway struct {
-	tc        client.Client
-	namespace string
+	tc                client.Client
+	namespace         string
+	activityTaskQueue string
+	now               func() time.Time
+	searchMaxPages    int
+	searchMaxDuration time.Duration
+	// queryRetry backs off the transient query errors, see queryWorkflow.
+	queryRetry DialRetry
+	// searchCache is nil when SearchBills results aren't cached.
+	searchCache *searchCache
+	// dc decodes memos and search attributes, it must match the client's DataConverter.
+	dc converter.DataConverter
+	// archivedLookup looks a not found bill up in the archive, see notFound.
+	archivedLookup bool
+	// taskQueueFor picks the workflow task queue of a new bill.
+	taskQueueFor TaskQueueFor
+	// tracer spans the calls to Temporal, see startSpan.
+	tracer trace.Tracer
+}
+
+// TaskQueueFor picks the workflow task queue a new bill is started on, e.g. to keep the bills of a region
+// on the workers of that region. The bill stays on it for its whole life, signals and queries follow it,
+// its credit notes are started on it too.
+type TaskQueueFor func(params app.MonthlyFeeAccrualWorkflowParams) string
+
+// TaskQueueByCurrency routes the bills in the given currencies to their task queues, e.g. {"EUR": "FEES_EU"},
+// other currencies go to DefaultTaskQueue.
+func TaskQueueByCurrency(queues map[libmoney.Currency]string) TaskQueueFor {
+	return func(params app.MonthlyFeeAccrualWorkflowParams) string {
+		if q := queues[params.Currency]; q != "" {
+			return q
+		}
+
+		return DefaultTaskQueue
+	}
 }
 
 func NewGateway(tc client.Client, namespace string) *Gateway {
-	return &Gateway{tc: tc, namespace: namespace}
+	return &Gateway{
+		tc:                tc,
+		namespace:         namespace,
+		now:               time.Now,
+		searchMaxPages:    defaultSearchMaxPages,
+		searchMaxDuration: defaultSearchMaxDuration,
+		queryRetry:        defaultQueryRetry,
+		dc:                converter.GetDefaultDataConverter(),
+		taskQueueFor:      TaskQueueByCurrency(nil),
+		tracer:            noopTracer,
+	}
+}
+
+// WithDataConverter sets the converter the client was dialed with, e.g. NewDataConverter with encryption,
+// nil keeps the SDK default.
+func (g *Gateway) WithDataConverter(dc converter.DataConverter) *Gateway {
+	if dc != nil {
+		g.dc = dc
+	}
+
+	return g
+}
+
+// WithActivityTaskQueue routes the bill activities to a dedicated task queue, so they can be scaled apart
+// from the workflow processing. Empty value keeps activities on the workflow task queue.
+func (g *Gateway) WithActivityTaskQueue(q string) *Gateway {
+	g.activityTaskQueue = q
+
+	return g
+}
+
+// WithTaskQueueFor sets the task queue strategy of new bills, nil keeps every bill on DefaultTaskQueue.
+func (g *Gateway) WithTaskQueueFor(f TaskQueueFor) *Gateway {
+	if f == nil {
+		f = TaskQueueByCurrency(nil)
+	}
+	g.taskQueueFor = f
+
+	return g
+}
+
+// WithArchivedLookup makes a query of a bill past retention fail with app.ErrBillArchived instead of
+// app.ErrBillNotFound, if the namespace has visibility archival enabled. It costs a lookup per not found bill.
+func (g *Gateway) WithArchivedLookup(enabled bool) *Gateway {
+	g.archivedLookup = enabled
+
+	return g
+}
+
+// WithTracer spans the workflow starts, signals, queries and lists with t, so their latency and errors show up
+// in the traces of the request. nil disables the spans.
+func (g *Gateway) WithTracer(t trace.Tracer) *Gateway {
+	if t == nil {
+		t = noopTracer
+	}
+	g.tracer = t
+
+	return g
+}
+
+// WithSearchCache caches SearchBills results of identical filters for ttl, zero or negative ttl disables the cache.
+func (g *Gateway) WithSearchCache(ttl time.Duration) *Gateway {
+	g.searchCache = nil
+	if ttl > 0 {
+		g.searchCache = newSearchCache(ttl)
+	}
+
+	return g
+}
+
+// WithSearchLimits caps SearchBills at maxPages pages of pageSize bills and maxDuration in total,
+// zero or negative values keep the defaults.
+func (g *Gateway) WithSearchLimits(maxPages int, maxDuration time.Duration) *Gateway {
+	if maxPages > 0 {
+		g.searchMaxPages = maxPages
+	}
+	if maxDuration > 0 {
+		g.searchMaxDuration = maxDuration
+	}
+
+	return g
 }
 
 func (g *Gateway) StartMonthlyBill(ctx context.Context, params app.MonthlyFeeAccrualWorkflowParams) error {
+	if params.ActivityTaskQueue == "" {
+		params.ActivityTaskQueue = g.activityTaskQueue
+	}
+
+	err := g.startMonthlyBill(ctx, params)
+	if isSearchAttributeNotRegistered(err) {
+		// Degrade gracefully: the bill still works (signals, queries), it's just not visible for SearchBills.
+		slog.WarnContext(ctx, "bill search attributes are not registered, starting bill without them",
+			"bill_id", params.BillID, "namespace", g.namespace, "err", err)
+		params.SkipSearchAttributes = true
+		err = g.startMonthlyBill(ctx, params)
+	}
+	if err != nil {
+		// If already started
+		var already *serviceerror.WorkflowExecutionAlreadyStarted
+		if errors.As(err, &already) {
+			return app.ErrBillWithPeriodAlreadyStarted
+		}
+
+		return fmt.Errorf("temporal workflow start error, %w", err)
+	}
+
+	return nil
+}
+
+func (g *Gateway) startMonthlyBill(ctx context.Context, params app.MonthlyFeeAccrualWorkflowParams) error {
 	wfID := string(params.BillID) // assume it's the same as bill id
 
+	opts := client.StartWorkflowOptions{
+		ID:        wfID,
+		TaskQueue: g.taskQueueFor(params),
+		// ensures to get AlreadyStarted on ExecuteWorkflow:
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+		// prevents reuse
+		WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+	}
+	memo := map[string]interface{}{
+		// the exact params, to reproduce the bill, the workflow input isn't readable without fetching the history
+		app.MemoKeyCreateParams: params,
+	}
+	if cid := app.CorrelationID(ctx); cid != "" {
+		// the workflow adds it to its logger, so the bill logs can be traced back to the create request
+		memo[app.MemoKeyCorrelationID] = cid
+	}
+	if params.CreateIdempotencyKey != "" {
+		memo[app.MemoKeyCreateIdempotencyKey] = params.CreateIdempotencyKey
+	}
+	opts.Memo = memo
+	if !params.SkipSearchAttributes {
+		now := g.now().UTC()
+		periodStart, periodEnd, err := libtime.PeriodBounds(string(params.Period))
+		if err != nil {
+			return err
+		}
+		opts.TypedSearchAttributes = temporal.NewSearchAttributes(
+			sa.KeyCustomerID.ValueSet(params.CustomerID),
+			sa.KeyBillingPeriodNum.ValueSet(params.PeriodYYYYMM),
+			sa.KeyBillStatus.ValueSet(string(domain.BillStatusOpen)),
+			sa.KeyBillCurrency.ValueSet(string(params.Currency)),
+			sa.KeyBillItemCount.ValueSet(0),  // length of LineItems, zero at init time
+			sa.KeyBillTotalCents.ValueSet(0), // zero total at init time
+			sa.KeyBillUpdatedAt.ValueSet(now),
+			sa.KeyBillCreatedAt.ValueSet(now),
+			sa.KeyBillPeriodStart.ValueSet(periodStart),
+			sa.KeyBillPeriodEnd.ValueSet(periodEnd),
+		)
+	}
+
 	// Try to start the workflow for this (customer, period).
-	_, err := g.tc.ExecuteWorkflow(ctx,
-		client.StartWorkflowOptions{
-			ID:        wfID,
-			TaskQueue: taskQueue,
-			// ensures to get AlreadyStarted on ExecuteWorkflow:
-			WorkflowExecutionErrorWhenAlreadyStarted: true,
-			// prevents reuse
-			WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
-			TypedSearchAttributes: temporal.NewSearchAttributes(
-				sa.KeyCustomerID.ValueSet(params.CustomerID),
-				sa.KeyBillingPeriodNum.ValueSet(params.PeriodYYYYMM),
-				sa.KeyBillStatus.ValueSet(string(domain.BillStatusOpen)),
-				sa.KeyBillCurrency.ValueSet(string(params.Currency)),
-				sa.KeyBillItemCount.ValueSet(0),  // length of LineItems, zero at init time
-				sa.KeyBillTotalCents.ValueSet(0), // zero total at init time
-			),
-		},
+	return g.executeWorkflow(ctx,
+		opts,
+		workflows.WorkflowTypeMonthlyBill,
 		workflows.MonthlyFeeAccrualWorkflow, // workflow definition
 		params,
 	)
+}
+
+func (g *Gateway) StartCreditNote(ctx context.Context, note domain.CreditNote) error {
+	memo := map[string]interface{}{
+		// the link back to the bill, credit notes aren't searchable so no SA registration is needed
+		app.MemoKeyOriginalBillID: string(note.BillID),
+	}
+	if cid := app.CorrelationID(ctx); cid != "" {
+		memo[app.MemoKeyCorrelationID] = cid
+	}
+	opts := client.StartWorkflowOptions{
+		ID: string(note.ID),
+		// the note goes along with its bill, e.g. to the workers of its region
+		TaskQueue: g.taskQueueFor(app.MonthlyFeeAccrualWorkflowParams{
+			BillID:     note.BillID,
+			CustomerID: note.CustomerID,
+			Period:     note.BillingPeriod,
+			Currency:   note.Currency,
+		}),
+		WorkflowExecutionErrorWhenAlreadyStarted: true,
+		// the ID is derived from the idempotency key, a reused key must never credit twice
+		WorkflowIDReusePolicy: enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE,
+		Memo:                  memo,
+	}
+
+	err := g.executeWorkflow(ctx, opts, workflows.WorkflowTypeCreditNote, workflows.CreditNoteWorkflow,
+		app.CreditNoteWorkflowParams{CreditNote: note})
 	if err != nil {
-		// If already started
 		var already *serviceerror.WorkflowExecutionAlreadyStarted
 		if errors.As(err, &already) {
-			return app.ErrBillWithPeriodAlreadyStarted
+			return app.ErrCreditNoteAlreadyExists
 		}
 
-		return fmt.Errorf("temporal workflow start error, %w", err)
+		return fmt.Errorf("temporal credit note start error, %w", err)
+	}
+
+	return nil
+}
+
+// ListCreditNotes lists the credit note workflows of the bill by their ID prefix, see domain.MakeCreditNoteID,
+// the note is the result of each one.
+func (g *Gateway) ListCreditNotes(ctx context.Context, id domain.BillID) ([]domain.CreditNote, error) {
+	q := newVisibilityQuery().
+		Equals("WorkflowType", workflows.WorkflowTypeCreditNote).
+		StartsWith("WorkflowId", string(domain.MakeCreditNoteID(id, ""))).
+		Build()
+	var notes []domain.CreditNote
+	var token []byte
+	for {
+		resp, err := g.listWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     g.namespace,
+			Query:         q,
+			PageSize:      pageSize,
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("list credit notes of %s: %w", id, err)
+		}
+		for _, info := range resp.GetExecutions() {
+			var note domain.CreditNote
+			exec := info.GetExecution()
+			if err := g.tc.GetWorkflow(ctx, exec.GetWorkflowId(), exec.GetRunId()).Get(ctx, &note); err != nil {
+				return nil, fmt.Errorf("credit note %s: %w", exec.GetWorkflowId(), err)
+			}
+			notes = append(notes, note)
+		}
+		token = resp.GetNextPageToken()
+		if len(token) == 0 {
+			return notes, nil
+		}
+	}
+}
+
+// isSearchAttributeNotRegistered detects the frontend rejection of an unknown search attribute, e.g.
+// "search attribute BillStatus is not defined" or "Namespace default has no mapping defined for search attribute ...".
+func isSearchAttributeNotRegistered(err error) bool {
+	var invalid *serviceerror.InvalidArgument
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	msg := strings.ToLower(invalid.Error())
+
+	return strings.Contains(msg, "search attribute") &&
+		(strings.Contains(msg, "not defined") || strings.Contains(msg, "no mapping defined"))
+}
+
+// signalBill signals the bill run of ctx, see app.WithRunID, the latest one by default. Do not treat the run ID as
+// the bill ID, the bill continues as new with another run ID. A given run that isn't running anymore is
+// app.ErrBillRunStale, the signal isn't redirected to the latest run as the caller acted on what it read.
+func (g *Gateway) signalBill(ctx context.Context, id domain.BillID, signalName string, arg any) error {
+	runID := app.RunID(ctx)
+	err := g.signalWorkflow(ctx, string(id), runID, signalName, arg)
+	if isStaleRun(err, runID) {
+		return fmt.Errorf("%w: %w", app.ErrBillRunStale, err)
 	}
 
 	return err
 }
 
+// isStaleRun tells a NotFound of an explicit run, the latest run being gone is a bill not found.
+func isStaleRun(err error, runID string) bool {
+	var nf *serviceerror.NotFound
+
+	return runID != "" && errors.As(err, &nf)
+}
+
 func (g *Gateway) AddLineItem(ctx context.Context, id domain.BillID, li domain.LineItem) error {
-	// Caution! // do not treat runID as billID, workflow could be re-run for compaction!
-	runID := ""
 	line := workflows.AddLineItemPayload{
 		Description:    li.Description,
 		Amount:         li.Amount,
 		IdempotencyKey: li.IdempotencyKey,
+		CorrelationID:  app.CorrelationID(ctx),
+		Tags:           li.Tags,
+	}
+
+	return g.signalBill(ctx, id, workflows.SignalAddLineItem, line)
+}
+
+func (g *Gateway) UpdateLineItemDescription(
+	ctx context.Context,
+	id domain.BillID,
+	idempotencyKey, description string,
+) error {
+	pl := workflows.UpdateLineItemDescriptionPayload{
+		IdempotencyKey: idempotencyKey,
+		NewDescription: description,
+		CorrelationID:  app.CorrelationID(ctx),
 	}
 
-	return g.tc.SignalWorkflow(ctx, string(id), runID, workflows.SignalAddLineItem, line)
+	return g.signalBill(ctx, id, workflows.SignalUpdateLineItemDescription, pl)
+}
+
+func (g *Gateway) CorrectLineItemAmount(
+	ctx context.Context,
+	id domain.BillID,
+	idempotencyKey string,
+	amount libmoney.Money,
+) error {
+	pl := workflows.CorrectLineItemAmountPayload{
+		IdempotencyKey: idempotencyKey,
+		NewAmount:      amount,
+		CorrelationID:  app.CorrelationID(ctx),
+	}
+
+	return g.signalBill(ctx, id, workflows.SignalCorrectLineItemAmount, pl)
+}
+
+func (g *Gateway) SetBillNote(ctx context.Context, id domain.BillID, note string) error {
+	pl := workflows.SetBillNotePayload{
+		Note:          note,
+		CorrelationID: app.CorrelationID(ctx),
+	}
+
+	return g.signalBill(ctx, id, workflows.SignalSetBillNote, pl)
 }
 
 func (g *Gateway) CloseBill(ctx context.Context, id domain.BillID) error {
-	// Caution! // do not treat runID as billID, workflow could be re-run for compaction!
-	runID := ""
+	sig := workflows.CloseBillSignal{CorrelationID: app.CorrelationID(ctx)}
+
+	return g.signalBill(ctx, id, workflows.SignalCloseBill, sig)
+}
+
+func (g *Gateway) RetryInvoicing(ctx context.Context, id domain.BillID) error {
+	sig := workflows.RetryInvoicingSignal{CorrelationID: app.CorrelationID(ctx)}
+
+	err := g.signalBill(ctx, id, workflows.SignalRetryInvoicing, sig)
+	if err != nil {
+		// completed workflow, i.e. manual retries are over, unless it's only the targeted run that's over
+		var nf *serviceerror.NotFound
+		if errors.As(err, &nf) && !errors.Is(err, app.ErrBillRunStale) {
+			return fmt.Errorf("%w: %w", domain.ErrInvoicingNotRetryable, err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (g *Gateway) ReconcileBill(ctx context.Context, id domain.BillID) error {
+	sig := workflows.ReconcileBillSignal{CorrelationID: app.CorrelationID(ctx)}
+
+	return g.signalBill(ctx, id, workflows.SignalReconcileBill, sig)
+}
+
+func (g *Gateway) TerminateBill(ctx context.Context, id domain.BillID, reason string) error {
+	runID := app.RunID(ctx)
+	err := g.tc.TerminateWorkflow(ctx, string(id), runID, reason, app.CorrelationID(ctx))
+	if isStaleRun(err, runID) {
+		return fmt.Errorf("%w: %w", app.ErrBillRunStale, err)
+	}
+	if err != nil {
+		// also a completed workflow, there's nothing to terminate
+		var nf *serviceerror.NotFound
+		if errors.As(err, &nf) {
+			return app.ErrBillNotFound
+		}
+
+		return fmt.Errorf("terminate bill: %w", err)
+	}
 
-	return g.tc.SignalWorkflow(ctx, string(id), runID, workflows.SignalCloseBill, nil)
+	return nil
 }
 
 func (g *Gateway) QueryBill(ctx context.Context, id domain.BillID) (domain.Bill, error) {
+	// Query by workflow ID; run ID "" is the latest run, see app.WithRunID
+	return g.QueryBillByExecution(ctx, string(id), app.RunID(ctx))
+}
+
+// GetBillMemo reads the memo of the run of ctx, the latest by default, it's set on start, only InvoiceURI and ErrorReason are added later.
+func (g *Gateway) GetBillMemo(ctx context.Context, id domain.BillID) (app.BillMemo, error) {
+	resp, err := g.tc.DescribeWorkflowExecution(ctx, string(id), app.RunID(ctx))
+	if err != nil {
+		var nf *serviceerror.NotFound
+		if errors.As(err, &nf) {
+			return app.BillMemo{}, app.ErrBillNotFound
+		}
+
+		return app.BillMemo{}, fmt.Errorf("describe bill: %w", err)
+	}
+
+	fields := resp.GetWorkflowExecutionInfo().GetMemo().GetFields()
+	dc := g.dc
+	var memo app.BillMemo
+	// a missing key stays empty, the memo only has what was given on start
+	if p := fields[app.MemoKeyCorrelationID]; p != nil {
+		err = decode(dc, p, &memo.CorrelationID)
+	}
+	if p := fields[app.MemoKeyCreateIdempotencyKey]; p != nil {
+		err = errors.Join(err, decode(dc, p, &memo.CreateIdempotencyKey))
+	}
+	if p := fields[app.MemoKeyInvoiceURI]; p != nil {
+		err = errors.Join(err, decode(dc, p, &memo.InvoiceURI))
+	}
+	if p := fields[app.MemoKeyErrorReason]; p != nil {
+		err = errors.Join(err, decode(dc, p, &memo.ErrorReason))
+	}
+	if p := fields[app.MemoKeyCreateParams]; p != nil {
+		var params app.MonthlyFeeAccrualWorkflowParams
+		err = errors.Join(err, decode(dc, p, &params))
+		memo.CreateParams = &params
+	}
+	if err != nil {
+		return app.BillMemo{}, fmt.Errorf("decode bill memo: %w", err)
+	}
+
+	return memo, nil
+}
+
+func (g *Gateway) QueryBillSummary(ctx context.Context, id domain.BillID) (views.BillStateSummary, error) {
 	// Queries can hang if a handler is busy. Wrap ctx
 	ctx, cancel := context.WithTimeout(ctx, queryTimeoutSeconds*time.Second)
 	defer cancel()
-	// Query by workflow ID; run ID can be "" (latest)
-	runID := ""
-	resp, err := g.tc.QueryWorkflow(ctx, string(id), runID, workflows.QueryState /* e.g., "CurrentBillState" */)
+	resp, err := g.queryWorkflow(ctx, string(id), app.RunID(ctx), workflows.QuerySummary)
 	if err != nil {
-		var nf *serviceerror.NotFound
-		if errors.As(err, &nf) {
-			return domain.Bill{}, app.ErrBillNotFound
+		if errors.Is(err, app.ErrBillNotFound) || errors.Is(err, app.ErrBillArchived) {
+			return views.BillStateSummary{}, err
+		}
+
+		return views.BillStateSummary{}, fmt.Errorf("query bill summary: %w", err)
+	}
+	var s workflows.BillSummaryDTO
+	if err := resp.Get(&s); err != nil {
+		return views.BillStateSummary{}, err
+	}
+
+	return views.BillStateSummary{
+		ID:            s.ID,
+		CustomerID:    s.CustomerID,
+		Currency:      s.Currency,
+		BillingPeriod: s.BillingPeriod,
+		Status:        s.Status,
+		Total:         s.Total,
+		ItemCount:     s.ItemCount,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+		ClosedAt:      s.ClosedAt,
+	}, nil
+}
+
+func (g *Gateway) QueryBillChangeLog(ctx context.Context, id domain.BillID) (views.BillChangeLog, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutSeconds*time.Second)
+	defer cancel()
+	resp, err := g.queryWorkflow(ctx, string(id), app.RunID(ctx), workflows.QueryChangeLog)
+	if err != nil {
+		if errors.Is(err, app.ErrBillNotFound) || errors.Is(err, app.ErrBillArchived) {
+			return views.BillChangeLog{}, err
+		}
+
+		return views.BillChangeLog{}, fmt.Errorf("query bill change log: %w", err)
+	}
+	var l workflows.ChangeLogDTO
+	if err := resp.Get(&l); err != nil {
+		return views.BillChangeLog{}, err
+	}
+
+	changes := make([]views.BillChange, 0, len(l.Entries))
+	for _, e := range l.Entries {
+		changes = append(changes, views.BillChange{
+			Type:           e.Type,
+			At:             e.At,
+			IdempotencyKey: e.IdempotencyKey,
+			Description:    e.Description,
+			Amount:         e.Amount,
+			FromStatus:     e.FromStatus,
+			ToStatus:       e.ToStatus,
+		})
+	}
+
+	return views.BillChangeLog{Changes: changes, Dropped: l.Dropped}, nil
+}
+
+// QueryBillByExecution lets ops query the exact run they see in Temporal UI, e.g. an older run of a Continue-As-New chain.
+func (g *Gateway) QueryBillByExecution(ctx context.Context, workflowID, runID string) (domain.Bill, error) {
+	// Queries can hang if a handler is busy. Wrap ctx
+	ctx, cancel := context.WithTimeout(ctx, queryTimeoutSeconds*time.Second)
+	defer cancel()
+	resp, err := g.queryWorkflow(ctx, workflowID, runID, workflows.QueryState /* e.g., "CurrentBillState" */)
+	if err != nil {
+		if errors.Is(err, app.ErrBillNotFound) || errors.Is(err, app.ErrBillArchived) {
+			return domain.Bill{}, err
 		}
 
 		return domain.Bill{}, fmt.Errorf("query bill: %w", err)
@@ -121,6 +583,7 @@ func (g *Gateway) QueryBill(ctx context.Context, id domain.BillID) (domain.Bill,
 			Description:    li.Description,
 			Amount:         li.Amount,
 			AddedAt:        li.AddedAt,
+			Tags:           li.Tags,
 		})
 	}
 
@@ -135,74 +598,346 @@ func (g *Gateway) QueryBill(ctx context.Context, id domain.BillID) (domain.Bill,
 		CreatedAt:     b.CreatedAt,
 		UpdatedAt:     b.UpdatedAt,
 		FinalizedAt:   b.ClosedAt,
+		Notes:         b.Notes,
+		InvoiceURI:    b.InvoiceURI,
+		PeriodStart:   b.PeriodStart,
+		PeriodEnd:     b.PeriodEnd,
+
+		InvoicingRetryable: b.InvoicingRetryable,
+		ItemsRequired:      b.ItemsRequired,
+		MaxItems:           b.MaxItems,
 	}, nil
 }
 
-func visQuote(s string) string {
-	s = strings.ReplaceAll(s, `\`, `\\`)
-	s = strings.ReplaceAll(s, `"`, `\"`)
+// queryWorkflow retries the transient errors with backoff until ctx is done, NotFound is returned right away as
+// app.ErrBillNotFound (or app.ErrBillArchived, see notFound). A query still failing transiently is app.ErrBillBusy: the bill exists, but no worker served
+// the query in time, e.g. it's stuck behind a long workflow task or the workers are down.
+func (g *Gateway) queryWorkflow(
+	ctx context.Context,
+	workflowID, runID, queryType string,
+) (_ converter.EncodedValue, err error) {
+	// one span for all the attempts, the caller waits for them all
+	ctx, span := g.startSpan(ctx, "temporal.QueryWorkflow",
+		attrBillID.String(workflowID), attrQueryType.String(queryType))
+	attempt := 0
+	defer func() {
+		span.SetAttributes(attrQueryAttempts.Int(attempt))
+		endSpan(span, err)
+	}()
+
+	attempts := max(g.queryRetry.MaxAttempts, 1)
+	interval := g.queryRetry.InitialInterval
+
+	for attempt = 1; ; attempt++ {
+		resp, err := g.tc.QueryWorkflow(ctx, workflowID, runID, queryType)
+		if err == nil {
+			return resp, nil
+		}
+		var nf *serviceerror.NotFound
+		if errors.As(err, &nf) {
+			return nil, g.notFound(ctx, workflowID)
+		}
+		if !isTransientQueryError(err) {
+			return nil, err
+		}
+		if attempt >= attempts || ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", app.ErrBillBusy, err)
+		}
+
+		t := time.NewTimer(jitter(interval))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+
+			return nil, fmt.Errorf("%w: %w", app.ErrBillBusy, err)
+		case <-t.C:
+		}
+		interval = min(interval*2, max(g.queryRetry.MaxInterval, g.queryRetry.InitialInterval))
+	}
+}
+
+// notFound tells a bill that never existed from one whose workflow was deleted after the retention period,
+// Temporal returns NotFound for both. With archivedLookup, a bill found in the visibility archive is
+// app.ErrBillArchived, anything else, the archival being disabled included, stays app.ErrBillNotFound.
+func (g *Gateway) notFound(ctx context.Context, workflowID string) error {
+	if !g.archivedLookup {
+		return app.ErrBillNotFound
+	}
+	resp, err := g.tc.ListArchivedWorkflow(ctx, &workflowservice.ListArchivedWorkflowExecutionsRequest{
+		Namespace: g.namespace,
+		PageSize:  1,
+		Query:     newVisibilityQuery().Equals("WorkflowId", workflowID).Build(),
+	})
+	if err != nil {
+		slog.DebugContext(ctx, "archived bill lookup failed", "workflow_id", workflowID, "err", err)
+
+		return app.ErrBillNotFound
+	}
+	if len(resp.GetExecutions()) == 0 {
+		return app.ErrBillNotFound
+	}
+
+	return app.ErrBillArchived
+}
+
+// isTransientQueryError tells the errors a retried query can get past, the frontend being unavailable
+// or the query timing out while the workflow is busy.
+func isTransientQueryError(err error) bool {
+	var unavailable *serviceerror.Unavailable
+	var deadline *serviceerror.DeadlineExceeded
+	var exhausted *serviceerror.ResourceExhausted
+
+	return errors.As(err, &unavailable) || errors.As(err, &deadline) || errors.As(err, &exhausted) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// RefreshSearchAttributes lists one page of running bills and signals each to re-upsert its SAs.
+// The signal is idempotent, so redoing a page after a failure is safe.
+func (g *Gateway) RefreshSearchAttributes(ctx context.Context, pageToken []byte) (app.RefreshPage, error) {
+	q := newVisibilityQuery().
+		Equals("WorkflowType", workflows.WorkflowTypeMonthlyBill).
+		Equals("ExecutionStatus", "Running").
+		Build()
+	resp, err := g.listWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		Namespace:     g.namespace,
+		Query:         q,
+		PageSize:      pageSize,
+		NextPageToken: pageToken,
+	})
+	if err != nil {
+		return app.RefreshPage{}, fmt.Errorf("list running bills: %w", err)
+	}
+
+	var page app.RefreshPage
+	for _, info := range resp.GetExecutions() {
+		wfID := info.GetExecution().GetWorkflowId()
+		err := g.signalWorkflow(ctx, wfID, "", workflows.SignalRefreshSearchAttributes, nil)
+		if err != nil {
+			var nf *serviceerror.NotFound
+			if errors.As(err, &nf) {
+				page.Skipped++
+
+				continue
+			}
+
+			return app.RefreshPage{}, fmt.Errorf("signal bill %s: %w", wfID, err)
+		}
+		page.Signaled++
+	}
+	page.NextPageToken = resp.GetNextPageToken()
+
+	return page, nil
+}
+
+// CountBills counts bills matching the filter without paging through executions.
+func (g *Gateway) CountBills(ctx context.Context, params app.SearchBillFilter) (int64, error) {
+	resp, err := g.tc.CountWorkflow(ctx, &workflowservice.CountWorkflowExecutionsRequest{
+		Namespace: g.namespace,
+		Query:     buildVisibilityQuery(params, g.now()),
+	})
+	if err != nil {
+		if isSearchAttributeNotRegistered(err) {
+			return 0, fmt.Errorf("%w: %w", app.ErrSearchAttributesNotRegistered, err)
+		}
+
+		return 0, err
+	}
+
+	return resp.GetCount(), nil
+}
+
+// buildVisibilityQuery is shared by SearchBills and CountBills so both always see the same bills,
+// now is the base for relative filters.
+func buildVisibilityQuery(params app.SearchBillFilter, now time.Time) string {
+	// String values are escaped by the builder, numbers and statuses are validated in the API layer.
+	q := newVisibilityQuery().
+		Equals("WorkflowType", workflows.WorkflowTypeMonthlyBill)
+	if !params.AllCustomers {
+		q.Equals(sa.CustomerIDName, params.CustomerID)
+	}
+	// status filter(s) with OR logic
+	q.In(sa.BillStatusName, params.Status).
+		// optional ranges, bounds are inclusive
+		GteOpt(sa.BillingPeriodNumName, params.FromYYYYMM).
+		LteOpt(sa.BillingPeriodNumName, params.ToYYYYMM).
+		GteOpt(sa.BillTotalCentsName, params.MinTotalCents).
+		LteOpt(sa.BillTotalCentsName, params.MaxTotalCents).
+		GteOpt(sa.BillItemCountName, params.MinItemCount).
+		LteOpt(sa.BillItemCountName, params.MaxItemCount)
+	// "now" is the server's, so clients don't compute dates on their side
+	if params.FinalizedWithinDays > 0 {
+		q.GteTime(sa.BillFinalizedAtName, now.AddDate(0, 0, -params.FinalizedWithinDays))
+	}
+	q.GteTimeOpt(sa.BillCreatedAtName, params.CreatedFrom).
+		LteTimeOpt(sa.BillCreatedAtName, params.CreatedTo).
+		// the period overlaps [ActiveFrom, ActiveTo]: it started by the end of the range and ended after its start
+		LteTimeOpt(sa.BillPeriodStartName, params.ActiveTo).
+		GteTimeOpt(sa.BillPeriodEndName, params.ActiveFrom)
 
-	return s
+	return q.Build()
 }
 
 func (g *Gateway) SearchBills(ctx context.Context, params app.SearchBillFilter) ([]views.BillSummary, error) {
 	// We don't use ListOpenWorkflow or ListClosedWorkflow because it's not domain specific status but technical one.
 	// E.g. we could have bill (i.e. Workflow in Closed domain status but workflow still executed in terms of sending
 	//	out invoices via payment gateway).
-	// SQL injection currently is protected by API layer validation, but for real public app here we should
-	//	apply additional checks and escaping.
+	q := buildVisibilityQuery(params, g.now())
+	if g.searchCache == nil {
+		return g.searchBills(ctx, q)
+	}
+	if bills, ok := g.searchCache.get(q, g.now()); ok {
+		return bills, nil
+	}
+	bills, err := g.searchBills(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	g.searchCache.put(q, bills, g.now())
+
+	return bills, nil
+}
+
+// SearchBillsPage lists one page of the bills matching the filter, uncached and without the search limits: the
+// caller streams the pages, so there's nothing held in memory to cap.
+func (g *Gateway) SearchBillsPage(
+	ctx context.Context,
+	params app.SearchBillFilter,
+	pageToken []byte,
+) (app.SearchBillPage, error) {
+	return g.listBillsPage(ctx, buildVisibilityQuery(params, g.now()), pageToken)
+}
 
-	// Build query with required filters
-	queryParts := []string{
-		fmt.Sprintf(`WorkflowType = "%s"`, workflows.WorkflowTypeMonthlyBill),
-		fmt.Sprintf(`CustomerID = "%s"`, visQuote(params.CustomerID)),
+// listBillsPage lists the page of the bills matching q that token points to, nil is the first page.
+func (g *Gateway) listBillsPage(ctx context.Context, q string, token []byte) (app.SearchBillPage, error) {
+	resp, err := g.listWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		Namespace:     g.namespace,
+		Query:         q,
+		PageSize:      pageSize,
+		NextPageToken: token,
+	})
+	if err != nil {
+		if isSearchAttributeNotRegistered(err) {
+			return app.SearchBillPage{}, fmt.Errorf("%w: %w", app.ErrSearchAttributesNotRegistered, err)
+		}
+
+		return app.SearchBillPage{}, err
 	}
 
-	// Add status filter(s) with OR logic
-	if len(params.Status) > 0 {
-		statusConditions := make([]string, len(params.Status))
-		for i, status := range params.Status {
-			statusConditions[i] = fmt.Sprintf(`BillStatus = "%s"`, status)
+	page := app.SearchBillPage{NextPageToken: resp.GetNextPageToken()}
+	for _, info := range resp.GetExecutions() {
+		sum, err := mapInfoToSummary(g.dc, g.namespace, info)
+		if err != nil {
+			return app.SearchBillPage{}, fmt.Errorf("search attributes extraction error, %w", err)
 		}
-		queryParts = append(queryParts, fmt.Sprintf("(%s)", strings.Join(statusConditions, " OR ")))
+		page.Bills = append(page.Bills, sum)
+	}
+
+	return page, nil
+}
+
+// AggregateBillTotals pages through the customer's bills like SearchBills, uncached, and sums them up.
+func (g *Gateway) AggregateBillTotals(
+	ctx context.Context,
+	customerID string,
+	from, to *int64,
+) ([]views.BillPeriodTotal, error) {
+	q := buildVisibilityQuery(app.SearchBillFilter{CustomerID: customerID, FromYYYYMM: from, ToYYYYMM: to}, g.now())
+	bills, err := g.searchBills(ctx, q)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add optional date range filters
-	if params.FromYYYYMM != nil {
-		queryParts = append(queryParts, fmt.Sprintf(`BillingPeriodNum >= %d`, *params.FromYYYYMM))
+	type key struct {
+		period   int64
+		currency string
 	}
-	if params.ToYYYYMM != nil {
-		queryParts = append(queryParts, fmt.Sprintf(`BillingPeriodNum <= %d`, *params.ToYYYYMM))
+	totals := make(map[key]*views.BillPeriodTotal)
+	for _, b := range bills {
+		k := key{b.BillingPeriodNum, b.Currency}
+		t, ok := totals[k]
+		if !ok {
+			t = &views.BillPeriodTotal{PeriodYYYYMM: b.BillingPeriodNum, Currency: b.Currency}
+			totals[k] = t
+		}
+		t.TotalCents += b.TotalCents
+		t.Count++
 	}
 
-	q := strings.Join(queryParts, " AND ")
-	var out []views.BillSummary
-	var token []byte
-	dc := converter.GetDefaultDataConverter()
+	out := make([]views.BillPeriodTotal, 0, len(totals))
+	for _, t := range totals {
+		out = append(out, *t)
+	}
+	slices.SortFunc(out, func(a, b views.BillPeriodTotal) int {
+		return cmp.Or(cmp.Compare(a.PeriodYYYYMM, b.PeriodYYYYMM), strings.Compare(a.Currency, b.Currency))
+	})
 
-	for {
-		resp, err := g.tc.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
-			Namespace:     g.namespace,
-			Query:         q,
-			PageSize:      pageSize,
-			NextPageToken: token,
-		})
+	return out, nil
+}
+
+func (g *Gateway) ListBillPeriods(ctx context.Context, customerID string) ([]string, error) {
+	q := buildVisibilityQuery(app.SearchBillFilter{CustomerID: customerID}, g.now())
+	bills, err := g.searchBills(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	nums := make([]int64, 0, len(bills))
+	for _, b := range bills {
+		nums = append(nums, b.BillingPeriodNum)
+	}
+	// YYYYMM numbers sort like the periods
+	slices.Sort(nums)
+	nums = slices.Compact(nums)
+	slices.Reverse(nums)
+
+	periods := make([]string, 0, len(nums))
+	for _, n := range nums {
+		p, err := libtime.FromYYYYMM(n)
 		if err != nil {
-			return nil, err
+			// a malformed BillingPeriodNum, there's no period to offer
+			slog.WarnContext(ctx, "skipping a bill period", "customer_id", customerID, "err", err)
+
+			continue
 		}
+		periods = append(periods, p)
+	}
 
-		for _, info := range resp.GetExecutions() {
-			sum, err := mapInfoToSummary(dc, info)
-			if err != nil {
-				return nil, fmt.Errorf("search attributes extraction error, %w", err)
+	return periods, nil
+}
+
+// searchBills pages through the visibility query within the search limits.
+func (g *Gateway) searchBills(ctx context.Context, q string) ([]views.BillSummary, error) {
+	var out []views.BillSummary
+	var token []byte
+
+	if g.searchMaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.searchMaxDuration)
+		defer cancel()
+	}
+
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("search bills stopped after %d pages: %w", page-1, err)
+		}
+		p, err := g.listBillsPage(ctx, q, token)
+		if err != nil {
+			// the client reports a deadline as a gRPC status, keep the context error so callers can tell
+			if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+				return nil, fmt.Errorf("search bills stopped on page %d: %w", page, ctxErr)
 			}
-			out = append(out, sum)
+
+			return nil, err
 		}
+		out = append(out, p.Bills...)
 
-		if len(resp.GetNextPageToken()) == 0 {
+		if len(p.NextPageToken) == 0 {
 			break
 		}
-		token = resp.GetNextPageToken()
+		if g.searchMaxPages > 0 && page >= g.searchMaxPages {
+			return nil, fmt.Errorf("%w: more than %d bills", app.ErrTooManyBills, page*pageSize)
+		}
+		token = p.NextPageToken
 	}
 
 	return out, nil
@@ -216,13 +951,33 @@ func decode[T any](dc converter.DataConverter, p *commonpb.Payload, out *T) erro
 	return dc.FromPayload(p, out)
 }
 
-func mapInfoToSummary(dc converter.DataConverter, info *workflowpb.WorkflowExecutionInfo) (views.BillSummary, error) {
+// decodeTimeOpt decodes a Datetime SA, nil when the bill doesn't have it.
+func decodeTimeOpt(dc converter.DataConverter, p *commonpb.Payload) (*time.Time, error) {
+	if p == nil {
+		return nil, nil
+	}
+	var t time.Time
+	if err := dc.FromPayload(p, &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// mapInfoToSummary takes the task queue from the execution info, as a bill may run on a queue other than DefaultTaskQueue.
+func mapInfoToSummary(
+	dc converter.DataConverter,
+	namespace string,
+	info *workflowpb.WorkflowExecutionInfo,
+) (views.BillSummary, error) {
 	attrs := info.GetSearchAttributes().GetIndexedFields()
 	get := func(key string) *commonpb.Payload { return attrs[key] }
 
 	sum := views.BillSummary{
 		WorkflowID: info.GetExecution().GetWorkflowId(),
 		RunID:      info.GetExecution().GetRunId(),
+		Namespace:  namespace,
+		TaskQueue:  info.GetTaskQueue(),
 	}
 	// Decode typed SAs we expect (ignore missing ones gracefully).
 	err := decode(dc, get(sa.CustomerIDName), &sum.CustomerID)
@@ -234,10 +989,26 @@ func mapInfoToSummary(dc converter.DataConverter, info *workflowpb.WorkflowExecu
 	if err != nil {
 		return views.BillSummary{}, err
 	}
-
-	// Datetime SAs decode straight into time.Time
-	// err = decode(dc, get(sa.PeriodStart), &sum.PeriodStart)
-	// err = decode(dc, get(sa.PeriodEnd), &sum.PeriodEnd)
+	// BillUpdatedAt and BillCreatedAt came later, older bills may not have them
+	if sum.UpdatedAt, err = decodeTimeOpt(dc, get(sa.BillUpdatedAtName)); err != nil {
+		return views.BillSummary{}, err
+	}
+	if sum.CreatedAt, err = decodeTimeOpt(dc, get(sa.BillCreatedAtName)); err != nil {
+		return views.BillSummary{}, err
+	}
+	// BillPeriodStart and BillPeriodEnd too, older bills have them once refreshed
+	if sum.PeriodStart, err = decodeTimeOpt(dc, get(sa.BillPeriodStartName)); err != nil {
+		return views.BillSummary{}, err
+	}
+	if sum.PeriodEnd, err = decodeTimeOpt(dc, get(sa.BillPeriodEndName)); err != nil {
+		return views.BillSummary{}, err
+	}
+	// the memo only has the reason once the bill errored
+	if p := info.GetMemo().GetFields()[app.MemoKeyErrorReason]; p != nil {
+		if err = decode(dc, p, &sum.ErrorReason); err != nil {
+			return views.BillSummary{}, err
+		}
+	}
 
 	// Optional summaries to upsert in the workflow
 	// err = decode(dc, get(sa.TotalCents), &sum.TotalCents)
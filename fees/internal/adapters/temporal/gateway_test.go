@@ -3,11 +3,13 @@ package temporal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	commonpb "go.temporal.io/api/common/v1"
 	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/operatorservice/v1"
@@ -16,11 +18,14 @@ import (
 	"go.temporal.io/api/workflowservice/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
 	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
 )
 
@@ -387,6 +392,461 @@ func TestGateway_StartMonthlyBill(t *testing.T) {
 	}
 }
 
+func TestGateway_StartMonthlyBill_CreatedAt(t *testing.T) {
+	now := time.Date(2025, 1, 15, 14, 30, 0, 0, time.FixedZone("UTC+4", 4*60*60))
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		createdAt, ok := opts.TypedSearchAttributes.GetTime(sa.KeyBillCreatedAt)
+		updatedAt, _ := opts.TypedSearchAttributes.GetTime(sa.KeyBillUpdatedAt)
+		return ok && createdAt.Equal(now) && createdAt.Location() == time.UTC && updatedAt.Equal(createdAt)
+	}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+	gateway := NewGateway(mockClient, "test-namespace")
+	gateway.now = func() time.Time { return now }
+
+	err := gateway.StartMonthlyBill(context.Background(), app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-123"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	})
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_StartMonthlyBill_PeriodBounds(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		periodStart, okStart := opts.TypedSearchAttributes.GetTime(sa.KeyBillPeriodStart)
+		periodEnd, okEnd := opts.TypedSearchAttributes.GetTime(sa.KeyBillPeriodEnd)
+		return okStart && okEnd &&
+			periodStart.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) &&
+			periodEnd.Equal(time.Date(2024, 2, 29, 23, 59, 59, 999999999, time.UTC))
+	}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+	err := NewGateway(mockClient, "test-namespace").StartMonthlyBill(context.Background(), app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-123"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2024-02"),
+		PeriodYYYYMM: 202402,
+		Currency:     libmoney.CurrencyUSD,
+	})
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_StartMonthlyBill_ActivityTaskQueue(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockRun := &MockWorkflowRun{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything,
+		mock.MatchedBy(func(args []interface{}) bool {
+			if len(args) != 1 {
+				return false
+			}
+			params, ok := args[0].(app.MonthlyFeeAccrualWorkflowParams)
+			return ok && params.ActivityTaskQueue == "FEES_ACTIVITY_TASK_QUEUE"
+		})).Return(mockRun, nil)
+
+	gateway := NewGateway(mockClient, "test-namespace").WithActivityTaskQueue("FEES_ACTIVITY_TASK_QUEUE")
+
+	err := gateway.StartMonthlyBill(context.Background(), app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-123"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	})
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_StartMonthlyBill_TaskQueueByCurrency(t *testing.T) {
+	tests := []struct {
+		name      string
+		currency  libmoney.Currency
+		wantQueue string
+	}{
+		{name: "EUR bill starts on the EU queue", currency: "EUR", wantQueue: "FEES_EU_TASK_QUEUE"},
+		{name: "unrouted currency starts on the default queue", currency: libmoney.CurrencyUSD, wantQueue: DefaultTaskQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+				return opts.TaskQueue == tt.wantQueue
+			}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+			gateway := NewGateway(mockClient, "test-namespace").
+				WithTaskQueueFor(TaskQueueByCurrency(map[libmoney.Currency]string{"EUR": "FEES_EU_TASK_QUEUE"}))
+
+			err := gateway.StartMonthlyBill(context.Background(), app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       domain.BillID("test-bill-123"),
+				CustomerID:   "customer-123",
+				Period:       domain.BillingPeriod("2025-01"),
+				PeriodYYYYMM: 202501,
+				Currency:     tt.currency,
+			})
+
+			assert.NoError(t, err)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGateway_StartCreditNote(t *testing.T) {
+	note := domain.CreditNote{
+		ID:     domain.MakeCreditNoteID("bill/customer-123/2025-01", "goodwill-1"),
+		BillID: "bill/customer-123/2025-01",
+		Total:  libmoney.NewFromInt(-5, libmoney.CurrencyUSD),
+		Reason: "outage",
+	}
+	ctx := app.WithCorrelationID(context.Background(), "req-42")
+
+	t.Run("started with the link to the bill", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			return opts.ID == "credit/bill/customer-123/2025-01/goodwill-1" &&
+				opts.WorkflowIDReusePolicy == enums.WORKFLOW_ID_REUSE_POLICY_REJECT_DUPLICATE &&
+				opts.Memo[app.MemoKeyOriginalBillID] == "bill/customer-123/2025-01" &&
+				opts.Memo[app.MemoKeyCorrelationID] == "req-42"
+		}), mock.Anything, []interface{}{app.CreditNoteWorkflowParams{CreditNote: note}}).Return(&MockWorkflowRun{}, nil)
+
+		err := NewGateway(mockClient, "test-namespace").StartCreditNote(ctx, note)
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("on the task queue of the bill", func(t *testing.T) {
+		gel := note
+		gel.Currency = libmoney.CurrencyGEL
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			return opts.TaskQueue == "FEES_GE"
+		}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+		err := NewGateway(mockClient, "test-namespace").
+			WithTaskQueueFor(TaskQueueByCurrency(map[libmoney.Currency]string{libmoney.CurrencyGEL: "FEES_GE"})).
+			StartCreditNote(ctx, gel)
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("same idempotency key", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&MockWorkflowRun{}, serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", ""))
+
+		err := NewGateway(mockClient, "test-namespace").StartCreditNote(ctx, note)
+
+		assert.ErrorIs(t, err, app.ErrCreditNoteAlreadyExists)
+	})
+
+	t.Run("other start error", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(&MockWorkflowRun{}, errors.New("unavailable"))
+
+		err := NewGateway(mockClient, "test-namespace").StartCreditNote(ctx, note)
+
+		assert.ErrorContains(t, err, "unavailable")
+		assert.NotErrorIs(t, err, app.ErrCreditNoteAlreadyExists)
+	})
+}
+
+func TestGateway_ListCreditNotes(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	note := domain.CreditNote{
+		ID:     domain.MakeCreditNoteID(billID, "goodwill-1"),
+		BillID: billID,
+		Total:  libmoney.NewFromInt(-5, libmoney.CurrencyUSD),
+		Reason: "outage",
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == `WorkflowType = "CreditNoteWorkflow" AND WorkflowId STARTS_WITH "credit/bill/customer-123/2025-01/"`
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: string(note.ID), RunId: "run-1"},
+		}},
+	}, nil)
+	run := &MockWorkflowRun{}
+	run.On("Get", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(1).(*domain.CreditNote) = note
+	}).Return(nil)
+	mockClient.On("GetWorkflow", mock.Anything, string(note.ID), "run-1").Return(run)
+
+	notes, err := NewGateway(mockClient, "test-namespace").ListCreditNotes(context.Background(), billID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []domain.CreditNote{note}, notes)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_CorrelationID(t *testing.T) {
+	ctx := app.WithCorrelationID(context.Background(), "req-42")
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-123"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	}
+
+	t.Run("memo is set on start", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			return opts.Memo[app.MemoKeyCorrelationID] == "req-42"
+		}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+		err := NewGateway(mockClient, "test-namespace").StartMonthlyBill(ctx, params)
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("not in memo without correlation ID", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+			_, ok := opts.Memo[app.MemoKeyCorrelationID]
+			return !ok
+		}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+		err := NewGateway(mockClient, "test-namespace").StartMonthlyBill(context.Background(), params)
+
+		assert.NoError(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("signals carry it", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalAddLineItem",
+			mock.MatchedBy(func(pl workflows.AddLineItemPayload) bool { return pl.CorrelationID == "req-42" })).
+			Return(nil)
+		mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalCloseBill",
+			workflows.CloseBillSignal{CorrelationID: "req-42"}).Return(nil)
+		gateway := NewGateway(mockClient, "test-namespace")
+
+		assert.NoError(t, gateway.AddLineItem(ctx, "test-bill-123", domain.LineItem{IdempotencyKey: "item-1"}))
+		assert.NoError(t, gateway.CloseBill(ctx, "test-bill-123"))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestGateway_StartMonthlyBill_IdempotencyKeyMemo(t *testing.T) {
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:               domain.BillID("test-bill-123"),
+		CustomerID:           "customer-123",
+		Period:               domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM:         202501,
+		Currency:             libmoney.CurrencyUSD,
+		CreateIdempotencyKey: "create-1",
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.MatchedBy(func(opts client.StartWorkflowOptions) bool {
+		return opts.Memo[app.MemoKeyCreateIdempotencyKey] == "create-1" &&
+			opts.Memo[app.MemoKeyCorrelationID] == "req-42"
+	}), mock.Anything, mock.Anything).Return(&MockWorkflowRun{}, nil)
+
+	ctx := app.WithCorrelationID(context.Background(), "req-42")
+	err := NewGateway(mockClient, "test-namespace").StartMonthlyBill(ctx, params)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_StartMonthlyBill_CreateParamsMemo(t *testing.T) {
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:            domain.BillID("test-bill-123"),
+		CustomerID:        "customer-123",
+		Period:            domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM:      202501,
+		Currency:          libmoney.CurrencyUSD,
+		Jurisdiction:      "US-CA",
+		InvoiceRetry:      app.RetryConfig{MaximumAttempts: 3, InitialInterval: time.Second},
+		ActivityTaskQueue: "activities",
+		MinChargeMinor:    50,
+		AutoClose:         true,
+		MaxItems:          100,
+		Template: []domain.LineItem{
+			{IdempotencyKey: "platform", Description: "Platform fee", Amount: libmoney.NewFromInt(10, libmoney.CurrencyUSD)},
+		},
+	}
+	var started map[string]interface{}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { started = args.Get(1).(client.StartWorkflowOptions).Memo }).
+		Return(&MockWorkflowRun{}, nil)
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	err := gateway.StartMonthlyBill(context.Background(), params)
+	assert.NoError(t, err)
+
+	// read it back the way Temporal stores it, encoded by the data converter
+	fields := map[string]*commonpb.Payload{}
+	for k, v := range started {
+		p, err := converter.GetDefaultDataConverter().ToPayload(v)
+		assert.NoError(t, err)
+		fields[k] = p
+	}
+	mockClient.On("DescribeWorkflowExecution", mock.Anything, "test-bill-123", "").
+		Return(&workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Memo: &commonpb.Memo{Fields: fields}},
+		}, nil)
+
+	memo, err := gateway.GetBillMemo(context.Background(), "test-bill-123")
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, memo.CreateParams) {
+		assert.Equal(t, params, *memo.CreateParams)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_GetBillMemo(t *testing.T) {
+	payload := func(v string) *commonpb.Payload {
+		p, err := converter.GetDefaultDataConverter().ToPayload(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return p
+	}
+	describe := func(fields map[string]*commonpb.Payload) *workflowservice.DescribeWorkflowExecutionResponse {
+		return &workflowservice.DescribeWorkflowExecutionResponse{
+			WorkflowExecutionInfo: &workflowpb.WorkflowExecutionInfo{Memo: &commonpb.Memo{Fields: fields}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		resp          *workflowservice.DescribeWorkflowExecutionResponse
+		err           error
+		expected      app.BillMemo
+		expectedError error
+	}{
+		{
+			name: "both keys",
+			resp: describe(map[string]*commonpb.Payload{
+				app.MemoKeyCorrelationID:        payload("req-42"),
+				app.MemoKeyCreateIdempotencyKey: payload("create-1"),
+			}),
+			expected: app.BillMemo{CorrelationID: "req-42", CreateIdempotencyKey: "create-1"},
+		},
+		{
+			name: "invoice archived",
+			resp: describe(map[string]*commonpb.Payload{
+				app.MemoKeyCorrelationID: payload("req-42"),
+				app.MemoKeyInvoiceURI:    payload("s3://invoices/customer-123/2025-01.json"),
+			}),
+			expected: app.BillMemo{CorrelationID: "req-42", InvoiceURI: "s3://invoices/customer-123/2025-01.json"},
+		},
+		{
+			name:     "no memo",
+			resp:     describe(nil),
+			expected: app.BillMemo{},
+		},
+		{
+			name:          "not found",
+			resp:          (*workflowservice.DescribeWorkflowExecutionResponse)(nil),
+			err:           serviceerror.NewNotFound("workflow not found"),
+			expectedError: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("DescribeWorkflowExecution", mock.Anything, "test-bill-123", "").Return(tt.resp, tt.err)
+
+			memo, err := NewGateway(mockClient, "test-namespace").GetBillMemo(context.Background(), "test-bill-123")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, memo)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGateway_StartMonthlyBill_SearchAttributesNotRegistered(t *testing.T) {
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       domain.BillID("test-bill-123"),
+		CustomerID:   "customer-123",
+		Period:       domain.BillingPeriod("2025-01"),
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	}
+	withSAs := mock.MatchedBy(func(opts client.StartWorkflowOptions) bool { return opts.TypedSearchAttributes.Size() > 0 })
+	withoutSAs := mock.MatchedBy(func(opts client.StartWorkflowOptions) bool { return opts.TypedSearchAttributes.Size() == 0 })
+	skipped := mock.MatchedBy(func(args []interface{}) bool {
+		p, ok := args[0].(app.MonthlyFeeAccrualWorkflowParams)
+		return ok && p.SkipSearchAttributes
+	})
+
+	tests := []struct {
+		name          string
+		notRegistered error
+		retryErr      error
+		expectedError error
+	}{
+		{
+			name:          "not defined, started without search attributes",
+			notRegistered: serviceerror.NewInvalidArgument("search attribute BillStatus is not defined"),
+		},
+		{
+			name:          "no mapping defined, started without search attributes",
+			notRegistered: serviceerror.NewInvalidArgument("Namespace test-namespace has no mapping defined for search attribute CustomerID"),
+		},
+		{
+			name:          "retry hits already started",
+			notRegistered: serviceerror.NewInvalidArgument("search attribute BillStatus is not defined"),
+			retryErr:      serviceerror.NewWorkflowExecutionAlreadyStarted("already started", "", ""),
+			expectedError: app.ErrBillWithPeriodAlreadyStarted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockRun := &MockWorkflowRun{}
+			mockClient.On("ExecuteWorkflow", mock.Anything, withSAs, mock.Anything, mock.Anything).
+				Return((*MockWorkflowRun)(nil), tt.notRegistered).Once()
+			mockClient.On("ExecuteWorkflow", mock.Anything, withoutSAs, mock.Anything, skipped).
+				Return(mockRun, tt.retryErr).Once()
+
+			gateway := NewGateway(mockClient, "test-namespace")
+
+			err := gateway.StartMonthlyBill(context.Background(), params)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestIsSearchAttributeNotRegistered(t *testing.T) {
+	assert.True(t, isSearchAttributeNotRegistered(serviceerror.NewInvalidArgument("search attribute BillStatus is not defined")))
+	assert.True(t, isSearchAttributeNotRegistered(fmt.Errorf("wrapped, %w",
+		serviceerror.NewInvalidArgument("Namespace default has no mapping defined for search attribute BillStatus"))))
+	assert.False(t, isSearchAttributeNotRegistered(serviceerror.NewInvalidArgument("invalid query")))
+	assert.False(t, isSearchAttributeNotRegistered(errors.New("search attribute BillStatus is not defined")))
+	assert.False(t, isSearchAttributeNotRegistered(nil))
+}
+
 func TestGateway_AddLineItem(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -448,6 +908,91 @@ func TestGateway_AddLineItem(t *testing.T) {
 	}
 }
 
+func TestGateway_UpdateLineItemDescription(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	expected := workflows.UpdateLineItemDescriptionPayload{IdempotencyKey: "item-1", NewDescription: "API usage fee"}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalUpdateLineItemDescription", expected).
+		Return(nil)
+
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	err := gateway.UpdateLineItemDescription(context.Background(), "test-bill-123", "item-1", "API usage fee")
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_CorrectLineItemAmount(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	amount, _ := libmoney.NewFromString("7.50", libmoney.CurrencyNone)
+	expected := workflows.CorrectLineItemAmountPayload{IdempotencyKey: "item-1", NewAmount: amount}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalCorrectLineItemAmount", expected).
+		Return(nil)
+
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	err := gateway.CorrectLineItemAmount(context.Background(), "test-bill-123", "item-1", amount)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_SetBillNote(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	expected := workflows.SetBillNotePayload{Note: "split the invoice"}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalSetBillNote", expected).
+		Return(nil)
+
+	err := NewGateway(mockClient, "test-namespace").SetBillNote(context.Background(), "test-bill-123", "split the invoice")
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_ReconcileBill(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalReconcileBill",
+		workflows.ReconcileBillSignal{}).Return(nil)
+
+	err := NewGateway(mockClient, "test-namespace").ReconcileBill(context.Background(), "test-bill-123")
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_TerminateBill(t *testing.T) {
+	tests := []struct {
+		name          string
+		terminateErr  error
+		expectedError error
+	}{
+		{name: "successful termination"},
+		{
+			name:          "missing or completed workflow",
+			terminateErr:  serviceerror.NewNotFound("workflow execution already completed"),
+			expectedError: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("TerminateWorkflow", mock.Anything, "test-bill-123", "", "stuck in signal loop",
+				[]interface{}{"corr-1"}).Return(tt.terminateErr)
+
+			ctx := app.WithCorrelationID(context.Background(), "corr-1")
+			err := NewGateway(mockClient, "test-namespace").TerminateBill(ctx, "test-bill-123", "stuck in signal loop")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGateway_CloseBill(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -459,7 +1004,7 @@ func TestGateway_CloseBill(t *testing.T) {
 			name:   "successful bill close",
 			billID: domain.BillID("test-bill-123"),
 			mockSetup: func(mockClient *MockTemporalClient) {
-				mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalCloseBill", nil).
+				mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalCloseBill", workflows.CloseBillSignal{}).
 					Return(nil)
 			},
 			expectedError: "",
@@ -468,7 +1013,7 @@ func TestGateway_CloseBill(t *testing.T) {
 			name:   "signal workflow error",
 			billID: domain.BillID("test-bill-456"),
 			mockSetup: func(mockClient *MockTemporalClient) {
-				mockClient.On("SignalWorkflow", mock.Anything, "test-bill-456", "", "SignalCloseBill", nil).
+				mockClient.On("SignalWorkflow", mock.Anything, "test-bill-456", "", "SignalCloseBill", workflows.CloseBillSignal{}).
 					Return(errors.New("signal failed"))
 			},
 			expectedError: "signal failed",
@@ -496,6 +1041,142 @@ func TestGateway_CloseBill(t *testing.T) {
 	}
 }
 
+func TestGateway_RetryInvoicing(t *testing.T) {
+	tests := []struct {
+		name          string
+		signalErr     error
+		expectedError error
+	}{
+		{name: "successful retry signal"},
+		{
+			name:          "completed workflow, retries are over",
+			signalErr:     serviceerror.NewNotFound("workflow execution already completed"),
+			expectedError: domain.ErrInvoicingNotRetryable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", "SignalRetryInvoicing", workflows.RetryInvoicingSignal{}).
+				Return(tt.signalErr)
+
+			gateway := NewGateway(mockClient, "test-namespace")
+
+			err := gateway.RetryInvoicing(context.Background(), domain.BillID("test-bill-123"))
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGateway_SignalRunID(t *testing.T) {
+	const runID = "5f8a2c1e-7b3d-4e9f-a1c2-3d4e5f6a7b8c"
+	tests := []struct {
+		name          string
+		runID         string
+		signalErr     error
+		expectedError error
+	}{
+		{name: "latest run by default"},
+		{name: "explicit run", runID: runID},
+		{
+			name:          "explicit run no longer running",
+			runID:         runID,
+			signalErr:     serviceerror.NewNotFound("workflow execution already completed"),
+			expectedError: app.ErrBillRunStale,
+		},
+		{
+			name:          "latest run not found is not stale",
+			signalErr:     serviceerror.NewNotFound("workflow not found"),
+			expectedError: &serviceerror.NotFound{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", tt.runID, "SignalCloseBill", mock.Anything).
+				Return(tt.signalErr).Once()
+
+			ctx := context.Background()
+			if tt.runID != "" {
+				ctx = app.WithRunID(ctx, tt.runID)
+			}
+			err := NewGateway(mockClient, "test-namespace").CloseBill(ctx, domain.BillID("test-bill-123"))
+
+			switch want := tt.expectedError.(type) {
+			case nil:
+				assert.NoError(t, err)
+			case *serviceerror.NotFound:
+				assert.ErrorAs(t, err, &want)
+				assert.NotErrorIs(t, err, app.ErrBillRunStale)
+			default:
+				assert.ErrorIs(t, err, want)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGateway_RetryInvoicing_StaleRun(t *testing.T) {
+	const runID = "5f8a2c1e-7b3d-4e9f-a1c2-3d4e5f6a7b8c"
+	mockClient := &MockTemporalClient{}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", runID, "SignalRetryInvoicing", mock.Anything).
+		Return(serviceerror.NewNotFound("workflow execution already completed")).Once()
+
+	err := NewGateway(mockClient, "test-namespace").
+		RetryInvoicing(app.WithRunID(context.Background(), runID), domain.BillID("test-bill-123"))
+
+	// the run continued as new, the bill itself may still be retryable
+	assert.ErrorIs(t, err, app.ErrBillRunStale)
+	assert.NotErrorIs(t, err, domain.ErrInvoicingNotRetryable)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_QueryRunID(t *testing.T) {
+	const runID = "5f8a2c1e-7b3d-4e9f-a1c2-3d4e5f6a7b8c"
+	tests := []struct {
+		name  string
+		runID string
+	}{
+		{name: "latest run by default"},
+		{name: "explicit run", runID: runID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockValue := &MockEncodedValue{}
+			mockValue.On("Get", mock.AnythingOfType("*workflows.BillDTO")).Run(func(args mock.Arguments) {
+				args.Get(0).(*workflows.BillDTO).ID = "test-bill-123"
+			}).Return(nil)
+			mockValue.On("Get", mock.AnythingOfType("*workflows.BillSummaryDTO")).Return(nil)
+			mockClient := &MockTemporalClient{}
+			mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", tt.runID, workflows.QueryState, mock.Anything).
+				Return(mockValue, nil).Once()
+			mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", tt.runID, workflows.QuerySummary, mock.Anything).
+				Return(mockValue, nil).Once()
+
+			ctx := context.Background()
+			if tt.runID != "" {
+				ctx = app.WithRunID(ctx, tt.runID)
+			}
+			gateway := NewGateway(mockClient, "test-namespace")
+			bill, err := gateway.QueryBill(ctx, domain.BillID("test-bill-123"))
+			assert.NoError(t, err)
+			assert.Equal(t, domain.BillID("test-bill-123"), bill.ID)
+			_, err = gateway.QueryBillSummary(ctx, domain.BillID("test-bill-123"))
+			assert.NoError(t, err)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
 func TestGateway_QueryBill(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -604,6 +1285,310 @@ func TestGateway_QueryBill(t *testing.T) {
 	}
 }
 
+func TestGateway_QueryBill_Retries(t *testing.T) {
+	unavailable := serviceerror.NewUnavailable("frontend restarting")
+	deadline := serviceerror.NewDeadlineExceeded("query timed out")
+	tests := []struct {
+		name      string
+		errs      []error // returned by the successive attempts, the attempt after the last one succeeds
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "not found is not retried", errs: []error{&serviceerror.NotFound{Message: "not found"}}, wantCalls: 1, wantErr: app.ErrBillNotFound},
+		{name: "unavailable then served", errs: []error{unavailable}, wantCalls: 2},
+		{name: "deadline then served", errs: []error{deadline, deadline}, wantCalls: 3},
+		{name: "busy after the last attempt", errs: []error{deadline, unavailable, deadline}, wantCalls: 3, wantErr: app.ErrBillBusy},
+		{name: "handler failure is not retried", errs: []error{serviceerror.NewQueryFailed("boom")}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockValue := &MockEncodedValue{}
+			for _, err := range tt.errs {
+				mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "CurrentBillState", mock.Anything).
+					Return(mockValue, err).Once()
+			}
+			if len(tt.errs) < tt.wantCalls {
+				mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "CurrentBillState", mock.Anything).
+					Return(mockValue, nil).Once()
+				mockValue.On("Get", mock.AnythingOfType("*workflows.BillDTO")).Run(func(args mock.Arguments) {
+					args.Get(0).(*workflows.BillDTO).ID = "test-bill-123"
+				}).Return(nil)
+			}
+
+			gateway := NewGateway(mockClient, "test-namespace")
+			gateway.queryRetry = DialRetry{MaxAttempts: 3, InitialInterval: time.Millisecond}
+
+			bill, err := gateway.QueryBill(context.Background(), "test-bill-123")
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case len(tt.errs) < tt.wantCalls:
+				assert.NoError(t, err)
+				assert.Equal(t, domain.BillID("test-bill-123"), bill.ID)
+			default:
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, app.ErrBillBusy)
+				assert.NotErrorIs(t, err, app.ErrBillNotFound)
+			}
+			mockClient.AssertNumberOfCalls(t, "QueryWorkflow", tt.wantCalls)
+		})
+	}
+}
+
+func TestGateway_QueryBill_BusyUntilDeadline(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "CurrentBillState", mock.Anything).
+		Return(&MockEncodedValue{}, serviceerror.NewUnavailable("frontend restarting"))
+
+	gateway := NewGateway(mockClient, "test-namespace")
+	gateway.queryRetry = DialRetry{MaxAttempts: 100, InitialInterval: 20 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := gateway.QueryBill(ctx, "test-bill-123")
+
+	assert.ErrorIs(t, err, app.ErrBillBusy)
+	assert.Less(t, len(mockClient.Calls), 100, "the retries stop with the caller deadline")
+}
+
+func TestGateway_QueryBill_Archived(t *testing.T) {
+	notFound := &serviceerror.NotFound{Message: "Workflow execution not found"}
+	isBillLookup := mock.MatchedBy(func(req *workflowservice.ListArchivedWorkflowExecutionsRequest) bool {
+		return req.Namespace == "test-namespace" && req.Query == `WorkflowId = "test-bill-123"`
+	})
+	tests := []struct {
+		name      string
+		lookup    bool
+		mockSetup func(*MockTemporalClient)
+		wantErr   error
+	}{
+		{
+			name:    "without the lookup a bill past retention is not found",
+			wantErr: app.ErrBillNotFound,
+		},
+		{
+			name:   "bill in the archive",
+			lookup: true,
+			mockSetup: func(m *MockTemporalClient) {
+				m.On("ListArchivedWorkflow", mock.Anything, isBillLookup).
+					Return(&workflowservice.ListArchivedWorkflowExecutionsResponse{
+						Executions: []*workflowpb.WorkflowExecutionInfo{
+							{Execution: &commonpb.WorkflowExecution{WorkflowId: "test-bill-123", RunId: "run-1"}},
+						},
+					}, nil)
+			},
+			wantErr: app.ErrBillArchived,
+		},
+		{
+			name:   "bill never existed",
+			lookup: true,
+			mockSetup: func(m *MockTemporalClient) {
+				m.On("ListArchivedWorkflow", mock.Anything, isBillLookup).
+					Return(&workflowservice.ListArchivedWorkflowExecutionsResponse{}, nil)
+			},
+			wantErr: app.ErrBillNotFound,
+		},
+		{
+			name:   "archival disabled in the namespace",
+			lookup: true,
+			mockSetup: func(m *MockTemporalClient) {
+				m.On("ListArchivedWorkflow", mock.Anything, isBillLookup).
+					Return((*workflowservice.ListArchivedWorkflowExecutionsResponse)(nil),
+						serviceerror.NewInvalidArgument("cluster is not configured for visibility archival"))
+			},
+			wantErr: app.ErrBillNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "CurrentBillState", mock.Anything).
+				Return(&MockEncodedValue{}, notFound)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockClient)
+			}
+
+			gateway := NewGateway(mockClient, "test-namespace").WithArchivedLookup(tt.lookup)
+			_, err := gateway.QueryBill(context.Background(), "test-bill-123")
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			mockClient.AssertExpectations(t)
+			if !tt.lookup {
+				mockClient.AssertNotCalled(t, "ListArchivedWorkflow", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestGateway_QueryBillSummary(t *testing.T) {
+	t.Run("summary is mapped", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockValue := &MockEncodedValue{}
+		mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "CurrentBillSummary", mock.Anything).
+			Return(mockValue, nil)
+		mockValue.On("Get", mock.AnythingOfType("*workflows.BillSummaryDTO")).Run(func(args mock.Arguments) {
+			dto := args.Get(0).(*workflows.BillSummaryDTO)
+			dto.ID = "test-bill-123"
+			dto.Status = "OPEN"
+			dto.Total = libmoney.NewFromInt(1000, libmoney.CurrencyUSD)
+			dto.ItemCount = 4
+		}).Return(nil)
+
+		gateway := NewGateway(mockClient, "test-namespace")
+		s, err := gateway.QueryBillSummary(context.Background(), "test-bill-123")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "test-bill-123", s.ID)
+		assert.Equal(t, "OPEN", s.Status)
+		assert.Equal(t, 4, s.ItemCount)
+		assert.True(t, s.Total.Equal(libmoney.NewFromInt(1000, libmoney.CurrencyUSD)))
+		mockClient.AssertExpectations(t)
+		mockValue.AssertExpectations(t)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("QueryWorkflow", mock.Anything, "test-bill-456", "", "CurrentBillSummary", mock.Anything).
+			Return(&MockEncodedValue{}, &serviceerror.NotFound{Message: "Workflow execution not found"})
+
+		gateway := NewGateway(mockClient, "test-namespace")
+		_, err := gateway.QueryBillSummary(context.Background(), "test-bill-456")
+
+		assert.ErrorIs(t, err, app.ErrBillNotFound)
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		mockClient := &MockTemporalClient{}
+		mockClient.On("QueryWorkflow", mock.Anything, "test-bill-456", "", "CurrentBillSummary", mock.Anything).
+			Return(&MockEncodedValue{}, serviceerror.NewDeadlineExceeded("query timed out"))
+
+		gateway := NewGateway(mockClient, "test-namespace")
+		gateway.queryRetry = DialRetry{MaxAttempts: 2, InitialInterval: time.Millisecond}
+		_, err := gateway.QueryBillSummary(context.Background(), "test-bill-456")
+
+		assert.ErrorIs(t, err, app.ErrBillBusy)
+		mockClient.AssertNumberOfCalls(t, "QueryWorkflow", 2)
+	})
+}
+
+func TestGateway_QueryBillChangeLog(t *testing.T) {
+	at := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+	mockClient := &MockTemporalClient{}
+	mockValue := &MockEncodedValue{}
+	mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", "BillChangeLog", mock.Anything).
+		Return(mockValue, nil)
+	mockValue.On("Get", mock.AnythingOfType("*workflows.ChangeLogDTO")).Run(func(args mock.Arguments) {
+		dto := args.Get(0).(*workflows.ChangeLogDTO)
+		dto.Entries = []workflows.ChangeLogEntryDTO{
+			{Type: views.BillChangeStatusChanged, At: at, ToStatus: "OPEN"},
+			{Type: views.BillChangeItemAdded, At: at, IdempotencyKey: "item-1", Description: "API usage fee",
+				Amount: libmoney.NewFromInt(10, libmoney.CurrencyUSD)},
+		}
+		dto.Dropped = 3
+	}).Return(nil)
+
+	l, err := NewGateway(mockClient, "test-namespace").QueryBillChangeLog(context.Background(), "test-bill-123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, l.Dropped)
+	if assert.Len(t, l.Changes, 2) {
+		assert.Equal(t, views.BillChange{Type: views.BillChangeStatusChanged, At: at, ToStatus: "OPEN"}, l.Changes[0])
+		assert.Equal(t, "item-1", l.Changes[1].IdempotencyKey)
+		assert.Equal(t, "10", l.Changes[1].Amount.ToString())
+	}
+	mockClient.AssertExpectations(t)
+
+	mockClient = &MockTemporalClient{}
+	mockClient.On("QueryWorkflow", mock.Anything, "test-bill-456", "", "BillChangeLog", mock.Anything).
+		Return(&MockEncodedValue{}, &serviceerror.NotFound{Message: "Workflow execution not found"})
+	_, err = NewGateway(mockClient, "test-namespace").QueryBillChangeLog(context.Background(), "test-bill-456")
+	assert.ErrorIs(t, err, app.ErrBillNotFound)
+}
+
+func TestGateway_QueryBillByExecution(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockValue := &MockEncodedValue{}
+	// the given run is queried, not the latest one
+	mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "run-1", "CurrentBillState", mock.Anything).
+		Return(mockValue, nil)
+	mockValue.On("Get", mock.AnythingOfType("*workflows.BillDTO")).Run(func(args mock.Arguments) {
+		billDTO := args.Get(0).(*workflows.BillDTO)
+		billDTO.ID = "test-bill-123"
+		billDTO.CustomerID = "customer-123"
+		billDTO.Status = "CLOSED"
+	}).Return(nil)
+
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	bill, err := gateway.QueryBillByExecution(context.Background(), "test-bill-123", "run-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BillID("test-bill-123"), bill.ID)
+	assert.Equal(t, domain.BillStatusClosed, bill.Status)
+	mockClient.AssertExpectations(t)
+	mockValue.AssertExpectations(t)
+}
+
+// The flag makes the trip the API relies on: set by the workflow, encoded by its query, decoded by the gateway.
+func TestGateway_QueryBill_InvoicingRetryableRoundTrip(t *testing.T) {
+	testSuite := &testsuite.WorkflowTestSuite{}
+	env := testSuite.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(time.Minute)
+	var charge *activities.ChargeActivities
+	env.RegisterActivity(&activities.ArchiveActivities{Archiver: activities.NoopArchiver{}})
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).
+		Return(errors.New("payment provider unavailable")).Once()
+	env.OnActivity(charge.ProcessInvoiceAndChargeActivity, mock.Anything, mock.Anything).Return(nil).Once()
+
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       "bill/customer-123/2025-06",
+		CustomerID:   "customer-123",
+		Period:       "2025-06",
+		PeriodYYYYMM: 202506,
+		Currency:     libmoney.CurrencyUSD,
+		InvoiceRetry: app.RetryConfig{MaximumAttempts: 1},
+		Template: []domain.LineItem{{
+			IdempotencyKey: app.TemplateIdempotencyKey("test", "base"),
+			Description:    "Base fee",
+			Amount:         libmoney.FromMinorUnits(1000, libmoney.CurrencyUSD),
+		}},
+	}
+	query := func() domain.Bill {
+		v, err := env.QueryWorkflow(workflows.QueryState)
+		require.NoError(t, err)
+		mockClient := &MockTemporalClient{}
+		mockClient.On("QueryWorkflow", mock.Anything, string(params.BillID), "", workflows.QueryState, mock.Anything).
+			Return(v, nil)
+		bill, err := NewGateway(mockClient, "test-namespace").QueryBill(context.Background(), params.BillID)
+		require.NoError(t, err)
+
+		return bill
+	}
+
+	env.RegisterDelayedCallback(func() {
+		env.SignalWorkflow(workflows.SignalCloseBill, struct{}{})
+	}, time.Millisecond)
+	env.RegisterDelayedCallback(func() {
+		bill := query()
+		assert.Equal(t, domain.BillStatusChargeFailed, bill.Status)
+		assert.True(t, bill.InvoicingRetryable)
+		assert.True(t, bill.ItemsRequired, "the close policy of the bill")
+
+		env.SignalWorkflow(workflows.SignalRetryInvoicing, nil)
+	}, time.Hour)
+
+	env.ExecuteWorkflow(workflows.MonthlyFeeAccrualWorkflow, params)
+
+	require.NoError(t, env.GetWorkflowError())
+	bill := query()
+	assert.Equal(t, domain.BillStatusClosed, bill.Status)
+	assert.False(t, bill.InvoicingRetryable)
+}
+
 func TestGateway_SearchBills(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -723,6 +1708,18 @@ func TestGateway_SearchBills(t *testing.T) {
 			},
 			expectedError: "list failed",
 		},
+		{
+			name: "search attributes not registered",
+			params: app.SearchBillFilter{
+				CustomerID: "customer-789",
+			},
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("ListWorkflow", mock.Anything, mock.Anything).
+					Return((*workflowservice.ListWorkflowExecutionsResponse)(nil),
+						serviceerror.NewInvalidArgument("invalid query: search attribute CustomerID is not defined"))
+			},
+			expectedError: app.ErrSearchAttributesNotRegistered.Error(),
+		},
 	}
 
 	for _, tt := range tests {
@@ -752,6 +1749,570 @@ func TestGateway_SearchBills(t *testing.T) {
 	}
 }
 
+func TestGateway_SearchBills_FinalizedWithinDays(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		// now-7d, computed server side
+		return req.Query == `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillFinalizedAt >= "2025-03-08T10:30:00Z"`
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+
+	gateway := NewGateway(mockClient, "test-namespace")
+	gateway.now = func() time.Time {
+		return time.Date(2025, 3, 15, 14, 30, 0, 0, time.FixedZone("UTC+4", 4*60*60))
+	}
+
+	_, err := gateway.SearchBills(context.Background(), app.SearchBillFilter{
+		CustomerID:          "customer-123",
+		FinalizedWithinDays: 7,
+	})
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_SearchBills_CreatedRange(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 23, 59, 59, 0, time.FixedZone("UTC+4", 4*60*60))
+	createdAt, err := converter.GetDefaultDataConverter().ToPayload(time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &workflowpb.WorkflowExecutionInfo{
+		Execution: &commonpb.WorkflowExecution{WorkflowId: "bill/customer-123/2025-01", RunId: "run-1"},
+		SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+			"CustomerID":       {Data: []byte(`"customer-123"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillingPeriodNum": {Data: []byte(`202501`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillStatus":       {Data: []byte(`"OPEN"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillCurrency":     {Data: []byte(`"USD"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillItemCount":    {Data: []byte(`0`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillTotalCents":   {Data: []byte(`0`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillCreatedAt":    createdAt,
+		}},
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		// bounds are sent in UTC
+		return req.Query == `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`+
+			` AND BillCreatedAt >= "2025-01-01T00:00:00Z" AND BillCreatedAt <= "2025-01-31T19:59:59Z"`
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{info},
+	}, nil)
+
+	bills, err := NewGateway(mockClient, "test-namespace").SearchBills(context.Background(), app.SearchBillFilter{
+		CustomerID:  "customer-123",
+		CreatedFrom: &from,
+		CreatedTo:   &to,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, bills, 1) && assert.NotNil(t, bills[0].CreatedAt) {
+		assert.True(t, bills[0].CreatedAt.Equal(time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)))
+		assert.Nil(t, bills[0].UpdatedAt)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_SearchBills_ActiveRange(t *testing.T) {
+	from := time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+	dc := converter.GetDefaultDataConverter()
+	periodStart, err := dc.ToPayload(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	periodEnd, err := dc.ToPayload(time.Date(2025, 2, 28, 23, 59, 59, 999999999, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &workflowpb.WorkflowExecutionInfo{
+		Execution: &commonpb.WorkflowExecution{WorkflowId: "bill/customer-123/2025-02", RunId: "run-1"},
+		SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+			"CustomerID":       {Data: []byte(`"customer-123"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillingPeriodNum": {Data: []byte(`202502`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillStatus":       {Data: []byte(`"OPEN"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillCurrency":     {Data: []byte(`"USD"`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillItemCount":    {Data: []byte(`0`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillTotalCents":   {Data: []byte(`0`), Metadata: map[string][]byte{"encoding": []byte("json/plain")}},
+			"BillPeriodStart":  periodStart,
+			"BillPeriodEnd":    periodEnd,
+		}},
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		// the period overlaps the range: started by its end, ended after its start
+		return req.Query == `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`+
+			` AND BillPeriodStart <= "2025-02-10T00:00:00Z" AND BillPeriodEnd >= "2025-01-20T00:00:00Z"`
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{info},
+	}, nil)
+
+	bills, err := NewGateway(mockClient, "test-namespace").SearchBills(context.Background(), app.SearchBillFilter{
+		CustomerID: "customer-123",
+		ActiveFrom: &from,
+		ActiveTo:   &to,
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, bills, 1) && assert.NotNil(t, bills[0].PeriodStart) && assert.NotNil(t, bills[0].PeriodEnd) {
+		assert.True(t, bills[0].PeriodStart.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, bills[0].PeriodEnd.Equal(time.Date(2025, 2, 28, 23, 59, 59, 999999999, time.UTC)))
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_SearchBills_AllCustomers(t *testing.T) {
+	jsonPayload := func(data string) *commonpb.Payload {
+		return &commonpb.Payload{Data: []byte(data), Metadata: map[string][]byte{"encoding": []byte("json/plain")}}
+	}
+	info := func(customerID string, memo map[string]*commonpb.Payload) *workflowpb.WorkflowExecutionInfo {
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: "bill/" + customerID + "/2025-01", RunId: "run-1"},
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+				"CustomerID":       jsonPayload(`"` + customerID + `"`),
+				"BillingPeriodNum": jsonPayload(`202501`),
+				"BillStatus":       jsonPayload(`"ERROR"`),
+				"BillCurrency":     jsonPayload(`"USD"`),
+				"BillItemCount":    jsonPayload(`1`),
+				"BillTotalCents":   jsonPayload(`1000`),
+			}},
+			Memo: &commonpb.Memo{Fields: memo},
+		}
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		// no CustomerID condition at all
+		return req.Query == `WorkflowType = "MonthlyFeeAccrualWorkflow" AND (BillStatus = "ERROR")`
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{
+			info("customer-1", map[string]*commonpb.Payload{"ErrorReason": jsonPayload(`"card declined"`)}),
+			// errored before the reason was kept
+			info("customer-2", nil),
+		},
+	}, nil)
+
+	bills, err := NewGateway(mockClient, "test-namespace").SearchBills(context.Background(), app.SearchBillFilter{
+		AllCustomers: true,
+		Status:       []string{"ERROR"},
+	})
+
+	assert.NoError(t, err)
+	if assert.Len(t, bills, 2) {
+		assert.Equal(t, "customer-1", bills[0].CustomerID)
+		assert.Equal(t, "card declined", bills[0].ErrorReason)
+		assert.Equal(t, "customer-2", bills[1].CustomerID)
+		assert.Empty(t, bills[1].ErrorReason)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_AggregateBillTotals(t *testing.T) {
+	bill := func(period int64, currency string, totalCents int64) *workflowpb.WorkflowExecutionInfo {
+		jsonPayload := func(data string) *commonpb.Payload {
+			return &commonpb.Payload{Data: []byte(data), Metadata: map[string][]byte{"encoding": []byte("json/plain")}}
+		}
+
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: fmt.Sprintf("bill/customer-123/%d", period), RunId: "run-1"},
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+				"CustomerID":       jsonPayload(`"customer-123"`),
+				"BillingPeriodNum": jsonPayload(fmt.Sprint(period)),
+				"BillStatus":       jsonPayload(`"CLOSED"`),
+				"BillCurrency":     jsonPayload(fmt.Sprintf("%q", currency)),
+				"BillItemCount":    jsonPayload(`1`),
+				"BillTotalCents":   jsonPayload(fmt.Sprint(totalCents)),
+			}},
+		}
+	}
+	from, to := int64(202501), int64(202503)
+	query := `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"` +
+		` AND BillingPeriodNum >= 202501 AND BillingPeriodNum <= 202503`
+
+	mockClient := &MockTemporalClient{}
+	// the sums span pages, periods come in any order
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && req.NextPageToken == nil
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions:    []*workflowpb.WorkflowExecutionInfo{bill(202503, "USD", 700), bill(202501, "USD", 1000)},
+		NextPageToken: []byte("page-2"),
+	}, nil).Once()
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && string(req.NextPageToken) == "page-2"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{bill(202501, "USD", 250), bill(202503, "GEL", 300)},
+	}, nil).Once()
+
+	totals, err := NewGateway(mockClient, "test-namespace").AggregateBillTotals(context.Background(), "customer-123", &from, &to)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []views.BillPeriodTotal{
+		{PeriodYYYYMM: 202501, Currency: "USD", TotalCents: 1250, Count: 2},
+		{PeriodYYYYMM: 202503, Currency: "GEL", TotalCents: 300, Count: 1},
+		{PeriodYYYYMM: 202503, Currency: "USD", TotalCents: 700, Count: 1},
+	}, totals)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_AggregateBillTotals_NoBills(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+
+	totals, err := NewGateway(mockClient, "test-namespace").AggregateBillTotals(context.Background(), "customer-123", nil, nil)
+
+	assert.NoError(t, err)
+	assert.Empty(t, totals)
+}
+
+func TestGateway_ListBillPeriods(t *testing.T) {
+	jsonPayload := func(data string) *commonpb.Payload {
+		return &commonpb.Payload{Data: []byte(data), Metadata: map[string][]byte{"encoding": []byte("json/plain")}}
+	}
+	bill := func(period int64) *workflowpb.WorkflowExecutionInfo {
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: fmt.Sprintf("bill/customer-123/%d", period), RunId: "run-1"},
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+				"CustomerID":       jsonPayload(`"customer-123"`),
+				"BillingPeriodNum": jsonPayload(fmt.Sprint(period)),
+				"BillStatus":       jsonPayload(`"CLOSED"`),
+				"BillCurrency":     jsonPayload(`"USD"`),
+				"BillItemCount":    jsonPayload(`1`),
+				"BillTotalCents":   jsonPayload(`100`),
+			}},
+		}
+	}
+	query := `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`
+
+	mockClient := &MockTemporalClient{}
+	// the periods come in any order, a period repeats e.g. for the runs of a restarted bill
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && req.NextPageToken == nil
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions:    []*workflowpb.WorkflowExecutionInfo{bill(202501), bill(202412), bill(202503)},
+		NextPageToken: []byte("page-2"),
+	}, nil).Once()
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && string(req.NextPageToken) == "page-2"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{bill(202503), bill(202501)},
+	}, nil).Once()
+
+	periods, err := NewGateway(mockClient, "test-namespace").ListBillPeriods(context.Background(), "customer-123")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2025-03", "2025-01", "2024-12"}, periods)
+	mockClient.AssertExpectations(t)
+}
+
+// endlessPages answers every ListWorkflow with another page token and runs onPage after each call.
+func endlessPages(mockClient *MockTemporalClient, onPage func(calls int)) *int {
+	calls := 0
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		calls++
+		if onPage != nil {
+			onPage(calls)
+		}
+	}).Return(&workflowservice.ListWorkflowExecutionsResponse{NextPageToken: []byte("next")}, nil)
+
+	return &calls
+}
+
+func TestGateway_SearchBills_PageCap(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	calls := endlessPages(mockClient, nil)
+
+	gateway := NewGateway(mockClient, "test-namespace").WithSearchLimits(3, 0)
+	bills, err := gateway.SearchBills(context.Background(), app.SearchBillFilter{CustomerID: "customer-123"})
+
+	assert.ErrorIs(t, err, app.ErrTooManyBills)
+	assert.Nil(t, bills)
+	assert.Equal(t, 3, *calls)
+}
+
+func TestGateway_SearchBills_CancelStopsPaging(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient := &MockTemporalClient{}
+	calls := endlessPages(mockClient, func(calls int) {
+		if calls == 2 {
+			cancel()
+		}
+	})
+
+	_, err := NewGateway(mockClient, "test-namespace").SearchBills(ctx, app.SearchBillFilter{CustomerID: "customer-123"})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestGateway_SearchBills_MaxDuration(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	calls := endlessPages(mockClient, func(int) { time.Sleep(5 * time.Millisecond) })
+
+	gateway := NewGateway(mockClient, "test-namespace").WithSearchLimits(1000, 20*time.Millisecond)
+	_, err := gateway.SearchBills(context.Background(), app.SearchBillFilter{CustomerID: "customer-123"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, *calls, 1000)
+}
+
+func TestGateway_SearchBillsPage(t *testing.T) {
+	jsonPayload := func(data string) *commonpb.Payload {
+		return &commonpb.Payload{Data: []byte(data), Metadata: map[string][]byte{"encoding": []byte("json/plain")}}
+	}
+	query := `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`
+
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && string(req.NextPageToken) == "page-2"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: "bill/customer-123/2025-02", RunId: "run-1"},
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: map[string]*commonpb.Payload{
+				"CustomerID":       jsonPayload(`"customer-123"`),
+				"BillingPeriodNum": jsonPayload(`202502`),
+				"BillStatus":       jsonPayload(`"OPEN"`),
+				"BillCurrency":     jsonPayload(`"USD"`),
+				"BillItemCount":    jsonPayload(`1`),
+				"BillTotalCents":   jsonPayload(`1000`),
+			}},
+		}},
+		NextPageToken: []byte("page-3"),
+	}, nil).Once()
+
+	// the page limit of SearchBills doesn't apply, the caller streams the pages
+	gateway := NewGateway(mockClient, "test-namespace").WithSearchLimits(1, 0)
+	page, err := gateway.SearchBillsPage(context.Background(), app.SearchBillFilter{CustomerID: "customer-123"},
+		[]byte("page-2"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("page-3"), page.NextPageToken)
+	if assert.Len(t, page.Bills, 1) {
+		assert.Equal(t, "bill/customer-123/2025-02", page.Bills[0].WorkflowID)
+		assert.Equal(t, int64(1000), page.Bills[0].TotalCents)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_RefreshSearchAttributes(t *testing.T) {
+	running := func(ids ...string) []*workflowpb.WorkflowExecutionInfo {
+		out := make([]*workflowpb.WorkflowExecutionInfo, 0, len(ids))
+		for _, id := range ids {
+			out = append(out, &workflowpb.WorkflowExecutionInfo{Execution: &commonpb.WorkflowExecution{WorkflowId: id}})
+		}
+		return out
+	}
+	query := `WorkflowType = "MonthlyFeeAccrualWorkflow" AND ExecutionStatus = "Running"`
+
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && len(req.NextPageToken) == 0
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions:    running("bill-1", "bill-2"),
+		NextPageToken: []byte("page-2"),
+	}, nil).Once()
+	mockClient.On("ListWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return req.Query == query && string(req.NextPageToken) == "page-2"
+	})).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: running("bill-3"),
+	}, nil).Once()
+	mockClient.On("SignalWorkflow", mock.Anything, "bill-1", "", "SignalRefreshSearchAttributes", nil).Return(nil).Once()
+	mockClient.On("SignalWorkflow", mock.Anything, "bill-2", "", "SignalRefreshSearchAttributes", nil).
+		Return(serviceerror.NewNotFound("workflow execution already completed")).Once()
+	mockClient.On("SignalWorkflow", mock.Anything, "bill-3", "", "SignalRefreshSearchAttributes", nil).Return(nil).Once()
+
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	first, err := gateway.RefreshSearchAttributes(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, app.RefreshPage{Signaled: 1, Skipped: 1, NextPageToken: []byte("page-2")}, first)
+
+	second, err := gateway.RefreshSearchAttributes(context.Background(), first.NextPageToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, second.Signaled)
+	assert.Empty(t, second.NextPageToken)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_RefreshSearchAttributes_SignalError(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).Return(&workflowservice.ListWorkflowExecutionsResponse{
+		Executions: []*workflowpb.WorkflowExecutionInfo{
+			{Execution: &commonpb.WorkflowExecution{WorkflowId: "bill-1"}},
+		},
+	}, nil)
+	mockClient.On("SignalWorkflow", mock.Anything, "bill-1", "", "SignalRefreshSearchAttributes", nil).
+		Return(errors.New("unavailable"))
+
+	gateway := NewGateway(mockClient, "test-namespace")
+
+	_, err := gateway.RefreshSearchAttributes(context.Background(), nil)
+
+	assert.ErrorContains(t, err, "signal bill bill-1: unavailable")
+	mockClient.AssertExpectations(t)
+}
+
+func TestGateway_CountBills(t *testing.T) {
+	from := int64(202501)
+	filter := app.SearchBillFilter{
+		CustomerID: "customer-123",
+		FromYYYYMM: &from,
+		Status:     []string{"OPEN", "PENDING"},
+	}
+	query := `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND (BillStatus = "OPEN" OR BillStatus = "PENDING") AND BillingPeriodNum >= 202501`
+
+	tests := []struct {
+		name          string
+		mockSetup     func(*MockTemporalClient)
+		expectedCount int64
+		expectedError string
+	}{
+		{
+			name: "successful count",
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("CountWorkflow", mock.Anything, mock.MatchedBy(func(req *workflowservice.CountWorkflowExecutionsRequest) bool {
+					return req.Namespace == "test-namespace" && req.Query == query
+				})).Return(&workflowservice.CountWorkflowExecutionsResponse{Count: 42}, nil)
+			},
+			expectedCount: 42,
+		},
+		{
+			name: "count workflow error",
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("CountWorkflow", mock.Anything, mock.Anything).
+					Return((*workflowservice.CountWorkflowExecutionsResponse)(nil), errors.New("count failed"))
+			},
+			expectedError: "count failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			tt.mockSetup(mockClient)
+
+			gateway := NewGateway(mockClient, "test-namespace")
+
+			n, err := gateway.CountBills(context.Background(), filter)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedCount, n)
+			}
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBuildVisibilityQuery(t *testing.T) {
+	from, to := int64(202501), int64(202512)
+	minTotal, maxTotal := int64(1000), int64(250050)
+	noItems, manyItems := int64(0), int64(500)
+	now := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   app.SearchBillFilter
+		expected string
+	}{
+		{
+			name:     "customer only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123"},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`,
+		},
+		{
+			name: "all filters",
+			filter: app.SearchBillFilter{
+				CustomerID:          `cust"1`,
+				FromYYYYMM:          &from,
+				ToYYYYMM:            &to,
+				Status:              []string{"CLOSED"},
+				FinalizedWithinDays: 1,
+			},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "cust\"1" AND (BillStatus = "CLOSED")` +
+				` AND BillingPeriodNum >= 202501 AND BillingPeriodNum <= 202512 AND BillFinalizedAt >= "2025-03-14T00:00:00Z"`,
+		},
+		{
+			name:     "min total only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", MinTotalCents: &minTotal},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillTotalCents >= 1000`,
+		},
+		{
+			name:     "max total only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", MaxTotalCents: &maxTotal},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillTotalCents <= 250050`,
+		},
+		{
+			name: "total range with period",
+			filter: app.SearchBillFilter{
+				CustomerID:    "customer-123",
+				FromYYYYMM:    &from,
+				MinTotalCents: &minTotal,
+				MaxTotalCents: &maxTotal,
+			},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"` +
+				` AND BillingPeriodNum >= 202501 AND BillTotalCents >= 1000 AND BillTotalCents <= 250050`,
+		},
+		{
+			name:     "empty bills",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", MaxItemCount: &noItems},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillItemCount <= 0`,
+		},
+		{
+			name:     "min items only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", MinItemCount: &manyItems},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillItemCount >= 500`,
+		},
+		{
+			name:     "created from only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", CreatedFrom: &now},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillCreatedAt >= "2025-03-15T00:00:00Z"`,
+		},
+		{
+			name:     "all customers",
+			filter:   app.SearchBillFilter{AllCustomers: true, Status: []string{"ERROR"}, FromYYYYMM: &from},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND (BillStatus = "ERROR") AND BillingPeriodNum >= 202501`,
+		},
+		{
+			name:     "empty customer is still a filter",
+			filter:   app.SearchBillFilter{Status: []string{"ERROR"}},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "" AND (BillStatus = "ERROR")`,
+		},
+		{
+			name:   "active at an instant",
+			filter: app.SearchBillFilter{CustomerID: "customer-123", ActiveFrom: &now, ActiveTo: &now},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"` +
+				` AND BillPeriodStart <= "2025-03-15T00:00:00Z" AND BillPeriodEnd >= "2025-03-15T00:00:00Z"`,
+		},
+		{
+			name:     "active from only",
+			filter:   app.SearchBillFilter{CustomerID: "customer-123", ActiveFrom: &now},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND BillPeriodEnd >= "2025-03-15T00:00:00Z"`,
+		},
+		{
+			name: "item count range with total and status",
+			filter: app.SearchBillFilter{
+				CustomerID:    "customer-123",
+				Status:        []string{"OPEN", "PENDING"},
+				MinTotalCents: &minTotal,
+				MinItemCount:  &noItems,
+				MaxItemCount:  &manyItems,
+			},
+			expected: `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123" AND (BillStatus = "OPEN" OR BillStatus = "PENDING")` +
+				` AND BillTotalCents >= 1000 AND BillItemCount >= 0 AND BillItemCount <= 500`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildVisibilityQuery(tt.filter, now))
+		})
+	}
+}
+
 func TestVisQuote(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -802,6 +2363,7 @@ func TestMapInfoToSummary(t *testing.T) {
 					WorkflowId: "test-bill-123",
 					RunId:      "test-run-123",
 				},
+				TaskQueue: "FEES_TASK_QUEUE_EU",
 				SearchAttributes: &commonpb.SearchAttributes{
 					IndexedFields: map[string]*commonpb.Payload{
 						"CustomerID": {
@@ -846,6 +2408,8 @@ func TestMapInfoToSummary(t *testing.T) {
 			expectedSummary: views.BillSummary{
 				WorkflowID:       "test-bill-123",
 				RunID:            "test-run-123",
+				Namespace:        "billing-eu",
+				TaskQueue:        "FEES_TASK_QUEUE_EU",
 				CustomerID:       "customer-123",
 				BillingPeriodNum: 202501,
 				Status:           "OPEN",
@@ -877,7 +2441,7 @@ func TestMapInfoToSummary(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			dc := converter.GetDefaultDataConverter()
-			summary, err := mapInfoToSummary(dc, tt.executionInfo)
+			summary, err := mapInfoToSummary(dc, "billing-eu", tt.executionInfo)
 
 			if tt.expectedError != "" {
 				assert.Error(t, err)
@@ -886,6 +2450,8 @@ func TestMapInfoToSummary(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.expectedSummary.WorkflowID, summary.WorkflowID)
 				assert.Equal(t, tt.expectedSummary.RunID, summary.RunID)
+				assert.Equal(t, tt.expectedSummary.Namespace, summary.Namespace)
+				assert.Equal(t, tt.expectedSummary.TaskQueue, summary.TaskQueue)
 				assert.Equal(t, tt.expectedSummary.CustomerID, summary.CustomerID)
 				assert.Equal(t, tt.expectedSummary.BillingPeriodNum, summary.BillingPeriodNum)
 				assert.Equal(t, tt.expectedSummary.Status, summary.Status)
@@ -897,6 +2463,60 @@ func TestMapInfoToSummary(t *testing.T) {
 	}
 }
 
+func TestMapInfoToSummary_Timestamps(t *testing.T) {
+	jsonPayload := func(data string) *commonpb.Payload {
+		return &commonpb.Payload{Data: []byte(data), Metadata: map[string][]byte{"encoding": []byte("json/plain")}}
+	}
+	info := func(updatedAt, createdAt *commonpb.Payload) *workflowpb.WorkflowExecutionInfo {
+		fields := map[string]*commonpb.Payload{
+			"CustomerID":       jsonPayload(`"customer-123"`),
+			"BillingPeriodNum": jsonPayload(`202501`),
+			"BillStatus":       jsonPayload(`"OPEN"`),
+			"BillCurrency":     jsonPayload(`"USD"`),
+			"BillItemCount":    jsonPayload(`1`),
+			"BillTotalCents":   jsonPayload(`1000`),
+		}
+		if updatedAt != nil {
+			fields["BillUpdatedAt"] = updatedAt
+		}
+		if createdAt != nil {
+			fields["BillCreatedAt"] = createdAt
+		}
+
+		return &workflowpb.WorkflowExecutionInfo{
+			Execution:        &commonpb.WorkflowExecution{WorkflowId: "test-bill-123", RunId: "test-run-123"},
+			SearchAttributes: &commonpb.SearchAttributes{IndexedFields: fields},
+		}
+	}
+	dc := converter.GetDefaultDataConverter()
+
+	t.Run("decoded", func(t *testing.T) {
+		summary, err := mapInfoToSummary(dc, "default",
+			info(jsonPayload(`"2025-01-15T10:30:00Z"`), jsonPayload(`"2025-01-02T08:00:00Z"`)))
+		assert.NoError(t, err)
+		if !assert.NotNil(t, summary.UpdatedAt) || !assert.NotNil(t, summary.CreatedAt) {
+			return
+		}
+		assert.True(t, summary.UpdatedAt.Equal(time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)))
+		assert.True(t, summary.CreatedAt.Equal(time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("missing on a bill not backfilled", func(t *testing.T) {
+		summary, err := mapInfoToSummary(dc, "default", info(nil, nil))
+		assert.NoError(t, err)
+		assert.Nil(t, summary.UpdatedAt)
+		assert.Nil(t, summary.CreatedAt)
+		assert.Equal(t, "customer-123", summary.CustomerID)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := mapInfoToSummary(dc, "default", info(jsonPayload(`"yesterday"`), nil))
+		assert.Error(t, err)
+		_, err = mapInfoToSummary(dc, "default", info(nil, jsonPayload(`42`)))
+		assert.Error(t, err)
+	})
+}
+
 func TestDecode(t *testing.T) {
 	tests := []struct {
 		name          string
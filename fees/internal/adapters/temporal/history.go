@@ -0,0 +1,265 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// Activity types whose results change the bill, the rest (charge, alerts, audit, webhook) don't.
+const (
+	activityTypeCalculateTax   = "CalculateTaxActivity"
+	activityTypeArchiveInvoice = "ArchiveInvoiceActivity"
+)
+
+// QueryBillAsOf reconstructs the bill as it was at `at` from the history of its run (app.RunID, the latest by
+// default), for audits. Unlike
+// QueryBill it needs no worker: the start params, the signals, the tax and archive results and the BillStatus
+// upserts up to `at` are applied to a fresh bill by the rules of the workflow (workflows.ApplyLineItem and the
+// like) under the versions it recorded, once the run completed its result is taken as is. A bill started after
+// `at` is app.ErrBillNotFound.
+func (g *Gateway) QueryBillAsOf(ctx context.Context, id domain.BillID, at time.Time) (domain.Bill, error) {
+	iter := g.tc.GetWorkflowHistory(ctx, string(id), app.RunID(ctx), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	r := &billHistoryReplay{dc: g.dc, activityTypes: map[int64]string{}, versions: map[string]workflow.Version{}}
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			var nf *serviceerror.NotFound
+			if errors.As(err, &nf) {
+				return domain.Bill{}, g.notFound(ctx, string(id))
+			}
+
+			return domain.Bill{}, fmt.Errorf("bill history: %w", err)
+		}
+		if event.GetEventTime().AsTime().After(at) {
+			break
+		}
+		if err := r.apply(event); err != nil {
+			return domain.Bill{}, fmt.Errorf("bill history event %d: %w", event.GetEventId(), err)
+		}
+	}
+	if !r.started {
+		return domain.Bill{}, app.ErrBillNotFound
+	}
+	// the order QueryBill gives, not the arrival one
+	domain.SortLineItems(r.bill.Items)
+
+	return r.bill, nil
+}
+
+// billHistoryReplay applies the history events to the bill with the rules of MonthlyFeeAccrualWorkflow, the
+// rejected signals (e.g. an item after close) are skipped as the workflow skips them.
+type billHistoryReplay struct {
+	dc      converter.DataConverter
+	params  app.MonthlyFeeAccrualWorkflowParams
+	bill    domain.Bill
+	started bool
+	// activityTypes are the scheduled activities by event ID, a completion only refers to it.
+	activityTypes map[int64]string
+	// versions are the ones recorded by workflow.GetVersion, by change ID.
+	versions map[string]workflow.Version
+	// closing is a close signal waiting for its workflow task, with params.DrainItemsOnClose the items signaled
+	// before the task started are added first.
+	closing bool
+}
+
+//nolint:cyclop
+func (r *billHistoryReplay) apply(event *historypb.HistoryEvent) error {
+	at := event.GetEventTime().AsTime()
+
+	switch event.GetEventType() {
+	case enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED:
+		return r.start(event.GetWorkflowExecutionStartedEventAttributes().GetInput(), at)
+	case enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED:
+		attrs := event.GetWorkflowExecutionSignaledEventAttributes()
+
+		return r.signal(attrs.GetSignalName(), attrs.GetInput(), at)
+	case enums.EVENT_TYPE_WORKFLOW_TASK_STARTED:
+		if r.closing {
+			r.close(at)
+			r.closing = false
+		}
+	case enums.EVENT_TYPE_MARKER_RECORDED:
+		return r.marker(event.GetMarkerRecordedEventAttributes())
+	case enums.EVENT_TYPE_UPSERT_WORKFLOW_SEARCH_ATTRIBUTES:
+		return r.upsert(event.GetUpsertWorkflowSearchAttributesEventAttributes().GetSearchAttributes())
+	case enums.EVENT_TYPE_ACTIVITY_TASK_SCHEDULED:
+		attrs := event.GetActivityTaskScheduledEventAttributes()
+		r.activityTypes[event.GetEventId()] = attrs.GetActivityType().GetName()
+	case enums.EVENT_TYPE_ACTIVITY_TASK_COMPLETED:
+		attrs := event.GetActivityTaskCompletedEventAttributes()
+
+		return r.activityCompleted(r.activityTypes[attrs.GetScheduledEventId()], attrs.GetResult(), at)
+	case enums.EVENT_TYPE_WORKFLOW_EXECUTION_COMPLETED:
+		result := event.GetWorkflowExecutionCompletedEventAttributes().GetResult().GetPayloads()
+		if len(result) == 0 {
+			return nil
+		}
+		var bill domain.Bill
+		if err := decode(r.dc, result[0], &bill); err != nil {
+			return err
+		}
+		r.bill = bill
+	default:
+		// timers, workflow tasks: nothing of the bill
+	}
+
+	return nil
+}
+
+func (r *billHistoryReplay) start(input *commonpb.Payloads, at time.Time) error {
+	if len(input.GetPayloads()) == 0 {
+		return errors.New("no workflow input")
+	}
+	if err := decode(r.dc, input.GetPayloads()[0], &r.params); err != nil {
+		return err
+	}
+	bill, err := workflows.NewBill(r.params, at)
+	if err != nil {
+		return err
+	}
+	r.bill = bill
+	r.started = true
+
+	return nil
+}
+
+// signal applies a signal, a signal the workflow would reject leaves the bill as is.
+func (r *billHistoryReplay) signal(name string, input *commonpb.Payloads, at time.Time) error {
+	if !r.started || len(input.GetPayloads()) == 0 {
+		return nil
+	}
+	p := input.GetPayloads()[0]
+
+	switch name {
+	case workflows.SignalAddLineItem:
+		var pl workflows.AddLineItemPayload
+		if err := decode(r.dc, p, &pl); err != nil {
+			return err
+		}
+		_, _ = workflows.ApplyLineItem(&r.bill, r.params, pl, at)
+	case workflows.SignalUpdateLineItemDescription:
+		var pl workflows.UpdateLineItemDescriptionPayload
+		if err := decode(r.dc, p, &pl); err != nil {
+			return err
+		}
+		_ = r.bill.UpdateItemDescription(pl.IdempotencyKey, pl.NewDescription, at)
+	case workflows.SignalCorrectLineItemAmount:
+		var pl workflows.CorrectLineItemAmountPayload
+		if err := decode(r.dc, p, &pl); err != nil {
+			return err
+		}
+		_ = r.bill.CorrectItemAmount(pl.IdempotencyKey, pl.NewAmount, at)
+	case workflows.SignalSetBillNote:
+		var pl workflows.SetBillNotePayload
+		if err := decode(r.dc, p, &pl); err != nil {
+			return err
+		}
+		_ = r.bill.SetNote(pl.Note, at)
+	case workflows.SignalReconcileBill:
+		r.bill.Reconcile()
+	case workflows.SignalCloseBill:
+		if r.params.DrainItemsOnClose {
+			r.closing = true
+
+			return nil
+		}
+		r.close(at)
+	}
+
+	return nil
+}
+
+func (r *billHistoryReplay) close(at time.Time) {
+	_ = workflows.PendBill(&r.bill, at)
+}
+
+// Version markers of workflow.GetVersion, see the Temporal SDK.
+const (
+	versionMarkerName         = "Version"
+	versionMarkerChangeIDName = "change-id"
+	versionMarkerDataName     = "version"
+)
+
+// marker records the version of a change, the rules of the bill are set up again with it.
+func (r *billHistoryReplay) marker(attrs *historypb.MarkerRecordedEventAttributes) error {
+	if attrs.GetMarkerName() != versionMarkerName {
+		return nil
+	}
+	var changeID string
+	if err := r.dc.FromPayloads(attrs.GetDetails()[versionMarkerChangeIDName], &changeID); err != nil {
+		return err
+	}
+	var version workflow.Version
+	if err := r.dc.FromPayloads(attrs.GetDetails()[versionMarkerDataName], &version); err != nil {
+		return err
+	}
+	r.versions[changeID] = version
+	workflows.SetUpBill(&r.bill, r.params, r.version)
+
+	return nil
+}
+
+// version is the recorded version of a change, a bill started before it has none.
+func (r *billHistoryReplay) version(changeID string, _ workflow.Version) workflow.Version {
+	if v, ok := r.versions[changeID]; ok {
+		return v
+	}
+
+	return workflow.DefaultVersion
+}
+
+// upsert follows the status changes of the workflow, a bill started without SAs stays as the signals left it.
+func (r *billHistoryReplay) upsert(attrs *commonpb.SearchAttributes) error {
+	fields := attrs.GetIndexedFields()
+	if p := fields[sa.BillStatusName]; p != nil {
+		var status string
+		if err := decode(r.dc, p, &status); err != nil {
+			return err
+		}
+		r.bill.Status = domain.BillStatus(status)
+	}
+	finalizedAt, err := decodeTimeOpt(r.dc, fields[sa.BillFinalizedAtName])
+	if err != nil {
+		return err
+	}
+	if finalizedAt != nil {
+		r.bill.FinalizedAt = finalizedAt
+	}
+
+	return nil
+}
+
+func (r *billHistoryReplay) activityCompleted(activityType string, result *commonpb.Payloads, at time.Time) error {
+	if len(result.GetPayloads()) == 0 {
+		return nil
+	}
+
+	switch activityType {
+	case activityTypeCalculateTax:
+		var tax libmoney.Money
+		if err := decode(r.dc, result.GetPayloads()[0], &tax); err != nil {
+			return err
+		}
+
+		return r.bill.AddTaxItem(r.params.Jurisdiction, tax, at)
+	case activityTypeArchiveInvoice:
+		return decode(r.dc, result.GetPayloads()[0], &r.bill.InvoiceURI)
+	}
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package temporal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows/sa"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// sliceHistoryIterator implements client.HistoryEventIterator over fixed events, err is returned after them.
+type sliceHistoryIterator struct {
+	events []*historypb.HistoryEvent
+	err    error
+}
+
+func (it *sliceHistoryIterator) HasNext() bool {
+	return len(it.events) > 0 || it.err != nil
+}
+
+func (it *sliceHistoryIterator) Next() (*historypb.HistoryEvent, error) {
+	if len(it.events) == 0 {
+		err := it.err
+		it.err = nil
+
+		return nil, err
+	}
+	e := it.events[0]
+	it.events = it.events[1:]
+
+	return e, nil
+}
+
+type billHistoryBuilder struct {
+	t      *testing.T
+	events []*historypb.HistoryEvent
+}
+
+func (b *billHistoryBuilder) add(at time.Time, eventType enums.EventType, attrs func(e *historypb.HistoryEvent)) {
+	e := &historypb.HistoryEvent{
+		EventId:   int64(len(b.events) + 1),
+		EventTime: timestamppb.New(at),
+		EventType: eventType,
+	}
+	attrs(e)
+	b.events = append(b.events, e)
+}
+
+func (b *billHistoryBuilder) payloads(v any) *commonpb.Payloads {
+	p, err := converter.GetDefaultDataConverter().ToPayloads(v)
+	require.NoError(b.t, err)
+
+	return p
+}
+
+func (b *billHistoryBuilder) payload(v any) *commonpb.Payload {
+	p, err := converter.GetDefaultDataConverter().ToPayload(v)
+	require.NoError(b.t, err)
+
+	return p
+}
+
+func (b *billHistoryBuilder) started(at time.Time, params app.MonthlyFeeAccrualWorkflowParams) {
+	b.add(at, enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED, func(e *historypb.HistoryEvent) {
+		e.Attributes = &historypb.HistoryEvent_WorkflowExecutionStartedEventAttributes{
+			WorkflowExecutionStartedEventAttributes: &historypb.WorkflowExecutionStartedEventAttributes{
+				Input: b.payloads(params),
+			},
+		}
+	})
+}
+
+func (b *billHistoryBuilder) signaled(at time.Time, name string, arg any) {
+	b.add(at, enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, func(e *historypb.HistoryEvent) {
+		e.Attributes = &historypb.HistoryEvent_WorkflowExecutionSignaledEventAttributes{
+			WorkflowExecutionSignaledEventAttributes: &historypb.WorkflowExecutionSignaledEventAttributes{
+				SignalName: name,
+				Input:      b.payloads(arg),
+			},
+		}
+	})
+}
+
+func (b *billHistoryBuilder) upserted(at time.Time, fields map[string]*commonpb.Payload) {
+	b.add(at, enums.EVENT_TYPE_UPSERT_WORKFLOW_SEARCH_ATTRIBUTES, func(e *historypb.HistoryEvent) {
+		e.Attributes = &historypb.HistoryEvent_UpsertWorkflowSearchAttributesEventAttributes{
+			UpsertWorkflowSearchAttributesEventAttributes: &historypb.UpsertWorkflowSearchAttributesEventAttributes{
+				SearchAttributes: &commonpb.SearchAttributes{IndexedFields: fields},
+			},
+		}
+	})
+}
+
+// versionMarker records the version of a change as workflow.GetVersion does.
+func (b *billHistoryBuilder) versionMarker(at time.Time, changeID string, version workflow.Version) {
+	b.add(at, enums.EVENT_TYPE_MARKER_RECORDED, func(e *historypb.HistoryEvent) {
+		e.Attributes = &historypb.HistoryEvent_MarkerRecordedEventAttributes{
+			MarkerRecordedEventAttributes: &historypb.MarkerRecordedEventAttributes{
+				MarkerName: versionMarkerName,
+				Details: map[string]*commonpb.Payloads{
+					versionMarkerChangeIDName: b.payloads(changeID),
+					versionMarkerDataName:     b.payloads(version),
+				},
+			},
+		}
+	})
+}
+
+func TestGateway_QueryBillAsOf(t *testing.T) {
+	const billID = "bill/customer-123/2025-01"
+	t0 := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	usd := func(v string) libmoney.Money {
+		m, _ := libmoney.NewFromString(v, libmoney.CurrencyUSD)
+		return m
+	}
+
+	h := &billHistoryBuilder{t: t}
+	h.started(t0, app.MonthlyFeeAccrualWorkflowParams{
+		BillID:       billID,
+		CustomerID:   "customer-123",
+		Period:       "2025-01",
+		PeriodYYYYMM: 202501,
+		Currency:     libmoney.CurrencyUSD,
+	})
+	h.signaled(t0.Add(time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+		IdempotencyKey: "item-1", Description: "API usage fee", Amount: usd("10.50"),
+	})
+	h.signaled(t0.Add(2*time.Hour), workflows.SignalSetBillNote, workflows.SetBillNotePayload{Note: "VIP"})
+	h.signaled(t0.Add(24*time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+		IdempotencyKey: "item-2", Description: "Storage fee", Amount: usd("5.00"),
+	})
+	h.signaled(t0.Add(48*time.Hour), workflows.SignalCloseBill, workflows.CloseBillSignal{})
+	closedAt := t0.Add(48*time.Hour + time.Second)
+	h.upserted(closedAt, map[string]*commonpb.Payload{
+		sa.BillStatusName:      h.payload(string(domain.BillStatusClosed)),
+		sa.BillFinalizedAtName: h.payload(closedAt),
+	})
+	// arrives after close, the workflow discards it
+	h.signaled(t0.Add(72*time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+		IdempotencyKey: "item-3", Description: "Late fee", Amount: usd("1.00"),
+	})
+
+	tests := []struct {
+		name       string
+		at         time.Time
+		wantErr    error
+		wantStatus domain.BillStatus
+		wantItems  []string
+		wantTotal  string
+		wantNotes  string
+	}{
+		{name: "before the bill started", at: t0.Add(-time.Minute), wantErr: app.ErrBillNotFound},
+		{name: "just started", at: t0, wantStatus: domain.BillStatusOpen, wantTotal: "0"},
+		{
+			name: "before the second item was added", at: t0.Add(12 * time.Hour),
+			wantStatus: domain.BillStatusOpen, wantItems: []string{"item-1"}, wantTotal: "10.5", wantNotes: "VIP",
+		},
+		{
+			name: "after the second item was added", at: t0.Add(25 * time.Hour),
+			wantStatus: domain.BillStatusOpen, wantItems: []string{"item-1", "item-2"}, wantTotal: "15.5", wantNotes: "VIP",
+		},
+		{
+			name: "being closed", at: t0.Add(48 * time.Hour),
+			wantStatus: domain.BillStatusPending, wantItems: []string{"item-1", "item-2"}, wantTotal: "15.5", wantNotes: "VIP",
+		},
+		{
+			name: "closed, the late item is discarded", at: t0.Add(100 * time.Hour),
+			wantStatus: domain.BillStatusClosed, wantItems: []string{"item-1", "item-2"}, wantTotal: "15.5", wantNotes: "VIP",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("GetWorkflowHistory", mock.Anything, billID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+				Return(&sliceHistoryIterator{events: h.events})
+
+			bill, err := NewGateway(mockClient, "test-namespace").QueryBillAsOf(context.Background(), billID, tt.at)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, domain.BillID(billID), bill.ID)
+			assert.Equal(t, tt.wantStatus, bill.Status)
+			keys := []string{}
+			for _, li := range bill.Items {
+				keys = append(keys, li.IdempotencyKey)
+			}
+			assert.ElementsMatch(t, tt.wantItems, keys)
+			assert.Equal(t, tt.wantTotal, bill.Total.ToString())
+			assert.Equal(t, tt.wantNotes, bill.Notes)
+			assert.Equal(t, t0, bill.CreatedAt)
+			if tt.wantStatus == domain.BillStatusClosed {
+				require.NotNil(t, bill.FinalizedAt)
+				assert.True(t, closedAt.Equal(*bill.FinalizedAt))
+			}
+		})
+	}
+}
+
+func TestGateway_QueryBillAsOf_ItemsOrder(t *testing.T) {
+	const billID = "bill/customer-123/2025-01"
+	t0 := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	h := &billHistoryBuilder{t: t}
+	h.started(t0, app.MonthlyFeeAccrualWorkflowParams{
+		BillID: billID, CustomerID: "customer-123", Period: "2025-01", PeriodYYYYMM: 202501, Currency: libmoney.CurrencyUSD,
+	})
+	// signaled in one workflow task, the items share their AddedAt
+	for _, key := range []string{"item-c", "item-a", "item-b"} {
+		h.signaled(t0.Add(time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+			IdempotencyKey: key, Description: "fee", Amount: libmoney.NewFromInt(1, libmoney.CurrencyUSD),
+		})
+	}
+	mockClient := &MockTemporalClient{}
+	mockClient.On("GetWorkflowHistory", mock.Anything, billID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+		Return(&sliceHistoryIterator{events: h.events})
+
+	bill, err := NewGateway(mockClient, "test-namespace").QueryBillAsOf(context.Background(), billID, t0.Add(2*time.Hour))
+
+	require.NoError(t, err)
+	keys := []string{}
+	for _, li := range bill.Items {
+		keys = append(keys, li.IdempotencyKey)
+	}
+	assert.Equal(t, []string{"item-a", "item-b", "item-c"}, keys)
+}
+
+func TestGateway_QueryBillAsOf_DrainItemsOnClose(t *testing.T) {
+	const billID = "bill/customer-123/2025-01"
+	t0 := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	amount := libmoney.NewFromInt(10, libmoney.CurrencyUSD)
+
+	for _, drain := range []bool{true, false} {
+		h := &billHistoryBuilder{t: t}
+		h.started(t0, app.MonthlyFeeAccrualWorkflowParams{
+			BillID: billID, CustomerID: "customer-123", Period: "2025-01", PeriodYYYYMM: 202501,
+			Currency: libmoney.CurrencyUSD, DrainItemsOnClose: drain,
+		})
+		h.signaled(t0.Add(time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+			IdempotencyKey: "early", Description: "Early", Amount: amount,
+		})
+		// both delivered before the workflow task handling them started
+		h.signaled(t0.Add(2*time.Hour), workflows.SignalCloseBill, workflows.CloseBillSignal{})
+		h.signaled(t0.Add(2*time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+			IdempotencyKey: "racing", Description: "Racing", Amount: amount,
+		})
+		h.add(t0.Add(2*time.Hour+time.Second), enums.EVENT_TYPE_WORKFLOW_TASK_STARTED, func(e *historypb.HistoryEvent) {})
+		mockClient := &MockTemporalClient{}
+		mockClient.On("GetWorkflowHistory", mock.Anything, billID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+			Return(&sliceHistoryIterator{events: h.events})
+
+		bill, err := NewGateway(mockClient, "test-namespace").QueryBillAsOf(context.Background(), billID, t0.Add(3*time.Hour))
+
+		require.NoError(t, err)
+		assert.Equal(t, domain.BillStatusPending, bill.Status)
+		if drain {
+			assert.Equal(t, "20", bill.Total.ToString(), "the racing item is drained before the close")
+		} else {
+			assert.Equal(t, "10", bill.Total.ToString(), "the racing item is dropped after the close")
+		}
+	}
+}
+
+func TestGateway_QueryBillAsOf_NotFound(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("GetWorkflowHistory", mock.Anything, "bill/unknown/2025-01", "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+		Return(&sliceHistoryIterator{err: serviceerror.NewNotFound("workflow not found")})
+
+	_, err := NewGateway(mockClient, "test-namespace").
+		QueryBillAsOf(context.Background(), "bill/unknown/2025-01", time.Now())
+	assert.ErrorIs(t, err, app.ErrBillNotFound)
+}
+
+func TestGateway_QueryBillAsOf_RecordedVersions(t *testing.T) {
+	const billID = "bill/customer-123/2025-01"
+	t0 := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	amount := libmoney.NewFromInt(10, libmoney.CurrencyUSD)
+	params := app.MonthlyFeeAccrualWorkflowParams{
+		BillID: billID, CustomerID: "customer-123", Period: "2025-01", PeriodYYYYMM: 202501,
+		Currency: libmoney.CurrencyUSD, MaxItems: 1,
+	}
+
+	tests := []struct {
+		name       string
+		markers    bool
+		items      []string
+		wantItems  int
+		wantStatus domain.BillStatus
+	}{
+		{name: "item past the cap is discarded", markers: true, items: []string{"a", "b"}, wantItems: 1,
+			wantStatus: domain.BillStatusPending},
+		{name: "no cap before the item limit", items: []string{"a", "b"}, wantItems: 2,
+			wantStatus: domain.BillStatusPending},
+		{name: "empty bill isn't closed", markers: true, wantStatus: domain.BillStatusOpen},
+		{name: "empty bill closed before the guard", wantStatus: domain.BillStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &billHistoryBuilder{t: t}
+			h.started(t0, params)
+			if tt.markers {
+				h.versionMarker(t0, "empty-bill-guard", 1)
+				h.versionMarker(t0, "item-limit", 1)
+			}
+			for _, key := range tt.items {
+				h.signaled(t0.Add(time.Hour), workflows.SignalAddLineItem, workflows.AddLineItemPayload{
+					IdempotencyKey: key, Description: "fee", Amount: amount,
+				})
+			}
+			h.signaled(t0.Add(2*time.Hour), workflows.SignalCloseBill, workflows.CloseBillSignal{})
+			mockClient := &MockTemporalClient{}
+			mockClient.On("GetWorkflowHistory", mock.Anything, billID, "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT).
+				Return(&sliceHistoryIterator{events: h.events})
+
+			bill, err := NewGateway(mockClient, "test-namespace").QueryBillAsOf(context.Background(), billID, t0.Add(3*time.Hour))
+
+			require.NoError(t, err)
+			assert.Len(t, bill.Items, tt.wantItems)
+			assert.Equal(t, tt.wantStatus, bill.Status)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package temporal
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+// searchCache keeps SearchBills results per visibility query for a short TTL. Visibility is eventually consistent
+// anyway, so a few seconds of staleness don't change what clients can rely on, and repeated listings
+// (dashboards polling the same filters) don't page through Temporal every time.
+type searchCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	bills   []views.BillSummary
+	expires time.Time
+}
+
+func newSearchCache(ttl time.Duration) *searchCache {
+	return &searchCache{ttl: ttl, entries: map[string]searchCacheEntry{}}
+}
+
+// get returns a copy of the cached bills of the query, if they haven't expired at now.
+func (c *searchCache) get(query string, now time.Time) ([]views.BillSummary, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[query]
+	if !ok || !now.Before(e.expires) {
+		return nil, false
+	}
+
+	return slices.Clone(e.bills), true
+}
+
+// put caches a copy of the bills, and drops the expired entries so the map only holds the recent queries.
+func (c *searchCache) put(query string, bills []views.BillSummary, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for q, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, q)
+		}
+	}
+	c.entries[query] = searchCacheEntry{bills: slices.Clone(bills), expires: now.Add(c.ttl)}
+}
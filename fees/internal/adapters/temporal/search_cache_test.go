@@ -0,0 +1,107 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+)
+
+// cachedGateway lists no bills in one page and counts the ListWorkflow calls, the clock is moved through now.
+func cachedGateway(ttl time.Duration) (*Gateway, *int, *time.Time) {
+	mockClient := &MockTemporalClient{}
+	calls := 0
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).Run(func(mock.Arguments) { calls++ }).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil)
+
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	gateway := NewGateway(mockClient, "test-namespace").WithSearchCache(ttl)
+	gateway.now = func() time.Time { return now }
+
+	return gateway, &calls, &now
+}
+
+func TestGateway_SearchBills_CacheHitWithinTTL(t *testing.T) {
+	gateway, calls, now := cachedGateway(5 * time.Second)
+	filter := app.SearchBillFilter{CustomerID: "customer-123"}
+
+	_, err := gateway.SearchBills(context.Background(), filter)
+	require.NoError(t, err)
+	*now = now.Add(4 * time.Second)
+	_, err = gateway.SearchBills(context.Background(), filter)
+	require.NoError(t, err)
+	assert.Equal(t, 1, *calls, "the second listing is served from the cache")
+
+	_, err = gateway.SearchBills(context.Background(), app.SearchBillFilter{CustomerID: "customer-456"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls, "another filter is another query")
+}
+
+func TestGateway_SearchBills_CacheMissAfterTTL(t *testing.T) {
+	gateway, calls, now := cachedGateway(5 * time.Second)
+	filter := app.SearchBillFilter{CustomerID: "customer-123"}
+
+	_, err := gateway.SearchBills(context.Background(), filter)
+	require.NoError(t, err)
+	*now = now.Add(5 * time.Second)
+	_, err = gateway.SearchBills(context.Background(), filter)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestGateway_SearchBills_CacheDisabled(t *testing.T) {
+	gateway, calls, _ := cachedGateway(0)
+	filter := app.SearchBillFilter{CustomerID: "customer-123"}
+
+	for range 3 {
+		_, err := gateway.SearchBills(context.Background(), filter)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, *calls)
+}
+
+func TestGateway_SearchBills_ErrorsAreNotCached(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).
+		Return((*workflowservice.ListWorkflowExecutionsResponse)(nil), errors.New("visibility unavailable")).Once()
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil).Once()
+	gateway := NewGateway(mockClient, "test-namespace").WithSearchCache(time.Minute)
+	filter := app.SearchBillFilter{CustomerID: "customer-123"}
+
+	_, err := gateway.SearchBills(context.Background(), filter)
+	require.Error(t, err)
+	_, err = gateway.SearchBills(context.Background(), filter)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSearchCache(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	c := newSearchCache(time.Second)
+	bills := []views.BillSummary{{WorkflowID: "bill-1"}}
+
+	c.put("q1", bills, now)
+	bills[0].WorkflowID = "changed by the caller"
+	got, ok := c.get("q1", now.Add(999*time.Millisecond))
+	require.True(t, ok)
+	assert.Equal(t, "bill-1", got[0].WorkflowID, "the cache keeps its own copy")
+
+	got[0].WorkflowID = "changed again"
+	got, _ = c.get("q1", now)
+	assert.Equal(t, "bill-1", got[0].WorkflowID, "callers get a copy")
+
+	_, ok = c.get("q1", now.Add(time.Second))
+	assert.False(t, ok, "expired")
+
+	c.put("q2", nil, now.Add(2*time.Second))
+	assert.Len(t, c.entries, 1, "expired entries are dropped on put")
+}
@@ -0,0 +1,84 @@
+package temporal
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+)
+
+// TracerName is the instrumentation scope of the gateway spans.
+const TracerName = "github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal"
+
+// Span attributes of the Temporal calls.
+const (
+	attrBillID          = attribute.Key("fees.bill_id")
+	attrWorkflowType    = attribute.Key("temporal.workflow_type")
+	attrSignalName      = attribute.Key("temporal.signal_name")
+	attrQueryType       = attribute.Key("temporal.query_type")
+	attrQueryAttempts   = attribute.Key("temporal.query_attempts")
+	attrVisibilityQuery = attribute.Key("temporal.visibility_query")
+)
+
+// noopTracer is the tracer of a gateway without WithTracer, spans cost nothing.
+var noopTracer = noop.NewTracerProvider().Tracer(TracerName)
+
+// startSpan starts a client span of a Temporal call, end it with endSpan.
+func (g *Gateway) startSpan(
+	ctx context.Context,
+	name string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	return g.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, as the error status of the span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// executeWorkflow starts a workflow within a span, the workflow ID is the bill (or credit note) ID.
+func (g *Gateway) executeWorkflow(
+	ctx context.Context,
+	opts client.StartWorkflowOptions,
+	workflowType string,
+	workflow any,
+	args ...any,
+) error {
+	ctx, span := g.startSpan(ctx, "temporal.ExecuteWorkflow",
+		attrBillID.String(opts.ID), attrWorkflowType.String(workflowType))
+	_, err := g.tc.ExecuteWorkflow(ctx, opts, workflow, args...)
+	endSpan(span, err)
+
+	return err
+}
+
+// signalWorkflow signals a bill within a span.
+func (g *Gateway) signalWorkflow(ctx context.Context, workflowID, runID, signalName string, arg any) error {
+	ctx, span := g.startSpan(ctx, "temporal.SignalWorkflow",
+		attrBillID.String(workflowID), attrSignalName.String(signalName))
+	err := g.tc.SignalWorkflow(ctx, workflowID, runID, signalName, arg)
+	endSpan(span, err)
+
+	return err
+}
+
+// listWorkflow lists a page of executions within a span.
+func (g *Gateway) listWorkflow(
+	ctx context.Context,
+	req *workflowservice.ListWorkflowExecutionsRequest,
+) (*workflowservice.ListWorkflowExecutionsResponse, error) {
+	ctx, span := g.startSpan(ctx, "temporal.ListWorkflow", attrVisibilityQuery.String(req.GetQuery()))
+	resp, err := g.tc.ListWorkflow(ctx, req)
+	endSpan(span, err)
+
+	return resp, err
+}
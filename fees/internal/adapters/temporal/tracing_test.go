@@ -0,0 +1,194 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// newTracedGateway returns a gateway whose spans end up in the returned recorder.
+func newTracedGateway(tc *MockTemporalClient) (*Gateway, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return NewGateway(tc, "test-namespace").WithTracer(tp.Tracer(TracerName)), recorder
+}
+
+// onlySpan returns the only span the recorder got, it must have ended.
+func onlySpan(t *testing.T, recorder *tracetest.SpanRecorder) sdktrace.ReadOnlySpan {
+	t.Helper()
+	assert.Empty(t, recorder.Started()[len(recorder.Ended()):], "every started span must end")
+	ended := recorder.Ended()
+	require.Len(t, ended, 1)
+
+	return ended[0]
+}
+
+func spanAttrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+
+	return attrs
+}
+
+func TestGateway_Tracing_SignalWorkflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		signalErr error
+		status    codes.Code
+	}{
+		{name: "ok", status: codes.Unset},
+		{name: "error is recorded", signalErr: errors.New("signal failed"), status: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", workflows.SignalCloseBill, mock.Anything).
+				Return(tt.signalErr)
+			gateway, recorder := newTracedGateway(mockClient)
+
+			err := gateway.CloseBill(context.Background(), "test-bill-123")
+			assert.Equal(t, tt.signalErr, err)
+
+			span := onlySpan(t, recorder)
+			assert.Equal(t, "temporal.SignalWorkflow", span.Name())
+			attrs := spanAttrs(span)
+			assert.Equal(t, "test-bill-123", attrs[attrBillID].AsString())
+			assert.Equal(t, workflows.SignalCloseBill, attrs[attrSignalName].AsString())
+			assert.Equal(t, tt.status, span.Status().Code)
+			if tt.signalErr != nil {
+				assert.Equal(t, "signal failed", span.Status().Description)
+				require.Len(t, span.Events(), 1)
+				assert.Equal(t, "exception", span.Events()[0].Name)
+			}
+		})
+	}
+}
+
+func TestGateway_Tracing_ExecuteWorkflow(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ExecuteWorkflow", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&MockWorkflowRun{}, serviceerror.NewWorkflowExecutionAlreadyStarted("started", "", ""))
+	gateway, recorder := newTracedGateway(mockClient)
+
+	err := gateway.StartMonthlyBill(context.Background(), app.MonthlyFeeAccrualWorkflowParams{
+		BillID: "bill/customer-123/2025-01", CustomerID: "customer-123", Period: "2025-01", PeriodYYYYMM: 202501,
+	})
+	assert.ErrorIs(t, err, app.ErrBillWithPeriodAlreadyStarted)
+
+	span := onlySpan(t, recorder)
+	assert.Equal(t, "temporal.ExecuteWorkflow", span.Name())
+	attrs := spanAttrs(span)
+	assert.Equal(t, "bill/customer-123/2025-01", attrs[attrBillID].AsString())
+	assert.Equal(t, workflows.WorkflowTypeMonthlyBill, attrs[attrWorkflowType].AsString())
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestGateway_Tracing_QueryWorkflow(t *testing.T) {
+	tests := []struct {
+		name      string
+		mockSetup func(*MockTemporalClient)
+		wantErr   error
+		attempts  int64
+		status    codes.Code
+	}{
+		{
+			name: "ok",
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", workflows.QueryState,
+					mock.Anything).Return(&MockEncodedValue{}, nil).Once()
+			},
+			attempts: 1,
+			status:   codes.Unset,
+		},
+		{
+			name: "one span for all the attempts",
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", workflows.QueryState,
+					mock.Anything).Return((*MockEncodedValue)(nil), serviceerror.NewUnavailable("busy")).Twice()
+			},
+			wantErr:  app.ErrBillBusy,
+			attempts: 2,
+			status:   codes.Error,
+		},
+		{
+			name: "not found",
+			mockSetup: func(mockClient *MockTemporalClient) {
+				mockClient.On("QueryWorkflow", mock.Anything, "test-bill-123", "", workflows.QueryState,
+					mock.Anything).Return((*MockEncodedValue)(nil), serviceerror.NewNotFound("gone")).Once()
+			},
+			wantErr:  app.ErrBillNotFound,
+			attempts: 1,
+			status:   codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockTemporalClient{}
+			tt.mockSetup(mockClient)
+			gateway, recorder := newTracedGateway(mockClient)
+			gateway.queryRetry = DialRetry{MaxAttempts: 2, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}
+
+			_, err := gateway.queryWorkflow(context.Background(), "test-bill-123", "", workflows.QueryState)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			span := onlySpan(t, recorder)
+			assert.Equal(t, "temporal.QueryWorkflow", span.Name())
+			attrs := spanAttrs(span)
+			assert.Equal(t, "test-bill-123", attrs[attrBillID].AsString())
+			assert.Equal(t, workflows.QueryState, attrs[attrQueryType].AsString())
+			assert.Equal(t, tt.attempts, attrs[attrQueryAttempts].AsInt64())
+			assert.Equal(t, tt.status, span.Status().Code)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGateway_Tracing_ListWorkflow(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("ListWorkflow", mock.Anything, mock.Anything).
+		Return(&workflowservice.ListWorkflowExecutionsResponse{}, nil).Once()
+	gateway, recorder := newTracedGateway(mockClient)
+
+	_, err := gateway.SearchBillsPage(context.Background(), app.SearchBillFilter{CustomerID: "customer-123"}, nil)
+	require.NoError(t, err)
+
+	span := onlySpan(t, recorder)
+	assert.Equal(t, "temporal.ListWorkflow", span.Name())
+	assert.Equal(t, `WorkflowType = "MonthlyFeeAccrualWorkflow" AND CustomerID = "customer-123"`,
+		spanAttrs(span)[attrVisibilityQuery].AsString())
+	assert.Equal(t, codes.Unset, span.Status().Code)
+}
+
+func TestGateway_WithTracer_Nil(t *testing.T) {
+	mockClient := &MockTemporalClient{}
+	mockClient.On("SignalWorkflow", mock.Anything, "test-bill-123", "", workflows.SignalCloseBill, mock.Anything).
+		Return(nil)
+
+	// nil keeps the spans off instead of panicking on the first call
+	gateway := NewGateway(mockClient, "test-namespace").WithTracer(nil)
+	assert.NoError(t, gateway.CloseBill(context.Background(), domain.BillID("test-bill-123")))
+}
@@ -0,0 +1,123 @@
+package temporal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// visibilityQueryBuilder collects visibility query conditions joined with AND,
+// string values are always escaped with visQuote so callers only deal with filter logic.
+type visibilityQueryBuilder struct {
+	parts []string
+}
+
+func newVisibilityQuery() *visibilityQueryBuilder {
+	return &visibilityQueryBuilder{}
+}
+
+// Equals adds `key = "val"`.
+func (b *visibilityQueryBuilder) Equals(key, val string) *visibilityQueryBuilder {
+	b.parts = append(b.parts, equals(key, val))
+
+	return b
+}
+
+// StartsWith adds `key STARTS_WITH "prefix"`, for keyword attributes like WorkflowId.
+func (b *visibilityQueryBuilder) StartsWith(key, prefix string) *visibilityQueryBuilder {
+	b.parts = append(b.parts, fmt.Sprintf(`%s STARTS_WITH "%s"`, key, visQuote(prefix)))
+
+	return b
+}
+
+// In adds `(key = "v1" OR key = "v2" ...)`, nothing when vals is empty.
+func (b *visibilityQueryBuilder) In(key string, vals []string) *visibilityQueryBuilder {
+	if len(vals) == 0 {
+		return b
+	}
+	conditions := make([]string, len(vals))
+	for i, v := range vals {
+		conditions[i] = equals(key, v)
+	}
+	b.parts = append(b.parts, fmt.Sprintf("(%s)", strings.Join(conditions, " OR ")))
+
+	return b
+}
+
+// Gte adds `key >= num`.
+func (b *visibilityQueryBuilder) Gte(key string, num int64) *visibilityQueryBuilder {
+	b.parts = append(b.parts, fmt.Sprintf(`%s >= %d`, key, num))
+
+	return b
+}
+
+// Lte adds `key <= num`.
+func (b *visibilityQueryBuilder) Lte(key string, num int64) *visibilityQueryBuilder {
+	b.parts = append(b.parts, fmt.Sprintf(`%s <= %d`, key, num))
+
+	return b
+}
+
+// GteTime adds `key >= "RFC3339"` for Datetime attributes, t is converted to UTC.
+func (b *visibilityQueryBuilder) GteTime(key string, t time.Time) *visibilityQueryBuilder {
+	b.parts = append(b.parts, fmt.Sprintf(`%s >= "%s"`, key, t.UTC().Format(time.RFC3339)))
+
+	return b
+}
+
+// LteTime adds `key <= "RFC3339"` for Datetime attributes, t is converted to UTC.
+func (b *visibilityQueryBuilder) LteTime(key string, t time.Time) *visibilityQueryBuilder {
+	b.parts = append(b.parts, fmt.Sprintf(`%s <= "%s"`, key, t.UTC().Format(time.RFC3339)))
+
+	return b
+}
+
+// GteTimeOpt and LteTimeOpt add the condition only when t is set, for optional filters.
+func (b *visibilityQueryBuilder) GteTimeOpt(key string, t *time.Time) *visibilityQueryBuilder {
+	if t == nil {
+		return b
+	}
+
+	return b.GteTime(key, *t)
+}
+
+func (b *visibilityQueryBuilder) LteTimeOpt(key string, t *time.Time) *visibilityQueryBuilder {
+	if t == nil {
+		return b
+	}
+
+	return b.LteTime(key, *t)
+}
+
+// GteOpt and LteOpt add the condition only when num is set, for optional filters.
+func (b *visibilityQueryBuilder) GteOpt(key string, num *int64) *visibilityQueryBuilder {
+	if num == nil {
+		return b
+	}
+
+	return b.Gte(key, *num)
+}
+
+func (b *visibilityQueryBuilder) LteOpt(key string, num *int64) *visibilityQueryBuilder {
+	if num == nil {
+		return b
+	}
+
+	return b.Lte(key, *num)
+}
+
+// Build joins all conditions with AND.
+func (b *visibilityQueryBuilder) Build() string {
+	return strings.Join(b.parts, " AND ")
+}
+
+func equals(key, val string) string {
+	return fmt.Sprintf(`%s = "%s"`, key, visQuote(val))
+}
+
+func visQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+
+	return s
+}
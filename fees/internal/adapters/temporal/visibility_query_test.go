@@ -0,0 +1,80 @@
+package temporal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisibilityQueryBuilder(t *testing.T) {
+	n := int64(202501)
+
+	tests := []struct {
+		name     string
+		build    func(b *visibilityQueryBuilder) *visibilityQueryBuilder
+		expected string
+	}{
+		{
+			name:     "empty",
+			build:    func(b *visibilityQueryBuilder) *visibilityQueryBuilder { return b },
+			expected: "",
+		},
+		{
+			name:     "equals escapes the value",
+			build:    func(b *visibilityQueryBuilder) *visibilityQueryBuilder { return b.Equals("CustomerID", `a"b\c`) },
+			expected: `CustomerID = "a\"b\\c"`,
+		},
+		{
+			name: "in joins values with OR and escapes each",
+			build: func(b *visibilityQueryBuilder) *visibilityQueryBuilder {
+				return b.In("BillStatus", []string{"OPEN", `X" OR CustomerID = "other`})
+			},
+			expected: `(BillStatus = "OPEN" OR BillStatus = "X\" OR CustomerID = \"other")`,
+		},
+		{
+			name:     "in with no values adds nothing",
+			build:    func(b *visibilityQueryBuilder) *visibilityQueryBuilder { return b.Equals("A", "1").In("B", nil) },
+			expected: `A = "1"`,
+		},
+		{
+			name:     "numeric bounds",
+			build:    func(b *visibilityQueryBuilder) *visibilityQueryBuilder { return b.Gte("N", -5).Lte("N", 10) },
+			expected: `N >= -5 AND N <= 10`,
+		},
+		{
+			name: "optional bounds are skipped when nil",
+			build: func(b *visibilityQueryBuilder) *visibilityQueryBuilder {
+				return b.GteOpt("N", &n).LteOpt("N", nil).GteOpt("M", nil)
+			},
+			expected: `N >= 202501`,
+		},
+		{
+			name: "time is formatted in UTC",
+			build: func(b *visibilityQueryBuilder) *visibilityQueryBuilder {
+				return b.GteTime("T", time.Date(2025, 3, 15, 3, 0, 0, 0, time.FixedZone("UTC+4", 4*60*60)))
+			},
+			expected: `T >= "2025-03-14T23:00:00Z"`,
+		},
+		{
+			name: "starts with escapes the prefix",
+			build: func(b *visibilityQueryBuilder) *visibilityQueryBuilder {
+				return b.StartsWith("WorkflowId", `credit/"x`)
+			},
+			expected: `WorkflowId STARTS_WITH "credit/\"x"`,
+		},
+		{
+			name: "conditions keep their order",
+			build: func(b *visibilityQueryBuilder) *visibilityQueryBuilder {
+				return b.Equals("A", "1").Lte("B", 2).In("C", []string{"3"})
+			},
+			expected: `A = "1" AND B <= 2 AND (C = "3")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.build(newVisibilityQuery()).Build())
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"encore.dev/beta/errs"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	rutranslations "github.com/go-playground/validator/v10/translations/ru"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// DefaultLocale is used when none of the requested locales is supported.
+const DefaultLocale = "en"
+
+// translators holds the seeded locales.
+var translators = ut.New(en.New(), en.New(), ru.New())
+
+func init() {
+	enTrans, _ := translators.GetTranslator("en")
+	ruTrans, _ := translators.GetTranslator("ru")
+	mustRegister(entranslations.RegisterDefaultTranslations(validate, enTrans))
+	mustRegister(rutranslations.RegisterDefaultTranslations(validate, ruTrans))
+	// the ru defaults miss some tags the API uses: datetime for periods (YYYY-MM), the pair for one-of-two fields
+	mustRegister(registerTranslation(ruTrans, "datetime", "{0} должно соответствовать формату {1}", true))
+	mustRegister(registerTranslation(ruTrans, "required_without", "{0} обязательное поле", false))
+	mustRegister(registerTranslation(ruTrans, "excluded_with", "{0} должно отсутствовать", false))
+	// nocontrol, idempotencykey and httpsurl are ours, neither locale has them
+	mustRegister(registerTranslation(enTrans, "nocontrol", "{0} must not contain control characters", false))
+	mustRegister(registerTranslation(ruTrans, "nocontrol", "{0} не должно содержать управляющих символов", false))
+	mustRegister(registerTranslation(enTrans, "idempotencykey", fmt.Sprintf(
+		"{0} must be up to %d letters, digits and - _ . : / and not start with a reserved prefix",
+		domain.MaxIdempotencyKeyLength), false))
+	mustRegister(registerTranslation(ruTrans, "idempotencykey", fmt.Sprintf(
+		"{0} должно содержать до %d букв, цифр и - _ . : / и не начинаться с зарезервированного префикса",
+		domain.MaxIdempotencyKeyLength), false))
+	mustRegister(registerTranslation(enTrans, "httpsurl", "{0} must be an https URL", false))
+	mustRegister(registerTranslation(ruTrans, "httpsurl", "{0} должно быть https URL", false))
+}
+
+// registerTranslation adds a translation of tag to trans, withParam passes the tag parameter as {1}.
+func registerTranslation(trans ut.Translator, tag, text string, withParam bool) error {
+	return validate.RegisterTranslation(tag, trans,
+		func(t ut.Translator) error {
+			return t.Add(tag, text, false)
+		},
+		func(t ut.Translator, fe validator.FieldError) string {
+			params := []string{fe.Field()}
+			if withParam {
+				params = append(params, fe.Param())
+			}
+			msg, _ := t.T(tag, params...)
+
+			return msg
+		})
+}
+
+func mustRegister(err error) {
+	if err != nil {
+		panic(fmt.Sprintf("validation translations: %v", err))
+	}
+}
+
+// StructLocalized is like StructAll, with the messages in the locale.
+// locale can be an Accept-Language value like "ru-RU,ru;q=0.9,en;q=0.8", unsupported locales fall back to English.
+func StructLocalized(s any, locale string) error {
+	if s == nil {
+		return nil
+	}
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) == 0 {
+		return &errs.Error{
+			Code:    errs.InvalidArgument,
+			Message: fmt.Sprintf("Validation failed: %v", err),
+		}
+	}
+
+	trans := translator(locale)
+	details := FieldErrors{Fields: make([]FieldError, 0, len(validationErrors))}
+	msgs := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		msg := fe.Translate(trans)
+		details.Fields = append(details.Fields, FieldError{Field: fe.Field(), Tag: fe.Tag(), Message: msg})
+		msgs = append(msgs, msg)
+	}
+
+	return &errs.Error{
+		Code:    errs.InvalidArgument,
+		Message: strings.Join(msgs, "; "),
+		Details: details,
+	}
+}
+
+// translator picks the first supported locale of an Accept-Language list, the q weights are taken in list order.
+func translator(acceptLanguage string) ut.Translator {
+	var locales []string
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		// "ru-RU" is tried as "ru_ru" and then as the base language "ru"
+		tag = strings.ReplaceAll(tag, "-", "_")
+		locales = append(locales, tag)
+		if base, _, ok := strings.Cut(tag, "_"); ok {
+			locales = append(locales, base)
+		}
+	}
+	if trans, found := translators.FindTranslator(locales...); found {
+		return trans
+	}
+	trans, _ := translators.GetTranslator(DefaultLocale)
+
+	return trans
+}
@@ -0,0 +1,247 @@
+package validation
+
+import (
+	"testing"
+
+	"encore.dev/beta/errs"
+)
+
+type LocalizedStruct struct {
+	BillingPeriod  string `json:"billingPeriod" validate:"required,datetime=2006-01"`
+	Currency       string `json:"currency,omitempty" validate:"required,oneof=GEL USD"`
+	From           string `query:"from" validate:"omitempty,datetime=2006-01"`
+	IdempotencyKey string `header:"Idempotency-Key" validate:"required"`
+	Plain          string `validate:"omitempty,min=2"`
+}
+
+func localizedFields(t *testing.T, err error) []FieldError {
+	t.Helper()
+	encoreErr, ok := err.(*errs.Error)
+	if !ok {
+		t.Fatalf("Expected Encore error, got %T (%v)", err, err)
+	}
+	if encoreErr.Code != errs.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", encoreErr.Code)
+	}
+	details, ok := encoreErr.Details.(FieldErrors)
+	if !ok {
+		t.Fatalf("Expected FieldErrors details, got %T", encoreErr.Details)
+	}
+
+	return details.Fields
+}
+
+func TestStructLocalized(t *testing.T) {
+	input := LocalizedStruct{BillingPeriod: "2025-13", From: "Jan", Plain: "x"}
+
+	tests := []struct {
+		name   string
+		locale string
+		want   []FieldError
+	}{
+		{
+			name:   "English",
+			locale: "en",
+			want: []FieldError{
+				{Field: "billingPeriod", Tag: "datetime", Message: "billingPeriod does not match the 2006-01 format"},
+				{Field: "currency", Tag: "required", Message: "currency is a required field"},
+				{Field: "from", Tag: "datetime", Message: "from does not match the 2006-01 format"},
+				{Field: "Idempotency-Key", Tag: "required", Message: "Idempotency-Key is a required field"},
+				{Field: "Plain", Tag: "min", Message: "Plain must be at least 2 characters in length"},
+			},
+		},
+		{
+			name:   "Russian from Accept-Language",
+			locale: "ru-RU,ru;q=0.9,en;q=0.8",
+			want: []FieldError{
+				{Field: "billingPeriod", Tag: "datetime", Message: "billingPeriod должно соответствовать формату 2006-01"},
+				{Field: "currency", Tag: "required", Message: "currency обязательное поле"},
+				{Field: "from", Tag: "datetime", Message: "from должно соответствовать формату 2006-01"},
+				{Field: "Idempotency-Key", Tag: "required", Message: "Idempotency-Key обязательное поле"},
+				{Field: "Plain", Tag: "min", Message: "Plain должен содержать минимум 2 символа"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := localizedFields(t, StructLocalized(input, tt.locale))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d field errors, got %+v", len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Field error %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestStructLocalized_Fallbacks(t *testing.T) {
+	english := "currency is a required field"
+	for _, locale := range []string{"", "*", "ka-GE", "de;q=1.0, fr"} {
+		fields := localizedFields(t, StructLocalized(LocalizedStruct{
+			BillingPeriod: "2025-01", IdempotencyKey: "k",
+		}, locale))
+		if len(fields) != 1 || fields[0].Message != english {
+			t.Errorf("locale %q: expected the English message %q, got %+v", locale, english, fields)
+		}
+	}
+
+	// the first supported locale of the list wins
+	fields := localizedFields(t, StructLocalized(LocalizedStruct{BillingPeriod: "2025-01", IdempotencyKey: "k"}, "ka, RU"))
+	if fields[0].Message != "currency обязательное поле" {
+		t.Errorf("Expected the Russian message, got %q", fields[0].Message)
+	}
+}
+
+func TestStructLocalized_Valid(t *testing.T) {
+	valid := LocalizedStruct{BillingPeriod: "2025-01", Currency: "GEL", IdempotencyKey: "k"}
+	if err := StructLocalized(valid, "ru"); err != nil {
+		t.Errorf("StructLocalized() returned error for valid input: %v", err)
+	}
+	if err := StructLocalized(nil, "en"); err != nil {
+		t.Errorf("StructLocalized(nil) = %v, want nil", err)
+	}
+}
+
+func TestStructLocalized_NoControl(t *testing.T) {
+	type tagged struct {
+		Tags map[string]string `json:"tags" validate:"dive,keys,nocontrol,endkeys,nocontrol"`
+	}
+
+	tests := []struct {
+		name   string
+		tags   map[string]string
+		locale string
+		want   []FieldError
+	}{
+		{name: "printable", tags: map[string]string{"costCenter": "R&D — Tbilisi"}, locale: "en"},
+		{
+			name:   "newline in a value",
+			tags:   map[string]string{"sku": "API\n100"},
+			locale: "en",
+			want: []FieldError{
+				{Field: "tags[sku]", Tag: "nocontrol", Message: "tags[sku] must not contain control characters"},
+			},
+		},
+		{
+			name:   "tab in a key",
+			tags:   map[string]string{"cost\tcenter": "R&D"},
+			locale: "ru",
+			want: []FieldError{
+				{Field: "tags[cost\tcenter]", Tag: "nocontrol", Message: "tags[cost\tcenter] не должно содержать управляющих символов"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StructLocalized(tagged{Tags: tt.tags}, tt.locale)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("StructLocalized() returned error for valid input: %v", err)
+				}
+
+				return
+			}
+			got := localizedFields(t, err)
+			if len(got) != len(tt.want) || got[0] != tt.want[0] {
+				t.Errorf("Field errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructLocalized_IdempotencyKey(t *testing.T) {
+	type item struct {
+		IdempotencyKey string `json:"IdempotencyKey" validate:"required,idempotencykey"`
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		locale string
+		want   []FieldError
+	}{
+		{name: "uuid", key: "0b7e4a9c-3f1d-4c2a-9e8b-5d6f7a8b9c0d", locale: "en"},
+		{name: "prefixed", key: "order:2025/01.fee_1", locale: "en"},
+		{
+			name:   "space",
+			key:    "li 1",
+			locale: "en",
+			want: []FieldError{{Field: "IdempotencyKey", Tag: "idempotencykey",
+				Message: "IdempotencyKey must be up to 255 letters, digits and - _ . : / and not start with a reserved prefix"}},
+		},
+		{
+			name:   "reserved prefix",
+			key:    "tax:US-CA",
+			locale: "ru",
+			want: []FieldError{{Field: "IdempotencyKey", Tag: "idempotencykey",
+				Message: "IdempotencyKey должно содержать до 255 букв, цифр и - _ . : / и не начинаться с зарезервированного префикса"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StructLocalized(item{IdempotencyKey: tt.key}, tt.locale)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("StructLocalized() returned error for valid input: %v", err)
+				}
+
+				return
+			}
+			got := localizedFields(t, err)
+			if len(got) != len(tt.want) || got[0] != tt.want[0] {
+				t.Errorf("Field errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructLocalized_HTTPSURL(t *testing.T) {
+	type request struct {
+		WebhookURL string `json:"webhookUrl" validate:"omitempty,httpsurl"`
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		locale string
+		want   []FieldError
+	}{
+		{name: "https", url: "https://customer.example.com/hooks/bills", locale: "en"},
+		{name: "empty", url: "", locale: "en"},
+		{
+			name:   "http",
+			url:    "http://customer.example.com/hooks/bills",
+			locale: "en",
+			want:   []FieldError{{Field: "webhookUrl", Tag: "httpsurl", Message: "webhookUrl must be an https URL"}},
+		},
+		{
+			name:   "no host",
+			url:    "https:///hooks",
+			locale: "ru",
+			want:   []FieldError{{Field: "webhookUrl", Tag: "httpsurl", Message: "webhookUrl должно быть https URL"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := StructLocalized(request{WebhookURL: tt.url}, tt.locale)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("StructLocalized() returned error for valid input: %v", err)
+				}
+
+				return
+			}
+			got := localizedFields(t, err)
+			if len(got) != len(tt.want) || got[0] != tt.want[0] {
+				t.Errorf("Field errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,13 +3,36 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode"
 
 	"encore.dev/beta/errs"
 	"github.com/go-playground/validator/v10"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
 )
 
 // validate holds the singleton validator instance, for input structure validation.
-var validate = validator.New(validator.WithRequiredStructEnabled())
+// Fields are named like on the wire (json/query/header tags), see wireFieldName.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(wireFieldName)
+	if err := v.RegisterValidation("nocontrol", noControlChars); err != nil {
+		panic(fmt.Sprintf("validation nocontrol: %v", err))
+	}
+	if err := v.RegisterValidation("idempotencykey", idempotencyKey); err != nil {
+		panic(fmt.Sprintf("validation idempotencykey: %v", err))
+	}
+	if err := v.RegisterValidation("httpsurl", httpsURL); err != nil {
+		panic(fmt.Sprintf("validation httpsurl: %v", err))
+	}
+
+	return v
+}
 
 // Struct validates a struct using the 'validate' tags.
 // It returns an Encore-compatible error if validation fails.
@@ -22,12 +45,9 @@ func Struct(s any) error {
 		var validationErrors validator.ValidationErrors
 		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
 			// Create a user-friendly error message with specific field and rule
-			firstErr := validationErrors[0]
-			msg := fmt.Sprintf("Validation failed for field '%s' with rule '%s'", firstErr.Field(), firstErr.Tag())
-
 			return &errs.Error{
 				Code:    errs.InvalidArgument,
-				Message: msg,
+				Message: fieldMessage(validationErrors[0]),
 			}
 		}
 
@@ -41,3 +61,89 @@ func Struct(s any) error {
 
 	return nil
 }
+
+// FieldError is one failed rule of a StructAll error.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Message string `json:"message"`
+}
+
+// FieldErrors are the Details of a StructAll error, one entry per failed field rule.
+type FieldErrors struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (FieldErrors) ErrDetails() {}
+
+// StructAll is like Struct, but reports every failed field in the Details (FieldErrors), not just the first,
+// so a client can fix a whole form at once. With a single failure, the message is the same as Struct's.
+func StructAll(s any) error {
+	if s == nil {
+		return nil
+	}
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) || len(validationErrors) == 0 {
+		return &errs.Error{
+			Code:    errs.InvalidArgument,
+			Message: fmt.Sprintf("Validation failed: %v", err),
+		}
+	}
+
+	details := FieldErrors{Fields: make([]FieldError, 0, len(validationErrors))}
+	names := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		details.Fields = append(details.Fields, FieldError{Field: fe.Field(), Tag: fe.Tag(), Message: fieldMessage(fe)})
+		names = append(names, fmt.Sprintf("'%s' (%s)", fe.Field(), fe.Tag()))
+	}
+	msg := details.Fields[0].Message
+	if len(names) > 1 {
+		msg = fmt.Sprintf("Validation failed for %d fields: %s", len(names), strings.Join(names, ", "))
+	}
+
+	return &errs.Error{
+		Code:    errs.InvalidArgument,
+		Message: msg,
+		Details: details,
+	}
+}
+
+// noControlChars is the "nocontrol" rule: a string without control characters (newlines, tabs, escapes),
+// e.g. for values passed on to reports and logs as is.
+func noControlChars(fl validator.FieldLevel) bool {
+	return !strings.ContainsFunc(fl.Field().String(), unicode.IsControl)
+}
+
+// idempotencyKey is the "idempotencykey" rule: a line item key of an API caller, see
+// domain.ValidateExternalIdempotencyKey.
+func idempotencyKey(fl validator.FieldLevel) bool {
+	return domain.ValidateExternalIdempotencyKey(fl.Field().String()) == nil
+}
+
+// httpsURL is the "httpsurl" rule: an absolute https URL, e.g. for the customer webhooks that carry bill data.
+func httpsURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+
+	return err == nil && u.Scheme == "https" && u.Host != ""
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	return fmt.Sprintf("Validation failed for field '%s' with rule '%s'", fe.Field(), fe.Tag())
+}
+
+// wireFieldName is the name a client sees, e.g. "billingPeriod", "from" or "Idempotency-Key".
+// Fields without a tag (or with json:"-") keep the Go name.
+func wireFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "header"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
@@ -275,12 +275,22 @@ func TestStruct_EdgeCases(t *testing.T) {
 	}
 }
 
+// JSONStruct is named on the wire differently from Go.
+type JSONStruct struct {
+	BillingPeriod string `json:"billingPeriod" validate:"required"`
+	CustomerName  string `json:"customer_name,omitempty" validate:"required"`
+	Period        string `query:"from" validate:"required"`
+	Key           string `header:"Idempotency-Key" validate:"required"`
+	Hidden        string `json:"-" validate:"required"`
+}
+
 func TestStruct_ErrorMessageFormat(t *testing.T) {
-	input := TestStruct{
-		Email:  "john@example.com",
-		Age:    25,
-		Status: "active",
-		// Name is missing (required)
+	input := JSONStruct{
+		CustomerName: "John",
+		Period:       "2025-01",
+		Key:          "k",
+		Hidden:       "h",
+		// BillingPeriod is missing (required)
 	}
 
 	err := Struct(input)
@@ -293,13 +303,31 @@ func TestStruct_ErrorMessageFormat(t *testing.T) {
 		t.Fatalf("Expected Encore error, got %T", err)
 	}
 
-	// Check error message format
-	expectedPrefix := "Validation failed for field 'Name' with rule 'required'"
+	// Check error message format, the field is named like in the JSON body
+	expectedPrefix := "Validation failed for field 'billingPeriod' with rule 'required'"
 	if encoreErr.Message != expectedPrefix {
 		t.Errorf("Expected error message '%s', got '%s'", expectedPrefix, encoreErr.Message)
 	}
 }
 
+func TestStruct_WireFieldNames(t *testing.T) {
+	err := StructAll(JSONStruct{})
+	encoreErr, ok := err.(*errs.Error)
+	if !ok {
+		t.Fatalf("Expected Encore error, got %T", err)
+	}
+	details := encoreErr.Details.(FieldErrors)
+	want := []string{"billingPeriod", "customer_name", "from", "Idempotency-Key", "Hidden"}
+	if len(details.Fields) != len(want) {
+		t.Fatalf("Expected %d field errors, got %+v", len(want), details.Fields)
+	}
+	for i, name := range want {
+		if details.Fields[i].Field != name {
+			t.Errorf("Field %d = %q, want %q", i, details.Fields[i].Field, name)
+		}
+	}
+}
+
 func TestStruct_MultipleErrors(t *testing.T) {
 	// Test that only the first error is returned
 	input := TestStruct{
@@ -325,6 +353,71 @@ func TestStruct_MultipleErrors(t *testing.T) {
 	}
 }
 
+func TestStructAll_ReportsAllErrors(t *testing.T) {
+	input := TestStruct{
+		Email:  "invalid-email",
+		Age:    5,
+		Status: "invalid-status",
+	}
+
+	err := StructAll(input)
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	encoreErr, ok := err.(*errs.Error)
+	if !ok {
+		t.Fatalf("Expected Encore error, got %T", err)
+	}
+	if encoreErr.Code != errs.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", encoreErr.Code)
+	}
+	details, ok := encoreErr.Details.(FieldErrors)
+	if !ok {
+		t.Fatalf("Expected FieldErrors details, got %T", encoreErr.Details)
+	}
+
+	want := []FieldError{
+		{Field: "Name", Tag: "required", Message: "Validation failed for field 'Name' with rule 'required'"},
+		{Field: "Email", Tag: "email", Message: "Validation failed for field 'Email' with rule 'email'"},
+		{Field: "Age", Tag: "min", Message: "Validation failed for field 'Age' with rule 'min'"},
+		{Field: "Status", Tag: "oneof", Message: "Validation failed for field 'Status' with rule 'oneof'"},
+	}
+	if len(details.Fields) != len(want) {
+		t.Fatalf("Expected %d field errors, got %+v", len(want), details.Fields)
+	}
+	for i, fe := range want {
+		if details.Fields[i] != fe {
+			t.Errorf("Field error %d = %+v, want %+v", i, details.Fields[i], fe)
+		}
+	}
+	wantMsg := "Validation failed for 4 fields: 'Name' (required), 'Email' (email), 'Age' (min), 'Status' (oneof)"
+	if encoreErr.Message != wantMsg {
+		t.Errorf("Expected message %q, got %q", wantMsg, encoreErr.Message)
+	}
+}
+
+func TestStructAll_SingleErrorAndValid(t *testing.T) {
+	err := StructAll(SimpleStruct{})
+	encoreErr, ok := err.(*errs.Error)
+	if !ok {
+		t.Fatalf("Expected Encore error, got %T", err)
+	}
+	// same message as Struct, the details have the one field
+	if want := Struct(SimpleStruct{}).(*errs.Error).Message; encoreErr.Message != want {
+		t.Errorf("Expected message %q, got %q", want, encoreErr.Message)
+	}
+	if details := encoreErr.Details.(FieldErrors); len(details.Fields) != 1 || details.Fields[0].Field != "Value" {
+		t.Errorf("Unexpected details %+v", details)
+	}
+
+	if err := StructAll(SimpleStruct{Value: "ok"}); err != nil {
+		t.Errorf("StructAll() returned error for valid input: %v", err)
+	}
+	if err := StructAll(nil); err != nil {
+		t.Errorf("StructAll(nil) = %v, want nil", err)
+	}
+}
+
 // Helper function for string contains check
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
@@ -3,17 +3,25 @@ package feesapi
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"encore.dev"
 	"encore.dev/beta/errs"
 	"encore.dev/middleware"
 	"encore.dev/rlog"
+	"github.com/google/uuid"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
 	"github.com/outofboxer/temporal-workflow/fees/app/usecases"
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
 	"github.com/outofboxer/temporal-workflow/fees/internal/validation"
 
@@ -36,15 +44,69 @@ func ValidationMiddleware(req middleware.Request, next middleware.Next) middlewa
 	return next(req)
 }
 
+// correlationIDHeader lets clients pass their own request ID, it's echoed into the Temporal memo and signals.
+const correlationIDHeader = "X-Correlation-ID"
+
+//encore:middleware target=all
+func CorrelationIDMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	data := req.Data()
+	cid := data.Headers.Get(correlationIDHeader)
+	if cid == "" && data.Trace != nil {
+		// Encore trace ID, so the bill logs can be matched with the request trace
+		cid = data.Trace.ExtCorrelationID
+		if cid == "" {
+			cid = data.Trace.TraceID
+		}
+	}
+	if cid == "" {
+		// the use cases generate one
+		return next(req)
+	}
+
+	return next(req.WithContext(app.WithCorrelationID(req.Context(), cid)))
+}
+
+// runIDHeader targets a run of the bill instead of the latest one, e.g. the run a client read before acting on
+// it. A signal to a run that isn't running anymore is rejected, so nothing lands on a run the client hasn't seen.
+// A run ID is of one bill, so only the endpoints of a single bill (tag:run) take it, the header is ignored by
+// those acting on several bills, e.g. CloseAllBills or PurgeBills.
+const runIDHeader = "X-Run-ID"
+
+//encore:middleware target=tag:run
+func RunIDMiddleware(req middleware.Request, next middleware.Next) middleware.Response {
+	runID := req.Data().Headers.Get(runIDHeader)
+	if runID == "" {
+		return next(req)
+	}
+	// Temporal run IDs are UUIDs, anything else would be a not found run
+	if _, err := uuid.Parse(runID); err != nil {
+		return middleware.Response{Err: &errs.Error{Code: errs.InvalidArgument, Message: runIDHeader + " must be a UUID"}}
+	}
+
+	return next(req.WithContext(app.WithRunID(req.Context(), runID)))
+}
+
 // CreateBillRequest is the request body for creating a new bill.
 type CreateBillRequest struct {
 	Currency      libmoney.Currency `json:"currency" validate:"required,oneof=GEL USD"`
 	BillingPeriod string            `json:"billingPeriod" validate:"required,datetime=2006-01"` // Validates YYYY-MM format
+	// Optional tax jurisdiction, e.g. "GE" or "US-CA". Tax is added as a line item at close time.
+	Jurisdiction string `json:"jurisdiction" validate:"omitempty,min=2,max=64"`
+	// Optional, a retry with the same key gets 200 with the existing bill instead of 409.
+	IdempotencyKey string `header:"Idempotency-Key" validate:"omitempty,max=255"`
+	// Optional, the bill starts with the base fees of this template, e.g. "standard".
+	TemplateID string `json:"templateId" validate:"omitempty,max=64"`
+	// Optional, the URL notified with a signed POST once the bill is closed.
+	WebhookURL string `json:"webhookUrl" validate:"omitempty,httpsurl,max=2048"`
+	// Optional, whether the bill closes itself when its period ends, Billing.AutoClose of the config by default.
+	AutoClose *bool `json:"autoClose,omitempty"`
+	// AcceptLanguage localizes the validation messages, English by default.
+	AcceptLanguage string `header:"Accept-Language"`
 }
 
 func (cbr *CreateBillRequest) Validate() error {
-	// Use the helper to validate the query parameter struct.
-	if err := validation.Struct(cbr); err != nil {
+	// All the failed fields at once, named and worded for the client, see validation.StructLocalized.
+	if err := validation.StructLocalized(cbr, cbr.AcceptLanguage); err != nil {
 		return err
 	}
 
@@ -52,23 +114,35 @@ func (cbr *CreateBillRequest) Validate() error {
 }
 
 type BillResponse struct {
-	ID            string                 `json:"id"`
-	CustomerID    string                 `json:"customerId"`
-	Currency      string                 `json:"currency"`
-	BillingPeriod string                 `json:"billingPeriod"`
-	Status        string                 `json:"status"`
-	Items         []BillLineItemResponse `json:"items"`
-	Total         string                 `json:"total"`
-	CreatedAt     time.Time              `json:"createdAt"`
-	UpdatedAt     time.Time              `json:"updatedAt"`
-	ClosedAt      *time.Time             `json:"closedAt,omitempty"`
+	ID            string `json:"id"`
+	CustomerID    string `json:"customerId"`
+	Currency      string `json:"currency"`
+	BillingPeriod string `json:"billingPeriod"`
+	Status        string `json:"status"`
+	// Items is null for the summary view. They're ordered by addedAt, then by idempotencyKey.
+	Items     []BillLineItemResponse `json:"items"`
+	ItemCount int64                  `json:"itemCount"`
+	Total     string                 `json:"total"`
+	// TotalMinor is Total in minor units of Currency (e.g. cents), rounded half away from zero, for exact math.
+	TotalMinor int64      `json:"totalMinor"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	ClosedAt   *time.Time `json:"closedAt,omitempty"`
+	// Notes is the internal note of the account managers, omitted when empty.
+	Notes string `json:"notes,omitempty"`
+	// InvoiceURI is where the final invoice is archived, omitted until the bill is charged.
+	InvoiceURI string `json:"invoiceUri,omitempty"`
+	// PeriodStart and PeriodEnd are the first and the last instant of the billing period, in UTC.
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
 }
 
 type BillLineItemResponse struct {
-	IdempotencyKey string         `json:"idempotencyKey"`
-	Description    string         `json:"description"`
-	Amount         libmoney.Money `json:"amount"`
-	AddedAt        time.Time      `json:"addedAt"`
+	IdempotencyKey string            `json:"idempotencyKey"`
+	Description    string            `json:"description"`
+	Amount         libmoney.Money    `json:"amount"`
+	AddedAt        time.Time         `json:"addedAt"`
+	Tags           map[string]string `json:"tags,omitempty"`
 }
 
 type CreateBillResponse struct {
@@ -84,45 +158,113 @@ func (s *Service) CreateBill(
 	customerID string,
 	req *CreateBillRequest,
 ) (*CreateBillResponse, error) {
+	return s.createBill(ctx, usecases.CreateBillCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(req.BillingPeriod), Currency: req.Currency,
+		Jurisdiction: req.Jurisdiction, IdempotencyKey: req.IdempotencyKey, TemplateID: req.TemplateID,
+		WebhookURL: req.WebhookURL, AutoClose: req.AutoClose,
+	})
+}
+
+// CreateBillForPeriodRequest is the request body for creating a bill addressed by the period path param.
+// BillingPeriod is optional here, if it's given, it must match the path period.
+type CreateBillForPeriodRequest struct {
+	Currency      libmoney.Currency `json:"currency" validate:"required,oneof=GEL USD"`
+	BillingPeriod string            `json:"billingPeriod" validate:"omitempty,datetime=2006-01"` // Validates YYYY-MM format
+	// Optional tax jurisdiction, e.g. "GE" or "US-CA". Tax is added as a line item at close time.
+	Jurisdiction string `json:"jurisdiction" validate:"omitempty,min=2,max=64"`
+	// Optional, a retry with the same key gets 200 with the existing bill instead of 409.
+	IdempotencyKey string `header:"Idempotency-Key" validate:"omitempty,max=255"`
+	// Optional, the bill starts with the base fees of this template, e.g. "standard".
+	TemplateID string `json:"templateId" validate:"omitempty,max=64"`
+	// Optional, the URL notified with a signed POST once the bill is closed.
+	WebhookURL string `json:"webhookUrl" validate:"omitempty,httpsurl,max=2048"`
+	// Optional, whether the bill closes itself when its period ends, Billing.AutoClose of the config by default.
+	AutoClose *bool `json:"autoClose,omitempty"`
+	// AcceptLanguage localizes the validation messages, English by default.
+	AcceptLanguage string `header:"Accept-Language"`
+}
+
+func (cbr *CreateBillForPeriodRequest) Validate() error {
+	// All the failed fields at once, named and worded for the client, see validation.StructLocalized.
+	if err := validation.StructLocalized(cbr, cbr.AcceptLanguage); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateBillForPeriod initiates a new monthly bill, the period is taken from the path like in other bill endpoints,
+// so workflow ID and Location are built from the same source as add/close/get use.
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period tag:validation
+func (s *Service) CreateBillForPeriod(
+	ctx context.Context,
+	customerID string,
+	period string,
+	req *CreateBillForPeriodRequest,
+) (*CreateBillResponse, error) {
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+	// body period is redundant here, but if a client sends it, it must not diverge from the path one.
+	if req.BillingPeriod != "" && req.BillingPeriod != period {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("billingPeriod in body does not match period in path").Err()
+	}
+
+	return s.createBill(ctx, usecases.CreateBillCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(period), Currency: req.Currency,
+		Jurisdiction: req.Jurisdiction, IdempotencyKey: req.IdempotencyKey, TemplateID: req.TemplateID,
+		WebhookURL: req.WebhookURL, AutoClose: req.AutoClose,
+	})
+}
+
+func (s *Service) createBill(ctx context.Context, cmd usecases.CreateBillCmd) (*CreateBillResponse, error) {
 	// Add a simple manual check for the path parameter.
-	if customerID == "" || len(customerID) > 1024 {
+	if cmd.CustomerID == "" || len(cmd.CustomerID) > 1024 {
 		return nil, &errs.Error{
 			Code:    errs.InvalidArgument,
 			Message: "customerId should be not empty and fit length restriction",
 		}
 	}
 
-	b, err := s.Create.Handle(ctx, usecases.CreateBillCmd{
-		CustomerID: customerID, Period: domain.BillingPeriod(req.BillingPeriod), Currency: req.Currency,
-	})
-	if err != nil {
+	res, err := s.Create.Handle(ctx, cmd)
+	if err = logAuditFailure(err); err != nil {
 		rlog.Error("Create.Handle", "err", err)
-		if errors.Is(err, app.ErrBillWithPeriodAlreadyStarted) {
-			// this code also sets 409 Conflict
-			return nil, errs.B().Code(errs.AlreadyExists).Msg("a bill already exists for this customer and period").Err()
-		}
-		// map adapter error strings/types to HTTP codes as needed
-		return nil, errs.B().Code(errs.Internal).Cause(err).Msg("create bill error in api").Err()
+
+		return nil, toHTTPError(err, "create bill error in api")
+	}
+	// make it RESTful, the same period is used for the workflow ID above, so they cannot diverge.
+	loc := fmt.Sprintf("/api/v1/customers/%s/bills/%s", cmd.CustomerID, cmd.Period)
+
+	status := http.StatusCreated
+	if res.Replayed {
+		// a retry of the request that created the bill
+		status = http.StatusOK
 	}
-	loc := fmt.Sprintf("/api/v1/customers/%s/bills/%s", customerID, req.BillingPeriod) // make it RESTful
 
 	return &CreateBillResponse{
-		Message:  map2BillingResponse(b),
-		Status:   http.StatusCreated,
+		Message:  map2BillingResponse(res.Bill),
+		Status:   status,
 		Location: loc,
 	}, nil
 }
 
 type AddLineItemRequest struct {
-	Description    string `json:"description" validate:"required,min=2,max=1024"`
-	Amount         string `json:"amount" validate:"required,min=1,max=100"`
-	IdempotencyKey string `json:"IdempotencyKey" validate:"required,min=1,max=1024"`
-	// currency enforced in workflow to match bill currency
+	Description string `json:"description" validate:"required,min=2,max=1024"`
+	// Exactly one of Amount (decimal, "10.50") and AmountMinor (integer minor units, "1050") is required.
+	Amount      string `json:"amount" validate:"required_without=AmountMinor,excluded_with=AmountMinor,max=100"`
+	AmountMinor string `json:"amountMinor" validate:"omitempty,max=20"`
+	// IdempotencyKey is up to 255 letters, digits and - _ . : /, the system:, tax: and template: prefixes are reserved.
+	IdempotencyKey string `json:"IdempotencyKey" validate:"required,idempotencykey"`
+	// Tags are optional key-values for downstream reporting, e.g. {"costCenter": "R&D", "sku": "API-100"}.
+	Tags map[string]string `json:"tags" validate:"omitempty,max=20,dive,keys,min=1,max=64,nocontrol,endkeys,max=256,nocontrol"`
+	// the amount is in the bill currency, there is no currency field
+	// AcceptLanguage localizes the validation messages, English by default.
+	AcceptLanguage string `header:"Accept-Language"`
 }
 
 func (cbr *AddLineItemRequest) Validate() error {
-	// Use the helper to validate the query parameter struct.
-	if err := validation.Struct(cbr); err != nil {
+	// All the failed fields at once, named and worded for the client, see validation.StructLocalized.
+	if err := validation.StructLocalized(cbr, cbr.AcceptLanguage); err != nil {
 		return err
 	}
 
@@ -130,7 +272,7 @@ func (cbr *AddLineItemRequest) Validate() error {
 }
 
 // AddLineItem sends a Temporal Signal to an open bill's workflow to add a new fee.
-// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/items tag:validation
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/items tag:validation tag:run
 func (s *Service) AddLineItem(
 	ctx context.Context,
 	customerID string,
@@ -140,34 +282,142 @@ func (s *Service) AddLineItem(
 	if _, err := time.Parse("2006-01", period); err != nil {
 		return nil, errs.B().Code(errs.InvalidArgument).Msg("invalid period").Cause(err).Err()
 	}
-	// currency enforced in workflow as derived from Bill Currency
-	amount, err := libmoney.NewFromString(req.Amount, libmoney.CurrencyNone)
-	if err != nil {
-		return nil, errs.B().Code(errs.InvalidArgument).Msg("amount is invalid").Err()
+	// before any Temporal call, a flood of signals would clog the bill workflow event loop
+	if !s.addItemLimiter.Allow(domain.MakeBillID(customerID, domain.BillingPeriod(period))) {
+		return nil, &errs.Error{Code: errs.ResourceExhausted, Message: "too many line items for this bill, retry later"}
 	}
-
 	item := domain.LineItem{
 		Description:    req.Description,
-		Amount:         amount,
 		IdempotencyKey: req.IdempotencyKey,
+		Tags:           req.Tags,
 	}
+	// the amount is parsed in the bill currency, a missing or closed bill is rejected before the signal
+	// and the workflow doesn't have to relabel it
 	b, err := s.AddItem.Handle(ctx, usecases.AddLineItemCmd{
 		CustomerID: customerID, Period: domain.BillingPeriod(period), Item: item,
+		RawAmount: usecases.AmountInput{Amount: req.Amount, AmountMinor: req.AmountMinor},
 	})
-	if err != nil {
+	if err = logAuditFailure(err); err != nil {
 		rlog.Error("AddItem.Handle", "err", err)
-		if errors.Is(err, app.ErrLineItemAlreadyAdded) {
-			// this code also sets 409 Conflict
-			return nil, errs.B().Code(errs.AlreadyExists).Msg("the line item already added").Err()
-		}
-		if errors.Is(err, app.ErrBillNotFound) {
-			return nil, errs.B().Code(errs.NotFound).Msg("bill not found").Err()
-		}
-		if errors.Is(err, app.ErrBillAlreadyClosed) {
-			return nil, errs.B().Code(errs.FailedPrecondition).Msg("bill already closed").Err()
-		}
 
-		return nil, errs.B().Cause(err).Msg("add item").Err()
+		return nil, toHTTPError(err, "add item")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+// UpdateLineItemRequest is the request body for correcting a line item, only the description can be changed.
+type UpdateLineItemRequest struct {
+	Description string `json:"description" validate:"required,min=2,max=1024"`
+}
+
+func (cbr *UpdateLineItemRequest) Validate() error {
+	// Use the helper to validate the query parameter struct.
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateLineItem sends a Temporal Signal to an open bill's workflow to correct a fee description.
+// The amount is never changed, so the bill total stays the same.
+// encore:api public method=PATCH path=/api/v1/customers/:customerID/bills/:period/items/:key tag:validation tag:run
+func (s *Service) UpdateLineItem(
+	ctx context.Context,
+	customerID string,
+	period string,
+	key string,
+	req *UpdateLineItemRequest,
+) (*BillResponse, error) {
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("invalid period").Cause(err).Err()
+	}
+
+	b, err := s.Update.Handle(ctx, usecases.UpdateLineItemDescriptionCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(period), IdempotencyKey: key, Description: req.Description,
+	})
+	if err != nil {
+		rlog.Error("Update.Handle", "err", err)
+
+		return nil, toHTTPError(err, "update item")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+// CorrectLineItemAmountRequest is the request body for correcting a line item amount, the key stays the same.
+type CorrectLineItemAmountRequest struct {
+	// Exactly one of Amount (decimal, "10.50") and AmountMinor (integer minor units, "1050") is required.
+	Amount      string `json:"amount" validate:"required_without=AmountMinor,excluded_with=AmountMinor,max=100"`
+	AmountMinor string `json:"amountMinor" validate:"omitempty,max=20"`
+}
+
+func (cbr *CorrectLineItemAmountRequest) Validate() error {
+	return validation.Struct(cbr)
+}
+
+// CorrectLineItemAmount sends a Temporal Signal to an open bill's workflow to correct a fee amount in place,
+// the bill total follows. Repeating the same correction changes nothing.
+// encore:api public method=PATCH path=/api/v1/customers/:customerID/bills/:period/items/:key/amount tag:validation tag:run
+func (s *Service) CorrectLineItemAmount(
+	ctx context.Context,
+	customerID string,
+	period string,
+	key string,
+	req *CorrectLineItemAmountRequest,
+) (*BillResponse, error) {
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"}
+	}
+	// parsed in the bill currency, e.g. amountMinor 1050 is 10.50 on a USD bill, ¥1050 on a JPY one
+	b, err := s.Correct.Handle(ctx, usecases.CorrectLineItemAmountCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(period), IdempotencyKey: key,
+		RawAmount: usecases.AmountInput{Amount: req.Amount, AmountMinor: req.AmountMinor},
+	})
+	if err != nil {
+		rlog.Error("Correct.Handle", "err", err)
+
+		return nil, toHTTPError(err, "correct item amount")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+// SetBillNoteRequest is the request body for the internal note of a bill, an empty note clears it.
+type SetBillNoteRequest struct {
+	Note string `json:"note" validate:"max=4096"`
+}
+
+func (cbr *SetBillNoteRequest) Validate() error {
+	// Use the helper to validate the query parameter struct.
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetBillNote sends a Temporal Signal to an open bill's workflow to replace its internal note.
+// The note never changes the total or the status.
+// encore:api public method=PATCH path=/api/v1/customers/:customerID/bills/:period/note tag:validation tag:run
+func (s *Service) SetBillNote(
+	ctx context.Context,
+	customerID string,
+	period string,
+	req *SetBillNoteRequest,
+) (*BillResponse, error) {
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"}
+	}
+
+	b, err := s.Note.Handle(ctx, usecases.SetBillNoteCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(period), Note: req.Note,
+	})
+	if err != nil {
+		rlog.Error("Note.Handle", "err", err)
+
+		return nil, toHTTPError(err, "set note")
 	}
 
 	return map2BillingResponse(b), nil
@@ -175,11 +425,26 @@ func (s *Service) AddLineItem(
 
 // ListBillsQueryParams defines the query parameters for the ListBills endpoint.
 type ListBillsQueryParams struct {
-	// Filter results by bill status (OPEN or CLOSED).
+	// Filter results by bill status (OPEN, CLOSED, ERROR, CHARGE_FAILED, REJECTED or WRITTEN_OFF), as CountBills.
 	// This must be a pointer to a built-in type, like *string.
-	Status      string `query:"status" validate:"oneof=OPEN CLOSED"`
+	Status      string `query:"status" validate:"oneof=OPEN CLOSED ERROR CHARGE_FAILED REJECTED WRITTEN_OFF"`
 	PeriodStart string `query:"from" validate:"datetime=2006-01"` // Validates YYYY-MM format
 	PeriodEnd   string `query:"to" validate:"datetime=2006-01"`   // Validates YYYY-MM format
+	// Keep bills finalized within the last N days, relative to now on the server.
+	FinalizedWithinDays int `query:"finalizedWithinDays" validate:"omitempty,min=1,max=3660"`
+	// Inclusive bounds on the bill total as decimal strings, e.g. 10.50.
+	MinTotal string `query:"minTotal" validate:"omitempty,numeric,max=32"`
+	MaxTotal string `query:"maxTotal" validate:"omitempty,numeric,max=32"`
+	// Inclusive bounds on the number of line items, maxItems=0 finds bills that never received any.
+	MinItems string `query:"minItems" validate:"omitempty,number,max=9"`
+	MaxItems string `query:"maxItems" validate:"omitempty,number,max=9"`
+	// Inclusive bounds on when the bill was started, RFC 3339 e.g. 2025-01-15T00:00:00Z.
+	CreatedFrom string `query:"createdFrom" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	CreatedTo   string `query:"createdTo" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Keeps bills whose billing period overlaps the range, RFC 3339. Both set to the same instant finds the
+	// bills active at that instant.
+	ActiveFrom string `query:"activeFrom" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	ActiveTo   string `query:"activeTo" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
 
 func (cbr *ListBillsQueryParams) Validate() error {
@@ -187,6 +452,182 @@ func (cbr *ListBillsQueryParams) Validate() error {
 	if err := validation.Struct(cbr); err != nil {
 		return err
 	}
+	if err := validatePeriodRange(cbr.PeriodStart, cbr.PeriodEnd); err != nil {
+		return err
+	}
+	minTotal, maxTotal, err := cbr.totalCents()
+	if err != nil {
+		return err
+	}
+	if minTotal != nil && maxTotal != nil && *minTotal > *maxTotal {
+		return &errs.Error{Code: errs.InvalidArgument, Message: app.ErrInvalidTotalRange.Error()}
+	}
+	minItems, maxItems, err := cbr.itemCounts()
+	if err != nil {
+		return err
+	}
+	if minItems != nil && maxItems != nil && *minItems > *maxItems {
+		return &errs.Error{Code: errs.InvalidArgument, Message: app.ErrInvalidItemCountRange.Error()}
+	}
+	createdFrom, createdTo, err := cbr.createdRange()
+	if err != nil {
+		return err
+	}
+	if createdFrom != nil && createdTo != nil && createdFrom.After(*createdTo) {
+		return &errs.Error{Code: errs.InvalidArgument, Message: app.ErrInvalidCreatedRange.Error()}
+	}
+	activeFrom, activeTo, err := cbr.activeRange()
+	if err != nil {
+		return err
+	}
+	if activeFrom != nil && activeTo != nil && activeFrom.After(*activeTo) {
+		return &errs.Error{Code: errs.InvalidArgument, Message: app.ErrInvalidActiveRange.Error()}
+	}
+
+	return nil
+}
+
+// createdRange parses CreatedFrom and CreatedTo, nil when not set.
+func (cbr *ListBillsQueryParams) createdRange() (createdFrom, createdTo *time.Time, err error) {
+	if createdFrom, err = parseCreatedAt("createdFrom", cbr.CreatedFrom); err != nil {
+		return nil, nil, err
+	}
+	if createdTo, err = parseCreatedAt("createdTo", cbr.CreatedTo); err != nil {
+		return nil, nil, err
+	}
+
+	return createdFrom, createdTo, nil
+}
+
+// activeRange parses ActiveFrom and ActiveTo, nil when not set.
+func (cbr *ListBillsQueryParams) activeRange() (activeFrom, activeTo *time.Time, err error) {
+	if activeFrom, err = parseCreatedAt("activeFrom", cbr.ActiveFrom); err != nil {
+		return nil, nil, err
+	}
+	if activeTo, err = parseCreatedAt("activeTo", cbr.ActiveTo); err != nil {
+		return nil, nil, err
+	}
+
+	return activeFrom, activeTo, nil
+}
+
+func parseCreatedAt(name, v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: name + " must be an RFC 3339 timestamp"}
+	}
+
+	return &t, nil
+}
+
+// itemCounts converts MinItems and MaxItems to numbers, nil when not set.
+func (cbr *ListBillsQueryParams) itemCounts() (minItems, maxItems *int64, err error) {
+	if minItems, err = parseItemCount("minItems", cbr.MinItems); err != nil {
+		return nil, nil, err
+	}
+	if maxItems, err = parseItemCount("maxItems", cbr.MaxItems); err != nil {
+		return nil, nil, err
+	}
+
+	return minItems, maxItems, nil
+}
+
+func parseItemCount(name, v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: name + " must be a non-negative integer"}
+	}
+
+	return &n, nil
+}
+
+// totalCents converts MinTotal and MaxTotal to minor units, nil when not set.
+func (cbr *ListBillsQueryParams) totalCents() (minTotal, maxTotal *int64, err error) {
+	if minTotal, err = parseTotalCents("minTotal", cbr.MinTotal); err != nil {
+		return nil, nil, err
+	}
+	if maxTotal, err = parseTotalCents("maxTotal", cbr.MaxTotal); err != nil {
+		return nil, nil, err
+	}
+
+	return minTotal, maxTotal, nil
+}
+
+// parseTotalCents parses a decimal total into cents, all supported currencies have 2 decimals
+// so it matches the BillTotalCents search attribute, finer amounts are rejected rather than rounded.
+func parseTotalCents(name, v string) (*int64, error) {
+	if v == "" {
+		return nil, nil
+	}
+	m, err := libmoney.NewFromStringStrict(v, libmoney.CurrencyNone)
+	if err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: name + " is invalid: " + err.Error()}
+	}
+	cents := m.ToMinorUnits()
+
+	return &cents, nil
+}
+
+// searchLimitError maps a search that hit the page cap or the deadline, nil for other errors.
+func searchLimitError(err error) error {
+	switch {
+	case errors.Is(err, app.ErrTooManyBills):
+		return &errs.Error{Code: errs.ResourceExhausted, Message: "too many bills match, narrow the filters"}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &errs.Error{Code: errs.DeadlineExceeded, Message: "search took too long, narrow the filters"}
+	case errors.Is(err, context.Canceled):
+		return &errs.Error{Code: errs.Canceled, Message: "search canceled"}
+	}
+
+	return nil
+}
+
+// domainErrorCodes are the HTTP codes of the domain error codes, AlreadyExists also sets 409 Conflict.
+var domainErrorCodes = map[domain.ErrorCode]errs.ErrCode{
+	domain.CodeNotFound:           errs.NotFound,
+	domain.CodeConflict:           errs.AlreadyExists,
+	domain.CodeFailedPrecondition: errs.FailedPrecondition,
+	domain.CodeInvalid:            errs.InvalidArgument,
+	domain.CodeUnavailable:        errs.Unavailable,
+}
+
+// toHTTPError maps a use case error by its domain code, the message is the one of the domain error, not of
+// the whole chain, so no adapter details leak. Any other error is Internal with the fallback message.
+func toHTTPError(err error, fallback string) error {
+	if errors.Is(err, libmoney.ErrPrecisionExceeded) {
+		return &errs.Error{Code: errs.InvalidArgument, Message: err.Error()}
+	}
+	if de, ok := domain.AsDomainError(err); ok {
+		if code, ok := domainErrorCodes[de.Code]; ok {
+			return &errs.Error{Code: code, Message: de.Error()}
+		}
+	}
+
+	return errs.B().Code(errs.Internal).Cause(err).Msg(fallback).Err()
+}
+
+// logAuditFailure logs an audit event a use case couldn't publish and drops the error, the command itself succeeded.
+func logAuditFailure(err error) error {
+	if errors.Is(err, app.ErrAuditNotPublished) {
+		rlog.Warn("bill audit event not published", "err", err)
+
+		return nil
+	}
+
+	return err
+}
+
+// validatePeriodRange rejects from > to, both are validated YYYY-MM, so they compare as strings.
+func validatePeriodRange(from, to string) error {
+	if from != "" && to != "" && from > to {
+		return &errs.Error{Code: errs.InvalidArgument, Message: domain.ErrInvalidPeriodRange.Error()}
+	}
 
 	return nil
 }
@@ -204,6 +645,19 @@ type ListBillResponse struct {
 	Status        string `json:"status"`
 	ItemCount     int64  `json:"itemCount"`
 	Total         string `json:"total"`
+	// TotalMinor is Total in minor units of Currency (e.g. cents), for exact math.
+	TotalMinor int64  `json:"totalMinor"`
+	Namespace  string `json:"namespace"`
+	TaskQueue  string `json:"taskQueue"`
+	// UpdatedAt is when the bill last changed, omitted for bills not backfilled with the BillUpdatedAt SA.
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// CreatedAt is when the bill was started, omitted for bills older than the BillCreatedAt SA.
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// PeriodStart and PeriodEnd bound the billing period, omitted for older bills until their SAs are refreshed.
+	PeriodStart *time.Time `json:"periodStart,omitempty"`
+	PeriodEnd   *time.Time `json:"periodEnd,omitempty"`
+	// ErrorReason is the last invoicing failure of a bill that errored, omitted otherwise.
+	ErrorReason string `json:"errorReason,omitempty"`
 }
 
 // ListBills retrieves a list of bills (open or closed) for a customer.
@@ -225,74 +679,1019 @@ func (s *Service) ListBills(
 
 		return nil, errs.B().Code(errs.InvalidArgument).Cause(err).Msg("body is invalid").Err()
 	}
+	minTotal, maxTotal, err := params.totalCents()
+	if err != nil {
+		return nil, err
+	}
+	minItems, maxItems, err := params.itemCounts()
+	if err != nil {
+		return nil, err
+	}
+	createdFrom, createdTo, err := params.createdRange()
+	if err != nil {
+		return nil, err
+	}
+	activeFrom, activeTo, err := params.activeRange()
+	if err != nil {
+		return nil, err
+	}
 
 	bills, err := s.Search.Handle(ctx, usecases.SearchBillCmd{
 		CustomerID: customerID,
 		PeriodFrom: domain.BillingPeriod(params.PeriodStart),
 		PeriodTo:   domain.BillingPeriod(params.PeriodEnd),
 		Status:     params.Status,
+
+		FinalizedWithinDays: params.FinalizedWithinDays,
+		MinTotalCents:       minTotal,
+		MaxTotalCents:       maxTotal,
+		MinItemCount:        minItems,
+		MaxItemCount:        maxItems,
+		CreatedFrom:         createdFrom,
+		CreatedTo:           createdTo,
+		ActiveFrom:          activeFrom,
+		ActiveTo:            activeTo,
 	})
 	if err != nil {
 		rlog.Error("Search.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
 
-		return nil, &errs.Error{Code: errs.Internal, Message: "calling search from api"}
+		return nil, toHTTPError(err, "calling search from api")
 	}
 	resp := mapBillListResponse(bills)
 
 	return &resp, nil
 }
 
-// GetBill retrieves the detailed state of a specific bill by its period.
-// This would use a Temporal Query to get the current state of a running or completed workflow.
-// encore:api public method=GET path=/api/v1/customers/:customerID/bills/:period
-func (s *Service) GetBill(ctx context.Context, customerID string, period string) (*BillResponse, error) {
-	if customerID == "" {
-		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
-	}
-	if period == "" {
-		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period cannot be empty"}
-	}
-	if _, err := time.Parse("2006-01", period); err != nil {
-		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
-	}
+// The formats of ExportBills.
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+)
 
-	b, err := s.Get.Handle(ctx, usecases.GetBillCmd{
-		CustomerID: customerID, Period: domain.BillingPeriod(period),
-	})
-	if err != nil {
-		rlog.Error("Get.Handle", "err", err)
-		if errors.Is(err, app.ErrBillNotFound) {
-			return nil, errs.B().Code(errs.NotFound).Msg("bill not found").Err()
-		}
-		// map adapter error strings/types to HTTP codes as needed
-		return nil, errs.B().Cause(err).Msg("create bill").Err()
-	}
+// exportCSVHeader is the header row of the CSV export, UTF-8 without a BOM.
+var exportCSVHeader = []string{"id", "period", "status", "currency", "total", "itemCount"}
 
-	return map2BillingResponse(b), nil
+// ExportBills streams all the bills of a customer, a page of the search at a time, so a large export isn't
+// buffered. The format is the format query param, csv or json (default), or else text/csv in Accept.
+// The JSON is shaped as the ListBills response. A search failing once the first page is sent can only cut
+// the export short, it's logged.
+// encore:api public raw method=GET path=/api/v1/customers/:customerID/bills/export
+func (s *Service) ExportBills(w http.ResponseWriter, req *http.Request) {
+	s.exportBills(w, req, encore.CurrentRequest().PathParams.Get("customerID"))
 }
 
-// CloseBill sends a Temporal Signal to finalize and close an active bill.
-// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/close
-func (s *Service) CloseBill(ctx context.Context, customerID string, period string) (*BillResponse, error) {
+func (s *Service) exportBills(w http.ResponseWriter, req *http.Request, customerID string) {
 	if customerID == "" {
-		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+		errs.HTTPError(w, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"})
+
+		return
 	}
-	if _, err := time.Parse("2006-01", period); err != nil {
-		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	format, err := exportFormat(req)
+	if err != nil {
+		errs.HTTPError(w, err)
+
+		return
 	}
 
-	b, err := s.Close.Handle(ctx, usecases.CloseBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	var out billExportWriter = &jsonBillExport{w: w}
+	if format == ExportFormatCSV {
+		out = &csvBillExport{w: w, csv: csv.NewWriter(w)}
+	}
+	started := false
+	err = s.Export.Handle(req.Context(), usecases.ExportBillsCmd{
+		CustomerID: customerID,
+		Emit: func(bills []views.BillSummary) error {
+			if !started {
+				started = true
+				if err := out.begin(); err != nil {
+					return err
+				}
+			}
+			if err := out.page(bills); err != nil {
+				return err
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+
+			return nil
+		},
+	})
 	if err != nil {
-		rlog.Error("Close.Handle", "err", err)
-		if errors.Is(err, app.ErrBillNotFound) {
-			return nil, errs.B().Code(errs.NotFound).Msg("bill not found").Err()
+		rlog.Error("Export.Handle", "err", err, "started", started)
+		if started {
+			return
 		}
-		if errors.Is(err, app.ErrBillAlreadyClosed) {
-			return nil, errs.B().Code(errs.FailedPrecondition).Msg("bill already closed").Err()
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			errs.HTTPError(w, &errs.Error{
+				Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal",
+			})
+
+			return
 		}
+		errs.HTTPError(w, errs.B().Code(errs.Internal).Cause(err).Msg("export bills").Err())
 
-		return nil, errs.B().Cause(err).Msg("close bill").Err()
+		return
+	}
+	if err := out.end(); err != nil {
+		rlog.Error("ExportBills end", "err", err)
+	}
+}
+
+// exportFormat picks the export format, the query param wins over the Accept header.
+func exportFormat(req *http.Request) (string, error) {
+	switch f := req.URL.Query().Get("format"); f {
+	case ExportFormatJSON, ExportFormatCSV:
+		return f, nil
+	case "":
+	default:
+		return "", &errs.Error{Code: errs.InvalidArgument, Message: "format must be csv or json"}
+	}
+	if strings.Contains(req.Header.Get("Accept"), "text/csv") {
+		return ExportFormatCSV, nil
+	}
+
+	return ExportFormatJSON, nil
+}
+
+// billExportWriter writes an export in one format: begin once the first page is fetched, then every page, and
+// end after the last one.
+type billExportWriter interface {
+	begin() error
+	page(bills []views.BillSummary) error
+	end() error
+}
+
+type csvBillExport struct {
+	w   http.ResponseWriter
+	csv *csv.Writer
+}
+
+func (e *csvBillExport) begin() error {
+	e.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	e.w.WriteHeader(http.StatusOK)
+
+	return e.csv.Write(exportCSVHeader)
+}
+
+func (e *csvBillExport) page(bills []views.BillSummary) error {
+	for _, b := range bills {
+		err := e.csv.Write([]string{
+			b.WorkflowID,
+			billingPeriodNumToString(b.BillingPeriodNum),
+			b.Status,
+			b.Currency,
+			totalCentsToString(b.TotalCents, libmoney.Currency(b.Currency)),
+			strconv.FormatInt(b.ItemCount, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	e.csv.Flush()
+
+	return e.csv.Error()
+}
+
+func (e *csvBillExport) end() error {
+	return nil
+}
+
+// jsonBillExport writes the ListBillsResponse JSON a bill at a time.
+type jsonBillExport struct {
+	w       http.ResponseWriter
+	written int
+}
+
+func (e *jsonBillExport) begin() error {
+	e.w.Header().Set("Content-Type", "application/json")
+	e.w.WriteHeader(http.StatusOK)
+	_, err := io.WriteString(e.w, `{"bills":[`)
+
+	return err
+}
+
+func (e *jsonBillExport) page(bills []views.BillSummary) error {
+	for _, b := range mapBillListResponse(bills).Bills {
+		if e.written > 0 {
+			if _, err := io.WriteString(e.w, ","); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+		e.written++
+	}
+
+	return nil
+}
+
+func (e *jsonBillExport) end() error {
+	_, err := io.WriteString(e.w, "]}\n")
+
+	return err
+}
+
+// CountBillsQueryParams defines the query parameters for the CountBills endpoint.
+type CountBillsQueryParams struct {
+	Status      string `query:"status" validate:"oneof=OPEN CLOSED ERROR CHARGE_FAILED REJECTED WRITTEN_OFF"`
+	PeriodStart string `query:"from" validate:"omitempty,datetime=2006-01"` // Validates YYYY-MM format
+	PeriodEnd   string `query:"to" validate:"omitempty,datetime=2006-01"`   // Validates YYYY-MM format
+}
+
+func (cbr *CountBillsQueryParams) Validate() error {
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return validatePeriodRange(cbr.PeriodStart, cbr.PeriodEnd)
+}
+
+type CountBillsResponse struct {
+	Count int64 `json:"count"`
+}
+
+// CountBills counts a customer's bills per status, for dashboards, without paging through the executions.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/count tag:validation
+func (s *Service) CountBills(
+	ctx context.Context,
+	customerID string,
+	params *CountBillsQueryParams,
+) (*CountBillsResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if err := validation.Struct(params); err != nil {
+		rlog.Error("validation.Struct", "err", err)
+
+		return nil, errs.B().Code(errs.InvalidArgument).Cause(err).Msg("body is invalid").Err()
+	}
+
+	n, err := s.Count.Handle(ctx, usecases.CountBillsCmd{
+		CustomerID: customerID,
+		PeriodFrom: domain.BillingPeriod(params.PeriodStart),
+		PeriodTo:   domain.BillingPeriod(params.PeriodEnd),
+		Status:     params.Status,
+	})
+	if err != nil {
+		rlog.Error("Count.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+
+		return nil, toHTTPError(err, "calling count from api")
+	}
+
+	return &CountBillsResponse{Count: n}, nil
+}
+
+// AggregateBillsQueryParams defines the query parameters for the AggregateBills endpoint.
+type AggregateBillsQueryParams struct {
+	PeriodStart string `query:"from" validate:"omitempty,datetime=2006-01"` // Validates YYYY-MM format
+	PeriodEnd   string `query:"to" validate:"omitempty,datetime=2006-01"`   // Validates YYYY-MM format
+}
+
+func (cbr *AggregateBillsQueryParams) Validate() error {
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return validatePeriodRange(cbr.PeriodStart, cbr.PeriodEnd)
+}
+
+type AggregateBillsResponse struct {
+	Totals []BillPeriodTotalResponse `json:"totals"`
+}
+
+type BillPeriodTotalResponse struct {
+	Period string `json:"period"`
+	// Currency is omitted for a period without bills, bills in other currencies are totaled apart.
+	Currency   string `json:"currency,omitempty"`
+	TotalCents int64  `json:"totalCents"`
+	Count      int64  `json:"count"`
+}
+
+// AggregateBills sums a customer's bill totals per billing period, e.g. for monthly revenue reports.
+// With both from and to, every period of the range is listed, periods without bills have a zero total.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/aggregate tag:validation
+func (s *Service) AggregateBills(
+	ctx context.Context,
+	customerID string,
+	params *AggregateBillsQueryParams,
+) (*AggregateBillsResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+
+	totals, err := s.Aggregate.Handle(ctx, usecases.AggregateBillTotalsCmd{
+		CustomerID: customerID,
+		PeriodFrom: domain.BillingPeriod(params.PeriodStart),
+		PeriodTo:   domain.BillingPeriod(params.PeriodEnd),
+	})
+	if err != nil {
+		rlog.Error("Aggregate.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, toHTTPError(err, "aggregate bills")
+	}
+
+	return mapAggregateBillsResponse(totals), nil
+}
+
+type ListBillPeriodsResponse struct {
+	// Periods are "YYYY-MM", latest first, each listed once.
+	Periods []string `json:"periods"`
+}
+
+// ListBillPeriods lists the billing periods a customer has bills for, e.g. for a period dropdown of a UI.
+// encore:api public method=GET path=/api/v1/customers/:customerID/periods
+func (s *Service) ListBillPeriods(ctx context.Context, customerID string) (*ListBillPeriodsResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+
+	periods, err := s.Periods.Handle(ctx, usecases.ListBillPeriodsCmd{CustomerID: customerID})
+	if err != nil {
+		rlog.Error("Periods.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, &errs.Error{Code: errs.Internal, Message: "list bill periods"}
+	}
+
+	return &ListBillPeriodsResponse{Periods: periods}, nil
+}
+
+type NextPeriodResponse struct {
+	// Period is "YYYY-MM".
+	Period string `json:"period"`
+}
+
+// GetNextPeriod returns the period of the next bill to create for a customer, the month after its latest bill,
+// or the current month for a customer without bills, e.g. for a "create next bill" button of a UI.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/next-period
+func (s *Service) GetNextPeriod(ctx context.Context, customerID string) (*NextPeriodResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+
+	period, err := s.NextPeriod.Handle(ctx, usecases.NextPeriodCmd{CustomerID: customerID})
+	if err != nil {
+		rlog.Error("NextPeriod.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, &errs.Error{Code: errs.Internal, Message: "next bill period"}
+	}
+
+	return &NextPeriodResponse{Period: string(period)}, nil
+}
+
+const (
+	BillViewFull    = "full"
+	BillViewSummary = "summary"
+)
+
+type GetBillQueryParams struct {
+	// View is full (default) or summary, the summary has no line items.
+	View string `query:"view" validate:"omitempty,oneof=full summary"`
+	// AsOf is optional, an RFC3339 time: the bill as it was then, reconstructed from its history.
+	AsOf string `query:"asOf" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+func (cbr *GetBillQueryParams) Validate() error {
+	return validation.Struct(cbr)
+}
+
+// GetBill retrieves the detailed state of a specific bill by its period.
+// This would use a Temporal Query to get the current state of a running or completed workflow.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/:period tag:run
+func (s *Service) GetBill(
+	ctx context.Context,
+	customerID string,
+	period string,
+	params *GetBillQueryParams,
+) (*BillResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if period == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+	cmd := usecases.GetBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)}
+
+	if params != nil && params.AsOf != "" {
+		return s.getBillAsOf(ctx, cmd, params)
+	}
+	if params != nil && params.View == BillViewSummary {
+		sum, err := s.GetSummary.Handle(ctx, cmd)
+		if err != nil {
+			rlog.Error("GetSummary.Handle", "err", err)
+
+			return nil, toHTTPError(err, "get bill summary")
+		}
+
+		return map2BillSummaryResponse(sum), nil
+	}
+
+	b, err := s.Get.Handle(ctx, cmd)
+	if err != nil {
+		rlog.Error("Get.Handle", "err", err)
+
+		return nil, toHTTPError(err, "get bill")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+// getBillAsOf is GetBill with asOf, the summary view is the reconstructed bill without its items.
+func (s *Service) getBillAsOf(ctx context.Context, cmd usecases.GetBillCmd, params *GetBillQueryParams) (*BillResponse, error) {
+	at, err := time.Parse(time.RFC3339, params.AsOf)
+	if err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "asOf must be an RFC3339 time"}
+	}
+	b, err := s.GetAsOf.Handle(ctx, usecases.GetBillAsOfCmd{CustomerID: cmd.CustomerID, Period: cmd.Period, At: at})
+	if err != nil {
+		rlog.Error("GetAsOf.Handle", "err", err)
+
+		return nil, toHTTPError(err, "get bill as of")
+	}
+	resp := map2BillingResponse(b)
+	if params.View == BillViewSummary {
+		resp.Items = nil
+	}
+
+	return resp, nil
+}
+
+type BillChangeLogResponse struct {
+	Changes []BillChangeResponse `json:"changes"`
+	// Dropped counts the oldest changes the workflow no longer keeps.
+	Dropped int `json:"dropped"`
+}
+
+type BillChangeResponse struct {
+	// Type is item_added, item_description_updated or status_changed.
+	Type           string    `json:"type"`
+	At             time.Time `json:"at"`
+	IdempotencyKey string    `json:"idempotencyKey,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	// Amount is set for item_added only.
+	Amount     string `json:"amount,omitempty"`
+	FromStatus string `json:"fromStatus,omitempty"`
+	ToStatus   string `json:"toStatus,omitempty"`
+}
+
+// GetBillChangeLog lists the bill changes, oldest first, from a Temporal Query, without fetching the history.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/:period/changelog tag:run
+func (s *Service) GetBillChangeLog(ctx context.Context, customerID string, period string) (*BillChangeLogResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"}
+	}
+
+	l, err := s.ChangeLog.Handle(ctx, usecases.GetBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	if err != nil {
+		rlog.Error("ChangeLog.Handle", "err", err)
+
+		return nil, toHTTPError(err, "get bill change log")
+	}
+
+	return mapBillChangeLogResponse(l), nil
+}
+
+type BillMemoResponse struct {
+	CorrelationID        string `json:"correlationId,omitempty"`
+	CreateIdempotencyKey string `json:"createIdempotencyKey,omitempty"`
+	InvoiceURI           string `json:"invoiceUri,omitempty"`
+	ErrorReason          string `json:"errorReason,omitempty"`
+	// CreateParams is missing for the bills created before they were stored.
+	CreateParams *BillCreateParamsResponse `json:"createParams,omitempty"`
+}
+
+// BillCreateParamsResponse are the workflow params the bill was started with, after the defaults were applied.
+type BillCreateParamsResponse struct {
+	BillID               string                 `json:"billId"`
+	CustomerID           string                 `json:"customerId"`
+	Period               string                 `json:"period"`
+	Currency             string                 `json:"currency"`
+	Jurisdiction         string                 `json:"jurisdiction,omitempty"`
+	ActivityTaskQueue    string                 `json:"activityTaskQueue,omitempty"`
+	MinChargeMinor       int64                  `json:"minChargeMinor,omitempty"`
+	StrictCurrency       bool                   `json:"strictCurrency"`
+	AutoClose            bool                   `json:"autoClose"`
+	AllowEmptyBills      bool                   `json:"allowEmptyBills"`
+	DrainItemsOnClose    bool                   `json:"drainItemsOnClose"`
+	MaxItems             int                    `json:"maxItems,omitempty"`
+	WebhookURL           string                 `json:"webhookUrl,omitempty"`
+	SkipSearchAttributes bool                   `json:"skipSearchAttributes"`
+	InvoiceRetry         InvoiceRetryResponse   `json:"invoiceRetry"`
+	Template             []BillLineItemResponse `json:"template,omitempty"`
+}
+
+// InvoiceRetryResponse is the invoicing retry policy of the bill, zero fields are the workflow defaults.
+type InvoiceRetryResponse struct {
+	InitialInterval        string   `json:"initialInterval,omitempty"`
+	MaximumAttempts        int32    `json:"maximumAttempts,omitempty"`
+	BackoffCoefficient     float64  `json:"backoffCoefficient,omitempty"`
+	MaximumInterval        string   `json:"maximumInterval,omitempty"`
+	NonRetryableErrorTypes []string `json:"nonRetryableErrorTypes,omitempty"`
+}
+
+// GetBillMemo returns the memo of the bill workflow, incl. the exact params the bill was created with,
+// to reproduce a bill while debugging.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/:period/memo tag:run
+func (s *Service) GetBillMemo(ctx context.Context, customerID string, period string) (*BillMemoResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"}
+	}
+
+	memo, err := s.Memo.Handle(ctx, usecases.GetBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	if err != nil {
+		rlog.Error("Memo.Handle", "err", err)
+
+		return nil, toHTTPError(err, "get bill memo")
+	}
+
+	return mapBillMemoResponse(memo), nil
+}
+
+// GetBillByExecution queries a specific workflow run, as seen in Temporal UI, bypassing the customer+period bill ID.
+// It helps debugging Continue-As-New chains where the latest run isn't the one of interest.
+// encore:api public method=GET path=/api/v1/executions/:workflowID/:runID/bill
+func (s *Service) GetBillByExecution(ctx context.Context, workflowID string, runID string) (*BillResponse, error) {
+	if workflowID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "workflowID cannot be empty"}
+	}
+	if runID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "runID cannot be empty"}
+	}
+
+	b, err := s.GetRun.Handle(ctx, usecases.GetBillByExecutionCmd{
+		WorkflowID: workflowID, RunID: runID,
+	})
+	if err != nil {
+		rlog.Error("GetRun.Handle", "err", err)
+
+		return nil, toHTTPError(err, "get bill by execution")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+// CloseBill sends a Temporal Signal to finalize and close an active bill.
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/close tag:run
+func (s *Service) CloseBill(ctx context.Context, customerID string, period string) (*BillResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+
+	b, err := s.Close.Handle(ctx, usecases.CloseBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	if err != nil {
+		rlog.Error("Close.Handle", "err", err)
+
+		return nil, toHTTPError(err, "close bill")
+	}
+
+	return map2BillingResponse(b), nil
+}
+
+type CloseAllBillsResponse struct {
+	Results []CloseAllBillResult `json:"results"`
+	Closed  int                  `json:"closed"`
+	Skipped int                  `json:"skipped"`
+	Failed  int                  `json:"failed"`
+}
+
+type CloseAllBillResult struct {
+	ID string `json:"id"`
+	// Outcome is closed, skipped (closed meanwhile) or error.
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// CloseAllBills signals close to every open bill of the customer, e.g. at the end of the month.
+// A bill failing to close doesn't fail the call, see its result.
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills:closeAll
+func (s *Service) CloseAllBills(ctx context.Context, customerID string) (*CloseAllBillsResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+
+	results, err := s.CloseAll.Handle(ctx, usecases.CloseAllBillsCmd{CustomerID: customerID})
+	if err != nil {
+		rlog.Error("CloseAll.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, &errs.Error{Code: errs.Internal, Message: "close all bills"}
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			rlog.Error("CloseAll.Handle bill", "billID", r.BillID, "err", r.Err)
+		}
+	}
+
+	return mapCloseAllBillsResponse(results), nil
+}
+
+// RetryInvoicing sends a Temporal Signal to re-run invoicing of a bill in CHARGE_FAILED state.
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/retry tag:run
+func (s *Service) RetryInvoicing(ctx context.Context, customerID string, period string) (*BillResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+
+	b, err := s.Retry.Handle(ctx, usecases.RetryInvoicingCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	if err != nil {
+		rlog.Error("Retry.Handle", "err", err)
+
+		return nil, toHTTPError(err, "retry invoicing")
 	}
 
 	return map2BillingResponse(b), nil
 }
+
+type CreateCreditNoteRequest struct {
+	// Amount is the positive amount credited back, at most the bill total.
+	Amount         string `json:"amount" validate:"required,min=1,max=100"`
+	Reason         string `json:"reason" validate:"required,min=2,max=1024"`
+	IdempotencyKey string `json:"IdempotencyKey" validate:"required,min=1,max=1024"`
+}
+
+func (cbr *CreateCreditNoteRequest) Validate() error {
+	// Use the helper to validate the query parameter struct.
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type CreditNoteResponse struct {
+	ID            string `json:"id"`
+	BillID        string `json:"billId"`
+	CustomerID    string `json:"customerId"`
+	Currency      string `json:"currency"`
+	BillingPeriod string `json:"billingPeriod"`
+	// Total is negative, the amount credited back.
+	Total      string    `json:"total"`
+	TotalMinor int64     `json:"totalMinor"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CreateCreditNote offsets a closed bill with a credit note, the bill itself stays closed and untouched.
+// encore:api public method=POST path=/api/v1/customers/:customerID/bills/:period/credit tag:validation tag:run
+func (s *Service) CreateCreditNote(
+	ctx context.Context,
+	customerID string,
+	period string,
+	req *CreateCreditNoteRequest,
+) (*CreditNoteResponse, error) {
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"}
+	}
+	// the bill currency precision is checked in the domain
+	amount, err := libmoney.NewFromStringStrict(req.Amount, libmoney.CurrencyNone)
+	if errors.Is(err, libmoney.ErrPrecisionExceeded) {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "amount is invalid"}
+	}
+
+	note, err := s.CreditNote.Handle(ctx, usecases.CreateCreditNoteCmd{
+		CustomerID:     customerID,
+		Period:         domain.BillingPeriod(period),
+		IdempotencyKey: req.IdempotencyKey,
+		Amount:         amount,
+		Reason:         req.Reason,
+	})
+	if err != nil {
+		rlog.Error("CreditNote.Handle", "err", err)
+
+		return nil, toHTTPError(err, "create credit note")
+	}
+	rlog.Info("credit note created", "creditNoteID", note.ID, "billID", note.BillID, "reason", note.Reason)
+
+	return mapCreditNoteResponse(note), nil
+}
+
+// SumFeesQueryParams defines the query parameters for the SumFees endpoint.
+type SumFeesQueryParams struct {
+	// Case-insensitive substring, or glob pattern like "api*fee".
+	Description string `query:"description" validate:"required,min=1,max=1024"`
+}
+
+func (cbr *SumFeesQueryParams) Validate() error {
+	// Use the helper to validate the query parameter struct.
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+	if err := usecases.CheckDescriptionPattern(cbr.Description); err != nil {
+		return &errs.Error{Code: errs.InvalidArgument, Message: "description is not a valid glob pattern"}
+	}
+
+	return nil
+}
+
+type SumFeesResponse struct {
+	Description string `json:"description"`
+	Currency    string `json:"currency"`
+	Total       string `json:"total"`
+	Count       int    `json:"count"`
+}
+
+// SumFees sums the bill's line items whose description matches the given pattern.
+// encore:api public method=GET path=/api/v1/customers/:customerID/bills/:period/fees/sum tag:validation tag:run
+func (s *Service) SumFees(
+	ctx context.Context,
+	customerID string,
+	period string,
+	params *SumFeesQueryParams,
+) (*SumFeesResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+
+	res, err := s.Sum.Handle(ctx, usecases.SumFeesCmd{
+		CustomerID: customerID, Period: domain.BillingPeriod(period), DescriptionPattern: params.Description,
+	})
+	if err != nil {
+		rlog.Error("Sum.Handle", "err", err)
+
+		return nil, toHTTPError(err, "sum fees")
+	}
+
+	return &SumFeesResponse{
+		Description: params.Description,
+		Currency:    string(res.Currency),
+		Total:       res.Total.ToString(),
+		Count:       res.Count,
+	}, nil
+}
+
+type BackfillSearchAttributesRequest struct {
+	// PageToken resumes an interrupted backfill, taken from a previous response or error meta.
+	PageToken []byte `json:"pageToken,omitempty"`
+}
+
+type BackfillSearchAttributesResponse struct {
+	Pages    int `json:"pages"`
+	Signaled int `json:"signaled"`
+	Skipped  int `json:"skipped"`
+}
+
+// BackfillSearchAttributes signals every running bill to refresh its search attributes, e.g. BillUpdatedAt
+// for bills started before the SA existed. It's an ops endpoint, hence private.
+// encore:api private method=POST path=/api/v1/admin/bills/search-attributes/refresh
+func (s *Service) BackfillSearchAttributes(
+	ctx context.Context,
+	req *BackfillSearchAttributesRequest,
+) (*BackfillSearchAttributesResponse, error) {
+	res, err := s.Backfill.Handle(ctx, usecases.BackfillSearchAttributesCmd{
+		PageToken: req.PageToken,
+		OnProgress: func(p usecases.BackfillSearchAttributesResult) {
+			rlog.Info("backfill search attributes progress", "pages", p.Pages, "signaled", p.Signaled, "skipped", p.Skipped)
+		},
+	})
+	if err != nil {
+		rlog.Error("Backfill.Handle", "err", err, "pageToken", res.NextPageToken)
+
+		return nil, errs.B().Cause(err).Meta("pageToken", res.NextPageToken).Msg("backfill search attributes").Err()
+	}
+
+	return &BackfillSearchAttributesResponse{
+		Pages:    res.Pages,
+		Signaled: res.Signaled,
+		Skipped:  res.Skipped,
+	}, nil
+}
+
+type ReconcileBillResponse struct {
+	Bill        *BillResponse `json:"bill"`
+	TotalBefore string        `json:"totalBefore"`
+	TotalAfter  string        `json:"totalAfter"`
+	Drift       bool          `json:"drift"`
+}
+
+// ReconcileBill recomputes an open bill's total from its items, repairing a drifted total.
+// It's an operational safety valve, hence private.
+// encore:api private method=POST path=/api/v1/customers/:customerID/bills/:period/reconcile tag:run
+func (s *Service) ReconcileBill(ctx context.Context, customerID string, period string) (*ReconcileBillResponse, error) {
+	if customerID == "" {
+		return nil, &errs.Error{Code: errs.InvalidArgument, Message: "customerId cannot be empty"}
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, errs.B().Code(errs.InvalidArgument).Msg("period must be YYYY-MM").Err()
+	}
+
+	res, err := s.Reconcile.Handle(ctx, usecases.ReconcileBillCmd{CustomerID: customerID, Period: domain.BillingPeriod(period)})
+	if err != nil {
+		rlog.Error("Reconcile.Handle", "err", err)
+
+		return nil, toHTTPError(err, "reconcile bill")
+	}
+	if res.Drift {
+		rlog.Warn("bill total drift repaired", "customerID", customerID, "period", period,
+			"before", res.Before.ToString(), "after", res.After.ToString())
+	}
+
+	return &ReconcileBillResponse{
+		Bill:        map2BillingResponse(res.Bill),
+		TotalBefore: res.Before.ToString(),
+		TotalAfter:  res.After.ToString(),
+		Drift:       res.Drift,
+	}, nil
+}
+
+type TerminateBillRequest struct {
+	// Reason is recorded on the terminated workflow, visible in Temporal UI.
+	Reason string `json:"reason" validate:"required,min=2,max=1024"`
+	// Operator is who asks for the termination, it's logged for the audit trail.
+	Operator string `json:"operator" validate:"required,max=255"`
+}
+
+func (cbr *TerminateBillRequest) Validate() error {
+	// Use the helper to validate the query parameter struct.
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TerminateBill force-kills a stuck bill workflow. Unlike CloseBill there's no invoicing, the bill just stops,
+// so it's destructive and private. The workflowID is the bill ID, URL-encoded.
+// encore:api private method=POST path=/api/v1/admin/bills/:workflowID/terminate tag:validation tag:run
+func (s *Service) TerminateBill(ctx context.Context, workflowID string, req *TerminateBillRequest) error {
+	if workflowID == "" {
+		return &errs.Error{Code: errs.InvalidArgument, Message: "workflowID cannot be empty"}
+	}
+	rlog.Warn("terminating bill", "workflowID", workflowID, "operator", req.Operator, "reason", req.Reason)
+
+	err := s.Terminate.Handle(ctx, usecases.TerminateBillCmd{BillID: domain.BillID(workflowID), Reason: req.Reason})
+	if err != nil {
+		rlog.Error("Terminate.Handle", "err", err)
+
+		return toHTTPError(err, "terminate bill")
+	}
+
+	return nil
+}
+
+type PurgeBillsRequest struct {
+	CustomerID string `json:"customerId" validate:"required,max=255"`
+	// Status is optional, empty purges the bills in any status.
+	Status string `json:"status" validate:"omitempty,oneof=OPEN PENDING CLOSED ERROR CHARGE_FAILED REJECTED WRITTEN_OFF"`
+	// CreatedBefore is optional, RFC 3339, the bills started afterwards are kept.
+	CreatedBefore string `json:"createdBefore" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	// Reason is recorded on every terminated workflow.
+	Reason string `json:"reason" validate:"required,min=2,max=1024"`
+	// Operator is who asks for the purge, it's logged for the audit trail.
+	Operator string `json:"operator" validate:"required,max=255"`
+}
+
+func (cbr *PurgeBillsRequest) Validate() error {
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+	_, err := parseCreatedAt("createdBefore", cbr.CreatedBefore)
+
+	return err
+}
+
+type PurgeBillsResponse struct {
+	Matched    int `json:"matched"`
+	Terminated int `json:"terminated"`
+	// Skipped are bills completed meanwhile.
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+// PurgeBills terminates the customer's bills matching the filter, to clean up the stale bills of dev and test
+// namespaces. Nothing is invoiced, it's refused unless the namespace is in Admin.PurgeAllowedNamespaces.
+// encore:api private method=POST path=/api/v1/admin/bills:purge tag:validation
+func (s *Service) PurgeBills(ctx context.Context, req *PurgeBillsRequest) (*PurgeBillsResponse, error) {
+	createdBefore, err := parseCreatedAt("createdBefore", req.CreatedBefore)
+	if err != nil {
+		return nil, err
+	}
+	rlog.Warn("purging bills", "customerID", req.CustomerID, "status", req.Status, "createdBefore", req.CreatedBefore,
+		"operator", req.Operator, "reason", req.Reason)
+
+	res, err := s.Purge.Handle(ctx, usecases.PurgeBillsCmd{
+		CustomerID:    req.CustomerID,
+		Status:        req.Status,
+		CreatedBefore: createdBefore,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		rlog.Error("Purge.Handle", "err", err)
+		if errors.Is(err, app.ErrPurgeNotAllowed) {
+			return nil, &errs.Error{Code: errs.PermissionDenied, Message: "purging bills is not allowed in this namespace"}
+		}
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, toHTTPError(err, "purge bills")
+	}
+	for id, err := range res.Failures {
+		rlog.Error("Purge.Handle bill", "billID", id, "err", err)
+	}
+
+	return &PurgeBillsResponse{
+		Matched:    res.Matched,
+		Terminated: res.Terminated,
+		Skipped:    res.Skipped,
+		Failed:     res.Failed,
+	}, nil
+}
+
+// ListErroredBillsQueryParams defines the query parameters for the ListErroredBills endpoint.
+type ListErroredBillsQueryParams struct {
+	PeriodStart string `query:"from" validate:"omitempty,datetime=2006-01"` // Validates YYYY-MM format
+	PeriodEnd   string `query:"to" validate:"omitempty,datetime=2006-01"`   // Validates YYYY-MM format
+}
+
+func (cbr *ListErroredBillsQueryParams) Validate() error {
+	if err := validation.Struct(cbr); err != nil {
+		return err
+	}
+
+	return validatePeriodRange(cbr.PeriodStart, cbr.PeriodEnd)
+}
+
+// ListErroredBills lists the bills whose invoicing failed across all customers, with the invoicing failure when the
+// bill keeps it. It's an ops endpoint, hence private.
+// encore:api private method=GET path=/api/v1/admin/bills/errors tag:validation
+func (s *Service) ListErroredBills(ctx context.Context, params *ListErroredBillsQueryParams) (*ListBillsResponse, error) {
+	bills, err := s.Errored.Handle(ctx, usecases.ListErroredBillsCmd{
+		PeriodFrom: domain.BillingPeriod(params.PeriodStart),
+		PeriodTo:   domain.BillingPeriod(params.PeriodEnd),
+	})
+	if err != nil {
+		rlog.Error("Errored.Handle", "err", err)
+		if errors.Is(err, app.ErrSearchAttributesNotRegistered) {
+			return nil, &errs.Error{Code: errs.FailedPrecondition, Message: "bill search attributes are not registered, run make init-temporal"}
+		}
+		if err := searchLimitError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, toHTTPError(err, "list errored bills")
+	}
+	resp := mapBillListResponse(bills)
+
+	return &resp, nil
+}
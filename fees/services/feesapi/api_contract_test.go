@@ -0,0 +1,109 @@
+package feesapi
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/outofboxer/temporal-workflow/fees/app/views"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+)
+
+// Regenerate the fixtures after an intended API change: go test ./fees/services/feesapi -run Contract -update
+var updateGolden = flag.Bool("update", false, "update JSON contract golden files")
+
+func TestResponseContract(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	closedAt := time.Date(2025, 2, 1, 0, 0, 5, 0, time.UTC)
+	amount, _ := libmoney.NewFromString("10.50", libmoney.CurrencyUSD)
+	fee, _ := libmoney.NewFromString("2.25", libmoney.CurrencyUSD)
+	total, _ := libmoney.NewFromString("12.75", libmoney.CurrencyUSD)
+
+	withItems := domain.Bill{
+		ID:            "bill/customer-123/2025-01",
+		CustomerID:    "customer-123",
+		Currency:      libmoney.CurrencyUSD,
+		BillingPeriod: "2025-01",
+		Status:        domain.BillStatusOpen,
+		Items: []domain.LineItem{
+			{IdempotencyKey: "api-fee-2025-01-15", Description: "API usage fee", Amount: amount, AddedAt: createdAt.Add(time.Hour)},
+			{IdempotencyKey: "storage-2025-01-20", Description: "Storage fee", Amount: fee, AddedAt: createdAt.Add(2 * time.Hour)},
+		},
+		Total:       total,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt.Add(2 * time.Hour),
+		PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2025, 1, 31, 23, 59, 59, 999999999, time.UTC),
+	}
+	closed := withItems
+	closed.Status = domain.BillStatusClosed
+	closed.UpdatedAt = closedAt
+	closed.FinalizedAt = &closedAt
+
+	tests := []struct {
+		name     string
+		golden   string
+		response any
+	}{
+		{name: "bill with items", golden: "bill_with_items.json", response: map2BillingResponse(withItems)},
+		{name: "closed bill", golden: "bill_closed.json", response: map2BillingResponse(closed)},
+		{
+			name:   "bill summary",
+			golden: "bill_summary.json",
+			response: map2BillSummaryResponse(views.BillStateSummary{
+				ID: string(closed.ID), CustomerID: closed.CustomerID, Currency: closed.Currency,
+				BillingPeriod: string(closed.BillingPeriod), Status: string(closed.Status), Total: closed.Total,
+				ItemCount: len(closed.Items), CreatedAt: closed.CreatedAt, UpdatedAt: closed.UpdatedAt,
+				ClosedAt: closed.FinalizedAt,
+			}),
+		},
+		{
+			name:   "bill list",
+			golden: "bill_list.json",
+			response: mapBillListResponse([]views.BillSummary{
+				{
+					WorkflowID: "bill/customer-123/2025-01", Status: "CLOSED", Currency: "USD",
+					CustomerID: "customer-123", BillingPeriodNum: 202501, TotalCents: 1275, ItemCount: 2,
+					Namespace: "default", TaskQueue: "FEES_TASK_QUEUE",
+				},
+				{
+					WorkflowID: "bill/customer-123/2025-02", Status: "OPEN", Currency: "USD",
+					CustomerID: "customer-123", BillingPeriodNum: 202502,
+					Namespace: "default", TaskQueue: "FEES_TASK_QUEUE",
+				},
+			}),
+		},
+		{
+			name:   "credit note",
+			golden: "credit_note.json",
+			response: mapCreditNoteResponse(domain.CreditNote{
+				ID: domain.MakeCreditNoteID(closed.ID, "goodwill-1"), BillID: closed.ID, CustomerID: closed.CustomerID,
+				BillingPeriod: closed.BillingPeriod, Currency: closed.Currency, Total: fee.Neg(), Reason: "outage",
+				CreatedAt: closedAt.Add(24 * time.Hour),
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.MarshalIndent(tt.response, "", "  ")
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(path, append(got, '\n'), 0o600))
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			assert.JSONEq(t, string(want), string(got), "API response shape changed, see %s", path)
+		})
+	}
+}
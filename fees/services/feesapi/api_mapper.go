@@ -1,12 +1,12 @@
 package feesapi
 
 import (
-	"fmt"
-
-	"github.com/shopspring/decimal"
-
+	"github.com/outofboxer/temporal-workflow/fees/app"
+	"github.com/outofboxer/temporal-workflow/fees/app/usecases"
 	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 func mapBillListResponse(summaries []views.BillSummary) ListBillsResponse {
@@ -19,32 +19,69 @@ func mapBillListResponse(summaries []views.BillSummary) ListBillsResponse {
 			BillingPeriod: billingPeriodNumToString(s.BillingPeriodNum),
 			Status:        s.Status,
 			ItemCount:     s.ItemCount,
-			Total:         totalCentsToString(s.TotalCents),
+			Total:         totalCentsToString(s.TotalCents, libmoney.Currency(s.Currency)),
+			TotalMinor:    s.TotalCents,
+			Namespace:     s.Namespace,
+			TaskQueue:     s.TaskQueue,
+			UpdatedAt:     s.UpdatedAt,
+			CreatedAt:     s.CreatedAt,
+			PeriodStart:   s.PeriodStart,
+			PeriodEnd:     s.PeriodEnd,
+			ErrorReason:   s.ErrorReason,
 		})
 	}
 
 	return ListBillsResponse{Bills: out}
 }
 
-// BillingPeriodNum (e.g., 202410) -> "YYYY-MM" (e.g., "2024-10").
-func billingPeriodNumToString(n int64) string {
-	if n < 100001 || n > 999912 { // quick sanity range: 0000-01 .. 9999-12
-		return "<formatting error in range>"
+func mapAggregateBillsResponse(totals []views.BillPeriodTotal) *AggregateBillsResponse {
+	out := &AggregateBillsResponse{Totals: make([]BillPeriodTotalResponse, 0, len(totals))}
+	for _, t := range totals {
+		out.Totals = append(out.Totals, BillPeriodTotalResponse{
+			Period:     billingPeriodNumToString(t.PeriodYYYYMM),
+			Currency:   t.Currency,
+			TotalCents: t.TotalCents,
+			Count:      t.Count,
+		})
 	}
-	year := n / 100  //nolint:mnd
-	month := n % 100 //nolint:mnd
-	if month < 1 || month > 12 {
-		return "<formatting error in month>"
+
+	return out
+}
+
+func mapCloseAllBillsResponse(results []usecases.CloseAllBillsResult) *CloseAllBillsResponse {
+	out := &CloseAllBillsResponse{Results: make([]CloseAllBillResult, 0, len(results))}
+	for _, r := range results {
+		res := CloseAllBillResult{ID: string(r.BillID), Outcome: string(r.Outcome)}
+		switch r.Outcome {
+		case usecases.CloseAllBillsClosed:
+			out.Closed++
+		case usecases.CloseAllBillsSkipped:
+			out.Skipped++
+		case usecases.CloseAllBillsError:
+			out.Failed++
+			// the cause is logged, it's internal
+			res.Error = "close failed, retry the bill alone"
+		}
+		out.Results = append(out.Results, res)
 	}
 
-	return fmt.Sprintf("%04d-%02d", year, month)
+	return out
 }
 
-// TotalCentsToString converts 12345 -> "123.45".
-func totalCentsToString(totalCents int64) string {
-	const shift = 2
+// BillingPeriodNum (e.g., 202410) -> "YYYY-MM" (e.g., "2024-10"), see libtime.FromYYYYMM. The responses have no
+// error field, a malformed number shows as a placeholder.
+func billingPeriodNumToString(n int64) string {
+	p, err := libtime.FromYYYYMM(n)
+	if err != nil {
+		return "<formatting error>"
+	}
+
+	return p
+}
 
-	return decimal.NewFromInt(totalCents).Shift(-shift).StringFixed(shift)
+// TotalCentsToString converts 12345 -> "123.45", the scale is the currency's minor unit.
+func totalCentsToString(totalCents int64, c libmoney.Currency) string {
+	return libmoney.FromMinorUnits(totalCents, c).ToFixedString()
 }
 
 func map2BillingResponse(b domain.Bill) *BillResponse {
@@ -55,6 +92,7 @@ func map2BillingResponse(b domain.Bill) *BillResponse {
 			Description:    bi.Description,
 			Amount:         bi.Amount,
 			AddedAt:        bi.AddedAt,
+			Tags:           bi.Tags,
 		})
 	}
 
@@ -65,9 +103,120 @@ func map2BillingResponse(b domain.Bill) *BillResponse {
 		BillingPeriod: string(b.BillingPeriod),
 		Status:        string(b.Status),
 		Items:         lineItems,
+		ItemCount:     int64(len(b.Items)),
 		Total:         b.Total.ToString(),
+		TotalMinor:    b.Total.ToMinorUnits(),
 		CreatedAt:     b.CreatedAt,
 		UpdatedAt:     b.UpdatedAt,
 		ClosedAt:      b.FinalizedAt,
+		Notes:         b.Notes,
+		InvoiceURI:    b.InvoiceURI,
+		PeriodStart:   b.PeriodStart,
+		PeriodEnd:     b.PeriodEnd,
+	}
+}
+
+func map2BillSummaryResponse(s views.BillStateSummary) *BillResponse {
+	// the summary query doesn't carry the bounds, they follow from the period
+	periodStart, periodEnd, _ := libtime.PeriodBounds(s.BillingPeriod)
+
+	return &BillResponse{
+		ID:            s.ID,
+		CustomerID:    s.CustomerID,
+		Currency:      string(s.Currency),
+		BillingPeriod: s.BillingPeriod,
+		Status:        s.Status,
+		ItemCount:     int64(s.ItemCount),
+		Total:         s.Total.ToString(),
+		TotalMinor:    s.Total.ToMinorUnits(),
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+		ClosedAt:      s.ClosedAt,
+		PeriodStart:   periodStart,
+		PeriodEnd:     periodEnd,
+	}
+}
+
+func mapBillChangeLogResponse(l views.BillChangeLog) *BillChangeLogResponse {
+	out := &BillChangeLogResponse{Changes: make([]BillChangeResponse, 0, len(l.Changes)), Dropped: l.Dropped}
+	for _, c := range l.Changes {
+		change := BillChangeResponse{
+			Type:           string(c.Type),
+			At:             c.At,
+			IdempotencyKey: c.IdempotencyKey,
+			Description:    c.Description,
+			FromStatus:     c.FromStatus,
+			ToStatus:       c.ToStatus,
+		}
+		if c.Type == views.BillChangeItemAdded {
+			change.Amount = c.Amount.ToString()
+		}
+		out.Changes = append(out.Changes, change)
+	}
+
+	return out
+}
+
+func mapBillMemoResponse(m app.BillMemo) *BillMemoResponse {
+	out := &BillMemoResponse{
+		CorrelationID:        m.CorrelationID,
+		CreateIdempotencyKey: m.CreateIdempotencyKey,
+		InvoiceURI:           m.InvoiceURI,
+		ErrorReason:          m.ErrorReason,
+	}
+	if p := m.CreateParams; p != nil {
+		params := &BillCreateParamsResponse{
+			BillID:               string(p.BillID),
+			CustomerID:           p.CustomerID,
+			Period:               string(p.Period),
+			Currency:             string(p.Currency),
+			Jurisdiction:         p.Jurisdiction,
+			ActivityTaskQueue:    p.ActivityTaskQueue,
+			MinChargeMinor:       p.MinChargeMinor,
+			StrictCurrency:       p.StrictCurrency,
+			AutoClose:            p.AutoClose,
+			AllowEmptyBills:      p.AllowEmptyBills,
+			DrainItemsOnClose:    p.DrainItemsOnClose,
+			MaxItems:             p.MaxItems,
+			WebhookURL:           p.WebhookURL,
+			SkipSearchAttributes: p.SkipSearchAttributes,
+			InvoiceRetry: InvoiceRetryResponse{
+				MaximumAttempts:        p.InvoiceRetry.MaximumAttempts,
+				BackoffCoefficient:     p.InvoiceRetry.BackoffCoefficient,
+				NonRetryableErrorTypes: p.InvoiceRetry.NonRetryableErrorTypes,
+			},
+		}
+		if p.InvoiceRetry.InitialInterval > 0 {
+			params.InvoiceRetry.InitialInterval = p.InvoiceRetry.InitialInterval.String()
+		}
+		if p.InvoiceRetry.MaximumInterval > 0 {
+			params.InvoiceRetry.MaximumInterval = p.InvoiceRetry.MaximumInterval.String()
+		}
+		for _, li := range p.Template {
+			params.Template = append(params.Template, BillLineItemResponse{
+				IdempotencyKey: li.IdempotencyKey,
+				Description:    li.Description,
+				Amount:         li.Amount,
+				AddedAt:        li.AddedAt,
+				Tags:           li.Tags,
+			})
+		}
+		out.CreateParams = params
+	}
+
+	return out
+}
+
+func mapCreditNoteResponse(n domain.CreditNote) *CreditNoteResponse {
+	return &CreditNoteResponse{
+		ID:            string(n.ID),
+		BillID:        string(n.BillID),
+		CustomerID:    n.CustomerID,
+		Currency:      string(n.Currency),
+		BillingPeriod: string(n.BillingPeriod),
+		Total:         n.Total.ToFixedString(),
+		TotalMinor:    n.Total.ToMinorUnits(),
+		Reason:        n.Reason,
+		CreatedAt:     n.CreatedAt,
 	}
 }
@@ -2,6 +2,13 @@ package feesapi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +22,7 @@ import (
 	"github.com/outofboxer/temporal-workflow/fees/app/usecases"
 	"github.com/outofboxer/temporal-workflow/fees/app/views"
 	"github.com/outofboxer/temporal-workflow/fees/domain"
+	"github.com/outofboxer/temporal-workflow/fees/internal/validation"
 	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
 )
 
@@ -28,26 +36,120 @@ func (m *MockTemporalPort) StartMonthlyBill(ctx context.Context, params app.Mont
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) StartCreditNote(ctx context.Context, note domain.CreditNote) error {
+	args := m.Called(ctx, note)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) ListCreditNotes(ctx context.Context, id domain.BillID) ([]domain.CreditNote, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]domain.CreditNote), args.Error(1)
+}
+
 func (m *MockTemporalPort) AddLineItem(ctx context.Context, id domain.BillID, li domain.LineItem) error {
 	args := m.Called(ctx, id, li)
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) UpdateLineItemDescription(ctx context.Context, id domain.BillID, key, description string) error {
+	args := m.Called(ctx, id, key, description)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) CorrectLineItemAmount(ctx context.Context, id domain.BillID, idempotencyKey string, amount libmoney.Money) error {
+	args := m.Called(ctx, id, idempotencyKey, amount)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) SetBillNote(ctx context.Context, id domain.BillID, note string) error {
+	args := m.Called(ctx, id, note)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) ReconcileBill(ctx context.Context, id domain.BillID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockTemporalPort) CloseBill(ctx context.Context, id domain.BillID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockTemporalPort) RetryInvoicing(ctx context.Context, id domain.BillID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockTemporalPort) QueryBill(ctx context.Context, id domain.BillID) (domain.Bill, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(domain.Bill), args.Error(1)
 }
 
+func (m *MockTemporalPort) TerminateBill(ctx context.Context, id domain.BillID, reason string) error {
+	args := m.Called(ctx, id, reason)
+	return args.Error(0)
+}
+
+func (m *MockTemporalPort) QueryBillSummary(ctx context.Context, id domain.BillID) (views.BillStateSummary, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(views.BillStateSummary), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillChangeLog(ctx context.Context, id domain.BillID) (views.BillChangeLog, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(views.BillChangeLog), args.Error(1)
+}
+
+func (m *MockTemporalPort) GetBillMemo(ctx context.Context, id domain.BillID) (app.BillMemo, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(app.BillMemo), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillAsOf(ctx context.Context, id domain.BillID, at time.Time) (domain.Bill, error) {
+	args := m.Called(ctx, id, at)
+	return args.Get(0).(domain.Bill), args.Error(1)
+}
+
+func (m *MockTemporalPort) QueryBillByExecution(ctx context.Context, workflowID, runID string) (domain.Bill, error) {
+	args := m.Called(ctx, workflowID, runID)
+	return args.Get(0).(domain.Bill), args.Error(1)
+}
+
 func (m *MockTemporalPort) SearchBills(ctx context.Context, params app.SearchBillFilter) ([]views.BillSummary, error) {
 	args := m.Called(ctx, params)
 	return args.Get(0).([]views.BillSummary), args.Error(1)
 }
 
+func (m *MockTemporalPort) SearchBillsPage(
+	ctx context.Context,
+	params app.SearchBillFilter,
+	pageToken []byte,
+) (app.SearchBillPage, error) {
+	args := m.Called(ctx, params, pageToken)
+	return args.Get(0).(app.SearchBillPage), args.Error(1)
+}
+
+func (m *MockTemporalPort) CountBills(ctx context.Context, params app.SearchBillFilter) (int64, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTemporalPort) AggregateBillTotals(ctx context.Context, customerID string, from, to *int64) ([]views.BillPeriodTotal, error) {
+	args := m.Called(ctx, customerID, from, to)
+	return args.Get(0).([]views.BillPeriodTotal), args.Error(1)
+}
+
+func (m *MockTemporalPort) ListBillPeriods(ctx context.Context, customerID string) ([]string, error) {
+	args := m.Called(ctx, customerID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockTemporalPort) RefreshSearchAttributes(ctx context.Context, pageToken []byte) (app.RefreshPage, error) {
+	args := m.Called(ctx, pageToken)
+	return args.Get(0).(app.RefreshPage), args.Error(1)
+}
+
 // Helper functions for creating test data
 var fixedTime = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
 
@@ -74,15 +176,43 @@ func createTestLineItem() domain.LineItem {
 	}
 }
 
+// testPoll keeps the re-queries after a signal short, the mocks often never show the change.
+var testPoll = usecases.PollBackoff{InitialInterval: time.Millisecond, Timeout: 10 * time.Millisecond}
+
 // Test service with mocked temporal port
 func createTestService() (*Service, *MockTemporalPort) {
 	mockTemporal := &MockTemporalPort{}
 	service := &Service{
-		Create:  usecases.CreateBill{T: mockTemporal},
-		AddItem: usecases.AddLineItem{T: mockTemporal},
-		Close:   usecases.CloseBill{T: mockTemporal},
-		Get:     usecases.GetBill{T: mockTemporal},
-		Search:  usecases.SearchBill{T: mockTemporal},
+		Create:     usecases.CreateBill{T: mockTemporal, Now: func() time.Time { return fixedTime }},
+		AddItem:    usecases.AddLineItem{T: mockTemporal, Poll: testPoll},
+		Update:     usecases.UpdateLineItemDescription{T: mockTemporal},
+		Note:       usecases.SetBillNote{T: mockTemporal},
+		Close:      usecases.CloseBill{T: mockTemporal, Poll: testPoll},
+		CloseAll:   usecases.CloseAllBills{T: mockTemporal, Poll: testPoll},
+		Retry:      usecases.RetryInvoicing{T: mockTemporal},
+		CreditNote: usecases.CreateCreditNote{T: mockTemporal, Now: func() time.Time { return fixedTime }},
+		Get:        usecases.GetBill{T: mockTemporal},
+		GetSummary: usecases.GetBillSummary{T: mockTemporal},
+		GetAsOf:    usecases.GetBillAsOf{T: mockTemporal},
+		GetRun:     usecases.GetBillByExecution{T: mockTemporal},
+		Search:     usecases.SearchBill{T: mockTemporal},
+		Count:      usecases.CountBills{T: mockTemporal},
+		Correct:    usecases.CorrectLineItemAmount{T: mockTemporal},
+		ChangeLog:  usecases.GetBillChangeLog{T: mockTemporal},
+		Memo:       usecases.GetBillMemo{T: mockTemporal},
+		Aggregate:  usecases.AggregateBillTotals{T: mockTemporal},
+		Periods:    usecases.ListBillPeriods{T: mockTemporal},
+		NextPeriod: usecases.NextPeriod{T: mockTemporal, Now: func() time.Time { return fixedTime }},
+		Export:     usecases.ExportBills{T: mockTemporal},
+		Sum:        usecases.SumFees{T: mockTemporal},
+
+		Backfill:  usecases.BackfillSearchAttributes{T: mockTemporal},
+		Reconcile: usecases.ReconcileBill{T: mockTemporal},
+		Terminate: usecases.TerminateBill{T: mockTemporal},
+		Purge: usecases.PurgeBills{
+			T: mockTemporal, Namespace: "default", AllowedNamespaces: []string{"default"},
+		},
+		Errored: usecases.ListErroredBills{T: mockTemporal},
 	}
 	return service, mockTemporal
 }
@@ -190,124 +320,81 @@ func TestCreateBill(t *testing.T) {
 				Message: "a bill already exists for this customer and period",
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			service, mockTemporal := createTestService()
-			tt.mockSetup(mockTemporal)
-
-			resp, err := service.CreateBill(context.Background(), tt.customerID, tt.request)
-
-			if tt.expectedError != nil {
-				require.Error(t, err)
-				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
-				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
-			} else {
-				require.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, resp.Status)
-				if tt.validateResponse != nil {
-					tt.validateResponse(t, resp)
-				}
-			}
-
-			mockTemporal.AssertExpectations(t)
-		})
-	}
-}
-
-func TestAddLineItem(t *testing.T) {
-	tests := []struct {
-		name             string
-		customerID       string
-		period           string
-		request          *AddLineItemRequest
-		mockSetup        func(*MockTemporalPort)
-		expectedError    *errs.Error
-		validateResponse func(t *testing.T, resp *BillResponse)
-	}{
 		{
-			name:       "successful line item addition",
+			name:       "retry with the same idempotency key",
 			customerID: "customer-123",
-			period:     "2025-01",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			request: &CreateBillRequest{
+				Currency:       libmoney.CurrencyUSD,
+				BillingPeriod:  "2025-01",
+				IdempotencyKey: "create-1",
 			},
 			mockSetup: func(m *MockTemporalPort) {
-				billID := domain.BillID("bill/customer-123/2025-01")
-				openBill := createTestBill()
-				updatedBill := createTestBill()
-				updatedBill.Items = []domain.LineItem{createTestLineItem()}
-
-				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
-				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
-					return li.Description == "Test item" && li.IdempotencyKey == "item-123"
-				})).Return(nil)
-				m.On("QueryBill", mock.Anything, billID).Return(updatedBill, nil).Once()
+				expectedParams := app.MonthlyFeeAccrualWorkflowParams{
+					BillID:               "bill/customer-123/2025-01",
+					CustomerID:           "customer-123",
+					Period:               "2025-01",
+					PeriodYYYYMM:         202501,
+					Currency:             libmoney.CurrencyUSD,
+					CreateIdempotencyKey: "create-1",
+				}
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+					Return(app.BillMemo{CreateIdempotencyKey: "create-1"}, nil)
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil)
 			},
-			validateResponse: func(t *testing.T, resp *BillResponse) {
-				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
-				assert.Equal(t, "customer-123", resp.CustomerID)
-				assert.Equal(t, "USD", resp.Currency)
-				assert.Equal(t, "2025-01", resp.BillingPeriod)
-				assert.Equal(t, "OPEN", resp.Status)
-				assert.Len(t, resp.Items, 1)
-				assert.Equal(t, "item-123", resp.Items[0].IdempotencyKey)
-				assert.Equal(t, "Test item", resp.Items[0].Description)
+			expectedStatus: 200,
+			validateResponse: func(t *testing.T, resp *CreateBillResponse) {
+				assert.Equal(t, "/api/v1/customers/customer-123/bills/2025-01", resp.Location)
+				assert.Equal(t, "bill/customer-123/2025-01", resp.Message.ID)
 			},
 		},
 		{
-			name:       "invalid period format",
+			name:       "another idempotency key is a conflict",
 			customerID: "customer-123",
-			period:     "invalid-period",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			request: &CreateBillRequest{
+				Currency:       libmoney.CurrencyUSD,
+				BillingPeriod:  "2025-01",
+				IdempotencyKey: "create-2",
 			},
 			mockSetup: func(m *MockTemporalPort) {
-				// No mock setup needed as validation fails before use case call
+				m.On("StartMonthlyBill", mock.Anything, mock.Anything).Return(app.ErrBillWithPeriodAlreadyStarted)
+				m.On("GetBillMemo", mock.Anything, domain.BillID("bill/customer-123/2025-01")).
+					Return(app.BillMemo{CreateIdempotencyKey: "create-1"}, nil)
 			},
 			expectedError: &errs.Error{
-				Code:    errs.InvalidArgument,
-				Message: "invalid period",
+				Code:    errs.AlreadyExists,
+				Message: "a bill already exists for this customer and period",
 			},
 		},
 		{
-			name:       "invalid amount format",
+			name:       "billing period in the future",
 			customerID: "customer-123",
-			period:     "2025-01",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "invalid-amount",
-				IdempotencyKey: "item-123",
+			request: &CreateBillRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "2099-01",
 			},
 			mockSetup: func(m *MockTemporalPort) {
-				// No mock setup needed as validation fails before use case call
+				// the period window is checked before the workflow is started
 			},
 			expectedError: &errs.Error{
 				Code:    errs.InvalidArgument,
-				Message: "amount is invalid",
+				Message: "billing period is out of the allowed range",
 			},
 		},
 		{
-			name:       "bill not found",
+			name:       "unknown template",
 			customerID: "customer-123",
-			period:     "2025-01",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			request: &CreateBillRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "2025-01",
+				TemplateID:    "no-such-plan",
 			},
 			mockSetup: func(m *MockTemporalPort) {
-				billID := domain.BillID("bill/customer-123/2025-01")
-				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+				// the template is resolved before the workflow is started
 			},
 			expectedError: &errs.Error{
-				Code:    errs.NotFound,
-				Message: "bill not found",
+				Code:    errs.InvalidArgument,
+				Message: "unknown bill template",
 			},
 		},
 	}
@@ -317,7 +404,7 @@ func TestAddLineItem(t *testing.T) {
 			service, mockTemporal := createTestService()
 			tt.mockSetup(mockTemporal)
 
-			resp, err := service.AddLineItem(context.Background(), tt.customerID, tt.period, tt.request)
+			resp, err := service.CreateBill(context.Background(), tt.customerID, tt.request)
 
 			if tt.expectedError != nil {
 				require.Error(t, err)
@@ -325,6 +412,7 @@ func TestAddLineItem(t *testing.T) {
 				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
 			} else {
 				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, resp.Status)
 				if tt.validateResponse != nil {
 					tt.validateResponse(t, resp)
 				}
@@ -335,55 +423,85 @@ func TestAddLineItem(t *testing.T) {
 	}
 }
 
-func TestGetBill(t *testing.T) {
+func TestCreateBillForPeriod(t *testing.T) {
 	tests := []struct {
 		name             string
 		customerID       string
 		period           string
+		request          *CreateBillForPeriodRequest
 		mockSetup        func(*MockTemporalPort)
 		expectedError    *errs.Error
-		validateResponse func(t *testing.T, resp *BillResponse)
+		validateResponse func(t *testing.T, resp *CreateBillResponse)
 	}{
 		{
-			name:       "successful bill retrieval",
+			name:       "period taken from path",
 			customerID: "customer-123",
 			period:     "2025-01",
+			request: &CreateBillForPeriodRequest{
+				Currency: libmoney.CurrencyUSD,
+			},
 			mockSetup: func(m *MockTemporalPort) {
-				billID := domain.BillID("bill/customer-123/2025-01")
-				expectedBill := createTestBill()
-				m.On("QueryBill", mock.Anything, billID).Return(expectedBill, nil)
+				expectedParams := app.MonthlyFeeAccrualWorkflowParams{
+					BillID:       "bill/customer-123/2025-01",
+					CustomerID:   "customer-123",
+					Period:       "2025-01",
+					PeriodYYYYMM: 202501,
+					Currency:     libmoney.CurrencyUSD,
+				}
+				m.On("StartMonthlyBill", mock.Anything, expectedParams).Return(nil)
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil)
 			},
-			validateResponse: func(t *testing.T, resp *BillResponse) {
-				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
-				assert.Equal(t, "customer-123", resp.CustomerID)
-				assert.Equal(t, "USD", resp.Currency)
-				assert.Equal(t, "2025-01", resp.BillingPeriod)
-				assert.Equal(t, "OPEN", resp.Status)
+			validateResponse: func(t *testing.T, resp *CreateBillResponse) {
+				assert.Equal(t, 201, resp.Status)
+				assert.Equal(t, "/api/v1/customers/customer-123/bills/2025-01", resp.Location)
+				assert.Equal(t, "bill/customer-123/2025-01", resp.Message.ID)
 			},
 		},
 		{
-			name:       "empty customer ID",
-			customerID: "",
+			name:       "matching body and path period",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &CreateBillForPeriodRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "2025-01",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("StartMonthlyBill", mock.Anything, mock.Anything).Return(nil)
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil)
+			},
+			validateResponse: func(t *testing.T, resp *CreateBillResponse) {
+				assert.Equal(t, "/api/v1/customers/customer-123/bills/2025-01", resp.Location)
+			},
+		},
+		{
+			name:       "mismatched body and path period",
+			customerID: "customer-123",
 			period:     "2025-01",
+			request: &CreateBillForPeriodRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "2025-02",
+			},
 			mockSetup: func(m *MockTemporalPort) {
 				// No mock setup needed as validation fails before use case call
 			},
 			expectedError: &errs.Error{
 				Code:    errs.InvalidArgument,
-				Message: "customerId cannot be empty",
+				Message: "billingPeriod in body does not match period in path",
 			},
 		},
 		{
-			name:       "bill not found",
+			name:       "invalid path period",
 			customerID: "customer-123",
-			period:     "2025-01",
+			period:     "2025-1",
+			request: &CreateBillForPeriodRequest{
+				Currency: libmoney.CurrencyUSD,
+			},
 			mockSetup: func(m *MockTemporalPort) {
-				billID := domain.BillID("bill/customer-123/2025-01")
-				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+				// No mock setup needed as validation fails before use case call
 			},
 			expectedError: &errs.Error{
-				Code:    errs.NotFound,
-				Message: "bill not found",
+				Code:    errs.InvalidArgument,
+				Message: "period must be YYYY-MM",
 			},
 		},
 	}
@@ -393,7 +511,7 @@ func TestGetBill(t *testing.T) {
 			service, mockTemporal := createTestService()
 			tt.mockSetup(mockTemporal)
 
-			resp, err := service.GetBill(context.Background(), tt.customerID, tt.period)
+			resp, err := service.CreateBillForPeriod(context.Background(), tt.customerID, tt.period, tt.request)
 
 			if tt.expectedError != nil {
 				require.Error(t, err)
@@ -411,53 +529,238 @@ func TestGetBill(t *testing.T) {
 	}
 }
 
-func TestCloseBill(t *testing.T) {
+func TestAddLineItem(t *testing.T) {
 	tests := []struct {
 		name             string
 		customerID       string
 		period           string
+		request          *AddLineItemRequest
 		mockSetup        func(*MockTemporalPort)
 		expectedError    *errs.Error
 		validateResponse func(t *testing.T, resp *BillResponse)
 	}{
 		{
-			name:       "successful bill closure",
+			name:       "successful line item addition",
 			customerID: "customer-123",
 			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
 			mockSetup: func(m *MockTemporalPort) {
 				billID := domain.BillID("bill/customer-123/2025-01")
 				openBill := createTestBill()
-				closedBill := createTestBill()
-				closedBill.Status = domain.BillStatusClosed
+				updatedBill := createTestBill()
+				updatedBill.Items = []domain.LineItem{createTestLineItem()}
 
 				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
-				m.On("CloseBill", mock.Anything, billID).Return(nil)
-				m.On("QueryBill", mock.Anything, billID).Return(closedBill, nil).Once()
+				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
+					return li.Description == "Test item" && li.IdempotencyKey == "item-123" &&
+						li.Amount.Currency() == libmoney.CurrencyUSD
+				})).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(updatedBill, nil).Once()
 			},
 			validateResponse: func(t *testing.T, resp *BillResponse) {
 				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
 				assert.Equal(t, "customer-123", resp.CustomerID)
 				assert.Equal(t, "USD", resp.Currency)
 				assert.Equal(t, "2025-01", resp.BillingPeriod)
-				assert.Equal(t, "CLOSED", resp.Status)
-			},
-		},
-		{
-			name:       "empty customer ID",
-			customerID: "",
-			period:     "2025-01",
-			mockSetup: func(m *MockTemporalPort) {
-				// No mock setup needed as validation fails before use case call
-			},
-			expectedError: &errs.Error{
-				Code:    errs.InvalidArgument,
-				Message: "customerId cannot be empty",
+				assert.Equal(t, "OPEN", resp.Status)
+				assert.Len(t, resp.Items, 1)
+				assert.Equal(t, "item-123", resp.Items[0].IdempotencyKey)
+				assert.Equal(t, "Test item", resp.Items[0].Description)
 			},
 		},
 		{
-			name:       "bill not found",
+			name:       "line item with tags",
 			customerID: "customer-123",
 			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags:           map[string]string{"costCenter": "R&D", "sku": "API-100"},
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				updatedBill := createTestBill()
+				item := createTestLineItem()
+				item.Tags = map[string]string{"costCenter": "R&D", "sku": "API-100"}
+				updatedBill.Items = []domain.LineItem{item}
+
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
+					return li.Tags["costCenter"] == "R&D" && li.Tags["sku"] == "API-100"
+				})).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(updatedBill, nil).Once()
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				require.Len(t, resp.Items, 1)
+				assert.Equal(t, map[string]string{"costCenter": "R&D", "sku": "API-100"}, resp.Items[0].Tags)
+			},
+		},
+		{
+			name:       "invalid period format",
+			customerID: "customer-123",
+			period:     "invalid-period",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as validation fails before use case call
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "invalid period",
+			},
+		},
+		{
+			name:       "invalid amount format",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "invalid-amount",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				// the amount is parsed after the bill currency is known, no signal is sent
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil).Once()
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "amount is invalid",
+			},
+		},
+		{
+			name:       "amount in minor units",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				AmountMinor:    "1050",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				updatedBill := createTestBill()
+				updatedBill.Items = []domain.LineItem{createTestLineItem()}
+
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
+					return li.Amount.ToFixedString() == "10.50" && li.Amount.Currency() == libmoney.CurrencyUSD
+				})).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(updatedBill, nil).Once()
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Len(t, resp.Items, 1)
+			},
+		},
+		{
+			name:       "amountMinor with decimals",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				AmountMinor:    "10.50",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil).Once()
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "amountMinor is invalid",
+			},
+		},
+		{
+			name:       "amount with sub-cent decimals",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.999",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil).Once()
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "more decimal places than the currency allows",
+			},
+		},
+		{
+			name:       "credit larger than the bill total",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Goodwill credit",
+				Amount:         "-0.01",
+				IdempotencyKey: "credit-1",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.FailedPrecondition,
+				Message: "bill total negative",
+			},
+		},
+		{
+			name:       "bill at the line item limit",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				full := createTestBill()
+				full.MaxItems = 2
+				full.Items = []domain.LineItem{{IdempotencyKey: "other-1"}, {IdempotencyKey: "other-2"}}
+				m.On("QueryBill", mock.Anything, billID).Return(full, nil)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.FailedPrecondition,
+				Message: "line item limit",
+			},
+		},
+		{
+			name:       "amount finer than the bill currency",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				jpyBill := createTestBill()
+				jpyBill.Currency = libmoney.CurrencyJPY
+				m.On("QueryBill", mock.Anything, billID).Return(jpyBill, nil)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "more decimal places than the currency allows",
+			},
+		},
+		{
+			name:       "bill not found",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
 			mockSetup: func(m *MockTemporalPort) {
 				billID := domain.BillID("bill/customer-123/2025-01")
 				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
@@ -467,6 +770,44 @@ func TestCloseBill(t *testing.T) {
 				Message: "bill not found",
 			},
 		},
+		{
+			name:       "bill already closed",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				closed := createTestBill()
+				closed.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(closed, nil).Once()
+			},
+			expectedError: &errs.Error{
+				Code:    errs.FailedPrecondition,
+				Message: "bill already closed",
+			},
+		},
+		{
+			name:       "amount in minor units of a JPY bill",
+			customerID: "customer-123",
+			period:     "2025-01",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				AmountMinor:    "1050",
+				IdempotencyKey: "item-123",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				jpyBill := createTestBill()
+				jpyBill.Currency = libmoney.CurrencyJPY
+				m.On("QueryBill", mock.Anything, billID).Return(jpyBill, nil)
+				m.On("AddLineItem", mock.Anything, billID, mock.MatchedBy(func(li domain.LineItem) bool {
+					return li.Amount.ToString() == "1050" && li.Amount.Currency() == libmoney.CurrencyJPY
+				})).Return(nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -474,7 +815,7 @@ func TestCloseBill(t *testing.T) {
 			service, mockTemporal := createTestService()
 			tt.mockSetup(mockTemporal)
 
-			resp, err := service.CloseBill(context.Background(), tt.customerID, tt.period)
+			resp, err := service.AddLineItem(context.Background(), tt.customerID, tt.period, tt.request)
 
 			if tt.expectedError != nil {
 				require.Error(t, err)
@@ -492,66 +833,50 @@ func TestCloseBill(t *testing.T) {
 	}
 }
 
-func TestListBills(t *testing.T) {
+func TestUpdateLineItem(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	openBill := createTestBill()
+	openBill.Items = []domain.LineItem{createTestLineItem()}
+
 	tests := []struct {
-		name             string
-		customerID       string
-		params           *ListBillsQueryParams
-		mockSetup        func(*MockTemporalPort)
-		expectedError    *errs.Error
-		validateResponse func(t *testing.T, resp *ListBillsResponse)
+		name          string
+		period        string
+		key           string
+		mockSetup     func(*MockTemporalPort)
+		expectedError *errs.Error
 	}{
 		{
-			name:       "successful bills listing with filters",
-			customerID: "customer-123",
-			params: &ListBillsQueryParams{
-				Status:      "OPEN",
-				PeriodStart: "2025-01",
-				PeriodEnd:   "2025-03",
-			},
+			name:   "successful description update",
+			period: "2025-01",
+			key:    "item-123",
 			mockSetup: func(m *MockTemporalPort) {
-				expectedFilter := app.SearchBillFilter{
-					CustomerID: "customer-123",
-					FromYYYYMM: int64Ptr(202501),
-					ToYYYYMM:   int64Ptr(202503),
-					Status:     []string{"OPEN", "PENDING"},
-				}
-				expectedBills := []views.BillSummary{
-					{
-						WorkflowID:       "bill/customer-123/2025-01",
-						CustomerID:       "customer-123",
-						BillingPeriodNum: 202501,
-						Status:           "OPEN",
-						TotalCents:       1000,
-						Currency:         "USD",
-						ItemCount:        2,
-					},
-				}
-				m.On("SearchBills", mock.Anything, expectedFilter).Return(expectedBills, nil)
-			},
-			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
-				assert.Len(t, resp.Bills, 1)
-				bill := resp.Bills[0]
-				assert.Equal(t, "bill/customer-123/2025-01", bill.ID)
-				assert.Equal(t, "customer-123", bill.CustomerID)
-				assert.Equal(t, "USD", bill.Currency)
-				assert.Equal(t, "2025-01", bill.BillingPeriod)
-				assert.Equal(t, "OPEN", bill.Status)
-				assert.Equal(t, int64(2), bill.ItemCount)
-				assert.Equal(t, "10.00", bill.Total)
+				updated := createTestBill()
+				item := createTestLineItem()
+				item.Description = "Corrected item"
+				updated.Items = []domain.LineItem{item}
+
+				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
+				m.On("UpdateLineItemDescription", mock.Anything, billID, "item-123", "Corrected item").Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(updated, nil).Once()
 			},
 		},
 		{
-			name:       "empty customer ID",
-			customerID: "",
-			params:     &ListBillsQueryParams{},
+			name:   "invalid period",
+			period: "2025-13",
+			key:    "item-123",
 			mockSetup: func(m *MockTemporalPort) {
 				// No mock setup needed as validation fails before use case call
 			},
-			expectedError: &errs.Error{
-				Code:    errs.InvalidArgument,
-				Message: "customerId cannot be empty",
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "invalid period"},
+		},
+		{
+			name:   "line item not found",
+			period: "2025-01",
+			key:    "missing",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil)
 			},
+			expectedError: &errs.Error{Code: errs.NotFound, Message: "line item not found"},
 		},
 	}
 
@@ -560,7 +885,8 @@ func TestListBills(t *testing.T) {
 			service, mockTemporal := createTestService()
 			tt.mockSetup(mockTemporal)
 
-			resp, err := service.ListBills(context.Background(), tt.customerID, tt.params)
+			resp, err := service.UpdateLineItem(context.Background(), "customer-123", tt.period, tt.key,
+				&UpdateLineItemRequest{Description: "Corrected item"})
 
 			if tt.expectedError != nil {
 				require.Error(t, err)
@@ -568,9 +894,8 @@ func TestListBills(t *testing.T) {
 				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
 			} else {
 				require.NoError(t, err)
-				if tt.validateResponse != nil {
-					tt.validateResponse(t, resp)
-				}
+				require.Len(t, resp.Items, 1)
+				assert.Equal(t, "Corrected item", resp.Items[0].Description)
 			}
 
 			mockTemporal.AssertExpectations(t)
@@ -578,339 +903,2250 @@ func TestListBills(t *testing.T) {
 	}
 }
 
-// Test API mapper functions
-func TestMap2BillingResponse(t *testing.T) {
-	bill := createTestBill()
-	bill.Items = []domain.LineItem{createTestLineItem()}
-
-	resp := map2BillingResponse(bill)
+func TestCorrectLineItemAmount(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	openBill := func() domain.Bill {
+		bill := createTestBill()
+		amount, _ := libmoney.NewFromString("10.00", libmoney.CurrencyUSD)
+		_, err := bill.AddItem("item-123", "Test item", amount, time.Now())
+		require.NoError(t, err)
+		return bill
+	}
+	corrected, _ := libmoney.NewFromString("7.50", libmoney.CurrencyUSD)
+	jpyBill := func() domain.Bill {
+		bill := createTestBill()
+		bill.Currency = libmoney.CurrencyJPY
+		_, err := bill.AddItem("item-123", "Test item", libmoney.NewFromInt(1000, libmoney.CurrencyJPY), time.Now())
+		require.NoError(t, err)
+		return bill
+	}
 
-	assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
-	assert.Equal(t, "customer-123", resp.CustomerID)
-	assert.Equal(t, "USD", resp.Currency)
-	assert.Equal(t, "2025-01", resp.BillingPeriod)
-	assert.Equal(t, "OPEN", resp.Status)
-	assert.Len(t, resp.Items, 1)
-	assert.Equal(t, "item-123", resp.Items[0].IdempotencyKey)
-	assert.Equal(t, "Test item", resp.Items[0].Description)
-	assert.Equal(t, fixedTime, resp.CreatedAt)
-	assert.Equal(t, fixedTime, resp.UpdatedAt)
-}
+	tests := []struct {
+		name          string
+		period        string
+		key           string
+		req           CorrectLineItemAmountRequest
+		mockSetup     func(*MockTemporalPort)
+		expectedError *errs.Error
+	}{
+		{
+			name:   "successful amount correction",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{Amount: "7.50"},
+			mockSetup: func(m *MockTemporalPort) {
+				updated := openBill()
+				require.NoError(t, updated.CorrectItemAmount("item-123", corrected, time.Now()))
 
-func TestMapBillListResponse(t *testing.T) {
-	summaries := []views.BillSummary{
+				m.On("QueryBill", mock.Anything, billID).Return(openBill(), nil).Once()
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", corrected).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(updated, nil).Once()
+			},
+		},
 		{
-			WorkflowID:       "bill/customer-123/2025-01",
-			CustomerID:       "customer-123",
-			BillingPeriodNum: 202501,
-			Status:           "OPEN",
-			TotalCents:       1000,
-			Currency:         "USD",
-			ItemCount:        2,
+			name:   "amount in minor units",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{AmountMinor: "750"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(openBill(), nil)
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", mock.Anything).Return(nil)
+			},
 		},
-	}
-
-	resp := mapBillListResponse(summaries)
-
-	assert.Len(t, resp.Bills, 1)
-	bill := resp.Bills[0]
-	assert.Equal(t, "bill/customer-123/2025-01", bill.ID)
-	assert.Equal(t, "customer-123", bill.CustomerID)
-	assert.Equal(t, "USD", bill.Currency)
-	assert.Equal(t, "2025-01", bill.BillingPeriod)
-	assert.Equal(t, "OPEN", bill.Status)
-	assert.Equal(t, int64(2), bill.ItemCount)
-	assert.Equal(t, "10.00", bill.Total)
-}
-
-func TestBillingPeriodNumToString(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    int64
-		expected string
-	}{
 		{
-			name:     "valid period",
-			input:    202501,
-			expected: "2025-01",
+			name:   "amount in minor units of a JPY bill",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{AmountMinor: "750"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(jpyBill(), nil)
+				m.On("CorrectLineItemAmount", mock.Anything, billID, "item-123", mock.MatchedBy(func(a libmoney.Money) bool {
+					return a.ToString() == "750" && a.Currency() == libmoney.CurrencyJPY
+				})).Return(nil)
+			},
 		},
 		{
-			name:     "valid period with single digit month",
-			input:    202503,
-			expected: "2025-03",
+			name:   "amount finer than the bill currency",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{Amount: "7.5"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(jpyBill(), nil)
+			},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "more decimal places than the currency allows"},
 		},
 		{
-			name:     "invalid range - too small",
-			input:    100000,
-			expected: "<formatting error in range>",
+			name:          "invalid period",
+			period:        "2025-13",
+			key:           "item-123",
+			req:           CorrectLineItemAmountRequest{Amount: "7.50"},
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"},
 		},
 		{
-			name:     "invalid range - too large",
-			input:    1000000,
-			expected: "<formatting error in range>",
+			name:   "invalid amount",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{Amount: "seven"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(openBill(), nil)
+			},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "amount is invalid"},
 		},
 		{
-			name:     "invalid month",
-			input:    202500,
-			expected: "<formatting error in month>",
+			name:   "line item not found",
+			period: "2025-01",
+			key:    "missing",
+			req:    CorrectLineItemAmountRequest{Amount: "7.50"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(openBill(), nil)
+			},
+			expectedError: &errs.Error{Code: errs.NotFound, Message: "line item not found"},
 		},
 		{
-			name:     "invalid month - too large",
-			input:    202513,
-			expected: "<formatting error in month>",
+			name:   "negative total",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{Amount: "-1"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(openBill(), nil)
+			},
+			expectedError: &errs.Error{Code: errs.FailedPrecondition, Message: "negative"},
+		},
+		{
+			name:   "bill already closed",
+			period: "2025-01",
+			key:    "item-123",
+			req:    CorrectLineItemAmountRequest{Amount: "7.50"},
+			mockSetup: func(m *MockTemporalPort) {
+				bill := openBill()
+				bill.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(bill, nil)
+			},
+			expectedError: &errs.Error{Code: errs.FailedPrecondition, Message: "bill already closed"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := billingPeriodNumToString(tt.input)
-			assert.Equal(t, tt.expected, result)
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.CorrectLineItemAmount(context.Background(), "customer-123", tt.period, tt.key, &tt.req)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, resp.Items, 1)
+			}
+
+			mockTemporal.AssertExpectations(t)
 		})
 	}
 }
 
-func TestTotalCentsToString(t *testing.T) {
+func TestCorrectLineItemAmountRequest_Validate(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    int64
-		expected string
+		name    string
+		req     CorrectLineItemAmountRequest
+		wantErr bool
+	}{
+		{name: "decimal amount", req: CorrectLineItemAmountRequest{Amount: "7.50"}},
+		{name: "minor units", req: CorrectLineItemAmountRequest{AmountMinor: "750"}},
+		{name: "neither", req: CorrectLineItemAmountRequest{}, wantErr: true},
+		{name: "both", req: CorrectLineItemAmountRequest{Amount: "7.50", AmountMinor: "750"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSetBillNote(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+
+	tests := []struct {
+		name          string
+		period        string
+		mockSetup     func(*MockTemporalPort)
+		expectedError *errs.Error
 	}{
 		{
-			name:     "zero cents",
-			input:    0,
-			expected: "0.00",
-		},
-		{
-			name:     "positive cents",
-			input:    1000,
-			expected: "10.00",
-		},
-		{
-			name:     "negative cents",
-			input:    -1000,
-			expected: "-10.00",
+			name:   "note is set",
+			period: "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				noted := createTestBill()
+				noted.Notes = "split the invoice"
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+				m.On("SetBillNote", mock.Anything, billID, "split the invoice").Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(noted, nil).Once()
+			},
 		},
 		{
-			name:     "single digit cents",
-			input:    5,
-			expected: "0.05",
+			name:          "invalid period",
+			period:        "2025-13",
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"},
 		},
 		{
-			name:     "large amount",
-			input:    123456,
-			expected: "1234.56",
+			name:   "bill already closed",
+			period: "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				closed := createTestBill()
+				closed.Status = domain.BillStatusClosed
+				m.On("QueryBill", mock.Anything, billID).Return(closed, nil)
+			},
+			expectedError: &errs.Error{Code: errs.FailedPrecondition, Message: "bill already closed"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := totalCentsToString(tt.input)
-			assert.Equal(t, tt.expected, result)
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.SetBillNote(context.Background(), "customer-123", tt.period,
+				&SetBillNoteRequest{Note: "split the invoice"})
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "split the invoice", resp.Notes)
+			}
+
+			mockTemporal.AssertExpectations(t)
 		})
 	}
+
+	assert.NoError(t, (&SetBillNoteRequest{}).Validate(), "empty clears the note")
+	assert.Error(t, (&SetBillNoteRequest{Note: strings.Repeat("a", 4097)}).Validate())
 }
 
-// Test validation functions
-func TestCreateBillRequest_Validate(t *testing.T) {
+func TestGetBill(t *testing.T) {
 	tests := []struct {
-		name    string
-		request *CreateBillRequest
-		wantErr bool
+		name             string
+		customerID       string
+		period           string
+		params           *GetBillQueryParams
+		mockSetup        func(*MockTemporalPort)
+		expectedError    *errs.Error
+		validateResponse func(t *testing.T, resp *BillResponse)
 	}{
 		{
-			name: "valid request",
-			request: &CreateBillRequest{
-				Currency:      libmoney.CurrencyUSD,
-				BillingPeriod: "2025-01",
+			name:       "successful bill retrieval",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				expectedBill := createTestBill()
+				m.On("QueryBill", mock.Anything, billID).Return(expectedBill, nil)
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+				assert.Equal(t, "customer-123", resp.CustomerID)
+				assert.Equal(t, "USD", resp.Currency)
+				assert.Equal(t, "2025-01", resp.BillingPeriod)
+				assert.Equal(t, "OPEN", resp.Status)
 			},
-			wantErr: false,
 		},
 		{
-			name: "invalid currency",
-			request: &CreateBillRequest{
-				Currency:      "INVALID",
-				BillingPeriod: "2025-01",
+			name:       "explicit full view",
+			customerID: "customer-123",
+			period:     "2025-01",
+			params:     &GetBillQueryParams{View: BillViewFull},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.NotNil(t, resp.Items)
 			},
-			wantErr: true,
 		},
 		{
-			name: "invalid billing period",
-			request: &CreateBillRequest{
-				Currency:      libmoney.CurrencyUSD,
-				BillingPeriod: "invalid-period",
+			name:       "summary view",
+			customerID: "customer-123",
+			period:     "2025-01",
+			params:     &GetBillQueryParams{View: BillViewSummary},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBillSummary", mock.Anything, billID).Return(views.BillStateSummary{
+					ID: string(billID), CustomerID: "customer-123", Currency: libmoney.CurrencyUSD,
+					BillingPeriod: "2025-01", Status: "OPEN", ItemCount: 3, CreatedAt: fixedTime, UpdatedAt: fixedTime,
+				}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+				assert.Equal(t, "OPEN", resp.Status)
+				assert.Equal(t, int64(3), resp.ItemCount)
+				assert.Nil(t, resp.Items)
+			},
+		},
+		{
+			name:       "as of a past time",
+			customerID: "customer-123",
+			period:     "2025-01",
+			params:     &GetBillQueryParams{AsOf: "2025-01-10T12:00:00Z"},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				at := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+				m.On("QueryBillAsOf", mock.Anything, billID, at).Return(createTestBill(), nil)
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+				assert.NotNil(t, resp.Items)
+			},
+		},
+		{
+			name:       "as of a time before the bill",
+			customerID: "customer-123",
+			period:     "2025-01",
+			params:     &GetBillQueryParams{AsOf: "2024-12-01T00:00:00Z"},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBillAsOf", mock.Anything, billID, mock.Anything).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
+			},
+		},
+		{
+			name:       "summary view bill not found",
+			customerID: "customer-123",
+			period:     "2025-01",
+			params:     &GetBillQueryParams{View: BillViewSummary},
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBillSummary", mock.Anything, billID).Return(views.BillStateSummary{}, app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
+			},
+		},
+		{
+			name:       "empty customer ID",
+			customerID: "",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as validation fails before use case call
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "customerId cannot be empty",
+			},
+		},
+		{
+			name:       "bill not found",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
+			},
+		},
+		{
+			name:       "bill busy",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, fmt.Errorf("%w: deadline exceeded", app.ErrBillBusy))
+			},
+			expectedError: &errs.Error{
+				Code:    errs.Unavailable,
+				Message: "bill is busy",
+			},
+		},
+		{
+			name:       "bill past retention",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillArchived)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill is archived",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.GetBill(context.Background(), tt.customerID, tt.period, tt.params)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				if tt.validateResponse != nil {
+					tt.validateResponse(t, resp)
+				}
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetBillByExecution(t *testing.T) {
+	tests := []struct {
+		name             string
+		workflowID       string
+		runID            string
+		mockSetup        func(*MockTemporalPort)
+		expectedError    *errs.Error
+		validateResponse func(t *testing.T, resp *BillResponse)
+	}{
+		{
+			name:       "successful bill retrieval",
+			workflowID: "bill/customer-123/2025-01",
+			runID:      "run-1",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBillByExecution", mock.Anything, "bill/customer-123/2025-01", "run-1").
+					Return(createTestBill(), nil)
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+				assert.Equal(t, "customer-123", resp.CustomerID)
+			},
+		},
+		{
+			name:       "empty run ID",
+			workflowID: "bill/customer-123/2025-01",
+			runID:      "",
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as validation fails before use case call
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "runID cannot be empty",
+			},
+		},
+		{
+			name:       "bill not found",
+			workflowID: "bill/customer-123/2025-01",
+			runID:      "run-unknown",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBillByExecution", mock.Anything, "bill/customer-123/2025-01", "run-unknown").
+					Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.GetBillByExecution(context.Background(), tt.workflowID, tt.runID)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				if tt.validateResponse != nil {
+					tt.validateResponse(t, resp)
+				}
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetryInvoicing(t *testing.T) {
+	tests := []struct {
+		name          string
+		period        string
+		mockSetup     func(*MockTemporalPort)
+		expectedError *errs.Error
+		expectedState string
+	}{
+		{
+			name:   "successful retry",
+			period: "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				errored := createTestBill()
+				errored.Status = domain.BillStatusChargeFailed
+				errored.InvoicingRetryable = true
+				pending := createTestBill()
+				pending.Status = domain.BillStatusPending
+
+				m.On("QueryBill", mock.Anything, billID).Return(errored, nil).Once()
+				m.On("RetryInvoicing", mock.Anything, billID).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(pending, nil).Once()
+			},
+			expectedState: "PENDING",
+		},
+		{
+			name:   "bill not in error",
+			period: "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, domain.BillID("bill/customer-123/2025-01")).Return(createTestBill(), nil)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.FailedPrecondition,
+				Message: "bill is not in error state",
+			},
+		},
+		{
+			name:      "invalid period",
+			period:    "2025-13",
+			mockSetup: func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "period must be YYYY-MM",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.RetryInvoicing(context.Background(), "customer-123", tt.period)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedState, resp.Status)
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCloseBill(t *testing.T) {
+	tests := []struct {
+		name             string
+		customerID       string
+		period           string
+		mockSetup        func(*MockTemporalPort)
+		expectedError    *errs.Error
+		validateResponse func(t *testing.T, resp *BillResponse)
+	}{
+		{
+			name:       "successful bill closure",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				openBill := createTestBill()
+				closedBill := createTestBill()
+				closedBill.Status = domain.BillStatusClosed
+
+				m.On("QueryBill", mock.Anything, billID).Return(openBill, nil).Once()
+				m.On("CloseBill", mock.Anything, billID).Return(nil)
+				m.On("QueryBill", mock.Anything, billID).Return(closedBill, nil).Once()
+			},
+			validateResponse: func(t *testing.T, resp *BillResponse) {
+				assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+				assert.Equal(t, "customer-123", resp.CustomerID)
+				assert.Equal(t, "USD", resp.Currency)
+				assert.Equal(t, "2025-01", resp.BillingPeriod)
+				assert.Equal(t, "CLOSED", resp.Status)
+			},
+		},
+		{
+			name:       "empty customer ID",
+			customerID: "",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as validation fails before use case call
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "customerId cannot be empty",
+			},
+		},
+		{
+			name:       "bill not found",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
+			},
+		},
+		{
+			name:       "empty bill refused by the policy",
+			customerID: "customer-123",
+			period:     "2025-01",
+			mockSetup: func(m *MockTemporalPort) {
+				billID := domain.BillID("bill/customer-123/2025-01")
+				emptyBill := createTestBill()
+				emptyBill.ItemsRequired = true
+				m.On("QueryBill", mock.Anything, billID).Return(emptyBill, nil)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.FailedPrecondition,
+				Message: "bill has no line items",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.CloseBill(context.Background(), tt.customerID, tt.period)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				if tt.validateResponse != nil {
+					tt.validateResponse(t, resp)
+				}
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListBills(t *testing.T) {
+	tests := []struct {
+		name             string
+		customerID       string
+		params           *ListBillsQueryParams
+		mockSetup        func(*MockTemporalPort)
+		expectedError    *errs.Error
+		validateResponse func(t *testing.T, resp *ListBillsResponse)
+	}{
+		{
+			name:       "successful bills listing with filters",
+			customerID: "customer-123",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-03",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				expectedFilter := app.SearchBillFilter{
+					CustomerID: "customer-123",
+					FromYYYYMM: int64Ptr(202501),
+					ToYYYYMM:   int64Ptr(202503),
+					Status:     []string{"OPEN", "PENDING"},
+				}
+				expectedBills := []views.BillSummary{
+					{
+						WorkflowID:       "bill/customer-123/2025-01",
+						CustomerID:       "customer-123",
+						BillingPeriodNum: 202501,
+						Status:           "OPEN",
+						TotalCents:       1000,
+						Currency:         "USD",
+						ItemCount:        2,
+						UpdatedAt:        &fixedTime,
+						CreatedAt:        &fixedTime,
+					},
+				}
+				m.On("SearchBills", mock.Anything, expectedFilter).Return(expectedBills, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				assert.Len(t, resp.Bills, 1)
+				bill := resp.Bills[0]
+				assert.Equal(t, "bill/customer-123/2025-01", bill.ID)
+				assert.Equal(t, "customer-123", bill.CustomerID)
+				assert.Equal(t, "USD", bill.Currency)
+				assert.Equal(t, "2025-01", bill.BillingPeriod)
+				assert.Equal(t, "OPEN", bill.Status)
+				assert.Equal(t, int64(2), bill.ItemCount)
+				assert.Equal(t, "10.00", bill.Total)
+				if assert.NotNil(t, bill.UpdatedAt) {
+					assert.True(t, bill.UpdatedAt.Equal(fixedTime))
+				}
+				if assert.NotNil(t, bill.CreatedAt) {
+					assert.True(t, bill.CreatedAt.Equal(fixedTime))
+				}
+			},
+		},
+		{
+			name:       "created range is parsed as RFC 3339",
+			customerID: "customer-123",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-01",
+				CreatedFrom: "2025-01-01T00:00:00Z",
+				CreatedTo:   "2025-01-31T23:59:59+04:00",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return f.CreatedFrom != nil && f.CreatedFrom.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+						f.CreatedTo != nil && f.CreatedTo.Equal(time.Date(2025, 1, 31, 19, 59, 59, 0, time.UTC))
+				})).Return([]views.BillSummary{}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				assert.Empty(t, resp.Bills)
+			},
+		},
+		{
+			name:       "active range is passed as the period overlap",
+			customerID: "customer-123",
+			params: &ListBillsQueryParams{
+				Status:      "CLOSED",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-03",
+				ActiveFrom:  "2025-02-15T12:00:00Z",
+				ActiveTo:    "2025-02-15T12:00:00Z",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				at := time.Date(2025, 2, 15, 12, 0, 0, 0, time.UTC)
+				periodStart := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+				periodEnd := time.Date(2025, 2, 28, 23, 59, 59, 999999999, time.UTC)
+				m.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+					return f.ActiveFrom != nil && f.ActiveFrom.Equal(at) && f.ActiveTo != nil && f.ActiveTo.Equal(at)
+				})).Return([]views.BillSummary{{
+					WorkflowID: "bill/customer-123/2025-02", Status: "CLOSED", Currency: "USD",
+					CustomerID: "customer-123", BillingPeriodNum: 202502,
+					PeriodStart: &periodStart, PeriodEnd: &periodEnd,
+				}}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				require.Len(t, resp.Bills, 1)
+				require.NotNil(t, resp.Bills[0].PeriodStart)
+				require.NotNil(t, resp.Bills[0].PeriodEnd)
+				assert.Equal(t, "2025-02-01T00:00:00Z", resp.Bills[0].PeriodStart.Format(time.RFC3339))
+			},
+		},
+		{
+			name:       "total bounds are converted to cents",
+			customerID: "customer-123",
+			params: &ListBillsQueryParams{
+				Status:      "CLOSED",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-01",
+				MinTotal:    "10",
+				MaxTotal:    "2500.5",
+			},
+			mockSetup: func(m *MockTemporalPort) {
+				expectedFilter := app.SearchBillFilter{
+					CustomerID:    "customer-123",
+					FromYYYYMM:    int64Ptr(202501),
+					ToYYYYMM:      int64Ptr(202501),
+					Status:        []string{"CLOSED"},
+					MinTotalCents: int64Ptr(1000),
+					MaxTotalCents: int64Ptr(250050),
+				}
+				m.On("SearchBills", mock.Anything, expectedFilter).Return([]views.BillSummary{}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				assert.Empty(t, resp.Bills)
+			},
+		},
+		{
+			name:       "charge failed bills",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "CHARGE_FAILED", PeriodStart: "2025-01", PeriodEnd: "2025-01"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("SearchBills", mock.Anything, app.SearchBillFilter{
+					CustomerID: "customer-123",
+					FromYYYYMM: int64Ptr(202501),
+					ToYYYYMM:   int64Ptr(202501),
+					Status:     []string{"CHARGE_FAILED"},
+				}).Return([]views.BillSummary{{
+					WorkflowID: "bill/customer-123/2025-01", CustomerID: "customer-123", BillingPeriodNum: 202501,
+					Status: "CHARGE_FAILED", Currency: "USD",
+				}}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				require.Len(t, resp.Bills, 1)
+				assert.Equal(t, "CHARGE_FAILED", resp.Bills[0].Status)
+			},
+		},
+		{
+			name:       "rejected bills",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "REJECTED", PeriodStart: "2025-01", PeriodEnd: "2025-01"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("SearchBills", mock.Anything, app.SearchBillFilter{
+					CustomerID: "customer-123",
+					FromYYYYMM: int64Ptr(202501),
+					ToYYYYMM:   int64Ptr(202501),
+					Status:     []string{"REJECTED"},
+				}).Return([]views.BillSummary{{
+					WorkflowID: "bill/customer-123/2025-01", CustomerID: "customer-123", BillingPeriodNum: 202501,
+					Status: "REJECTED", Currency: "USD",
+				}}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				require.Len(t, resp.Bills, 1)
+				assert.Equal(t, "REJECTED", resp.Bills[0].Status)
+			},
+		},
+		{
+			name:       "max items zero finds empty bills",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MaxItems: "0"},
+			mockSetup: func(m *MockTemporalPort) {
+				expectedFilter := app.SearchBillFilter{
+					CustomerID:   "customer-123",
+					FromYYYYMM:   int64Ptr(202501),
+					ToYYYYMM:     int64Ptr(202501),
+					Status:       []string{"OPEN", "PENDING"},
+					MaxItemCount: int64Ptr(0),
+				}
+				m.On("SearchBills", mock.Anything, expectedFilter).Return([]views.BillSummary{}, nil)
+			},
+			validateResponse: func(t *testing.T, resp *ListBillsResponse) {
+				assert.Empty(t, resp.Bills)
+			},
+		},
+		{
+			name:       "too many bills",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "CLOSED", PeriodStart: "2020-01", PeriodEnd: "2025-12"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("SearchBills", mock.Anything, mock.Anything).Return([]views.BillSummary(nil), app.ErrTooManyBills)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.ResourceExhausted,
+				Message: "narrow the filters",
+			},
+		},
+		{
+			name:       "search deadline",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "CLOSED", PeriodStart: "2020-01", PeriodEnd: "2025-12"},
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("SearchBills", mock.Anything, mock.Anything).Return([]views.BillSummary(nil), context.DeadlineExceeded)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.DeadlineExceeded,
+				Message: "search took too long",
+			},
+		},
+		{
+			name:       "total finer than cents",
+			customerID: "customer-123",
+			params:     &ListBillsQueryParams{Status: "CLOSED", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinTotal: "10.005"},
+			mockSetup:  func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "minTotal is invalid",
+			},
+		},
+		{
+			name:       "empty customer ID",
+			customerID: "",
+			params:     &ListBillsQueryParams{},
+			mockSetup: func(m *MockTemporalPort) {
+				// No mock setup needed as validation fails before use case call
+			},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "customerId cannot be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.ListBills(context.Background(), tt.customerID, tt.params)
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
+			} else {
+				require.NoError(t, err)
+				if tt.validateResponse != nil {
+					tt.validateResponse(t, resp)
+				}
+			}
+
+			mockTemporal.AssertExpectations(t)
+		})
+	}
+}
+
+// Test API mapper functions
+func TestMap2BillingResponse(t *testing.T) {
+	bill := createTestBill()
+	bill.Items = []domain.LineItem{createTestLineItem()}
+
+	resp := map2BillingResponse(bill)
+
+	assert.Equal(t, "bill/customer-123/2025-01", resp.ID)
+	assert.Equal(t, "customer-123", resp.CustomerID)
+	assert.Equal(t, "USD", resp.Currency)
+	assert.Equal(t, "2025-01", resp.BillingPeriod)
+	assert.Equal(t, "OPEN", resp.Status)
+	assert.Len(t, resp.Items, 1)
+	assert.Equal(t, "item-123", resp.Items[0].IdempotencyKey)
+	assert.Equal(t, "Test item", resp.Items[0].Description)
+	assert.Equal(t, fixedTime, resp.CreatedAt)
+	assert.Equal(t, fixedTime, resp.UpdatedAt)
+}
+
+func TestMapBillListResponse(t *testing.T) {
+	summaries := []views.BillSummary{
+		{
+			WorkflowID:       "bill/customer-123/2025-01",
+			CustomerID:       "customer-123",
+			BillingPeriodNum: 202501,
+			Status:           "OPEN",
+			TotalCents:       1000,
+			Currency:         "USD",
+			ItemCount:        2,
+		},
+	}
+
+	resp := mapBillListResponse(summaries)
+
+	assert.Len(t, resp.Bills, 1)
+	bill := resp.Bills[0]
+	assert.Equal(t, "bill/customer-123/2025-01", bill.ID)
+	assert.Equal(t, "customer-123", bill.CustomerID)
+	assert.Equal(t, "USD", bill.Currency)
+	assert.Equal(t, "2025-01", bill.BillingPeriod)
+	assert.Equal(t, "OPEN", bill.Status)
+	assert.Equal(t, int64(2), bill.ItemCount)
+	assert.Equal(t, "10.00", bill.Total)
+	assert.Equal(t, int64(1000), bill.TotalMinor)
+}
+
+func TestTotalMinor_AgreesWithTotal(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         string
+		currency      libmoney.Currency
+		expectedMinor int64
+	}{
+		{name: "zero", total: "0", currency: libmoney.CurrencyUSD, expectedMinor: 0},
+		{name: "whole cents", total: "10.5", currency: libmoney.CurrencyUSD, expectedMinor: 1050},
+		{name: "negative", total: "-10.5", currency: libmoney.CurrencyGEL, expectedMinor: -1050},
+		{name: "half a cent rounds up", total: "0.005", currency: libmoney.CurrencyUSD, expectedMinor: 1},
+		{name: "negative half a cent rounds away from zero", total: "-0.005", currency: libmoney.CurrencyUSD, expectedMinor: -1},
+		{name: "below half a cent rounds down", total: "12.344", currency: libmoney.CurrencyUSD, expectedMinor: 1234},
+		{name: "zero-decimal currency", total: "1050.5", currency: libmoney.CurrencyJPY, expectedMinor: 1051},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, err := libmoney.NewFromString(tt.total, tt.currency)
+			require.NoError(t, err)
+			bill := createTestBill()
+			bill.Currency = tt.currency
+			bill.Total = total
+
+			resp := map2BillingResponse(bill)
+			assert.Equal(t, tt.expectedMinor, resp.TotalMinor)
+
+			// The display string is unrounded, so the minor units must match it rounded to the currency precision.
+			display, err := libmoney.NewFromString(resp.Total, libmoney.Currency(resp.Currency))
+			require.NoError(t, err)
+			assert.Equal(t, display.ToMinorUnits(), resp.TotalMinor)
+
+			// The list view derives its string from the minor units, so both must round-trip exactly.
+			list := mapBillListResponse([]views.BillSummary{{
+				BillingPeriodNum: 202501,
+				Currency:         string(tt.currency),
+				TotalCents:       resp.TotalMinor,
+			}})
+			require.Len(t, list.Bills, 1)
+			listTotal, err := libmoney.NewFromString(list.Bills[0].Total, tt.currency)
+			require.NoError(t, err)
+			assert.Equal(t, list.Bills[0].TotalMinor, listTotal.ToMinorUnits())
+			assert.Equal(t, libmoney.FromMinorUnits(resp.TotalMinor, tt.currency).ToFixedString(), list.Bills[0].Total)
+		})
+	}
+}
+
+func TestBillingPeriodNumToString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int64
+		expected string
+	}{
+		{
+			name:     "valid period",
+			input:    202501,
+			expected: "2025-01",
+		},
+		{
+			name:     "valid period with single digit month",
+			input:    202503,
+			expected: "2025-03",
+		},
+		{
+			name:     "invalid range - too small",
+			input:    100000,
+			expected: "<formatting error>",
+		},
+		{
+			name:     "invalid range - too large",
+			input:    1000000,
+			expected: "<formatting error>",
+		},
+		{
+			name:     "invalid month",
+			input:    202500,
+			expected: "<formatting error>",
+		},
+		{
+			name:     "invalid month - too large",
+			input:    202513,
+			expected: "<formatting error>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := billingPeriodNumToString(tt.input)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestTotalCentsToString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    int64
+		currency libmoney.Currency
+		expected string
+	}{
+		{
+			name:     "zero cents",
+			input:    0,
+			currency: libmoney.CurrencyUSD,
+			expected: "0.00",
+		},
+		{
+			name:     "positive cents",
+			input:    1000,
+			currency: libmoney.CurrencyUSD,
+			expected: "10.00",
+		},
+		{
+			name:     "negative cents",
+			input:    -1000,
+			currency: libmoney.CurrencyUSD,
+			expected: "-10.00",
+		},
+		{
+			name:     "single digit cents",
+			input:    5,
+			currency: libmoney.CurrencyGEL,
+			expected: "0.05",
+		},
+		{
+			name:     "large amount",
+			input:    123456,
+			currency: libmoney.CurrencyUSD,
+			expected: "1234.56",
+		},
+		{
+			name:     "zero-decimal currency",
+			input:    1050,
+			currency: libmoney.CurrencyJPY,
+			expected: "1050",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := totalCentsToString(tt.input, tt.currency)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// Test validation functions
+func TestCreateBillRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *CreateBillRequest
+		wantErr bool
+	}{
+		{
+			name: "valid request",
+			request: &CreateBillRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "2025-01",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid currency",
+			request: &CreateBillRequest{
+				Currency:      "INVALID",
+				BillingPeriod: "2025-01",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid billing period",
+			request: &CreateBillRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "invalid-period",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty currency",
+			request: &CreateBillRequest{
+				Currency:      "",
+				BillingPeriod: "2025-01",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty billing period",
+			request: &CreateBillRequest{
+				Currency:      libmoney.CurrencyUSD,
+				BillingPeriod: "",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAddLineItem_RateLimited(t *testing.T) {
+	service, mockTemporal := createTestService()
+	service.addItemLimiter = newBillRateLimiter(0.001, 2)
+	billID := domain.BillID("bill/customer-123/2025-01")
+	// distinct keys, a repeated one would be rejected as already added and not reach the limit
+	item1, item2 := createTestLineItem(), createTestLineItem()
+	item1.IdempotencyKey, item2.IdempotencyKey = "item-1", "item-2"
+	billWithItem1, billWithItems := createTestBill(), createTestBill()
+	billWithItem1.Items = []domain.LineItem{item1}
+	billWithItems.Items = []domain.LineItem{item1, item2}
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil).Once()
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(billWithItem1, nil).Twice()
+	mockTemporal.On("QueryBill", mock.Anything, billID).Return(billWithItems, nil).Once()
+	mockTemporal.On("AddLineItem", mock.Anything, billID, mock.Anything).Return(nil)
+
+	for i := range 2 {
+		req := &AddLineItemRequest{Description: "Test item", Amount: "10.50", IdempotencyKey: fmt.Sprintf("item-%d", i+1)}
+		_, err := service.AddLineItem(context.Background(), "customer-123", "2025-01", req)
+		require.NoError(t, err, "call %d within the burst", i+1)
+	}
+	req := &AddLineItemRequest{Description: "Test item", Amount: "10.50", IdempotencyKey: "item-3"}
+	_, err := service.AddLineItem(context.Background(), "customer-123", "2025-01", req)
+
+	require.Error(t, err)
+	assert.Equal(t, errs.ResourceExhausted, err.(*errs.Error).Code)
+	mockTemporal.AssertNumberOfCalls(t, "AddLineItem", 2)
+}
+
+func TestAddLineItemRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *AddLineItemRequest
+		wantErr bool
+	}{
+		{
+			name: "valid request",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty description",
+			request: &AddLineItemRequest{
+				Description:    "",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "description too short",
+			request: &AddLineItemRequest{
+				Description:    "A",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "description too long",
+			request: &AddLineItemRequest{
+				Description:    string(make([]byte, 1025)), // 1025 bytes
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty amount",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty idempotency key",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "",
+			},
+			wantErr: true,
+		},
+		{
+			name: "amount in minor units",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				AmountMinor:    "1050",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: false,
+		},
+		{
+			name: "both amount and amountMinor",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				AmountMinor:    "1050",
+				IdempotencyKey: "item-123",
+			},
+			wantErr: true,
+		},
+		{
+			name: "with tags",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags:           map[string]string{"costCenter": "R&D", "sku": "API-100"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty tag key",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags:           map[string]string{"": "R&D"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "tag value too long",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags:           map[string]string{"sku": strings.Repeat("x", 257)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "control char in a tag",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags:           map[string]string{"sku": "API\n100"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "too many tags",
+			request: &AddLineItemRequest{
+				Description:    "Test item",
+				Amount:         "10.50",
+				IdempotencyKey: "item-123",
+				Tags: func() map[string]string {
+					tags := map[string]string{}
+					for i := range 21 {
+						tags[fmt.Sprintf("tag-%d", i)] = "v"
+					}
+					return tags
+				}(),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsAllFields(t *testing.T) {
+	fields := func(err error) []string {
+		var e *errs.Error
+		require.ErrorAs(t, err, &e)
+		assert.Equal(t, errs.InvalidArgument, e.Code)
+		details, ok := e.Details.(validation.FieldErrors)
+		require.True(t, ok, "details are %T", e.Details)
+		names := make([]string, 0, len(details.Fields))
+		for _, fe := range details.Fields {
+			names = append(names, fe.Field+":"+fe.Tag)
+		}
+
+		return names
+	}
+
+	assert.Equal(t, []string{"currency:required", "billingPeriod:required"},
+		fields((&CreateBillRequest{}).Validate()))
+	assert.Equal(t, []string{"currency:oneof", "billingPeriod:datetime", "jurisdiction:min"},
+		fields((&CreateBillRequest{Currency: "EUR", BillingPeriod: "2025-13", Jurisdiction: "G"}).Validate()))
+	assert.Equal(t, []string{"description:required", "amount:required_without", "IdempotencyKey:required"},
+		fields((&AddLineItemRequest{}).Validate()))
+}
+
+func TestValidate_Localized(t *testing.T) {
+	var e *errs.Error
+	require.ErrorAs(t, (&CreateBillRequest{BillingPeriod: "2025-01"}).Validate(), &e)
+	assert.Equal(t, "currency is a required field", e.Message)
+
+	require.ErrorAs(t, (&CreateBillRequest{BillingPeriod: "2025-01", AcceptLanguage: "ru-RU"}).Validate(), &e)
+	assert.Equal(t, "currency обязательное поле", e.Message)
+
+	require.ErrorAs(t, (&CreateBillForPeriodRequest{AcceptLanguage: "ru"}).Validate(), &e)
+	assert.Equal(t, "currency обязательное поле", e.Message)
+
+	require.ErrorAs(t, (&AddLineItemRequest{Description: "Fee", Amount: "1", AcceptLanguage: "ru"}).Validate(), &e)
+	assert.Equal(t, "IdempotencyKey обязательное поле", e.Message)
+
+	// exactly one of amount and amountMinor
+	neither := &AddLineItemRequest{Description: "Fee", IdempotencyKey: "k"}
+	require.ErrorAs(t, neither.Validate(), &e)
+	assert.Equal(t, "amount is a required field", e.Message)
+	both := &AddLineItemRequest{Description: "Fee", Amount: "1", AmountMinor: "100", IdempotencyKey: "k"}
+	require.ErrorAs(t, both.Validate(), &e)
+	assert.Equal(t, "amount is an excluded field", e.Message)
+	both.AcceptLanguage = "ru"
+	require.ErrorAs(t, both.Validate(), &e)
+	assert.Equal(t, "amount должно отсутствовать", e.Message)
+}
+
+func TestListBillsQueryParams_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  *ListBillsQueryParams
+		wantErr bool
+	}{
+		{
+			name: "valid params with status",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-03",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid params with minimal filters",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",    // Status is required by validation
+				PeriodStart: "2025-01", // Valid datetime format required
+				PeriodEnd:   "2025-01", // Valid datetime format required
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid status",
+			params: &ListBillsQueryParams{
+				Status:      "INVALID",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2025-03",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid period start",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "invalid-period",
+				PeriodEnd:   "2025-03",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid period end",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "invalid-period",
+			},
+			wantErr: true,
+		},
+		{
+			name: "reversed range",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-03",
+				PeriodEnd:   "2025-01",
+			},
+			wantErr: true,
+		},
+		{
+			name: "reversed range across years",
+			params: &ListBillsQueryParams{
+				Status:      "OPEN",
+				PeriodStart: "2025-01",
+				PeriodEnd:   "2024-12",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "total range",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinTotal: "10", MaxTotal: "25.50"},
+			wantErr: false,
+		},
+		{
+			name:    "min total only",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinTotal: "0.01"},
+			wantErr: false,
+		},
+		{
+			name:    "reversed total range",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinTotal: "25.50", MaxTotal: "10"},
+			wantErr: true,
+		},
+		{
+			name:    "total finer than cents",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MaxTotal: "10.001"},
+			wantErr: true,
+		},
+		{
+			name:    "empty bills",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MaxItems: "0"},
+			wantErr: false,
+		},
+		{
+			name:    "reversed item count range",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinItems: "5", MaxItems: "1"},
+			wantErr: true,
+		},
+		{
+			name:    "negative item count",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinItems: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "total not a number",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", MinTotal: "ten"},
+			wantErr: true,
+		},
+		{
+			name: "created range",
+			params: &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01",
+				CreatedFrom: "2025-01-01T00:00:00Z", CreatedTo: "2025-01-01T04:00:00+04:00"},
+			wantErr: false,
+		},
+		{
+			name: "reversed created range",
+			params: &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01",
+				CreatedFrom: "2025-01-02T00:00:00Z", CreatedTo: "2025-01-01T00:00:00Z"},
+			wantErr: true,
+		},
+		{
+			name: "reversed active range",
+			params: &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01",
+				ActiveFrom: "2025-01-02T00:00:00Z", ActiveTo: "2025-01-01T00:00:00Z"},
+			wantErr: true,
+		},
+		{
+			name:    "created from not a timestamp",
+			params:  &ListBillsQueryParams{Status: "OPEN", PeriodStart: "2025-01", PeriodEnd: "2025-01", CreatedFrom: "2025-01-01"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// Helper function to create int64 pointer
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func TestCountBillsQueryParams_Validate_PeriodRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		wantErr bool
+	}{
+		{name: "reversed", from: "2025-03", to: "2025-01", wantErr: true},
+		{name: "equal", from: "2025-01", to: "2025-01"},
+		{name: "ascending", from: "2024-12", to: "2025-01"},
+		{name: "only from", from: "2025-03"},
+		{name: "only to", to: "2025-01"},
+		{name: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := &CountBillsQueryParams{Status: "OPEN", PeriodStart: tt.from, PeriodEnd: tt.to}
+			err := params.Validate()
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+				assert.Equal(t, "from must be <= to", err.(*errs.Error).Message)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSumFeesQueryParams_Validate(t *testing.T) {
+	for _, description := range []string{"api", "api*fee", "storage [a-z]*"} {
+		p := &SumFeesQueryParams{Description: description}
+		assert.NoError(t, p.Validate(), "description %q", description)
+	}
+
+	p := &SumFeesQueryParams{Description: "[api"}
+	err := p.Validate()
+	require.Error(t, err)
+	assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+}
+
+func TestGetBillQueryParams_Validate(t *testing.T) {
+	for _, view := range []string{"", BillViewFull, BillViewSummary} {
+		p := &GetBillQueryParams{View: view}
+		assert.NoError(t, p.Validate(), "view %q", view)
+	}
+	p := &GetBillQueryParams{View: "items"}
+	assert.Error(t, p.Validate())
+
+	for _, asOf := range []string{"2025-01-10T12:00:00Z", "2025-01-10T12:00:00+04:00"} {
+		p := &GetBillQueryParams{AsOf: asOf}
+		assert.NoError(t, p.Validate(), "asOf %q", asOf)
+	}
+	for _, asOf := range []string{"2025-01-10", "yesterday"} {
+		p := &GetBillQueryParams{AsOf: asOf}
+		assert.Error(t, p.Validate(), "asOf %q", asOf)
+	}
+}
+
+func TestTerminateBill(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	tests := []struct {
+		name          string
+		workflowID    string
+		mockSetup     func(*MockTemporalPort)
+		expectedError *errs.Error
+	}{
+		{
+			name:       "bill terminated",
+			workflowID: string(billID),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("TerminateBill", mock.Anything, billID, "stuck in signal loop").Return(nil)
 			},
-			wantErr: true,
 		},
 		{
-			name: "empty currency",
-			request: &CreateBillRequest{
-				Currency:      "",
-				BillingPeriod: "2025-01",
+			name:       "empty workflow ID",
+			workflowID: "",
+			mockSetup:  func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{
+				Code:    errs.InvalidArgument,
+				Message: "workflowID cannot be empty",
 			},
-			wantErr: true,
 		},
 		{
-			name: "empty billing period",
-			request: &CreateBillRequest{
-				Currency:      libmoney.CurrencyUSD,
-				BillingPeriod: "",
+			name:       "bill not found",
+			workflowID: string(billID),
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("TerminateBill", mock.Anything, billID, "stuck in signal loop").Return(app.ErrBillNotFound)
+			},
+			expectedError: &errs.Error{
+				Code:    errs.NotFound,
+				Message: "bill not found",
 			},
-			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.request.Validate()
-			if tt.wantErr {
-				assert.Error(t, err)
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			err := service.TerminateBill(context.Background(), tt.workflowID,
+				&TerminateBillRequest{Reason: "stuck in signal loop", Operator: "ops@example.com"})
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
 			} else {
-				assert.NoError(t, err)
+				require.NoError(t, err)
 			}
+
+			mockTemporal.AssertExpectations(t)
 		})
 	}
 }
 
-func TestAddLineItemRequest_Validate(t *testing.T) {
+func TestCreateCreditNote(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+	closedBill := func() domain.Bill {
+		b := createTestBill()
+		b.Status = domain.BillStatusClosed
+		b.Total = libmoney.NewFromInt(20, libmoney.CurrencyUSD)
+		return b
+	}
 	tests := []struct {
-		name    string
-		request *AddLineItemRequest
-		wantErr bool
+		name             string
+		period           string
+		amount           string
+		mockSetup        func(*MockTemporalPort)
+		expectedError    *errs.Error
+		validateResponse func(t *testing.T, resp *CreditNoteResponse)
 	}{
 		{
-			name: "valid request",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			name:   "credit note against a closed bill",
+			period: "2025-01",
+			amount: "7.50",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+				m.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
+				m.On("StartCreditNote", mock.Anything, mock.Anything).Return(nil)
+			},
+			validateResponse: func(t *testing.T, resp *CreditNoteResponse) {
+				assert.Equal(t, "credit/bill/customer-123/2025-01/goodwill-1", resp.ID)
+				assert.Equal(t, string(billID), resp.BillID)
+				assert.Equal(t, "USD", resp.Currency)
+				assert.Equal(t, "-7.50", resp.Total)
+				assert.Equal(t, int64(-750), resp.TotalMinor)
+				assert.Equal(t, "outage", resp.Reason)
+				assert.Equal(t, fixedTime, resp.CreatedAt)
 			},
-			wantErr: false,
 		},
 		{
-			name: "empty description",
-			request: &AddLineItemRequest{
-				Description:    "",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			name:   "non-existent bill",
+			period: "2025-01",
+			amount: "7.50",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(domain.Bill{}, app.ErrBillNotFound)
 			},
-			wantErr: true,
+			expectedError: &errs.Error{Code: errs.NotFound, Message: "bill not found"},
 		},
 		{
-			name: "description too short",
-			request: &AddLineItemRequest{
-				Description:    "A",
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			name:   "open bill",
+			period: "2025-01",
+			amount: "7.50",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(createTestBill(), nil)
+				m.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
 			},
-			wantErr: true,
+			expectedError: &errs.Error{Code: errs.FailedPrecondition, Message: "closed bill"},
 		},
 		{
-			name: "description too long",
-			request: &AddLineItemRequest{
-				Description:    string(make([]byte, 1025)), // 1025 bytes
-				Amount:         "10.50",
-				IdempotencyKey: "item-123",
+			name:   "more than the bill total",
+			period: "2025-01",
+			amount: "20.01",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+				m.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
 			},
-			wantErr: true,
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "exceeds the bill total"},
 		},
 		{
-			name: "empty amount",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "",
-				IdempotencyKey: "item-123",
+			name:   "already credited with this key",
+			period: "2025-01",
+			amount: "7.50",
+			mockSetup: func(m *MockTemporalPort) {
+				m.On("QueryBill", mock.Anything, billID).Return(closedBill(), nil)
+				m.On("ListCreditNotes", mock.Anything, billID).Return([]domain.CreditNote(nil), nil)
+				m.On("StartCreditNote", mock.Anything, mock.Anything).Return(app.ErrCreditNoteAlreadyExists)
 			},
-			wantErr: true,
+			expectedError: &errs.Error{Code: errs.AlreadyExists, Message: "already exists"},
 		},
 		{
-			name: "empty idempotency key",
-			request: &AddLineItemRequest{
-				Description:    "Test item",
-				Amount:         "10.50",
-				IdempotencyKey: "",
-			},
-			wantErr: true,
+			name:          "invalid amount",
+			period:        "2025-01",
+			amount:        "seven",
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "amount is invalid"},
+		},
+		{
+			name:          "invalid period",
+			period:        "2025-1",
+			amount:        "7.50",
+			mockSetup:     func(m *MockTemporalPort) {},
+			expectedError: &errs.Error{Code: errs.InvalidArgument, Message: "period must be YYYY-MM"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.request.Validate()
-			if tt.wantErr {
-				assert.Error(t, err)
+			service, mockTemporal := createTestService()
+			tt.mockSetup(mockTemporal)
+
+			resp, err := service.CreateCreditNote(context.Background(), "customer-123", tt.period,
+				&CreateCreditNoteRequest{Amount: tt.amount, Reason: "outage", IdempotencyKey: "goodwill-1"})
+
+			if tt.expectedError != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError.Code, err.(*errs.Error).Code)
+				assert.Contains(t, err.(*errs.Error).Message, tt.expectedError.Message)
 			} else {
-				assert.NoError(t, err)
+				require.NoError(t, err)
+				tt.validateResponse(t, resp)
 			}
+			mockTemporal.AssertExpectations(t)
 		})
 	}
 }
 
-func TestListBillsQueryParams_Validate(t *testing.T) {
+func TestCreateCreditNoteRequest_Validate(t *testing.T) {
+	assert.NoError(t, (&CreateCreditNoteRequest{Amount: "1", Reason: "outage", IdempotencyKey: "k"}).Validate())
+	assert.Error(t, (&CreateCreditNoteRequest{Amount: "1", IdempotencyKey: "k"}).Validate(), "no reason")
+	assert.Error(t, (&CreateCreditNoteRequest{Reason: "outage", IdempotencyKey: "k"}).Validate(), "no amount")
+	assert.Error(t, (&CreateCreditNoteRequest{Amount: "1", Reason: "outage"}).Validate(), "no idempotency key")
+}
+
+func TestTerminateBillRequest_Validate(t *testing.T) {
+	valid := &TerminateBillRequest{Reason: "stuck in signal loop", Operator: "ops@example.com"}
+	assert.NoError(t, valid.Validate())
+
+	noReason := &TerminateBillRequest{Operator: "ops@example.com"}
+	assert.Error(t, noReason.Validate())
+
+	noOperator := &TerminateBillRequest{Reason: "stuck in signal loop"}
+	assert.Error(t, noOperator.Validate())
+}
+
+func TestCloseAllBills(t *testing.T) {
+	service, mockTemporal := createTestService()
+	open := createTestBill()
+	open.Items = []domain.LineItem{createTestLineItem()}
+	closed := createTestBill()
+	closed.ID = "bill/customer-123/2025-02"
+	closed.Status = domain.BillStatusClosed
+	failing := createTestBill()
+	failing.ID = "bill/customer-123/2025-03"
+
+	mockTemporal.On("SearchBills", mock.Anything, mock.Anything).Return([]views.BillSummary{
+		{WorkflowID: string(open.ID)}, {WorkflowID: string(closed.ID)}, {WorkflowID: string(failing.ID)},
+	}, nil)
+	mockTemporal.On("QueryBill", mock.Anything, open.ID).Return(open, nil)
+	mockTemporal.On("CloseBill", mock.Anything, open.ID).Return(nil)
+	mockTemporal.On("QueryBill", mock.Anything, closed.ID).Return(closed, nil)
+	mockTemporal.On("QueryBill", mock.Anything, failing.ID).Return(failing, nil)
+	mockTemporal.On("CloseBill", mock.Anything, failing.ID).Return(errors.New("temporal unavailable"))
+
+	resp, err := service.CloseAllBills(context.Background(), "customer-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Closed)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Equal(t, 1, resp.Failed)
+	require.Len(t, resp.Results, 3)
+	assert.Equal(t, CloseAllBillResult{ID: string(open.ID), Outcome: "closed"}, resp.Results[0])
+	assert.Equal(t, CloseAllBillResult{ID: string(closed.ID), Outcome: "skipped"}, resp.Results[1])
+	assert.Equal(t, "error", resp.Results[2].Outcome)
+	assert.NotContains(t, resp.Results[2].Error, "temporal unavailable")
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestCloseAllBills_Errors(t *testing.T) {
+	service, mockTemporal := createTestService()
+
+	_, err := service.CloseAllBills(context.Background(), "")
+	require.Error(t, err)
+	assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+
+	mockTemporal.On("SearchBills", mock.Anything, mock.Anything).
+		Return([]views.BillSummary(nil), app.ErrSearchAttributesNotRegistered)
+	_, err = service.CloseAllBills(context.Background(), "customer-123")
+	require.Error(t, err)
+	assert.Equal(t, errs.FailedPrecondition, err.(*errs.Error).Code)
+}
+
+func TestPurgeBills(t *testing.T) {
+	req := &PurgeBillsRequest{
+		CustomerID: "customer-123", Status: "OPEN", CreatedBefore: "2025-03-01T00:00:00Z",
+		Reason: "dev cleanup", Operator: "dev@example.com",
+	}
+
+	t.Run("terminates the matching bills", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		before := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+			return f.CustomerID == "customer-123" && len(f.Status) == 1 && f.Status[0] == "OPEN" &&
+				f.CreatedTo != nil && f.CreatedTo.Equal(before)
+		})).Return([]views.BillSummary{
+			{WorkflowID: "bill/customer-123/2025-01"}, {WorkflowID: "bill/customer-123/2025-02"},
+		}, nil)
+		mockTemporal.On("TerminateBill", mock.Anything, domain.BillID("bill/customer-123/2025-01"), "dev cleanup").Return(nil)
+		mockTemporal.On("TerminateBill", mock.Anything, domain.BillID("bill/customer-123/2025-02"), "dev cleanup").
+			Return(app.ErrBillNotFound)
+
+		resp, err := service.PurgeBills(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, &PurgeBillsResponse{Matched: 2, Terminated: 1, Skipped: 1}, resp)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("refused outside the allowed namespaces", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		service.Purge.Namespace = "fees-prod"
+
+		_, err := service.PurgeBills(context.Background(), req)
+
+		require.Error(t, err)
+		assert.Equal(t, errs.PermissionDenied, err.(*errs.Error).Code)
+		mockTemporal.AssertNotCalled(t, "SearchBills", mock.Anything, mock.Anything)
+	})
+}
+
+func TestPurgeBillsRequest_Validate(t *testing.T) {
+	valid := &PurgeBillsRequest{CustomerID: "customer-123", Reason: "dev cleanup", Operator: "dev@example.com"}
+	assert.NoError(t, valid.Validate())
+
+	noCustomer := &PurgeBillsRequest{Reason: "dev cleanup", Operator: "dev@example.com"}
+	assert.Error(t, noCustomer.Validate())
+
+	badStatus := &PurgeBillsRequest{CustomerID: "customer-123", Status: "DONE", Reason: "dev cleanup", Operator: "dev@example.com"}
+	assert.Error(t, badStatus.Validate())
+
+	badCreated := &PurgeBillsRequest{CustomerID: "customer-123", CreatedBefore: "2025-03-01", Reason: "dev cleanup",
+		Operator: "dev@example.com"}
+	assert.Error(t, badCreated.Validate())
+}
+
+func TestListErroredBills(t *testing.T) {
+	service, mockTemporal := createTestService()
+	mockTemporal.On("SearchBills", mock.Anything, mock.MatchedBy(func(f app.SearchBillFilter) bool {
+		return f.CustomerID == "" && slices.Equal(f.Status, []string{"CHARGE_FAILED", "REJECTED", "ERROR"}) &&
+			*f.FromYYYYMM == 202501 && *f.ToYYYYMM == 202503
+	})).Return([]views.BillSummary{
+		{WorkflowID: "bill/customer-1/2025-02", CustomerID: "customer-1", Status: "REJECTED", Currency: "USD",
+			BillingPeriodNum: 202502, TotalCents: 1000, ErrorReason: "card declined"},
+	}, nil)
+
+	resp, err := service.ListErroredBills(context.Background(), &ListErroredBillsQueryParams{
+		PeriodStart: "2025-01", PeriodEnd: "2025-03",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Bills, 1)
+	assert.Equal(t, "customer-1", resp.Bills[0].CustomerID)
+	assert.Equal(t, "card declined", resp.Bills[0].ErrorReason)
+	mockTemporal.AssertExpectations(t)
+
+	reversed := &ListErroredBillsQueryParams{PeriodStart: "2025-03", PeriodEnd: "2025-01"}
+	assert.Error(t, reversed.Validate())
+}
+
+func TestAggregateBills(t *testing.T) {
+	service, mockTemporal := createTestService()
+	mockTemporal.On("AggregateBillTotals", mock.Anything, "customer-123", int64Ptr(202501), int64Ptr(202503)).
+		Return([]views.BillPeriodTotal{
+			{PeriodYYYYMM: 202501, Currency: "USD", TotalCents: 1250, Count: 2},
+			{PeriodYYYYMM: 202503, Currency: "USD", TotalCents: 700, Count: 1},
+		}, nil)
+
+	resp, err := service.AggregateBills(context.Background(), "customer-123",
+		&AggregateBillsQueryParams{PeriodStart: "2025-01", PeriodEnd: "2025-03"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []BillPeriodTotalResponse{
+		{Period: "2025-01", Currency: "USD", TotalCents: 1250, Count: 2},
+		{Period: "2025-02"},
+		{Period: "2025-03", Currency: "USD", TotalCents: 700, Count: 1},
+	}, resp.Totals)
+	mockTemporal.AssertExpectations(t)
+}
+
+func TestListBillPeriods(t *testing.T) {
+	service, mockTemporal := createTestService()
+	mockTemporal.On("ListBillPeriods", mock.Anything, "customer-123").
+		Return([]string{"2025-03", "2025-01"}, nil)
+
+	resp, err := service.ListBillPeriods(context.Background(), "customer-123")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-03", "2025-01"}, resp.Periods)
+	mockTemporal.AssertExpectations(t)
+
+	_, err = service.ListBillPeriods(context.Background(), "")
+	require.Error(t, err)
+	assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+}
+
+func TestGetNextPeriod(t *testing.T) {
+	t.Run("after the latest bill", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("ListBillPeriods", mock.Anything, "customer-123").Return([]string{"2024-12", "2024-11"}, nil)
+
+		resp, err := service.GetNextPeriod(context.Background(), "customer-123")
+
+		require.NoError(t, err)
+		assert.Equal(t, "2025-01", resp.Period)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("no bills", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("ListBillPeriods", mock.Anything, "customer-123").Return([]string{}, nil)
+
+		resp, err := service.GetNextPeriod(context.Background(), "customer-123")
+
+		require.NoError(t, err)
+		assert.Equal(t, fixedTime.UTC().Format("2006-01"), resp.Period)
+	})
+
+	t.Run("empty customer", func(t *testing.T) {
+		service, _ := createTestService()
+
+		_, err := service.GetNextPeriod(context.Background(), "")
+
+		require.Error(t, err)
+		assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+	})
+}
+
+func TestExportBills(t *testing.T) {
+	filter := app.SearchBillFilter{CustomerID: "acme, inc"}
+	twoPages := func(m *MockTemporalPort) {
+		m.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).Return(app.SearchBillPage{
+			Bills: []views.BillSummary{{
+				WorkflowID: "bill/acme, inc/2025-01", CustomerID: "acme, inc", Status: "CLOSED", Currency: "USD",
+				BillingPeriodNum: 202501, TotalCents: 1050, ItemCount: 2,
+			}},
+			NextPageToken: []byte("page-2"),
+		}, nil).Once()
+		m.On("SearchBillsPage", mock.Anything, filter, []byte("page-2")).Return(app.SearchBillPage{
+			Bills: []views.BillSummary{{
+				WorkflowID: "bill/acme, inc/2025-02", CustomerID: "acme, inc", Status: "OPEN", Currency: "EUR",
+				BillingPeriodNum: 202502, TotalCents: 7, ItemCount: 1,
+			}},
+		}, nil).Once()
+	}
+	export := func(service *Service, target string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		service.exportBills(rec, req, "acme, inc")
+
+		return rec
+	}
+
+	t.Run("csv rows escape the commas", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		twoPages(mockTemporal)
+
+		rec := export(service, "/api/v1/customers/acme/bills/export?format=csv", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "id,period,status,currency,total,itemCount\n"+
+			"\"bill/acme, inc/2025-01\",2025-01,CLOSED,USD,10.50,2\n"+
+			"\"bill/acme, inc/2025-02\",2025-02,OPEN,EUR,0.07,1\n", rec.Body.String())
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("csv from the Accept header", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		twoPages(mockTemporal)
+
+		rec := export(service, "/api/v1/customers/acme/bills/export", "text/csv")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Body.String(), "id,period,status,currency,total,itemCount\n"))
+	})
+
+	t.Run("json by default", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		twoPages(mockTemporal)
+
+		rec := export(service, "/api/v1/customers/acme/bills/export", "")
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var resp ListBillsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Bills, 2)
+		assert.Equal(t, "bill/acme, inc/2025-01", resp.Bills[0].ID)
+		assert.Equal(t, "10.50", resp.Bills[0].Total)
+		assert.Equal(t, "2025-02", resp.Bills[1].BillingPeriod)
+	})
+
+	t.Run("no bills", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).Return(app.SearchBillPage{}, nil)
+
+		csvRec := export(service, "/api/v1/customers/acme/bills/export?format=csv", "")
+		jsonRec := export(service, "/api/v1/customers/acme/bills/export?format=json", "")
+
+		assert.Equal(t, "id,period,status,currency,total,itemCount\n", csvRec.Body.String())
+		assert.JSONEq(t, `{"bills":[]}`, jsonRec.Body.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		service, _ := createTestService()
+
+		rec := export(service, "/api/v1/customers/acme/bills/export?format=xml", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "format must be csv or json")
+	})
+
+	t.Run("search attributes not registered", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("SearchBillsPage", mock.Anything, filter, []byte(nil)).
+			Return(app.SearchBillPage{}, app.ErrSearchAttributesNotRegistered)
+
+		rec := export(service, "/api/v1/customers/acme/bills/export?format=csv", "")
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "make init-temporal")
+	})
+}
+
+func TestToHTTPError(t *testing.T) {
 	tests := []struct {
-		name    string
-		params  *ListBillsQueryParams
-		wantErr bool
+		name        string
+		err         error
+		wantCode    errs.ErrCode
+		wantMessage string
 	}{
+		{name: "not found", err: app.ErrBillNotFound, wantCode: errs.NotFound, wantMessage: "bill not found"},
 		{
-			name: "valid params with status",
-			params: &ListBillsQueryParams{
-				Status:      "OPEN",
-				PeriodStart: "2025-01",
-				PeriodEnd:   "2025-03",
-			},
-			wantErr: false,
+			name:        "archived is a not found",
+			err:         fmt.Errorf("get: %w", app.ErrBillArchived),
+			wantCode:    errs.NotFound,
+			wantMessage: app.ErrBillArchived.Error(),
 		},
 		{
-			name: "valid params with minimal filters",
-			params: &ListBillsQueryParams{
-				Status:      "OPEN",    // Status is required by validation
-				PeriodStart: "2025-01", // Valid datetime format required
-				PeriodEnd:   "2025-01", // Valid datetime format required
-			},
-			wantErr: false,
+			name:        "conflict",
+			err:         app.ErrBillWithPeriodAlreadyStarted,
+			wantCode:    errs.AlreadyExists,
+			wantMessage: "a bill already exists for this customer and period",
 		},
 		{
-			name: "invalid status",
-			params: &ListBillsQueryParams{
-				Status:      "INVALID",
-				PeriodStart: "2025-01",
-				PeriodEnd:   "2025-03",
-			},
-			wantErr: true,
+			name:        "failed precondition",
+			err:         fmt.Errorf("close: %w", app.ErrBillAlreadyClosed),
+			wantCode:    errs.FailedPrecondition,
+			wantMessage: "bill already closed",
 		},
 		{
-			name: "invalid period start",
-			params: &ListBillsQueryParams{
-				Status:      "OPEN",
-				PeriodStart: "invalid-period",
-				PeriodEnd:   "2025-03",
-			},
-			wantErr: true,
+			name:        "stale run",
+			err:         fmt.Errorf("close: %w", app.ErrBillRunStale),
+			wantCode:    errs.FailedPrecondition,
+			wantMessage: app.ErrBillRunStale.Error(),
 		},
 		{
-			name: "invalid period end",
-			params: &ListBillsQueryParams{
-				Status:      "OPEN",
-				PeriodStart: "2025-01",
-				PeriodEnd:   "invalid-period",
-			},
-			wantErr: true,
+			name:        "invalid with details",
+			err:         app.ErrUnknownBillTemplate.Detailf("%s", "gold"),
+			wantCode:    errs.InvalidArgument,
+			wantMessage: "unknown bill template: gold",
+		},
+		{
+			name:        "unavailable hides the adapter error",
+			err:         fmt.Errorf("%w: %w", app.ErrBillBusy, errors.New("context deadline exceeded")),
+			wantCode:    errs.Unavailable,
+			wantMessage: app.ErrBillBusy.Error(),
+		},
+		{
+			name:        "precision",
+			err:         libmoney.ErrPrecisionExceeded,
+			wantCode:    errs.InvalidArgument,
+			wantMessage: libmoney.ErrPrecisionExceeded.Error(),
+		},
+		{
+			name:        "unknown code",
+			err:         domain.NewError("teapot", "short and stout"),
+			wantCode:    errs.Internal,
+			wantMessage: "fallback",
 		},
+		{name: "plain error", err: errors.New("boom"), wantCode: errs.Internal, wantMessage: "fallback"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := tt.params.Validate()
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-			}
+			err := toHTTPError(tt.err, "fallback")
+
+			var e *errs.Error
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, tt.wantCode, e.Code)
+			assert.Equal(t, tt.wantMessage, e.Message)
 		})
 	}
 }
 
-// Helper function to create int64 pointer
-func int64Ptr(i int64) *int64 {
-	return &i
+func TestAggregateBillsQueryParams_Validate(t *testing.T) {
+	assert.NoError(t, (&AggregateBillsQueryParams{}).Validate())
+	assert.NoError(t, (&AggregateBillsQueryParams{PeriodStart: "2025-01", PeriodEnd: "2025-01"}).Validate())
+	assert.Error(t, (&AggregateBillsQueryParams{PeriodStart: "2025-03", PeriodEnd: "2025-01"}).Validate())
+	assert.Error(t, (&AggregateBillsQueryParams{PeriodStart: "2025-13"}).Validate())
+}
+
+func TestGetBillMemo(t *testing.T) {
+	billID := domain.BillID("bill/customer-123/2025-01")
+
+	t.Run("with create params", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{
+			CorrelationID:        "req-42",
+			CreateIdempotencyKey: "create-1",
+			CreateParams: &app.MonthlyFeeAccrualWorkflowParams{
+				BillID:       billID,
+				CustomerID:   "customer-123",
+				Period:       "2025-01",
+				PeriodYYYYMM: 202501,
+				Currency:     libmoney.CurrencyUSD,
+				AutoClose:    true,
+				InvoiceRetry: app.RetryConfig{MaximumAttempts: 3, InitialInterval: 2 * time.Second},
+				Template: []domain.LineItem{
+					{IdempotencyKey: "platform", Description: "Platform fee", Amount: libmoney.FromMinorUnits(1000, libmoney.CurrencyUSD)},
+				},
+			},
+		}, nil)
+
+		resp, err := service.GetBillMemo(context.Background(), "customer-123", "2025-01")
+
+		require.NoError(t, err)
+		assert.Equal(t, "req-42", resp.CorrelationID)
+		assert.Equal(t, "create-1", resp.CreateIdempotencyKey)
+		require.NotNil(t, resp.CreateParams)
+		assert.Equal(t, "bill/customer-123/2025-01", resp.CreateParams.BillID)
+		assert.Equal(t, "USD", resp.CreateParams.Currency)
+		assert.True(t, resp.CreateParams.AutoClose)
+		assert.Equal(t, InvoiceRetryResponse{MaximumAttempts: 3, InitialInterval: "2s"}, resp.CreateParams.InvoiceRetry)
+		require.Len(t, resp.CreateParams.Template, 1)
+		assert.Equal(t, "platform", resp.CreateParams.Template[0].IdempotencyKey)
+		mockTemporal.AssertExpectations(t)
+	})
+
+	t.Run("bill created before the params were stored", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{CorrelationID: "req-42"}, nil)
+
+		resp, err := service.GetBillMemo(context.Background(), "customer-123", "2025-01")
+
+		require.NoError(t, err)
+		assert.Nil(t, resp.CreateParams)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		service, mockTemporal := createTestService()
+		mockTemporal.On("GetBillMemo", mock.Anything, billID).Return(app.BillMemo{}, app.ErrBillNotFound)
+
+		_, err := service.GetBillMemo(context.Background(), "customer-123", "2025-01")
+
+		require.Error(t, err)
+		assert.Equal(t, errs.NotFound, err.(*errs.Error).Code)
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		service, _ := createTestService()
+
+		_, err := service.GetBillMemo(context.Background(), "customer-123", "2025-13")
+
+		require.Error(t, err)
+		assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
+	})
+}
+
+func TestGetBillChangeLog(t *testing.T) {
+	service, mockTemporal := createTestService()
+	billID := domain.BillID("bill/customer-123/2025-01")
+	mockTemporal.On("QueryBillChangeLog", mock.Anything, billID).Return(views.BillChangeLog{
+		Changes: []views.BillChange{
+			{Type: views.BillChangeStatusChanged, At: fixedTime, ToStatus: "OPEN"},
+			{Type: views.BillChangeItemAdded, At: fixedTime, IdempotencyKey: "item-1", Description: "API usage fee",
+				Amount: libmoney.FromMinorUnits(1050, libmoney.CurrencyUSD)},
+			{Type: views.BillChangeStatusChanged, At: fixedTime, FromStatus: "OPEN", ToStatus: "PENDING"},
+		},
+	}, nil)
+
+	resp, err := service.GetBillChangeLog(context.Background(), "customer-123", "2025-01")
+
+	require.NoError(t, err)
+	assert.Equal(t, []BillChangeResponse{
+		{Type: "status_changed", At: fixedTime, ToStatus: "OPEN"},
+		{Type: "item_added", At: fixedTime, IdempotencyKey: "item-1", Description: "API usage fee", Amount: "10.5"},
+		{Type: "status_changed", At: fixedTime, FromStatus: "OPEN", ToStatus: "PENDING"},
+	}, resp.Changes)
+	mockTemporal.AssertExpectations(t)
+
+	_, err = service.GetBillChangeLog(context.Background(), "customer-123", "2025-13")
+	require.Error(t, err)
+	assert.Equal(t, errs.InvalidArgument, err.(*errs.Error).Code)
 }
@@ -10,6 +10,32 @@ package feesapi
     Namespace: *"default"        | string
     UseTLS:    *false            | bool
     UseAPIKey: *false            | bool
+    ActivityTaskQueue: *""       | string
+    DialMaxAttempts:    *10 | int
+    DialTimeout:        *"60s" | string
+    ArchivedLookup:     *false | bool
+    TaskQueuesByCurrency: {[string]: string} | *{}
+  }
+  Billing: {
+    PeriodMonthsAhead: *1  | int
+    PeriodMonthsBack:  *24 | int
+    AllowEmptyBills:   *true | bool
+    MaxItemsPerBill:   *10000 | int
+    DrainItemsOnClose: *false | bool
+    AutoClose:         *false | bool
+    MinChargeMinor: {[string]: int} | *{}
+  }
+  Search: {
+    MaxPages:           *50 | int
+    MaxDurationSeconds: *20 | int
+    CacheTTLSeconds:    *5  | int
+  }
+  RateLimit: {
+    AddItemPerSecond: *10.0 | number
+    AddItemBurst:     *50   | int
+  }
+  Admin: {
+    PurgeAllowedNamespaces: [...string] | *[]
   }
 }
 #Config
\ No newline at end of file
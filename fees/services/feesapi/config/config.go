@@ -14,9 +14,66 @@ type TemporalConfig struct {
 	Namespace config.String
 	UseTLS    config.Bool
 	UseAPIKey config.Bool
+	// Empty means activities share the workflow task queue.
+	ActivityTaskQueue config.String
+	// Dial retries on boot, see temporal.DialRetry. DialTimeout is a Go duration like in the worker config,
+	// empty means no cap.
+	DialMaxAttempts config.Int
+	DialTimeout     config.String
+	// Tells bills past retention from missing ones, needs visibility archival, see temporal.Gateway.WithArchivedLookup.
+	ArchivedLookup config.Bool
+	// Workflow task queue of the new bills and their credit notes by currency, e.g. {"EUR": "FEES_EU_TASK_QUEUE"}
+	// for EU data residency. Other currencies go to the default queue, a worker must listen on each of them,
+	// see worker TaskQueue.
+	TaskQueuesByCurrency map[string]string
+}
+
+// Bill creation rules.
+type BillingConfig struct {
+	// How many months ahead / back of the current one a bill can be created for.
+	PeriodMonthsAhead config.Int
+	PeriodMonthsBack  config.Int
+	// Whether a bill without line items can be closed, applies to the bills created afterwards.
+	AllowEmptyBills config.Bool
+	// Line item cap of a bill, applies to the bills created afterwards.
+	MaxItemsPerBill config.Int
+	// Whether the line items delivered along with the close are added before it, instead of being dropped,
+	// applies to the bills created afterwards.
+	DrainItemsOnClose config.Bool
+	// Whether a bill closes itself when its period ends, applies to the bills created afterwards unless
+	// the create request tells otherwise.
+	AutoClose config.Bool
+	// Minimum charge by currency in its minor units, e.g. {"USD": 50} writes off the USD bills below $0.50
+	// instead of invoicing them, applies to the bills created afterwards.
+	MinChargeMinor map[string]int64
+}
+
+// Bill search limits, see temporal.Gateway.WithSearchLimits.
+type SearchConfig struct {
+	MaxPages           config.Int
+	MaxDurationSeconds config.Int
+	// Identical listings within the TTL are served from memory, zero disables the cache.
+	CacheTTLSeconds config.Int
+}
+
+// API protection, see AddLineItem.
+type RateLimitConfig struct {
+	// Line items per second per bill, zero disables the limit.
+	AddItemPerSecond config.Float64
+	AddItemBurst     config.Int
+}
+
+// Ops endpoints, see PurgeBills.
+type AdminConfig struct {
+	// Namespaces the bills can be purged in, dev and test ones only. Empty (the default) refuses everywhere.
+	PurgeAllowedNamespaces []string
 }
 
 type Config struct {
-	DB       DBConfig
-	Temporal TemporalConfig
+	DB        DBConfig
+	Temporal  TemporalConfig
+	Billing   BillingConfig
+	Search    SearchConfig
+	RateLimit RateLimitConfig
+	Admin     AdminConfig
 }
@@ -13,6 +13,10 @@ if (#Meta.Environment.Type == "development" || #Meta.Environment.Type == "test")
       Namespace: "default"
       UseTLS:    false
       UseAPIKey: false
+      ActivityTaskQueue: "FEES_ACTIVITY_TASK_QUEUE"
+    }
+    Admin: {
+      PurgeAllowedNamespaces: ["default"]
     }
   }
 }
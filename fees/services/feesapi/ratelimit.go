@@ -0,0 +1,71 @@
+package feesapi
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+// sweepEvery is how many Allow calls pass between drops of idle buckets, so the map doesn't grow with every bill.
+const sweepEvery = 1024
+
+// billRateLimiter is a token bucket per bill (customer+period), it keeps one flooding client from overwhelming
+// the single-threaded event loop of a bill workflow. It's per service instance, not cluster-wide.
+// A nil limiter allows everything.
+type billRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[domain.BillID]*rate.Limiter
+	limit   rate.Limit
+	burst   int
+	calls   int
+	now     func() time.Time
+}
+
+// newBillRateLimiter allows perSecond signals per bill on average and bursts of up to burst,
+// a non-positive perSecond disables limiting (nil).
+func newBillRateLimiter(perSecond float64, burst int) *billRateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+
+	return &billRateLimiter{
+		buckets: map[domain.BillID]*rate.Limiter{},
+		limit:   rate.Limit(perSecond),
+		burst:   max(burst, 1),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token from the bill bucket, false means the caller should back off.
+func (l *billRateLimiter) Allow(id domain.BillID) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%sweepEvery == 0 {
+		l.sweep(now)
+	}
+	b, ok := l.buckets[id]
+	if !ok {
+		b = rate.NewLimiter(l.limit, l.burst)
+		l.buckets[id] = b
+	}
+
+	return b.AllowN(now, 1)
+}
+
+// sweep drops the refilled buckets, a new bucket starts full anyway.
+func (l *billRateLimiter) sweep(now time.Time) {
+	for id, b := range l.buckets {
+		if b.TokensAt(now) >= float64(l.burst) {
+			delete(l.buckets, id)
+		}
+	}
+}
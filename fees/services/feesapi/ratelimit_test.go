@@ -0,0 +1,72 @@
+package feesapi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+)
+
+func newTestLimiter(perSecond float64, burst int) (*billRateLimiter, *time.Time) {
+	now := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	l := newBillRateLimiter(perSecond, burst)
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func TestBillRateLimiter_RejectsBurst(t *testing.T) {
+	l, _ := newTestLimiter(1, 3)
+	bill := domain.MakeBillID("customer-123", "2025-01")
+
+	for i := range 3 {
+		assert.True(t, l.Allow(bill), "call %d within the burst", i+1)
+	}
+	assert.False(t, l.Allow(bill), "burst is used up")
+	assert.True(t, l.Allow(domain.MakeBillID("customer-123", "2025-02")), "other bills have their own bucket")
+	assert.True(t, l.Allow(domain.MakeBillID("customer-456", "2025-01")), "other customers have their own bucket")
+}
+
+func TestBillRateLimiter_Refills(t *testing.T) {
+	l, now := newTestLimiter(2, 2)
+	bill := domain.MakeBillID("customer-123", "2025-01")
+
+	assert.True(t, l.Allow(bill))
+	assert.True(t, l.Allow(bill))
+	assert.False(t, l.Allow(bill))
+
+	*now = now.Add(500 * time.Millisecond) // one token at 2/s
+	assert.True(t, l.Allow(bill))
+	assert.False(t, l.Allow(bill))
+
+	*now = now.Add(time.Minute) // refilled up to the burst, not beyond
+	assert.True(t, l.Allow(bill))
+	assert.True(t, l.Allow(bill))
+	assert.False(t, l.Allow(bill))
+}
+
+func TestBillRateLimiter_SweepsIdleBuckets(t *testing.T) {
+	l, now := newTestLimiter(1, 1)
+	for i := range sweepEvery - 1 {
+		l.Allow(domain.MakeBillID(fmt.Sprintf("customer-%d", i), "2025-01"))
+	}
+	assert.Len(t, l.buckets, sweepEvery-1)
+
+	*now = now.Add(time.Hour)
+	l.Allow(domain.MakeBillID("customer-new", "2025-01"))
+
+	assert.Len(t, l.buckets, 1, "refilled buckets are dropped, only the new one is left")
+}
+
+func TestBillRateLimiter_Disabled(t *testing.T) {
+	l := newBillRateLimiter(0, 10)
+	assert.Nil(t, l)
+
+	bill := domain.MakeBillID("customer-123", "2025-01")
+	for range 100 {
+		assert.True(t, l.Allow(bill))
+	}
+}
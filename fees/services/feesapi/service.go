@@ -2,29 +2,86 @@ package feesapi
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"encore.dev/config"
 	"encore.dev/rlog"
+	"go.opentelemetry.io/otel"
+	"go.temporal.io/sdk/converter"
 
 	"github.com/outofboxer/temporal-workflow/fees/app"
 	"github.com/outofboxer/temporal-workflow/fees/app/usecases"
+	"github.com/outofboxer/temporal-workflow/fees/domain"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/kafka"
 	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
 	feesServiceConfig "github.com/outofboxer/temporal-workflow/fees/services/feesapi/config"
+	libmoney "github.com/outofboxer/temporal-workflow/libs/money"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 //nolint:unused
 var cfg *feesServiceConfig.Config = config.Load[*feesServiceConfig.Config]()
 
+// Temporal credentials, only read when UseTLS / UseAPIKey is set. Empty in local dev, see .secrets.local.cue.
+//
+//nolint:unused
+var secrets struct {
+	TemporalTLSCertPath string // mTLS client certificate PEM file
+	TemporalTLSKeyPath  string // its private key PEM file
+	TemporalAPIKey      string // Temporal Cloud API key
+	// PayloadEncryptionKey is the base64 AES-256 key encrypting the payloads (e.g. line-item descriptions) in the
+	// Temporal history, empty stores them in plaintext. feesapi and the worker must share it.
+	PayloadEncryptionKey string
+}
+
 // This is the DOMAIN SERVICE for Fees.
 // encore:service
 type Service struct {
 	temporalClient app.TemporalClient
+	// addItemLimiter is nil when not configured, then nothing is limited.
+	addItemLimiter *billRateLimiter
 	// Use cases
-	Create  usecases.CreateBill
-	AddItem usecases.AddLineItem
-	Close   usecases.CloseBill
-	Get     usecases.GetBill
-	Search  usecases.SearchBill
+	Create     usecases.CreateBill
+	AddItem    usecases.AddLineItem
+	Update     usecases.UpdateLineItemDescription
+	Correct    usecases.CorrectLineItemAmount
+	Note       usecases.SetBillNote
+	Close      usecases.CloseBill
+	CloseAll   usecases.CloseAllBills
+	Retry      usecases.RetryInvoicing
+	CreditNote usecases.CreateCreditNote
+	Get        usecases.GetBill
+	GetSummary usecases.GetBillSummary
+	GetAsOf    usecases.GetBillAsOf
+	GetRun     usecases.GetBillByExecution
+	ChangeLog  usecases.GetBillChangeLog
+	Memo       usecases.GetBillMemo
+	Search     usecases.SearchBill
+	Count      usecases.CountBills
+	Aggregate  usecases.AggregateBillTotals
+	Periods    usecases.ListBillPeriods
+	NextPeriod usecases.NextPeriod
+	Sum        usecases.SumFees
+	Export     usecases.ExportBills
+	// Admin
+	Backfill  usecases.BackfillSearchAttributes
+	Reconcile usecases.ReconcileBill
+	Terminate usecases.TerminateBill
+	Purge     usecases.PurgeBills
+	Errored   usecases.ListErroredBills
+}
+
+// billTemplates are the base fee sets of the plans, see CreateBillRequest.TemplateID.
+// Amounts are in minor units of the bill currency.
+//
+//nolint:unused
+var billTemplates = app.BillTemplates{
+	"standard": {
+		{Key: "platform", Description: "Platform fee", AmountMinor: 1000},
+		{Key: "support", Description: "Support fee", AmountMinor: 500},
+	},
 }
 
 // All Dependency Injection (DI) should come here! And hierarchical wiring, too.
@@ -33,20 +90,79 @@ type Service struct {
 func initService() (*Service, error) {
 	rlog.Debug("config", "temporal.host", cfg.Temporal.Host())
 
-	tc, err := temporal.NewClient(cfg.Temporal.Host(), cfg.Temporal.Namespace())
+	dialTimeout, err := libtime.ParseDuration(cfg.Temporal.DialTimeout())
+	if err != nil {
+		return nil, fmt.Errorf("temporal dial timeout: %w", err)
+	}
+	dc, err := temporal.NewDataConverter(secrets.PayloadEncryptionKey)
 	if err != nil {
 		return nil, err
 	}
 
-	tgw := temporal.NewGateway(tc, cfg.Temporal.Namespace())
+	dialRetry := temporal.DefaultDialRetry
+	dialRetry.MaxAttempts = cfg.Temporal.DialMaxAttempts()
+	dialRetry.MaxElapsed = dialTimeout
+	tc, err := temporal.NewClient(cfg.Temporal.Host(), cfg.Temporal.Namespace(), connectOptions(dc), dialRetry)
+	if err != nil {
+		return nil, err
+	}
+
+	tgw := temporal.NewGateway(tc, cfg.Temporal.Namespace()).
+		WithDataConverter(dc).
+		WithActivityTaskQueue(cfg.Temporal.ActivityTaskQueue()).
+		WithArchivedLookup(cfg.Temporal.ArchivedLookup()).
+		WithTaskQueueFor(temporal.TaskQueueByCurrency(taskQueuesByCurrency())).
+		WithSearchLimits(cfg.Search.MaxPages(), time.Duration(cfg.Search.MaxDurationSeconds())*time.Second).
+		WithSearchCache(time.Duration(cfg.Search.CacheTTLSeconds()) * time.Second).
+		// the global provider is a no-op until an exporter is set up
+		WithTracer(otel.Tracer(temporal.TracerName))
+
+	// audit events go to the log until the Kafka producer is configured
+	audit := kafka.LogPublisher{}
 
+	periodWindow := domain.BillingPeriodWindow{
+		MonthsAhead: cfg.Billing.PeriodMonthsAhead(),
+		MonthsBack:  cfg.Billing.PeriodMonthsBack(),
+	}
+
+	create := usecases.CreateBill{
+		T: tgw, PeriodWindow: &periodWindow, Audit: audit,
+		AllowEmptyBills: cfg.Billing.AllowEmptyBills(), MaxItems: cfg.Billing.MaxItemsPerBill(), Templates: billTemplates,
+		DrainItemsOnClose: cfg.Billing.DrainItemsOnClose(), MinChargeMinor: minChargeMinor(),
+		AutoClose: cfg.Billing.AutoClose(), IsTaxJurisdiction: activities.IsTaxJurisdiction,
+	}
 	s := &Service{
 		temporalClient: tc,
-		Create:         usecases.CreateBill{T: tgw},
-		AddItem:        usecases.AddLineItem{T: tgw},
+		addItemLimiter: newBillRateLimiter(cfg.RateLimit.AddItemPerSecond(), cfg.RateLimit.AddItemBurst()),
+		Create:         create,
+		AddItem:        usecases.AddLineItem{T: tgw, Audit: audit},
+		Update:         usecases.UpdateLineItemDescription{T: tgw},
+		Correct:        usecases.CorrectLineItemAmount{T: tgw},
+		Note:           usecases.SetBillNote{T: tgw},
 		Close:          usecases.CloseBill{T: tgw},
+		CloseAll:       usecases.CloseAllBills{T: tgw},
+		Retry:          usecases.RetryInvoicing{T: tgw},
+		CreditNote:     usecases.CreateCreditNote{T: tgw},
 		Get:            usecases.GetBill{T: tgw},
+		GetSummary:     usecases.GetBillSummary{T: tgw},
+		GetAsOf:        usecases.GetBillAsOf{T: tgw},
+		GetRun:         usecases.GetBillByExecution{T: tgw},
+		ChangeLog:      usecases.GetBillChangeLog{T: tgw},
+		Memo:           usecases.GetBillMemo{T: tgw},
 		Search:         usecases.SearchBill{T: tgw},
+		Count:          usecases.CountBills{T: tgw},
+		Aggregate:      usecases.AggregateBillTotals{T: tgw},
+		Periods:        usecases.ListBillPeriods{T: tgw},
+		NextPeriod:     usecases.NextPeriod{T: tgw},
+		Sum:            usecases.SumFees{T: tgw},
+		Export:         usecases.ExportBills{T: tgw},
+		Backfill:       usecases.BackfillSearchAttributes{T: tgw},
+		Reconcile:      usecases.ReconcileBill{T: tgw},
+		Terminate:      usecases.TerminateBill{T: tgw},
+		Purge: usecases.PurgeBills{
+			T: tgw, Namespace: cfg.Temporal.Namespace(), AllowedNamespaces: cfg.Admin.PurgeAllowedNamespaces,
+		},
+		Errored: usecases.ListErroredBills{T: tgw},
 	}
 
 	// This project is a template for me, we don't use database in this project, but I leave it here.
@@ -63,3 +179,35 @@ func (s *Service) Shutdown(_ context.Context) {
 	rlog.Debug("FeesApi service Shutdown!")
 	s.temporalClient.Close()
 }
+
+// taskQueuesByCurrency types the configured currency -> task queue routes.
+func taskQueuesByCurrency() map[libmoney.Currency]string {
+	queues := make(map[libmoney.Currency]string, len(cfg.Temporal.TaskQueuesByCurrency))
+	for c, q := range cfg.Temporal.TaskQueuesByCurrency {
+		queues[libmoney.Currency(c)] = q
+	}
+
+	return queues
+}
+
+// minChargeMinor types the configured currency -> minimum charge.
+func minChargeMinor() map[libmoney.Currency]int64 {
+	minCharges := make(map[libmoney.Currency]int64, len(cfg.Billing.MinChargeMinor))
+	for c, m := range cfg.Billing.MinChargeMinor {
+		minCharges[libmoney.Currency(c)] = m
+	}
+
+	return minCharges
+}
+
+// connectOptions combines the TLS / API-key switches of the config with the secrets.
+func connectOptions(dc converter.DataConverter) temporal.ConnectOptions {
+	return temporal.ConnectOptions{
+		UseTLS:        cfg.Temporal.UseTLS(),
+		TLSCertPath:   secrets.TemporalTLSCertPath,
+		TLSKeyPath:    secrets.TemporalTLSKeyPath,
+		UseAPIKey:     cfg.Temporal.UseAPIKey(),
+		APIKey:        secrets.TemporalAPIKey,
+		DataConverter: dc,
+	}
+}
@@ -6,6 +6,13 @@ package worker
     Namespace: *"default"        | string
     UseTLS:    *false            | bool
     UseAPIKey: *false            | bool
+    TaskQueue:         *"FEES_TASK_QUEUE" | string
+    ActivityTaskQueue: *""       | string
+    WorkerStopTimeout: *"30s"    | string
+    DialMaxAttempts:   *10       | int
+    DialTimeout:       *"60s"    | string
+    MaxConcurrentActivityExecutions: *0 | int
+    MaxConcurrentWorkflowTasks:      *0 | int
   }
 }
 #Config
\ No newline at end of file
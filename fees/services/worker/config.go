@@ -6,6 +6,22 @@ import "encore.dev/config"
 type TemporalConfig struct {
 	Host      config.String
 	Namespace config.String
+	UseTLS    config.Bool
+	UseAPIKey config.Bool
+	// Workflow task queue this worker listens on, e.g. the EU one of feesapi TaskQueuesByCurrency in the EU region.
+	// Empty means temporal.DefaultTaskQueue.
+	TaskQueue config.String
+	// Empty means activities share the workflow task queue.
+	ActivityTaskQueue config.String
+	// Time the workers wait on Shutdown for in-flight activities, a Go duration like "30s", empty means no wait.
+	WorkerStopTimeout config.String
+	// Dial retries on boot, see temporal.DialRetry. DialTimeout is a Go duration, empty means no cap.
+	DialMaxAttempts config.Int
+	DialTimeout     config.String
+	// Per-worker concurrency caps, so a burst of bills can't overload the worker or the payment provider.
+	// Zero means the default, see workerLimits.
+	MaxConcurrentActivityExecutions config.Int
+	MaxConcurrentWorkflowTasks      config.Int
 }
 
 type Config struct {
@@ -10,6 +10,8 @@ if (#Meta.Environment.Type == "development" || #Meta.Environment.Type == "test")
       UseTLS:    false
       UseAPIKey: false
       Host: "localhost:7233"
+      ActivityTaskQueue: "FEES_ACTIVITY_TASK_QUEUE"
+      WorkerStopTimeout: "30s"
     }
   }
 }
@@ -2,56 +2,127 @@ package worker
 
 import (
 	"context"
+	"time"
+
 	// Encore.
 	"encore.dev/beta/errs"
 	"encore.dev/config"
+	"encore.dev/rlog"
 	"go.temporal.io/sdk/workflow"
 
 	// Temporal.
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/worker"
 
 	// Worker service.
 	"github.com/outofboxer/temporal-workflow/fees/app/workflows"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/kafka"
+	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal"
 	"github.com/outofboxer/temporal-workflow/fees/internal/adapters/temporal/activities"
+	libtime "github.com/outofboxer/temporal-workflow/libs/time"
 )
 
 //nolint:unused
 var cfg *Config = config.Load[*Config]()
 
+// Temporal credentials, only read when UseTLS / UseAPIKey is set. Empty in local dev, see .secrets.local.cue.
+//
 //nolint:unused
-const taskQueue = "FEES_TASK_QUEUE"
+var secrets struct {
+	TemporalTLSCertPath string // mTLS client certificate PEM file
+	TemporalTLSKeyPath  string // its private key PEM file
+	TemporalAPIKey      string // Temporal Cloud API key
+	// PayloadEncryptionKey is the base64 AES-256 key encrypting the payloads (e.g. line-item descriptions) in the
+	// Temporal history, empty stores them in plaintext. feesapi and the worker must share it.
+	PayloadEncryptionKey string
+	// WebhookSigningKey signs the bill webhooks (X-Fees-Signature), empty sends them unsigned.
+	WebhookSigningKey string
+}
 
 //encore:service
 type Service struct {
 	tc client.Client
 	w  worker.Worker
+	// aw is the dedicated activity worker, nil when activities share the workflow task queue.
+	aw worker.Worker
 }
 
 //nolint:unused
 func initService() (*Service, error) {
-	// (Optionally read host/namespace from Encore config)
-	tc, err := client.Dial(client.Options{
-		HostPort:  cfg.Temporal.Host(),
-		Namespace: cfg.Temporal.Namespace(),
-		// DataConverter: custom if you use one
-	})
+	// Parse the durations first, nothing to clean up yet.
+	stopTimeout, err := libtime.ParseDuration(cfg.Temporal.WorkerStopTimeout())
+	if err != nil {
+		return nil, errs.B().Cause(err).Msg("worker stop timeout").Err()
+	}
+	dialTimeout, err := libtime.ParseDuration(cfg.Temporal.DialTimeout())
+	if err != nil {
+		return nil, errs.B().Cause(err).Msg("temporal dial timeout").Err()
+	}
+
+	dc, err := temporal.NewDataConverter(secrets.PayloadEncryptionKey)
+	if err != nil {
+		return nil, errs.B().Cause(err).Msg("temporal data converter").Err()
+	}
+	workflows.UseMemoDataConverter(dc)
+
+	dialRetry := temporal.DefaultDialRetry
+	dialRetry.MaxAttempts = cfg.Temporal.DialMaxAttempts()
+	dialRetry.MaxElapsed = dialTimeout
+	tc, err := temporal.NewClient(cfg.Temporal.Host(), cfg.Temporal.Namespace(), connectOptions(dc), dialRetry)
 	if err != nil {
 		return nil, errs.B().Cause(err).Msg("temporal dial").Err()
 	}
 
 	// Create a worker bound to your task queue
-	w := worker.New(tc, taskQueue, worker.Options{
-		// Tune as needed:
-		// MaxConcurrentActivityExecutionSize: 100,
-		// MaxConcurrentWorkflowTaskExecutionSize: 50,
-	})
+	taskQueue := cfg.Temporal.TaskQueue()
+	if taskQueue == "" {
+		taskQueue = temporal.DefaultTaskQueue
+	}
+	limits := workerLimits{
+		Activities:    cfg.Temporal.MaxConcurrentActivityExecutions(),
+		WorkflowTasks: cfg.Temporal.MaxConcurrentWorkflowTasks(),
+	}
+	w := worker.New(tc, taskQueue, workerOptions(stopTimeout, limits))
 
 	// Register workflows (function or method receiver)
 	w.RegisterWorkflowWithOptions(workflows.MonthlyFeeAccrualWorkflow,
 		workflow.RegisterOptions{Name: workflows.WorkflowTypeMonthlyBill})
+	w.RegisterWorkflowWithOptions(workflows.CreditNoteWorkflow,
+		workflow.RegisterOptions{Name: workflows.WorkflowTypeCreditNote})
+
+	alerts := &activities.AlertActivities{Alerter: activities.NoopAlerter{}}
+	audit := &activities.AuditActivities{Kafka: kafka.LogPublisher{}}
+	archive := &activities.ArchiveActivities{Archiver: activities.NoopArchiver{}}
+	charge := &activities.ChargeActivities{Payments: activities.NoopPaymentGateway{}}
+	// fine in local dev, a deploy without the key would send webhooks the receivers can't verify
+	if secrets.WebhookSigningKey == "" {
+		rlog.Warn("WebhookSigningKey secret is not set, the bill webhooks are sent unsigned")
+	}
+	webhooks := &activities.WebhookActivities{
+		Poster:     activities.HTTPWebhookPoster{Client: activities.NewWebhookHTTPClient(webhookHTTPTimeout)},
+		SigningKey: []byte(secrets.WebhookSigningKey),
+	}
 
-	w.RegisterActivity(activities.ProcessInvoiceAndChargeActivity)
+	// Activities go to their own task queue if configured, so charging can be scaled apart from workflows.
+	var aw worker.Worker
+	activityTaskQueue := cfg.Temporal.ActivityTaskQueue()
+	if activityTaskQueue != "" && activityTaskQueue != taskQueue {
+		aw = worker.New(tc, activityTaskQueue, workerOptions(stopTimeout, limits))
+		aw.RegisterActivity(charge)
+		aw.RegisterActivity(activities.CalculateTaxActivity)
+		aw.RegisterActivity(alerts)
+		aw.RegisterActivity(audit)
+		aw.RegisterActivity(archive)
+		aw.RegisterActivity(webhooks)
+	} else {
+		w.RegisterActivity(charge)
+		w.RegisterActivity(activities.CalculateTaxActivity)
+		w.RegisterActivity(alerts)
+		w.RegisterActivity(audit)
+		w.RegisterActivity(archive)
+		w.RegisterActivity(webhooks)
+	}
 
 	// Start non-blocking, return service so Encore can manage lifecycle
 	if err := w.Start(); err != nil {
@@ -59,12 +130,67 @@ func initService() (*Service, error) {
 
 		return nil, errs.B().Cause(err).Msg("worker start").Err()
 	}
+	if aw != nil {
+		if err := aw.Start(); err != nil {
+			w.Stop()
+			tc.Close()
 
-	return &Service{tc: tc, w: w}, nil
+			return nil, errs.B().Cause(err).Msg("activity worker start").Err()
+		}
+	}
+
+	return &Service{tc: tc, w: w, aw: aw}, nil
+}
+
+// webhookHTTPTimeout is below the StartToClose timeout of the webhook activity, so a hung endpoint is retried.
+const webhookHTTPTimeout = 5 * time.Second
+
+// Concurrency defaults of a worker, well below the SDK ones (1000), as an activity is a call to the payment provider.
+const (
+	defaultMaxConcurrentActivityExecutions = 100
+	defaultMaxConcurrentWorkflowTasks      = 50
+)
+
+// workerLimits are the configured concurrency caps of a worker, zero or negative means the default.
+type workerLimits struct {
+	Activities    int
+	WorkflowTasks int
+}
+
+// workerOptions are shared by the workflow and the activity worker.
+func workerOptions(stopTimeout time.Duration, limits workerLimits) worker.Options {
+	if limits.Activities <= 0 {
+		limits.Activities = defaultMaxConcurrentActivityExecutions
+	}
+	if limits.WorkflowTasks <= 0 {
+		limits.WorkflowTasks = defaultMaxConcurrentWorkflowTasks
+	}
+
+	return worker.Options{
+		MaxConcurrentActivityExecutionSize:     limits.Activities,
+		MaxConcurrentWorkflowTaskExecutionSize: limits.WorkflowTasks,
+		WorkerStopTimeout:                      stopTimeout,
+	}
 }
 
 func (s *Service) Shutdown(_ context.Context) {
-	// Graceful stop
+	// Graceful stop, Stop blocks up to WorkerStopTimeout so in-flight activities (e.g. a charge) can complete
+	// before the client is closed. The activity worker goes first, it is the one running them.
+	if s.aw != nil {
+		s.aw.Stop()
+	}
 	s.w.Stop()
 	s.tc.Close()
 }
+
+// connectOptions combines the TLS / API-key switches of the config with the secrets.
+func connectOptions(dc converter.DataConverter) temporal.ConnectOptions {
+	return temporal.ConnectOptions{
+		UseTLS:        cfg.Temporal.UseTLS(),
+		TLSCertPath:   secrets.TemporalTLSCertPath,
+		TLSKeyPath:    secrets.TemporalTLSKeyPath,
+		UseAPIKey:     cfg.Temporal.UseAPIKey(),
+		APIKey:        secrets.TemporalAPIKey,
+		DataConverter: dc,
+	}
+}
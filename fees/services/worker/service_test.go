@@ -0,0 +1,35 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerOptions_StopTimeout(t *testing.T) {
+	opts := workerOptions(45*time.Second, workerLimits{})
+	assert.Equal(t, 45*time.Second, opts.WorkerStopTimeout)
+}
+
+func TestWorkerOptions_Concurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		limits         workerLimits
+		wantActivities int
+		wantWorkflows  int
+	}{
+		{name: "unset means the defaults", wantActivities: 100, wantWorkflows: 50},
+		{name: "configured", limits: workerLimits{Activities: 8, WorkflowTasks: 4}, wantActivities: 8, wantWorkflows: 4},
+		{name: "only activities", limits: workerLimits{Activities: 20}, wantActivities: 20, wantWorkflows: 50},
+		{name: "negative means the default", limits: workerLimits{Activities: -1, WorkflowTasks: -1}, wantActivities: 100, wantWorkflows: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := workerOptions(0, tt.limits)
+			assert.Equal(t, tt.wantActivities, opts.MaxConcurrentActivityExecutionSize)
+			assert.Equal(t, tt.wantWorkflows, opts.MaxConcurrentWorkflowTaskExecutionSize)
+		})
+	}
+}
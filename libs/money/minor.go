@@ -0,0 +1,21 @@
+package libmoney
+
+// MoneyMinor is Money in integer minor units of the currency, it serializes as {"minor":1050,"currency":"USD"}
+// for downstream systems (e.g. the audit topic) that want no decimal strings. Money.MarshalJSON is unaffected.
+type MoneyMinor struct {
+	Minor    int64    `json:"minor"`
+	Currency Currency `json:"currency"`
+}
+
+// NewMoneyMinor converts m to minor units, sub-minor-unit fractions are rounded half away from zero.
+func NewMoneyMinor(m Money) MoneyMinor {
+	return MoneyMinor{
+		Minor:    m.ToMinorUnits(),
+		Currency: m.currency,
+	}
+}
+
+// Money converts back, exact for any value made by NewMoneyMinor.
+func (mm MoneyMinor) Money() Money {
+	return FromMinorUnits(mm.Minor, mm.Currency)
+}
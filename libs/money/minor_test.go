@@ -0,0 +1,55 @@
+package libmoney
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoneyMinor_JSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency Currency
+		json     string
+	}{
+		{name: "usd", amount: "10.50", currency: CurrencyUSD, json: `{"minor":1050,"currency":"USD"}`},
+		{name: "gel", amount: "0.05", currency: CurrencyGEL, json: `{"minor":5,"currency":"GEL"}`},
+		{name: "negative", amount: "-10.50", currency: CurrencyUSD, json: `{"minor":-1050,"currency":"USD"}`},
+		{name: "zero", amount: "0", currency: CurrencyUSD, json: `{"minor":0,"currency":"USD"}`},
+		{name: "zero-decimal currency", amount: "1050", currency: CurrencyJPY, json: `{"minor":1050,"currency":"JPY"}`},
+		{name: "sub-cent is rounded", amount: "10.505", currency: CurrencyUSD, json: `{"minor":1051,"currency":"USD"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustMoney(t, tt.amount, tt.currency)
+
+			data, err := json.Marshal(NewMoneyMinor(m))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.json, string(data))
+
+			var back MoneyMinor
+			require.NoError(t, json.Unmarshal(data, &back))
+			assert.Equal(t, NewMoneyMinor(m), back)
+			got := back.Money()
+			assert.Equal(t, tt.currency, got.currency)
+			assert.Equal(t, m.ToMinorUnits(), got.ToMinorUnits(), "minor units must survive the round trip")
+		})
+	}
+}
+
+func TestMoneyMinor_RoundTripIsExact(t *testing.T) {
+	m := mustMoney(t, "1234.56", CurrencyUSD)
+	got := NewMoneyMinor(m).Money()
+	assert.True(t, got.Equal(m))
+	assert.Equal(t, "1234.56", got.ToFixedString())
+}
+
+func TestMoney_MarshalJSON_Unchanged(t *testing.T) {
+	data, err := json.Marshal(mustMoney(t, "10.50", CurrencyUSD))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Value":"10.5","Currency":"USD"}`, string(data))
+}
@@ -1,11 +1,15 @@
 package libmoney
 
 import (
+	"bytes"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
@@ -17,6 +21,7 @@ const (
 	CurrencyNone Currency = "None" // sometimes we don't know currency or currency is depending on parent object
 	CurrencyUSD  Currency = "USD"
 	CurrencyGEL  Currency = "GEL"
+	CurrencyJPY  Currency = "JPY"
 )
 
 type Money struct {
@@ -24,10 +29,49 @@ type Money struct {
 	currency Currency
 }
 
+// ErrPrecisionExceeded is returned for amounts finer than the currency minor unit, e.g. 10.999 USD or 1.5 JPY.
+var ErrPrecisionExceeded = errors.New("amount has more decimal places than the currency allows")
+
+// ErrInvalidMinorUnits is returned by NewFromMinorUnitsString for anything but an optionally signed integer.
+var ErrInvalidMinorUnits = errors.New("minor units must be an integer, e.g. 1050")
+
+// ErrNegativeResult is returned by SubNonNegative when the result would drop below zero.
+var ErrNegativeResult = errors.New("subtraction result would be negative")
+
 func SupportedCurrency(currency Currency) bool {
 	return currency == CurrencyGEL || currency == CurrencyUSD
 }
 
+// minorUnitExponent is the number of decimals of the currency minor unit, e.g. 2 for cents.
+func minorUnitExponent(c Currency) int32 {
+	if c == CurrencyJPY {
+		return 0
+	}
+
+	return 2 //nolint:mnd
+}
+
+// currencySymbols is used by Format, currencies without a symbol fall back to the ISO code.
+var currencySymbols = map[Currency]string{
+	CurrencyUSD: "$",
+	CurrencyGEL: "₾",
+	CurrencyJPY: "¥",
+}
+
+// localeFormat describes how an amount is rendered for a locale.
+type localeFormat struct {
+	decimalSep  string
+	symbolAfter bool // "10,50 ₾" instead of "₾10.50"
+}
+
+// DefaultLocale is used by Format for unknown locales.
+const DefaultLocale = "en-US"
+
+var localeFormats = map[string]localeFormat{
+	"en-US": {decimalSep: "."},
+	"ka-GE": {decimalSep: ",", symbolAfter: true},
+}
+
 func NewFromFloat[fl float32 | float64](v fl, c Currency) Money {
 	v2 := float64(v)
 	if math.IsNaN(v2) {
@@ -74,6 +118,43 @@ func NewFromString(m string, c Currency) (Money, error) {
 	}, nil
 }
 
+// NewFromStringStrict is NewFromString rejecting amounts with more decimal places than the minor unit of c,
+// instead of rounding them later. Trailing zeros are fine, and CurrencyNone allows 2 decimal places.
+func NewFromStringStrict(m string, c Currency) (Money, error) {
+	v, err := NewFromString(m, c)
+	if err != nil {
+		return Money{}, err
+	}
+	if err := v.CheckPrecision(c); err != nil {
+		return Money{}, err
+	}
+
+	return v, nil
+}
+
+// FromMinorUnits builds Money from an amount in minor units of the currency, e.g. 1050 cents -> 10.50 USD.
+func FromMinorUnits(units int64, c Currency) Money {
+	return Money{
+		value:    decimal.New(units, -minorUnitExponent(c)),
+		currency: c,
+	}
+}
+
+// NewFromMinorUnitsString parses an integer amount in minor units of the currency, e.g. "1050" -> 10.50 USD.
+// Only digits with an optional leading minus are accepted, no decimal point, plus sign, spaces or exponent.
+func NewFromMinorUnitsString(s string, c Currency) (Money, error) {
+	digits := strings.TrimPrefix(s, "-")
+	if digits == "" || strings.TrimLeft(digits, "0123456789") != "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMinorUnits, s)
+	}
+	units, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q is out of range", ErrInvalidMinorUnits, s)
+	}
+
+	return FromMinorUnits(units, c), nil
+}
+
 func NewFomBigInt(i *big.Int, e int32, c Currency) Money {
 	return Money{
 		value:    decimal.NewFromBigInt(i, e),
@@ -117,6 +198,73 @@ func (m *Money) ToString() string {
 	return m.value.String()
 }
 
+// CheckPrecision returns ErrPrecisionExceeded if m is finer than the minor unit of c.
+// c is a parameter, as amounts are often parsed with CurrencyNone before the currency is known.
+func (m *Money) CheckPrecision(c Currency) error {
+	exp := minorUnitExponent(c)
+	if !m.value.Equal(m.value.Truncate(exp)) {
+		return fmt.Errorf("%w: %s has more than %d decimal places for %s", ErrPrecisionExceeded, m.value.String(), exp, c)
+	}
+
+	return nil
+}
+
+func (m *Money) Currency() Currency {
+	return m.currency
+}
+
+// ToMinorUnits returns the amount in minor units of the currency, e.g. 10.50 USD -> 1050,
+// sub-minor-unit fractions are rounded half away from zero.
+func (m *Money) ToMinorUnits() int64 {
+	return m.value.Shift(minorUnitExponent(m.currency)).Round(0).IntPart()
+}
+
+// ToFixedString returns the value with the precision of the currency minor unit, e.g. "10.50", "1050" for JPY.
+func (m Money) ToFixedString() string {
+	return m.value.StringFixed(minorUnitExponent(m.currency))
+}
+
+// String implements fmt.Stringer, e.g. "USD 10.50", with the precision of the currency minor unit.
+// Use ToString for the plain decimal value.
+func (m Money) String() string {
+	v := m.ToFixedString()
+	if m.currency == "" || m.currency == CurrencyNone {
+		return v
+	}
+
+	return string(m.currency) + " " + v
+}
+
+// Format renders m with the currency symbol placed according to the locale,
+// e.g. "$10.50" for en-US or "10,50 ₾" for ka-GE. Unknown locales use DefaultLocale.
+func (m Money) Format(locale string) string {
+	lf, ok := localeFormats[locale]
+	if !ok {
+		lf = localeFormats[DefaultLocale]
+	}
+
+	v := m.value.Abs().StringFixed(minorUnitExponent(m.currency))
+	if lf.decimalSep != "." {
+		v = strings.Replace(v, ".", lf.decimalSep, 1)
+	}
+	sign := ""
+	if m.value.IsNegative() && !m.value.Round(minorUnitExponent(m.currency)).IsZero() {
+		sign = "-"
+	}
+
+	symbol, ok := currencySymbols[m.currency]
+	switch {
+	case !ok && (m.currency == "" || m.currency == CurrencyNone):
+		return sign + v
+	case !ok:
+		return sign + v + " " + string(m.currency)
+	case lf.symbolAfter:
+		return sign + v + " " + symbol
+	default:
+		return sign + symbol + v
+	}
+}
+
 func (m *Money) ToPgNumeric() *pgtype.Numeric {
 	var numeric pgtype.Numeric
 	if err := numeric.Scan(m.ToString()); err != nil {
@@ -126,6 +274,60 @@ func (m *Money) ToPgNumeric() *pgtype.Numeric {
 	return &numeric
 }
 
+// Value implements driver.Valuer, the money is stored in a text column as its currency and exact value, e.g.
+// "USD 10.505", a money without a currency (or with CurrencyNone) as the bare value.
+func (m Money) Value() (driver.Value, error) {
+	if m.currency == "" || m.currency == CurrencyNone {
+		return m.value.String(), nil
+	}
+
+	return string(m.currency) + " " + m.value.String(), nil
+}
+
+// Scan implements sql.Scanner, it reads back what Value stores and NULL as the zero Money. The currency is
+// CurrencyNone for the sources without one: a bare "10.50", a numeric column, an int64 or a float64.
+// A JSON object, e.g. of a jsonb column, is read as by UnmarshalJSON.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Money{}
+
+		return nil
+	case string:
+		return m.scanText(v)
+	case []byte:
+		return m.scanText(string(v))
+	case int64:
+		*m = NewFromInt(v, CurrencyNone)
+
+		return nil
+	case float64:
+		*m = NewFromFloat(v, CurrencyNone)
+
+		return nil
+	default:
+		return fmt.Errorf("money: can't scan %T", src)
+	}
+}
+
+func (m *Money) scanText(s string) error {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		return m.UnmarshalJSON([]byte(s))
+	}
+	c := CurrencyNone
+	if code, v, ok := strings.Cut(s, " "); ok {
+		c, s = Currency(code), strings.TrimSpace(v)
+	}
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return fmt.Errorf("money: can't scan %q: %w", s, err)
+	}
+	*m = NewFomDecimal(d, c)
+
+	return nil
+}
+
 // MarshalJSON outputs Money as JSON in the format:
 //
 //	{"Value":"123.45","Currency":"USD"}
@@ -147,7 +349,18 @@ func (m Money) MarshalJSON() ([]byte, error) {
 //
 //	{"Value":"123.45","Currency":"USD"}  ← string (safe, recommended)
 //	{"Value":123.45,"Currency":"USD"}    ← number (also accepted)
+//	"123.45" or 123.45                   ← bare value from systems without the envelope, with CurrencyNone
 func (m *Money) UnmarshalJSON(data []byte) error {
+	if isBareJSONValue(data) {
+		var d decimal.Decimal
+		if err := d.UnmarshalJSON(bytes.TrimSpace(data)); err != nil {
+			return fmt.Errorf("money.value: %w", err)
+		}
+		*m = NewFomDecimal(d, CurrencyNone)
+
+		return nil
+	}
+
 	// Decode into a light helper so we can parse Value flexibly.
 	var aux struct {
 		Value    json.RawMessage `json:"Value"`
@@ -170,6 +383,17 @@ func (m *Money) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// isBareJSONValue tells a JSON string or number from the object form, null and the rest go the object way.
+func isBareJSONValue(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return false
+	}
+	c := data[0]
+
+	return c == '"' || c == '-' || (c >= '0' && c <= '9')
+}
+
 func (m *Money) Add(m2 ...Money) Money {
 	res := m.value
 	for _, v := range m2 {
@@ -194,6 +418,30 @@ func (m *Money) Sub(m2 ...Money) Money {
 	}
 }
 
+// SubNonNegative is Sub for values that must not go below zero, e.g. a total after a discount or credit.
+// Exactly zero is allowed. Use Sub where negative results are fine, e.g. refunds.
+func (m *Money) SubNonNegative(m2 ...Money) (Money, error) {
+	res := m.Sub(m2...)
+	if res.IsNegative() {
+		return Money{}, fmt.Errorf("%w: %s", ErrNegativeResult, res.ToFixedString())
+	}
+
+	return res, nil
+}
+
+// AddCents adds an amount given in minor units of the currency, e.g. 50 -> 0.50 USD.
+func (m *Money) AddCents(cents int64) Money {
+	return Money{
+		value:    m.value.Add(decimal.New(cents, -minorUnitExponent(m.currency))),
+		currency: m.currency,
+	}
+}
+
+// SubCents subtracts an amount given in minor units of the currency, e.g. 50 -> 0.50 USD.
+func (m *Money) SubCents(cents int64) Money {
+	return m.AddCents(-cents)
+}
+
 func (m *Money) Mul(m2 Money) Money {
 	return Money{
 		value:    m.value.Mul(m2.value),
@@ -262,6 +510,31 @@ func (m *Money) Cmp(m2 Money) int {
 	return m.value.Cmp(m2.value)
 }
 
+// Equal reports whether m == m2, by value: 10.5 equals 10.50.
+func (m *Money) Equal(m2 Money) bool {
+	return m.Cmp(m2) == 0
+}
+
+// GreaterThan reports whether m > m2.
+func (m *Money) GreaterThan(m2 Money) bool {
+	return m.Cmp(m2) > 0
+}
+
+// GreaterThanOrEqual reports whether m >= m2.
+func (m *Money) GreaterThanOrEqual(m2 Money) bool {
+	return m.Cmp(m2) >= 0
+}
+
+// LessThan reports whether m < m2.
+func (m *Money) LessThan(m2 Money) bool {
+	return m.Cmp(m2) < 0
+}
+
+// LessThanOrEqual reports whether m <= m2.
+func (m *Money) LessThanOrEqual(m2 Money) bool {
+	return m.Cmp(m2) <= 0
+}
+
 func (m *Money) IsPositive() bool {
 	return m.value.IsPositive()
 }
@@ -270,8 +543,38 @@ func (m *Money) IsNegative() bool {
 	return m.value.IsNegative()
 }
 
+// IsZeroOrNegative reports m <= 0, e.g. a bill total with nothing to charge.
+func (m *Money) IsZeroOrNegative() bool {
+	return !m.value.IsPositive()
+}
+
+// GetPercent returns percent % of m, e.g. 7.25 for 7.25%. The percent is taken by its shortest decimal
+// representation and the math is decimal, so 3% of 0.10 is exactly 0.003. NaN and infinite percents give zero.
+// The result isn't rounded to the currency minor unit.
 func (m *Money) GetPercent(percent float64) Money {
-	return NewFromFloat(m.ToFloat64()*percent/100, m.currency) //nolint:mnd
+	if math.IsNaN(percent) || math.IsInf(percent, 0) {
+		return Money{}
+	}
+
+	return m.PercentDecimal(decimal.NewFromFloat(percent))
+}
+
+// PercentDecimal is GetPercent for an exact percent, e.g. decimal.RequireFromString("7.25").
+func (m *Money) PercentDecimal(p decimal.Decimal) Money {
+	return Money{
+		value:    m.value.Mul(p).Shift(-2), //nolint:mnd
+		currency: m.currency,
+	}
+}
+
+// ConvertTo multiplies m by the exchange rate (target units per unit of m's currency) and labels it with target,
+// e.g. 10 USD at 2.7 is 27 GEL. The rate is given, e.g. fetched by an activity, and the math is decimal.
+// The result isn't rounded to the minor unit of target.
+func (m *Money) ConvertTo(rate decimal.Decimal, target Currency) Money {
+	return Money{
+		value:    m.value.Mul(rate),
+		currency: target,
+	}
 }
 
 func (m *Money) IsZero() bool {
@@ -285,3 +588,76 @@ func (m *Money) Neg() Money {
 		currency: m.currency,
 	}
 }
+
+// Allocate splits m proportionally to the ratios using the largest-remainder method,
+// so the parts always sum back exactly to m (in minor units, e.g. cents).
+// E.g. 0.10 allocated by [1,1,1] gives [0.04,0.03,0.03].
+func (m *Money) Allocate(ratios []int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("allocate: empty ratios")
+	}
+	sum := int64(0)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("allocate: negative ratio %d", r)
+		}
+		sum += int64(r)
+	}
+	if sum == 0 {
+		return nil, errors.New("allocate: ratios sum to zero")
+	}
+
+	exp := minorUnitExponent(m.currency)
+	units := m.value.Round(exp).Shift(exp) // integer amount of minor units
+	negative := units.IsNegative()
+	units = units.Abs()
+	total := decimal.NewFromInt(sum)
+
+	parts := make([]decimal.Decimal, len(ratios))
+	remainders := make([]decimal.Decimal, len(ratios))
+	left := units
+	for i, r := range ratios {
+		parts[i], remainders[i] = units.Mul(decimal.NewFromInt(int64(r))).QuoRem(total, 0)
+		left = left.Sub(parts[i])
+	}
+	// hand out the leftover units one by one to the largest remainders, ties go to the earlier parts.
+	for left.IsPositive() {
+		best := 0
+		for i := range remainders {
+			if remainders[i].GreaterThan(remainders[best]) {
+				best = i
+			}
+		}
+		parts[best] = parts[best].Add(decimal.NewFromInt(1))
+		remainders[best] = decimal.NewFromInt(-1) // each part gets at most one extra unit
+		left = left.Sub(decimal.NewFromInt(1))
+	}
+
+	out := make([]Money, len(parts))
+	for i, p := range parts {
+		if negative {
+			p = p.Neg()
+		}
+		out[i] = Money{value: p.Shift(-exp), currency: m.currency}
+	}
+
+	return out, nil
+}
+
+// Split divides m into n equal parts in whole minor units of the currency, e.g. cents, and returns the leftover,
+// so that n*part + remainder == m. E.g. 10.00 USD split by 3 gives three 3.33 parts and 0.01 remainder.
+// Parts of a negative amount are negative as well, the remainder has the sign of m.
+func (m *Money) Split(n int) (parts []Money, remainder Money, err error) {
+	if n <= 0 {
+		return nil, Money{}, fmt.Errorf("split: parts count must be positive, got %d", n)
+	}
+
+	part, rem := m.value.QuoRem(decimal.NewFromInt(int64(n)), minorUnitExponent(m.currency))
+
+	parts = make([]Money, n)
+	for i := range parts {
+		parts[i] = Money{value: part, currency: m.currency}
+	}
+
+	return parts, Money{value: rem, currency: m.currency}, nil
+}
@@ -0,0 +1,616 @@
+package libmoney
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMoney(t *testing.T, v string, c Currency) Money {
+	t.Helper()
+	m, err := NewFromString(v, c)
+	require.NoError(t, err)
+	return m
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		ratios   []int
+		expected []string
+	}{
+		{
+			name:     "0.10 split evenly in three",
+			amount:   "0.10",
+			ratios:   []int{1, 1, 1},
+			expected: []string{"0.04", "0.03", "0.03"},
+		},
+		{
+			name:     "uneven ratios",
+			amount:   "100",
+			ratios:   []int{70, 20, 10},
+			expected: []string{"70", "20", "10"},
+		},
+		{
+			name:     "largest remainder wins the extra cent",
+			amount:   "0.05",
+			ratios:   []int{1, 3},
+			expected: []string{"0.01", "0.04"},
+		},
+		{
+			name:     "zero ratio gets nothing",
+			amount:   "1.00",
+			ratios:   []int{0, 1, 2},
+			expected: []string{"0", "0.33", "0.67"},
+		},
+		{
+			name:     "negative amount",
+			amount:   "-0.10",
+			ratios:   []int{1, 1, 1},
+			expected: []string{"-0.04", "-0.03", "-0.03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustMoney(t, tt.amount, CurrencyUSD)
+			parts, err := m.Allocate(tt.ratios)
+			require.NoError(t, err)
+			require.Len(t, parts, len(tt.expected))
+
+			sum := NewFromInt(0, CurrencyUSD)
+			for i, p := range parts {
+				assert.Equal(t, tt.expected[i], p.ToString())
+				sum = sum.Add(p)
+			}
+			assert.Equal(t, 0, sum.Cmp(m), "parts must sum back to the original amount")
+		})
+	}
+}
+
+func TestMoney_Allocate_Errors(t *testing.T) {
+	m := mustMoney(t, "10.00", CurrencyUSD)
+
+	_, err := m.Allocate(nil)
+	assert.Error(t, err)
+
+	_, err = m.Allocate([]int{1, -1})
+	assert.Error(t, err)
+
+	_, err = m.Allocate([]int{0, 0})
+	assert.Error(t, err)
+}
+
+func TestMoney_AddCents(t *testing.T) {
+	m := mustMoney(t, "10.00", CurrencyUSD)
+
+	got := m.AddCents(50)
+	assert.Equal(t, "10.5", got.ToString())
+	assert.Equal(t, 0, got.Cmp(mustMoney(t, "10.50", CurrencyUSD)))
+
+	got = m.AddCents(-1050)
+	assert.Equal(t, "-0.5", got.ToString())
+	// the receiver is untouched
+	assert.Equal(t, "10", m.ToString())
+}
+
+func TestMoney_SubCents(t *testing.T) {
+	m := mustMoney(t, "10.00", CurrencyGEL)
+
+	got := m.SubCents(1)
+	assert.Equal(t, "9.99", got.ToString())
+	assert.Equal(t, 0, got.Cmp(mustMoney(t, "9.99", CurrencyGEL)))
+
+	got = m.SubCents(-50)
+	assert.Equal(t, "10.5", got.ToString())
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		expected string
+	}{
+		{name: "pads to minor units", money: mustMoney(t, "10.5", CurrencyUSD), expected: "USD 10.50"},
+		{name: "rounds to minor units", money: mustMoney(t, "10.005", CurrencyGEL), expected: "GEL 10.01"},
+		{name: "zero value", money: Money{}, expected: "0.00"},
+		{name: "zero with currency", money: NewFromInt(0, CurrencyUSD), expected: "USD 0.00"},
+		{name: "negative", money: mustMoney(t, "-3.2", CurrencyUSD), expected: "USD -3.20"},
+		{name: "JPY has no decimals", money: mustMoney(t, "1050", CurrencyJPY), expected: "JPY 1050"},
+		{name: "unknown currency", money: mustMoney(t, "1", CurrencyNone), expected: "1.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.money.String())
+			assert.Equal(t, tt.expected, fmt.Sprint(tt.money))
+		})
+	}
+}
+
+func TestMoney_Format(t *testing.T) {
+	tests := []struct {
+		name     string
+		money    Money
+		locale   string
+		expected string
+	}{
+		{name: "USD en-US", money: mustMoney(t, "10.5", CurrencyUSD), locale: "en-US", expected: "$10.50"},
+		{name: "GEL en-US", money: mustMoney(t, "10.5", CurrencyGEL), locale: "en-US", expected: "₾10.50"},
+		{name: "GEL ka-GE", money: mustMoney(t, "10.5", CurrencyGEL), locale: "ka-GE", expected: "10,50 ₾"},
+		{name: "negative", money: mustMoney(t, "-10.5", CurrencyUSD), locale: "en-US", expected: "-$10.50"},
+		{name: "negative rounding to zero", money: mustMoney(t, "-0.001", CurrencyUSD), locale: "en-US", expected: "$0.00"},
+		{name: "zero", money: NewFromInt(0, CurrencyUSD), locale: "en-US", expected: "$0.00"},
+		{name: "JPY", money: mustMoney(t, "1050", CurrencyJPY), locale: "en-US", expected: "¥1050"},
+		{name: "unknown locale falls back", money: mustMoney(t, "10.5", CurrencyUSD), locale: "xx", expected: "$10.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.money.Format(tt.locale))
+		})
+	}
+}
+
+func TestMoney_Split(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    Money
+		n         int
+		part      string
+		remainder string
+	}{
+		{name: "10.00 USD in three", amount: mustMoney(t, "10.00", CurrencyUSD), n: 3, part: "3.33", remainder: "0.01"},
+		{name: "even split", amount: mustMoney(t, "9.00", CurrencyGEL), n: 3, part: "3", remainder: "0"},
+		{name: "less than a cent per part", amount: mustMoney(t, "0.02", CurrencyUSD), n: 3, part: "0", remainder: "0.02"},
+		{name: "sub-cent amount stays in remainder", amount: mustMoney(t, "1.005", CurrencyUSD), n: 2, part: "0.5", remainder: "0.005"},
+		{name: "negative", amount: mustMoney(t, "-10.00", CurrencyUSD), n: 3, part: "-3.33", remainder: "-0.01"},
+		{name: "JPY has no minor units", amount: mustMoney(t, "100", CurrencyJPY), n: 3, part: "33", remainder: "1"},
+		{name: "single part", amount: mustMoney(t, "10.00", CurrencyUSD), n: 1, part: "10", remainder: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, rem, err := tt.amount.Split(tt.n)
+			require.NoError(t, err)
+			require.Len(t, parts, tt.n)
+
+			sum := rem
+			for _, p := range parts {
+				assert.Equal(t, tt.part, p.ToString())
+				sum = sum.Add(p)
+			}
+			assert.Equal(t, tt.remainder, rem.ToString())
+			assert.Equal(t, 0, sum.Cmp(tt.amount), "parts and remainder must sum back to the amount")
+			assert.Equal(t, tt.amount.currency, rem.currency)
+		})
+	}
+}
+
+func TestMoney_Split_Errors(t *testing.T) {
+	m := mustMoney(t, "10.00", CurrencyUSD)
+
+	for _, n := range []int{0, -1} {
+		_, _, err := m.Split(n)
+		assert.Error(t, err, "n=%d", n)
+	}
+}
+
+func TestMoney_ToMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   Money
+		expected int64
+	}{
+		{name: "USD 10.50", amount: mustMoney(t, "10.50", CurrencyUSD), expected: 1050},
+		{name: "USD 0.01", amount: mustMoney(t, "0.01", CurrencyUSD), expected: 1},
+		{name: "USD 100.00", amount: mustMoney(t, "100.00", CurrencyUSD), expected: 10000},
+		{name: "USD 0.00", amount: mustMoney(t, "0.00", CurrencyUSD), expected: 0},
+		{name: "GEL 25.75", amount: mustMoney(t, "25.75", CurrencyGEL), expected: 2575},
+		{name: "sub-cent rounds half away from zero", amount: mustMoney(t, "-0.005", CurrencyUSD), expected: -1},
+		{name: "JPY has no minor units", amount: mustMoney(t, "1050", CurrencyJPY), expected: 1050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.amount.ToMinorUnits())
+		})
+	}
+}
+
+func TestNewFromStringStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency Currency
+		wantErr  bool
+	}{
+		{name: "USD 2 decimals", value: "10.99", currency: CurrencyUSD},
+		{name: "USD whole", value: "10", currency: CurrencyUSD},
+		{name: "USD trailing zero", value: "10.990", currency: CurrencyUSD},
+		{name: "USD 3 decimals", value: "10.999", currency: CurrencyUSD, wantErr: true},
+		{name: "JPY whole", value: "1050", currency: CurrencyJPY},
+		{name: "JPY 1 decimal", value: "1050.5", currency: CurrencyJPY, wantErr: true},
+		{name: "JPY 2 decimals", value: "0.01", currency: CurrencyJPY, wantErr: true},
+		{name: "None allows 2 decimals", value: "0.01", currency: CurrencyNone},
+		{name: "None 3 decimals", value: "0.001", currency: CurrencyNone, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewFromStringStrict(tt.value, tt.currency)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrPrecisionExceeded)
+				assert.Contains(t, err.Error(), tt.value)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, m.Equal(mustMoney(t, tt.value, tt.currency)))
+		})
+	}
+
+	t.Run("error message", func(t *testing.T) {
+		_, err := NewFromStringStrict("10.999", CurrencyUSD)
+		require.EqualError(t, err, ErrPrecisionExceeded.Error()+": 10.999 has more than 2 decimal places for USD")
+	})
+
+	t.Run("invalid string", func(t *testing.T) {
+		_, err := NewFromStringStrict("abc", CurrencyUSD)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrPrecisionExceeded)
+	})
+
+	t.Run("lenient NewFromString keeps the decimals", func(t *testing.T) {
+		m, err := NewFromString("10.999", CurrencyUSD)
+		require.NoError(t, err)
+		assert.Equal(t, "10.999", m.ToString())
+	})
+}
+
+func TestMoney_SubNonNegative(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		subtract []string
+		expected string
+		wantErr  bool
+	}{
+		{name: "positive result", value: "10.00", subtract: []string{"2.50", "1.25"}, expected: "6.25"},
+		{name: "exactly zero", value: "10.00", subtract: []string{"7.50", "2.50"}, expected: "0.00"},
+		{name: "slightly negative", value: "10.00", subtract: []string{"10.01"}, wantErr: true},
+		{name: "nothing to subtract", value: "3.00", expected: "3.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustMoney(t, tt.value, CurrencyUSD)
+			others := make([]Money, 0, len(tt.subtract))
+			for _, v := range tt.subtract {
+				others = append(others, mustMoney(t, v, CurrencyUSD))
+			}
+
+			res, err := m.SubNonNegative(others...)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrNegativeResult)
+				assert.Contains(t, err.Error(), "-0.01")
+				assert.Equal(t, "10.00", m.ToFixedString(), "receiver is untouched")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, res.ToFixedString())
+			assert.Equal(t, CurrencyUSD, res.Currency())
+		})
+	}
+}
+
+func TestFromMinorUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		units    int64
+		currency Currency
+		expected string
+	}{
+		{name: "USD", units: 1050, currency: CurrencyUSD, expected: "10.50"},
+		{name: "GEL", units: -5, currency: CurrencyGEL, expected: "-0.05"},
+		{name: "JPY", units: 1050, currency: CurrencyJPY, expected: "1050"},
+		{name: "zero", units: 0, currency: CurrencyUSD, expected: "0.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := FromMinorUnits(tt.units, tt.currency)
+			assert.Equal(t, tt.expected, m.ToFixedString())
+			assert.Equal(t, tt.units, m.ToMinorUnits(), "round trip")
+		})
+	}
+}
+
+func TestNewFromMinorUnitsString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		currency Currency
+		expected string
+		wantErr  bool
+	}{
+		{name: "USD cents", input: "1050", currency: CurrencyUSD, expected: "10.50"},
+		{name: "negative", input: "-5", currency: CurrencyGEL, expected: "-0.05"},
+		{name: "zero", input: "0", currency: CurrencyNone, expected: "0.00"},
+		{name: "leading zeros", input: "007", currency: CurrencyUSD, expected: "0.07"},
+		{name: "JPY has no minor unit", input: "1050", currency: CurrencyJPY, expected: "1050"},
+		{name: "decimal", input: "10.50", currency: CurrencyUSD, wantErr: true},
+		{name: "plus sign", input: "+10", currency: CurrencyUSD, wantErr: true},
+		{name: "spaces", input: " 10", currency: CurrencyUSD, wantErr: true},
+		{name: "exponent", input: "1e3", currency: CurrencyUSD, wantErr: true},
+		{name: "empty", input: "", currency: CurrencyUSD, wantErr: true},
+		{name: "only minus", input: "-", currency: CurrencyUSD, wantErr: true},
+		{name: "out of range", input: "99999999999999999999", currency: CurrencyUSD, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewFromMinorUnitsString(tt.input, tt.currency)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidMinorUnits)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.ToFixedString())
+			assert.Equal(t, tt.currency, m.Currency())
+		})
+	}
+}
+
+func TestMoney_Comparisons(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		gt   bool
+		gte  bool
+		lt   bool
+		lte  bool
+		eq   bool
+	}{
+		{name: "positive greater", a: "10.50", b: "10.49", gt: true, gte: true},
+		{name: "positive less", a: "0.01", b: "1", lt: true, lte: true},
+		{name: "equal", a: "10.50", b: "10.50", gte: true, lte: true, eq: true},
+		{name: "equal with different scale", a: "10.5", b: "10.500", gte: true, lte: true, eq: true},
+		{name: "zeros", a: "0", b: "0.00", gte: true, lte: true, eq: true},
+		{name: "negative zero", a: "-0", b: "0", gte: true, lte: true, eq: true},
+		{name: "zero greater than negative", a: "0", b: "-0.01", gt: true, gte: true},
+		{name: "negative less than zero", a: "-0.01", b: "0", lt: true, lte: true},
+		{name: "negative closer to zero is greater", a: "-1", b: "-2", gt: true, gte: true},
+		{name: "negative further from zero is less", a: "-2", b: "-1", lt: true, lte: true},
+		{name: "equal negatives", a: "-3.33", b: "-3.33", gte: true, lte: true, eq: true},
+		{name: "positive greater than negative", a: "1", b: "-1", gt: true, gte: true},
+		{name: "sub-cent difference", a: "0.005", b: "0.004", gt: true, gte: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := mustMoney(t, tt.a, CurrencyUSD)
+			b := mustMoney(t, tt.b, CurrencyUSD)
+
+			assert.Equal(t, tt.gt, a.GreaterThan(b), "GreaterThan")
+			assert.Equal(t, tt.gte, a.GreaterThanOrEqual(b), "GreaterThanOrEqual")
+			assert.Equal(t, tt.lt, a.LessThan(b), "LessThan")
+			assert.Equal(t, tt.lte, a.LessThanOrEqual(b), "LessThanOrEqual")
+			assert.Equal(t, tt.eq, a.Equal(b), "Equal")
+			// the helpers must agree with Cmp
+			assert.Equal(t, tt.gt, a.Cmp(b) > 0)
+			assert.Equal(t, tt.lt, a.Cmp(b) < 0)
+		})
+	}
+}
+
+func TestMoney_IsZeroOrNegative(t *testing.T) {
+	tests := []struct {
+		v    string
+		want bool
+	}{
+		{v: "0", want: true},
+		{v: "-0", want: true},
+		{v: "0.00", want: true},
+		{v: "-0.01", want: true},
+		{v: "-0.001", want: true},
+		{v: "-10", want: true},
+		{v: "0.001", want: false},
+		{v: "0.01", want: false},
+		{v: "10", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.v, func(t *testing.T) {
+			m := mustMoney(t, tt.v, CurrencyUSD)
+			assert.Equal(t, tt.want, m.IsZeroOrNegative())
+		})
+	}
+}
+
+func TestMoney_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		currency Currency
+		wantErr  bool
+	}{
+		{name: "object with string value", input: `{"Value":"10.50","Currency":"USD"}`, expected: "10.5", currency: CurrencyUSD},
+		{name: "object with number value", input: `{"Value":10.50,"Currency":"GEL"}`, expected: "10.5", currency: CurrencyGEL},
+		{name: "object without value", input: `{"Currency":"USD"}`, expected: "0", currency: CurrencyUSD},
+		{name: "bare string", input: `"10.50"`, expected: "10.5", currency: CurrencyNone},
+		{name: "bare number", input: `10.50`, expected: "10.5", currency: CurrencyNone},
+		{name: "bare negative number", input: ` -3 `, expected: "-3", currency: CurrencyNone},
+		{name: "bare string not a number", input: `"ten"`, wantErr: true},
+		{name: "array", input: `[10.50]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tt.input), &m)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.value.String())
+			assert.Equal(t, tt.currency, m.Currency())
+		})
+	}
+}
+
+func TestMoney_UnmarshalJSON_InStruct(t *testing.T) {
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"25.99"}`), &payload))
+	assert.Equal(t, "25.99", payload.Amount.ToString())
+	assert.Equal(t, CurrencyNone, payload.Amount.Currency())
+
+	// the envelope still round trips
+	data, err := json.Marshal(NewFromInt(5, CurrencyUSD))
+	require.NoError(t, err)
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, CurrencyUSD, m.Currency())
+	assert.Equal(t, "5", m.value.String())
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      any
+		expected string
+		currency Currency
+		wantErr  bool
+	}{
+		{name: "string with currency", src: "USD 10.505", expected: "10.505", currency: CurrencyUSD},
+		{name: "bytes with currency", src: []byte("GEL -3.20"), expected: "-3.2", currency: CurrencyGEL},
+		{name: "bare numeric text", src: []byte("123.45"), expected: "123.45", currency: CurrencyNone},
+		{name: "int64", src: int64(42), expected: "42", currency: CurrencyNone},
+		{name: "float64", src: 10.25, expected: "10.25", currency: CurrencyNone},
+		{name: "json object", src: `{"Value":"7.50","Currency":"JPY"}`, expected: "7.5", currency: CurrencyJPY},
+		{name: "NULL", src: nil, expected: "0", currency: ""},
+		{name: "not a number", src: "USD ten", wantErr: true},
+		{name: "unsupported type", src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewFromInt(99, CurrencyUSD)
+			err := m.Scan(tt.src)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.value.String())
+			assert.Equal(t, tt.currency, m.Currency())
+		})
+	}
+}
+
+func TestMoney_Value_RoundTrip(t *testing.T) {
+	for _, m := range []Money{
+		mustMoney(t, "10.505", CurrencyUSD),
+		mustMoney(t, "-0.01", CurrencyGEL),
+		NewFromInt(1050, CurrencyJPY),
+		mustMoney(t, "3.14", CurrencyNone),
+	} {
+		v, err := m.Value()
+		require.NoError(t, err)
+		require.IsType(t, "", v)
+
+		var got Money
+		require.NoError(t, got.Scan(v))
+		assert.True(t, m.Equal(got), "%v != %v", m, got)
+		assert.Equal(t, m.Currency(), got.Currency())
+	}
+
+	v, err := mustMoney(t, "10.50", CurrencyUSD).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "USD 10.5", v)
+	// a Money without a currency scans back as CurrencyNone
+	v, err = Money{}.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "0", v)
+}
+
+func TestMoney_GetPercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		percent  float64
+		expected string
+	}{
+		{name: "whole percent", value: "200.00", percent: 18, expected: "36"},
+		{name: "fractional percent", value: "100.00", percent: 7.25, expected: "7.25"},
+		{name: "fractional percent of cents", value: "19.99", percent: 7.25, expected: "1.449275"},
+		{name: "float noise", value: "0.10", percent: 3, expected: "0.003"},
+		{name: "negative", value: "-50.00", percent: 10, expected: "-5"},
+		{name: "zero", value: "0", percent: 12.5, expected: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustMoney(t, tt.value, CurrencyUSD)
+
+			got := m.GetPercent(tt.percent)
+			assert.Equal(t, tt.expected, got.ToString())
+			assert.Equal(t, CurrencyUSD, got.Currency())
+		})
+	}
+}
+
+func TestMoney_GetPercent_NotFloatMath(t *testing.T) {
+	m := mustMoney(t, "0.10", CurrencyUSD)
+
+	// the float math of old gave 0.0030000000000000005
+	old := NewFromFloat(m.ToFloat64()*3/100, CurrencyUSD)
+	assert.Equal(t, "0.0030000000000000005", old.ToString())
+
+	got := m.GetPercent(3)
+	assert.Equal(t, "0.003", got.ToString())
+	assert.Equal(t, 0, got.Cmp(mustMoney(t, "0.003", CurrencyUSD)))
+
+	nan, inf := m.GetPercent(math.NaN()), m.GetPercent(math.Inf(1))
+	assert.True(t, nan.IsZero())
+	assert.True(t, inf.IsZero())
+}
+
+func TestMoney_PercentDecimal(t *testing.T) {
+	m := mustMoney(t, "1234.56", CurrencyGEL)
+
+	got := m.PercentDecimal(decimal.RequireFromString("7.25"))
+	assert.Equal(t, "89.5056", got.ToString())
+	assert.Equal(t, CurrencyGEL, got.Currency())
+
+	got = m.PercentDecimal(decimal.RequireFromString("0.001"))
+	assert.Equal(t, "0.0123456", got.ToString())
+	// the receiver is untouched
+	assert.Equal(t, "1234.56", m.ToString())
+}
+
+func TestMoney_ConvertTo(t *testing.T) {
+	m := mustMoney(t, "10.50", CurrencyUSD)
+
+	got := m.ConvertTo(decimal.RequireFromString("2.6875"), CurrencyGEL)
+	assert.Equal(t, "28.21875", got.ToString())
+	assert.Equal(t, CurrencyGEL, got.Currency())
+	assert.Equal(t, "28.22", got.ToFixedString())
+	// the receiver is untouched
+	assert.Equal(t, "10.5", m.ToString())
+	assert.Equal(t, CurrencyUSD, m.Currency())
+
+	// rate 1 only relabels the currency
+	same := m.ConvertTo(decimal.NewFromInt(1), CurrencyGEL)
+	assert.True(t, same.Equal(m))
+	assert.Equal(t, CurrencyGEL, same.Currency())
+}
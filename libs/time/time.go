@@ -18,6 +18,16 @@ func ToYYYYMM(period string) (int64, error) {
 	return int64(y)*100 + int64(m), nil
 }
 
+// FromYYYYMM converts 202410 -> "2024-10", the reverse of ToYYYYMM.
+func FromYYYYMM(yyyymm int64) (string, error) {
+	year, month := yyyymm/100, yyyymm%100 //nolint:mnd
+	if year < 1 || year > 9999 || month < 1 || month > 12 {
+		return "", fmt.Errorf("invalid period number %d (want YYYYMM)", yyyymm)
+	}
+
+	return fmt.Sprintf("%04d-%02d", year, month), nil
+}
+
 // ToYYYYMM converts "YYYY-MM" -> 202410.
 func ToYYYYMMNullable(period string) (*int64, error) {
 	if period == "" {
@@ -33,3 +43,65 @@ func ToYYYYMMNullable(period string) (*int64, error) {
 
 	return &res, nil
 }
+
+// PeriodBounds returns the first and the last instant of the "YYYY-MM" period, in UTC,
+// e.g. "2024-02" -> 2024-02-01T00:00:00Z, 2024-02-29T23:59:59.999999999Z.
+func PeriodBounds(period string) (start, end time.Time, err error) {
+	s := strings.TrimSpace(period)
+	start, err = time.Parse("2006-01", s) // strict: requires zero-padded month
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q (want YYYY-MM): %w", period, err)
+	}
+	// AddDate normalizes the month length, leap years included
+	end = start.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+	return start, end, nil
+}
+
+// PeriodEnd is the last instant of the "YYYY-MM" period, see PeriodBounds. It's the zero time for an invalid period.
+func PeriodEnd(period string) time.Time {
+	_, end, err := PeriodBounds(period)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return end
+}
+
+// periodSeparators are the year/month separators ParsePeriodFlexible accepts besides "-".
+var periodSeparators = strings.NewReplacer("/", "-", ".", "-")
+
+// ParsePeriodFlexible normalizes common spellings of a period to the canonical "YYYY-MM",
+// e.g. "2025-1", "2025/01" or "Jan 2025" -> "2025-01". The strict functions above still apply to its result.
+func ParsePeriodFlexible(s string) (string, error) {
+	in := strings.TrimSpace(s)
+	// "Jan 2025", "January 2025"
+	for _, layout := range []string{"Jan 2006", "January 2006"} {
+		if t, err := time.Parse(layout, in); err == nil {
+			return t.Format("2006-01"), nil
+		}
+	}
+	// "2025-01", "2025-1", "2025/01", "2025.1"; time.Parse's "1" accepts a zero-padded month too
+	norm := periodSeparators.Replace(in)
+	if t, err := time.Parse("2006-1", norm); err == nil {
+		return t.Format("2006-01"), nil
+	}
+
+	return "", fmt.Errorf("invalid period %q (want YYYY-MM, YYYY-M, YYYY/MM or Mon YYYY)", s)
+}
+
+// ParseDuration parses a configured Go duration like "30s", empty means 0 and a negative duration is an error.
+func ParseDuration(v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %q", v)
+	}
+
+	return d, nil
+}
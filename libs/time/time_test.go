@@ -0,0 +1,146 @@
+package time
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		period string
+		start  time.Time
+		end    time.Time
+	}{
+		{
+			name:   "31-day month",
+			period: "2025-01",
+			start:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2025, 1, 31, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:   "30-day month",
+			period: "2025-04",
+			start:  time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2025, 4, 30, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:   "February in a leap year",
+			period: "2024-02",
+			start:  time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 2, 29, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:   "February in a common year",
+			period: "2025-02",
+			start:  time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2025, 2, 28, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			name:   "December rolls over the year",
+			period: "2024-12",
+			start:  time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
+			end:    time.Date(2024, 12, 31, 23, 59, 59, 999999999, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := PeriodBounds(tt.period)
+			require.NoError(t, err)
+			assert.True(t, tt.start.Equal(start), "start %s", start)
+			assert.True(t, tt.end.Equal(end), "end %s", end)
+			assert.Equal(t, time.UTC, end.Location())
+			assert.True(t, tt.end.Equal(PeriodEnd(tt.period)))
+		})
+	}
+}
+
+func TestPeriodBounds_Invalid(t *testing.T) {
+	for _, period := range []string{"", "2025-1", "2025-13", "202501"} {
+		_, _, err := PeriodBounds(period)
+		assert.Error(t, err, "period %q", period)
+		assert.True(t, PeriodEnd(period).IsZero(), "period %q", period)
+	}
+}
+
+func TestParsePeriodFlexible(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "2025-01", want: "2025-01"},
+		{in: "2025-1", want: "2025-01"},
+		{in: "2025/01", want: "2025-01"},
+		{in: "2025/1", want: "2025-01"},
+		{in: "2025.12", want: "2025-12"},
+		{in: " 2025-01 ", want: "2025-01"},
+		{in: "Jan 2025", want: "2025-01"},
+		{in: "jan 2025", want: "2025-01"},
+		{in: "September 2024", want: "2024-09"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParsePeriodFlexible(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// the result is always accepted by the strict conversion
+			_, err = ToYYYYMM(got)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParsePeriodFlexible_Invalid(t *testing.T) {
+	for _, in := range []string{"", "202501", "2025-13", "2025-0", "25-01", "01/2025", "2025-01-15", "Foo 2025", "Jan"} {
+		_, err := ParsePeriodFlexible(in)
+		assert.Error(t, err, "period %q", in)
+	}
+}
+
+func TestFromYYYYMM(t *testing.T) {
+	got, err := FromYYYYMM(202501)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", got)
+
+	n, err := ToYYYYMM(got)
+	require.NoError(t, err)
+	assert.Equal(t, int64(202501), n)
+
+	for _, n := range []int64{0, 202500, 202513, 2025, 1000001} {
+		_, err := FromYYYYMM(n)
+		assert.Error(t, err, "period number %d", n)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "empty means 0", input: "", expected: 0},
+		{name: "seconds", input: "30s", expected: 30 * time.Second},
+		{name: "minutes", input: "2m", expected: 2 * time.Minute},
+		{name: "invalid", input: "thirty", wantErr: true},
+		{name: "negative", input: "-1s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}